@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/store"
+)
+
+// accountTTL is how long a looked-up account is trusted before it is
+// looked up again, e.g. after a username changed hands
+const accountTTL = 24 * time.Hour
+
+// accountCache remembers account lookups in a profile's state store, so
+// repeated runs and daemon ticks don't spend rate limit resolving the same
+// username every time. A nil cache remembers nothing.
+type accountCache struct {
+	s store.Store
+}
+
+type cachedAccount struct {
+	ID      string    `json:"id,omitempty"`
+	Checked time.Time `json:"checked"`
+}
+
+func accountKey(platform, username string) string {
+	return platform + "/" + strings.ToLower(username)
+}
+
+// get returns the ID looked up for the username, if it is recent enough
+func (a *accountCache) get(platform, username string) (id string, ok bool) {
+	if a == nil {
+		return "", false
+	}
+	data, err := a.s.Get("accounts", accountKey(platform, username))
+	if err != nil {
+		return "", false
+	}
+	var c cachedAccount
+	if json.Unmarshal(data, &c) != nil || time.Since(c.Checked) > accountTTL {
+		return "", false
+	}
+	return c.ID, true
+}
+
+// put remembers a lookup. Failing to only costs a lookup next time, so
+// errors are ignored.
+func (a *accountCache) put(platform, username, id string) {
+	if a == nil {
+		return
+	}
+	data, err := json.Marshal(cachedAccount{ID: id, Checked: time.Now()})
+	if err == nil {
+		a.s.Put("accounts", accountKey(platform, username), data)
+	}
+}
+
+type accountsKey struct{}
+
+// withAccounts has checks and runs under ctx use the profile's account
+// cache
+func withAccounts(ctx context.Context, a *accountCache) context.Context {
+	return context.WithValue(ctx, accountsKey{}, a)
+}
+
+// accountsFrom returns the account cache of ctx, or nil
+func accountsFrom(ctx context.Context) *accountCache {
+	a, _ := ctx.Value(accountsKey{}).(*accountCache)
+	return a
+}
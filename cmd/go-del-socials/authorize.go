@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"go-del-socials/pkg/flickr"
+	"go-del-socials/pkg/generic"
+	"go-del-socials/pkg/reddit"
+	"go-del-socials/pkg/secrets"
+)
+
+// authorizeReddit signs the profile's installed Reddit app in to an account
+// through the browser and prints the refresh token to configure
+func authorizeReddit(config *Config, profile string) error {
+	profiles, err := config.selectProfiles(profile, false)
+	if err != nil {
+		return err
+	}
+	var c RedditConfig
+	if err := decodeSection(profiles[0].Sections["reddit"], &c); err != nil {
+		return err
+	}
+	err = checkSettings(
+		setting{"client_id", c.ClientID, "copy the string under your app's name at https://www.reddit.com/prefs/apps"},
+		setting{"user_agent", c.UserAgent, "set it to something like \"installed:go-del-socials:v1.0 (by /u/yourname)\""},
+	)
+	if err != nil {
+		return err
+	}
+	if err := secrets.ResolveAll(&c.ClientID); err != nil {
+		return fmt.Errorf("error resolving secret: %v", err)
+	}
+	if c.RedirectURI == "" {
+		c.RedirectURI = reddit.DefaultRedirectURI
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	token, err := reddit.Authorize(ctx, c.AuthURL, c.ClientID, c.RedirectURI, c.UserAgent, func(link string) {
+		fmt.Printf("Open this address, signed in as the account to delete from, and allow access:\n\n%s\n\nWaiting for Reddit to send you back to %s...\n", link, c.RedirectURI)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nAuthorized. Add this to the reddit section of profile %s, in place of client_secret and password:\n\n\"refresh_token\": %q\n\nThe token is as good as a password: store it like one, e.g. as a secret reference.\n", profiles[0].Name, token)
+	return nil
+}
+
+// authorizeFlickr authorizes the profile's Flickr app to an account with
+// delete permission and prints the OAuth token to configure
+func authorizeFlickr(config *Config, profile string) error {
+	profiles, err := config.selectProfiles(profile, false)
+	if err != nil {
+		return err
+	}
+	var c generic.FlickrConfig
+	if err := decodeSection(profiles[0].Sections["flickr"], &c); err != nil {
+		return err
+	}
+	err = checkSettings(
+		setting{"api_key", c.APIKey, "copy the key of your app from https://www.flickr.com/services/apps/"},
+		setting{"api_secret", c.APISecret, "copy the secret of your app, or set a secret reference such as op://vault/flickr/secret"},
+	)
+	if err != nil {
+		return err
+	}
+	if err := secrets.ResolveAll(&c.APISecret); err != nil {
+		return fmt.Errorf("error resolving secret: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	creds, user, err := flickr.Authorize(ctx, c.APIKey, c.APISecret, func(link string) {
+		fmt.Printf("Open this address, signed in as the account to delete from, and allow access:\n\n%s\n\n", link)
+	}, func() (string, error) {
+		fmt.Print("Enter the code Flickr shows: ")
+		code, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil {
+			return "", fmt.Errorf("failed to read the code: %v", err)
+		}
+		return code, nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nAuthorized as %s. Add this to the flickr section of profile %s:\n\n\"oauth_token\": %q,\n\"oauth_token_secret\": %q\n\nThe token is as good as a password: store it like one, e.g. as a secret reference.\n", user, profiles[0].Name, creds.Token, creds.TokenSecret)
+	return nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// benchRequests is how many read-only requests bench times per account
+const benchRequests = 5
+
+// benchPageSize is roughly how many items a listing page holds
+const benchPageSize = 100
+
+// benchAccount is what bench measured and estimated for one account
+type benchAccount struct {
+	latency  time.Duration
+	items    int
+	duration time.Duration
+}
+
+// bench times read-only requests to every configured platform of the
+// profiles and, from the items the local index knows and the pacing,
+// estimates how long deleting them takes with different numbers of
+// profiles run at once
+func bench(config *Config, providers []Provider, profiles []namedProfile) error {
+	fmt.Printf("Timing %d read-only requests per account; nothing is deleted.\n", benchRequests)
+
+	measured := map[string][]benchAccount{}
+	for _, p := range profiles {
+		fmt.Printf("\nProfile %s:\n", p.Name)
+		for _, prov := range providers {
+			title := prov.Capabilities().Title
+			pr, ok := prov.(prober)
+			if !ok {
+				fmt.Printf("  %s: can't be measured\n", title)
+				continue
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), checkTimeout*benchRequests)
+			latency, err := timeProbe(ctx, pr, p.Profile)
+			cancel()
+			if errors.Is(err, errNotConfigured) {
+				fmt.Printf("  %s: not configured\n", title)
+				continue
+			}
+			if err != nil {
+				fmt.Printf("  %s: failed: %v\n", title, err)
+				continue
+			}
+
+			a, err := estimateAccount(config, prov, p.Name, latency)
+			if err != nil {
+				return err
+			}
+			if latency < time.Millisecond {
+				fmt.Printf("  %s: requests take under a millisecond\n", title)
+			} else {
+				fmt.Printf("  %s: %v a request, up to %s requests a minute\n", title, latency.Round(time.Millisecond), thousands(int(time.Minute/latency)))
+			}
+			if a.items == 0 {
+				fmt.Printf("    No items known yet; import an archive or make a plan to count them\n")
+				continue
+			}
+			fmt.Printf("    %s items known, deleted in about %v\n", thousands(a.items), roughly(a.duration))
+			measured[prov.Name()] = append(measured[prov.Name()], a)
+		}
+	}
+
+	if len(measured) == 0 {
+		return nil
+	}
+	fmt.Printf("\nEstimated time to delete everything known:\n")
+	for _, prov := range providers {
+		accounts := measured[prov.Name()]
+		if len(accounts) == 0 {
+			continue
+		}
+		var parts []string
+		for _, workers := range workerLevels(len(accounts)) {
+			label := fmt.Sprintf("%d at a time", workers)
+			switch {
+			case workers == 1 && len(accounts) == 1:
+				label = "on its own"
+			case workers == 1:
+				label = "one at a time"
+			case workers == len(accounts):
+				label = "all at once"
+			}
+			parts = append(parts, fmt.Sprintf("%s %v", label, roughly(makespan(accounts, workers))))
+		}
+		fmt.Printf("  %s (%d %s): %s\n", prov.Capabilities().Title, len(accounts), plural("profile", len(accounts)), strings.Join(parts, ", "))
+	}
+	fmt.Printf("These assume one write per item at the configured pacing; overwrites, retries and rate limits add to them.\n")
+	return nil
+}
+
+// timeProbe returns the average time of a read-only request to the
+// profile's account
+func timeProbe(ctx context.Context, pr prober, p *Profile) (time.Duration, error) {
+	probe, err := pr.Probe(ctx, p)
+	if err != nil {
+		return 0, err
+	}
+	var total time.Duration
+	for range benchRequests {
+		start := time.Now()
+		if err := probe(ctx); err != nil {
+			return 0, err
+		}
+		total += time.Since(start)
+	}
+	return total / benchRequests, nil
+}
+
+// estimateAccount estimates how long deleting an account's known items
+// takes: every item is a write, spaced by the write cap unless requests
+// are slower, and listing them waits the delay between pages
+func estimateAccount(config *Config, prov Provider, profile string, latency time.Duration) (benchAccount, error) {
+	items, err := liveItems(config, profile, prov.Name())
+	if err != nil {
+		return benchAccount{}, err
+	}
+
+	def := defaultPacing(prov.Name(), prov.Capabilities().MaxRate)
+	delay, writes, err := config.Pacing[prov.Name()].limits(def)
+	if err != nil {
+		return benchAccount{}, err
+	}
+
+	perItem := max(latency, writes.interval())
+	pages := (items + benchPageSize - 1) / benchPageSize
+	return benchAccount{
+		latency:  latency,
+		items:    items,
+		duration: time.Duration(items)*perItem + time.Duration(pages)*(delay+latency),
+	}, nil
+}
+
+// workerLevels are the numbers of profiles at once to estimate for: powers
+// of two up to all of them
+func workerLevels(n int) []int {
+	var levels []int
+	for w := 1; w < n; w *= 2 {
+		levels = append(levels, w)
+	}
+	return append(levels, n)
+}
+
+// makespan is how long the accounts take when workers of them run at once,
+// each worker taking the longest account left
+func makespan(accounts []benchAccount, workers int) time.Duration {
+	durations := make([]time.Duration, len(accounts))
+	for i, a := range accounts {
+		durations[i] = a.duration
+	}
+	slices.Sort(durations)
+	slices.Reverse(durations)
+
+	busy := make([]time.Duration, workers)
+	for _, d := range durations {
+		i := slices.Index(busy, slices.Min(busy))
+		busy[i] += d
+	}
+	return slices.Max(busy)
+}
+
+// roughly rounds an estimate to what is worth reading
+func roughly(d time.Duration) time.Duration {
+	if d < time.Minute {
+		return d.Round(time.Second)
+	}
+	return d.Round(time.Minute)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// checkTimeout bounds each provider's health check
+const checkTimeout = 30 * time.Second
+
+// doctor checks every provider for every selected profile and reports
+// whether all configured ones are healthy
+func doctor(providers []Provider, profiles []namedProfile) bool {
+	healthy := true
+	for _, p := range profiles {
+		fmt.Printf("Profile %s:\n", p.Name)
+		for _, prov := range providers {
+			ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+			err := prov.Check(ctx, p.Profile)
+			cancel()
+
+			switch {
+			case errors.Is(err, errNotConfigured) && plain:
+				fmt.Printf("  %s: not configured\n", prov.Name())
+			case errors.Is(err, errNotConfigured):
+				fmt.Printf("  - %s: not configured\n", prov.Name())
+			case err != nil && plain:
+				fmt.Printf("  %s: failed: %v\n", prov.Name(), err)
+				healthy = false
+			case err != nil:
+				fmt.Printf("  ✗ %s: %v\n", prov.Name(), err)
+				healthy = false
+			case plain:
+				fmt.Printf("  %s: ok\n", prov.Name())
+			default:
+				fmt.Printf("  ✓ %s: ok\n", prov.Name())
+			}
+		}
+	}
+	return healthy
+}
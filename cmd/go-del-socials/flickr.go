@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"go-del-socials/pkg/generic"
+	"go-del-socials/pkg/secrets"
+)
+
+// validateFlickr is the flickr provider's check of a profile's section
+func validateFlickr(section json.RawMessage) error {
+	var c generic.FlickrConfig
+	if err := decodeSection(section, &c); err != nil {
+		return err
+	}
+	if c.APIKey == "" && c.APISecret == "" && c.OAuthToken == "" && c.OAuthTokenSecret == "" {
+		return nil
+	}
+	return checkSettings(
+		setting{"api_key", c.APIKey, "copy the key of your app from https://www.flickr.com/services/apps/"},
+		setting{"api_secret", c.APISecret, "copy the secret of your app, or set a secret reference such as op://vault/flickr/secret"},
+		setting{"oauth_token", c.OAuthToken, "run go-del-socials flickr-authorize to get one"},
+		setting{"oauth_token_secret", c.OAuthTokenSecret, "run go-del-socials flickr-authorize to get one"},
+	)
+}
+
+// flickrClient returns a client for the profile's flickr section
+func flickrClient(p *Profile, out io.Writer) (*generic.Client, error) {
+	var c generic.FlickrConfig
+	if err := decodeSection(p.Sections["flickr"], &c); err != nil {
+		return nil, err
+	}
+	if c.APIKey == "" {
+		return nil, errNotConfigured
+	}
+	if err := secrets.ResolveAll(&c.APISecret, &c.OAuthToken, &c.OAuthTokenSecret); err != nil {
+		return nil, fmt.Errorf("error resolving secret: %v", err)
+	}
+	return generic.NewFlickrClient(&c, out), nil
+}
+
+// checkFlickr verifies the app is authorized to the account
+func checkFlickr(ctx context.Context, p *Profile) error {
+	client, err := flickrClient(p, io.Discard)
+	if err != nil {
+		return err
+	}
+	return client.Check(ctx)
+}
+
+// probeFlickr times signing in
+func probeFlickr(ctx context.Context, p *Profile) (func(ctx context.Context) error, error) {
+	client, err := flickrClient(p, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+	return client.Check, nil
+}
+
+func runFlickrDeletion(ctx context.Context, j *job) ([]count, error) {
+	client, err := flickrClient(j.Profile, j.Out)
+	if err != nil {
+		return nil, err
+	}
+	return deleteGeneric(ctx, j, client, "Flickr", generic.FlickrTypes())
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"go-del-socials/pkg/generic"
+	"go-del-socials/pkg/secrets"
+)
+
+// defaultProviderDir is where generic provider specs are found unless the
+// config says otherwise
+const defaultProviderDir = "providers"
+
+// genericProvider is a provider described by a YAML spec
+type genericProvider struct {
+	spec *generic.Spec
+}
+
+func (p genericProvider) Name() string { return p.spec.Name }
+
+func (p genericProvider) Capabilities() Capabilities {
+	return Capabilities{
+		Title:        p.spec.Title,
+		ContentTypes: p.spec.ContentTypes(),
+		MaxRate:      p.spec.RateLimit,
+		Flags:        []string{"receipts"},
+	}
+}
+
+func (p genericProvider) Validate(section json.RawMessage) error {
+	vars := map[string]string{}
+	if err := decodeSection(section, &vars); err != nil {
+		return fmt.Errorf("settings must map names to strings: %v", err)
+	}
+	return nil
+}
+
+func (p genericProvider) Run(ctx context.Context, j *job) ([]count, error) {
+	return runGenericDeletion(ctx, j, p.spec)
+}
+
+func (p genericProvider) Check(ctx context.Context, profile *Profile) error {
+	vars, err := genericVars(p.spec, profile)
+	if err != nil {
+		return err
+	}
+	return generic.NewClient(p.spec, vars, io.Discard).Check(ctx)
+}
+
+// Probe times the request Check sends, listing the first page
+func (p genericProvider) Probe(ctx context.Context, profile *Profile) (func(ctx context.Context) error, error) {
+	vars, err := genericVars(p.spec, profile)
+	if err != nil {
+		return nil, err
+	}
+	return generic.NewClient(p.spec, vars, io.Discard).Check, nil
+}
+
+// genericVars returns the spec's vars with the profile's section applied,
+// e.g. its token, and secrets resolved
+func genericVars(spec *generic.Spec, p *Profile) (map[string]string, error) {
+	vars := maps.Clone(spec.Vars)
+	if vars == nil {
+		vars = map[string]string{}
+	}
+	if section, ok := p.Sections[spec.Name]; ok {
+		if err := json.Unmarshal(section, &vars); err != nil {
+			return nil, fmt.Errorf("profile's %s section must map names to strings: %v", spec.Name, err)
+		}
+	}
+	for k, v := range vars {
+		resolved, err := secrets.Resolve(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %v", k, err)
+		}
+		vars[k] = resolved
+	}
+	return vars, nil
+}
+
+func runGenericDeletion(ctx context.Context, j *job, spec *generic.Spec) ([]count, error) {
+	vars, err := genericVars(spec, j.Profile)
+	if err != nil {
+		return nil, err
+	}
+	return deleteGeneric(ctx, j, generic.NewClient(spec, vars, j.Out), spec.Title, spec.ContentTypes())
+}
+
+// webhookProvider is a provider whose listing and deleting is done by a
+// webhook service
+type webhookProvider struct {
+	name string
+	cfg  *generic.WebhookConfig
+}
+
+func (p webhookProvider) Name() string { return p.name }
+
+func (p webhookProvider) Capabilities() Capabilities {
+	return Capabilities{
+		Title:        p.cfg.Title,
+		ContentTypes: p.cfg.Types(),
+		MaxRate:      p.cfg.RateLimit,
+		Flags:        []string{"receipts"},
+	}
+}
+
+// Validate only checks the section is an object; it is passed on to the
+// service as is
+func (p webhookProvider) Validate(section json.RawMessage) error {
+	return validateObject(section)
+}
+
+func (p webhookProvider) Check(ctx context.Context, profile *Profile) error {
+	return generic.NewWebhookClient(p.name, p.cfg, profile.Sections[p.name], io.Discard).Check(ctx)
+}
+
+func (p webhookProvider) Probe(ctx context.Context, profile *Profile) (func(ctx context.Context) error, error) {
+	return generic.NewWebhookClient(p.name, p.cfg, profile.Sections[p.name], io.Discard).Check, nil
+}
+
+func (p webhookProvider) Run(ctx context.Context, j *job) ([]count, error) {
+	client := generic.NewWebhookClient(p.name, p.cfg, j.Profile.Sections[p.name], j.Out)
+	return deleteGeneric(ctx, j, client, p.cfg.Title, p.cfg.Types())
+}
+
+// fakeProvider makes up content, for rehearsing filters, plans and
+// schedules without touching a real account
+type fakeProvider struct {
+	cfg *generic.FakeConfig
+}
+
+func (p fakeProvider) Name() string { return "fake" }
+
+func (p fakeProvider) Capabilities() Capabilities {
+	return Capabilities{
+		Title:        "Fake",
+		ContentTypes: p.cfg.Types(),
+		Undo:         true,
+		MaxRate:      p.cfg.Rate(),
+		Flags:        []string{"receipts"},
+	}
+}
+
+func (p fakeProvider) Validate(section json.RawMessage) error {
+	return fmt.Errorf("the fake platform is set up by the top-level fake section, not in profiles")
+}
+
+func (p fakeProvider) Check(ctx context.Context, profile *Profile) error { return nil }
+
+func (p fakeProvider) Probe(ctx context.Context, profile *Profile) (func(ctx context.Context) error, error) {
+	return generic.NewFakeClient(p.cfg, io.Discard).Check, nil
+}
+
+func (p fakeProvider) Run(ctx context.Context, j *job) ([]count, error) {
+	return deleteGeneric(ctx, j, generic.NewFakeClient(p.cfg, j.Out), "Fake", p.cfg.Types())
+}
+
+// deleteGeneric runs a generic client, which lists and deletes through a
+// spec or a webhook
+func deleteGeneric(ctx context.Context, j *job, client *generic.Client, title string, types []string) ([]count, error) {
+	fmt.Fprintf(j.Out, "\nDeleting %s content before %s...\n", title, j.CutoffDate.Format("2006-01-02"))
+
+	if err := j.checkKeepLast(slices.DeleteFunc(slices.Clone(types), func(t string) bool { return t == "all" })...); err != nil {
+		return nil, err
+	}
+	targets, err := genericTargets(j)
+	if err != nil {
+		return nil, err
+	}
+	retention, err := j.retention()
+	if err != nil {
+		return nil, err
+	}
+	pacer, err := j.pacer(client.Name(), client.RateLimit())
+	if err != nil {
+		return nil, err
+	}
+	defer j.learn(client.Name(), pacer)
+	result, err := client.DeleteContent(ctx, generic.DeleteOptions{
+		ContentType: j.ContentType,
+		CutoffDate:  j.CutoffDate,
+		Plan:        j.Plan,
+		Approved:    j.Approved,
+		Kept:        j.Kept,
+		Tombstones:  j.Tombstones,
+		Index:       j.Index,
+		Receipts:    j.Receipts,
+		Hooks:       j.Hooks,
+		Pause:       j.Options.Pause,
+		Simulate:    j.Options.Simulate,
+		Pace:        pacer,
+		Targets:     targets,
+		KeepLast:    j.Options.KeepLast,
+		Retention:   retention,
+	})
+	j.Report.Matched, j.Report.Failed = result.Matched, result.Failed
+	j.Report.Skip("not in the plan", result.NotPlanned)
+	j.Report.Skip("already deleted by an earlier run", result.AlreadyDeleted)
+	j.Report.Skip("already gone", result.AlreadyGone)
+	j.Report.Skip("vetoed by the before-delete hook", result.Vetoed)
+	j.Report.Skip("can't be deleted through the API", result.Unremovable)
+	if result.Unremovable > 0 {
+		fmt.Fprintf(j.Out, "\n%d items can't be deleted through the API and stay online; --export-kept lists them with the reason\n", result.Unremovable)
+	}
+	if j.Plan != nil {
+		return planCounts(j, err)
+	}
+
+	var counts []count
+	for _, kind := range types {
+		if kind != "all" && (j.ContentType == "all" || j.ContentType == kind) {
+			counts = append(counts, count{strings.ToUpper(kind[:1]) + kind[1:] + " deleted", result.Deleted[kind]})
+		}
+	}
+	if err != nil {
+		return counts, fmt.Errorf("error during deletion: %v", err)
+	}
+	return counts, nil
+}
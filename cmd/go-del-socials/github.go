@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// githubActions adds GitHub Actions workflow commands to the output, and
+// a job summary, for runs in a workflow
+var githubActions bool
+
+// githubWarning matches a warning line, after the profile's prefix if any
+var githubWarning = regexp.MustCompile(`^(\[[^\]]+\] )?Warning: (.*)$`)
+
+// githubWriter turns warnings into ::warning commands, so they show as
+// annotations on the workflow run
+type githubWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+func (g *githubWriter) Write(b []byte) (int, error) {
+	g.buf = append(g.buf, b...)
+	for {
+		i := bytes.IndexByte(g.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(g.buf[:i])
+		g.buf = g.buf[i+1:]
+		if m := githubWarning.FindStringSubmatch(line); m != nil {
+			line = "::warning::" + githubEscape(m[1]+m[2])
+		}
+		if _, err := io.WriteString(g.w, line+"\n"); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// githubEscape escapes a workflow command's message
+func githubEscape(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// githubProperty escapes a workflow command's property, such as its title
+func githubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// annotateGitHub prints a notice or error for every profile's run and
+// appends a Markdown table of the results to the job summary
+func annotateGitHub(results []*runResult) {
+	for _, r := range results {
+		title := githubProperty(fmt.Sprintf("%s (profile %s)", r.Title, r.Profile))
+		if r.Err != nil {
+			fmt.Fprintf(stdout, "::error title=%s::%s\n", title, githubEscape(r.Err.Error()))
+			continue
+		}
+		parts := make([]string, 0, len(r.Counts))
+		for _, c := range r.Counts {
+			parts = append(parts, fmt.Sprintf("%s: %d", c.Label, c.N))
+		}
+		msg := fmt.Sprintf("%d items. %s", r.total(), strings.Join(parts, ", "))
+		if r.Simulated {
+			msg += " (simulated, nothing was actually deleted)"
+		}
+		fmt.Fprintf(stdout, "::notice title=%s::%s\n", title, githubEscape(msg))
+	}
+
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		fmt.Fprintf(stdout, "::warning::%s\n", githubEscape(fmt.Sprintf("failed to write the job summary: %v", err)))
+		return
+	}
+	defer f.Close()
+	if _, err := io.WriteString(f, githubSummary(results)); err != nil {
+		fmt.Fprintf(stdout, "::warning::%s\n", githubEscape(fmt.Sprintf("failed to write the job summary: %v", err)))
+	}
+}
+
+// githubSummary is the Markdown of a job summary
+func githubSummary(results []*runResult) string {
+	cell := strings.NewReplacer("|", "\\|", "\n", " ").Replace
+	var b strings.Builder
+	b.WriteString("## go-del-socials\n\n| Profile | Platform | Result | Deleted | Details |\n| --- | --- | --- | ---: | --- |\n")
+	for _, r := range results {
+		result := "done"
+		switch {
+		case r.Err != nil:
+			result = "**failed**: " + cell(r.Err.Error())
+		case r.ResumeAt != nil:
+			result = "stopped, resumes " + r.ResumeAt.Local().Format("2006-01-02 15:04")
+		case r.Simulated:
+			result = "simulated"
+		}
+		details := make([]string, 0, len(r.Counts))
+		for _, c := range r.Counts {
+			details = append(details, fmt.Sprintf("%s: %d", c.Label, c.N))
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %d | %s |\n", cell(r.Profile), cell(r.Title), result, r.total(), cell(strings.Join(details, ", ")))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
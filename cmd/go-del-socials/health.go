@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-del-socials/pkg/pause"
+)
+
+// wedgedAfter is how long an active run may go without output before the
+// liveness check fails
+const wedgedAfter = time.Hour
+
+// platformHealth is what the readiness check says about a platform
+type platformHealth struct {
+	Healthy     bool       `json:"healthy"`
+	LastCheck   *time.Time `json:"last_check,omitempty"`
+	CheckError  string     `json:"check_error,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+// healthState backs the /healthz and /readyz endpoints of the progress
+// server, for container orchestrators
+type healthState struct {
+	pause *pause.Gate
+
+	active   atomic.Int32
+	activity atomic.Int64
+
+	mu        sync.Mutex
+	platforms map[string]*platformHealth
+}
+
+func newHealthState(g *pause.Gate) *healthState {
+	h := &healthState{pause: g, platforms: map[string]*platformHealth{}}
+	h.activity.Store(time.Now().UnixNano())
+	return h
+}
+
+// Write notes output of a run, which shows it is making progress
+func (h *healthState) Write(b []byte) (int, error) {
+	h.activity.Store(time.Now().UnixNano())
+	return len(b), nil
+}
+
+// started and finished track the runs in progress
+func (h *healthState) started() {
+	h.activity.Store(time.Now().UnixNano())
+	h.active.Add(1)
+}
+
+func (h *healthState) finished() { h.active.Add(-1) }
+
+// checked records a provider's health check
+func (h *healthState) checked(platform string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	p := h.platform(platform)
+	now := time.Now()
+	p.LastCheck, p.Healthy, p.CheckError = &now, err == nil, ""
+	if err != nil {
+		p.CheckError = err.Error()
+	}
+}
+
+// ran records the outcome of a profile's run
+func (h *healthState) ran(platform string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	p := h.platform(platform)
+	if err != nil {
+		p.LastError = err.Error()
+		return
+	}
+	now := time.Now()
+	p.LastSuccess, p.LastError = &now, ""
+}
+
+func (h *healthState) platform(name string) *platformHealth {
+	if h.platforms[name] == nil {
+		h.platforms[name] = &platformHealth{}
+	}
+	return h.platforms[name]
+}
+
+// live fails while a run that isn't paused has been silent for wedgedAfter
+func (h *healthState) live(w http.ResponseWriter, r *http.Request) {
+	silent := time.Since(time.Unix(0, h.activity.Load()))
+	status := struct {
+		Status     string  `json:"status"`
+		ActiveRuns int32   `json:"active_runs"`
+		Paused     bool    `json:"paused"`
+		SilentFor  float64 `json:"silent_seconds"`
+	}{"ok", h.active.Load(), h.pause.Paused(), silent.Seconds()}
+
+	code := http.StatusOK
+	if status.ActiveRuns > 0 && !status.Paused && silent > wedgedAfter {
+		status.Status, code = "wedged", http.StatusServiceUnavailable
+	}
+	writeHealth(w, code, status)
+}
+
+// ready fails while a platform's last health check failed, and reports
+// each platform's last check and last successful run
+func (h *healthState) ready(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	status := struct {
+		Status    string                     `json:"status"`
+		Platforms map[string]*platformHealth `json:"platforms"`
+	}{"ready", h.platforms}
+
+	code := http.StatusOK
+	for _, p := range h.platforms {
+		if p.LastCheck != nil && !p.Healthy {
+			status.Status, code = "unhealthy provider", http.StatusServiceUnavailable
+		}
+	}
+	writeHealth(w, code, status)
+}
+
+func writeHealth(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
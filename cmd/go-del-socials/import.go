@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+
+	"go-del-socials/pkg/export"
+	"go-del-socials/pkg/index"
+	"go-del-socials/pkg/reddit"
+	"go-del-socials/pkg/state"
+	"go-del-socials/pkg/twitter"
+)
+
+// importArchive loads a platform's data export, such as a Twitter archive
+// or an extracted Reddit data request, into a profile's item index, so plans
+// and runs can work from it instead of listing everything again
+func importArchive(config *Config, profile, path string) error {
+	if profile == "" {
+		profile = defaultProfile
+	} else if _, ok := config.Profiles[profile]; !ok {
+		return fmt.Errorf("profile %q not found in config file", profile)
+	}
+
+	st, err := state.Open(config.stateDir(), profile)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	s, err := config.openStore(st.Root)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	ix := index.New(s)
+
+	format, err := export.Detect(path)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case export.Reddit:
+		n, err := reddit.ImportExport(path, ix)
+		fmt.Printf("Imported %d Reddit posts and comments into profile %s\n", n, profile)
+		return err
+	case export.Twitter:
+		n, skipped, err := twitter.ImportArchive(path, ix)
+		fmt.Printf("Imported %d tweets and replies into profile %s\n", n, profile)
+		if skipped > 0 {
+			fmt.Printf("Skipped %d retweets: the archive doesn't record which tweets they retweeted\n", skipped)
+		}
+		return err
+	}
+
+	n, err := format.Import(path, ix)
+	fmt.Printf("Imported %d items of the %s into profile %s\n", n, format.Name, profile)
+	switch {
+	case err != nil:
+	case findProvider(builtins, format.Platform) != nil:
+		fmt.Printf("Runs of the %s provider include them\n", format.Platform)
+	default:
+		fmt.Printf("Runs of a generic or webhook platform named %s include them\n", format.Platform)
+	}
+	return err
+}
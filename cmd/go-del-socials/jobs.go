@@ -0,0 +1,402 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-del-socials/pkg/state"
+
+	"gopkg.in/yaml.v3"
+)
+
+// JobsFile describes several deletion jobs run by one `run --jobs`
+type JobsFile struct {
+	// Parallel runs the jobs concurrently instead of one after another
+	Parallel bool  `yaml:"parallel"`
+	Jobs     []Job `yaml:"jobs"`
+}
+
+// Job is one platform run with its own profiles, filters and schedule
+type Job struct {
+	Name string `yaml:"name"`
+
+	// Template fills in what the job leaves unset
+	Template string `yaml:"template"`
+
+	Platform    string   `yaml:"platform"`
+	Profiles    []string `yaml:"profiles"`
+	AllProfiles bool     `yaml:"all_profiles"`
+	ContentType string   `yaml:"content_type"`
+
+	// Cutoff is a date (YYYY, YYYY-MM or YYYY-MM-DD); OlderThan is an age
+	// such as 90d or 36h, counted back from the start of the run
+	Cutoff    string `yaml:"cutoff"`
+	OlderThan string `yaml:"older_than"`
+
+	// Every skips the job until this long after its last successful run
+	Every string `yaml:"every"`
+
+	// Options override the command-line flags, named as in a plan's options
+	Options map[string]any `yaml:"options"`
+
+	// ExportKept is the job's --export-kept file
+	ExportKept string `yaml:"export_kept"`
+}
+
+// jobRun is a job ready to run
+type jobRun struct {
+	Job
+	opts        options
+	provider    Provider
+	profiles    []namedProfile
+	contentType string
+	cutoff      time.Time
+	every       time.Duration
+}
+
+// jobReport is a job's part of the combined report
+type jobReport struct {
+	Name        string      `json:"name"`
+	Platform    string      `json:"platform"`
+	ContentType string      `json:"content_type"`
+	Cutoff      time.Time   `json:"cutoff"`
+	Skipped     string      `json:"skipped,omitempty"`
+	ResumeAt    *time.Time  `json:"resume_at,omitempty"`
+	Profiles    []jobResult `json:"profiles,omitempty"`
+}
+
+type jobResult struct {
+	Profile string         `json:"profile"`
+	Counts  map[string]int `json:"counts"`
+	Total   int            `json:"total"`
+	Report  string         `json:"report,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// readJobs reads a jobs file and prepares every job, so a mistake in the
+// last one stops the run before the first one deletes anything
+func readJobs(path string, config *Config, base *options, now time.Time) (*JobsFile, []*jobRun, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read jobs file: %v", err)
+	}
+	var f JobsFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse jobs file: %v", err)
+	}
+	if len(f.Jobs) == 0 {
+		return nil, nil, fmt.Errorf("%s lists no jobs", path)
+	}
+
+	names := map[string]bool{}
+	runs := make([]*jobRun, len(f.Jobs))
+	for i, j := range f.Jobs {
+		if j.Name == "" {
+			j.Name = fmt.Sprintf("job-%d", i+1)
+		}
+		if names[j.Name] {
+			return nil, nil, fmt.Errorf("job name %s is used twice", j.Name)
+		}
+		names[j.Name] = true
+
+		r, err := prepareJob(j, config, base, now)
+		if err != nil {
+			return nil, nil, fmt.Errorf("job %s: %v", j.Name, err)
+		}
+		runs[i] = r
+	}
+	return &f, runs, nil
+}
+
+func prepareJob(j Job, config *Config, base *options, now time.Time) (*jobRun, error) {
+	r := &jobRun{Job: j, opts: *base}
+
+	if j.Template != "" {
+		t, err := config.template(j.Template)
+		if err != nil {
+			return nil, err
+		}
+		if j.Platform == "" {
+			j.Platform = t.Platform
+		}
+		if j.ContentType == "" {
+			j.ContentType = t.ContentType
+		}
+		if j.Cutoff == "" && j.OlderThan == "" {
+			j.Cutoff, j.OlderThan = t.Cutoff, t.OlderThan
+		}
+		if err := applyOptions(&r.opts, t.Options); err != nil {
+			return nil, err
+		}
+		r.Job = j
+	}
+
+	r.provider = selectProvider(base.Providers, j.Platform)
+	if r.provider == nil {
+		return nil, fmt.Errorf("unknown platform %q", j.Platform)
+	}
+
+	r.contentType = j.ContentType
+	if r.contentType == "" {
+		r.contentType = "all"
+	}
+	if !slices.Contains(r.provider.Capabilities().ContentTypes, r.contentType) {
+		return nil, fmt.Errorf("%s doesn't support the content type %s", j.Platform, r.contentType)
+	}
+
+	var err error
+	if j.Every != "" {
+		if r.every, err = parseAge(j.Every); err != nil {
+			return nil, fmt.Errorf("invalid every: %v", err)
+		}
+	}
+
+	if len(j.Options) > 0 {
+		data, err := json.Marshal(j.Options)
+		if err != nil {
+			return nil, fmt.Errorf("invalid options: %v", err)
+		}
+		if err := applyOptions(&r.opts, data); err != nil {
+			return nil, err
+		}
+	}
+	if r.cutoff, err = resolveCutoff(j.Cutoff, j.OlderThan, now, len(r.opts.KeepLast) > 0); err != nil {
+		return nil, err
+	}
+	if _, err := parseRetention(r.opts.Retention, now); err != nil {
+		return nil, fmt.Errorf("invalid retention: %v", err)
+	}
+	r.opts.ExportKept, r.opts.Kept = j.ExportKept, nil
+
+	if j.AllProfiles {
+		if len(j.Profiles) > 0 {
+			return nil, fmt.Errorf("set either profiles or all_profiles, not both")
+		}
+		profiles, err := config.selectProfiles("", true)
+		if err != nil {
+			return nil, err
+		}
+		r.profiles = profiles
+	} else if len(j.Profiles) == 0 {
+		profiles, err := config.selectProfiles("", false)
+		if err != nil {
+			return nil, err
+		}
+		r.profiles = profiles
+	}
+	for _, name := range j.Profiles {
+		profiles, err := config.selectProfiles(name, false)
+		if err != nil {
+			return nil, err
+		}
+		r.profiles = append(r.profiles, profiles...)
+	}
+	return r, nil
+}
+
+// parseAge reads a duration that may also be given in days, e.g. 90d
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid number of days %q", days)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// runJobs runs the jobs of a jobs file and writes the combined report. It
+// returns whether every job that was due succeeded.
+func runJobs(config *Config, base *options, path string, parallel bool) (bool, error) {
+	now := time.Now()
+	f, runs, err := readJobs(path, config, base, now)
+	if err != nil {
+		return false, err
+	}
+
+	dir := config.stateDir()
+	if dir == "" {
+		if dir, err = state.BaseDir(); err != nil {
+			return false, err
+		}
+	}
+	dir = filepath.Join(dir, "jobs")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return false, fmt.Errorf("failed to create jobs state directory: %v", err)
+	}
+	lastRun, err := readLastRun(filepath.Join(dir, "last-run.json"))
+	if err != nil {
+		return false, err
+	}
+	// Jobs stopped by a daily write limit are due again once it resets,
+	// whatever their schedule
+	resume, err := readLastRun(filepath.Join(dir, "resume.json"))
+	if err != nil {
+		return false, err
+	}
+
+	parallel = parallel || f.Parallel
+	if parallel {
+		// A profile's state is locked by the run using it
+		users := map[string]string{}
+		for _, r := range runs {
+			for _, p := range r.profiles {
+				if other, ok := users[p.Name]; ok {
+					return false, fmt.Errorf("jobs %s and %s both use profile %s, so they can't run in parallel", other, r.Name, p.Name)
+				}
+				users[p.Name] = r.Name
+			}
+		}
+	}
+	reports := make([]jobReport, len(runs))
+	results := make([][]*runResult, len(runs))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i, r := range runs {
+		reports[i] = jobReport{Name: r.Name, Platform: r.Platform, ContentType: r.contentType, Cutoff: r.cutoff}
+		at, resuming := resume[r.Name]
+		switch {
+		case resuming && now.Before(at):
+			reports[i].Skipped = fmt.Sprintf("daily write limit reached; resuming at %s", at.Format("2006-01-02 15:04"))
+		case resuming:
+			fmt.Fprintf(stdout, "\nResuming job %s, which stopped at a daily write limit\n", r.Name)
+		default:
+			if last, ok := lastRun[r.Name]; ok && r.every > 0 && now.Sub(last) < r.every {
+				reports[i].Skipped = fmt.Sprintf("not due until %s", last.Add(r.every).Format("2006-01-02 15:04"))
+			}
+		}
+		if reports[i].Skipped != "" {
+			fmt.Fprintf(stdout, "\nSkipping job %s: %s\n", r.Name, reports[i].Skipped)
+			continue
+		}
+
+		exec := func(out io.Writer) {
+			fmt.Fprintf(out, "\nRunning job %s: %s %s before %s for %d profiles\n",
+				r.Name, r.Platform, r.contentType, r.cutoff.Format("2006-01-02"), len(r.profiles))
+			results[i] = runProfilesTo(out, &r.opts, r.profiles, config, r.provider, r.contentType, r.cutoff, parallel)
+		}
+		if parallel {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				exec(newPrefixWriter(stdout, &mu, "["+r.Name+"] "))
+			}()
+		} else {
+			exec(stdout)
+		}
+	}
+	wg.Wait()
+
+	ok := true
+	grand := 0
+	for i, r := range runs {
+		if results[i] == nil {
+			continue
+		}
+		fmt.Fprintf(stdout, "\n=== Job %s ===\n", r.Name)
+		printSummary(results[i])
+
+		failed := false
+		delete(resume, r.Name)
+		for _, res := range results[i] {
+			jr := jobResult{Profile: res.Profile, Counts: map[string]int{}, Total: res.total(), Report: res.ReportPath}
+			for _, c := range res.Counts {
+				jr.Counts[c.Label] = c.N
+			}
+			if res.Err != nil {
+				jr.Error, failed = res.Err.Error(), true
+			}
+			if res.ResumeAt != nil && res.ResumeAt.After(resume[r.Name]) {
+				resume[r.Name] = *res.ResumeAt
+			}
+			reports[i].Profiles = append(reports[i].Profiles, jr)
+			grand += jr.Total
+		}
+		if at, ok := resume[r.Name]; ok {
+			reports[i].ResumeAt = &at
+			fmt.Fprintf(stdout, "Job %s stopped at a daily write limit and resumes from %s\n", r.Name, at.Format("2006-01-02 15:04"))
+		}
+		if failed {
+			ok = false
+		} else {
+			lastRun[r.Name] = now
+		}
+	}
+
+	fmt.Fprintf(stdout, "\nAll Jobs: %d ran, %d skipped, %d items in total\n", countRan(results), len(runs)-countRan(results), grand)
+
+	combined := struct {
+		JobsFile string      `json:"jobs_file"`
+		Started  time.Time   `json:"started"`
+		Finished time.Time   `json:"finished"`
+		Jobs     []jobReport `json:"jobs"`
+	}{path, now, time.Now(), reports}
+	data, err := json.MarshalIndent(combined, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	reportPath := filepath.Join(dir, fmt.Sprintf("report-%s.json", now.UTC().Format("20060102T150405Z")))
+	if err := os.WriteFile(reportPath, data, 0600); err != nil {
+		return false, fmt.Errorf("failed to write combined report: %v", err)
+	}
+	fmt.Fprintf(stdout, "Combined report: %s\n", reportPath)
+
+	if err := writeLastRun(filepath.Join(dir, "last-run.json"), lastRun); err != nil {
+		return false, err
+	}
+	if err := writeLastRun(filepath.Join(dir, "resume.json"), resume); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
+
+func countRan(results [][]*runResult) int {
+	n := 0
+	for _, r := range results {
+		if r != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// readLastRun reads a time per job, such as when each last succeeded; a
+// missing file means none is recorded yet
+func readLastRun(path string) (map[string]time.Time, error) {
+	last := map[string]time.Time{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return last, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read job schedule state: %v", err)
+	}
+	if err := json.Unmarshal(data, &last); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return last, nil
+}
+
+func writeLastRun(path string, last map[string]time.Time) error {
+	data, err := json.MarshalIndent(last, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write job schedule state: %v", err)
+	}
+	return nil
+}
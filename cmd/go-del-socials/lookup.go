@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go-del-socials/pkg/logtext"
+	"go-del-socials/pkg/state"
+	"go-del-socials/pkg/store"
+	"go-del-socials/pkg/tombstone"
+)
+
+// lookup searches the tombstone index of every profile for a link or ID of
+// deleted content and prints where the local copy is
+func lookup(config *Config, query string) (int, error) {
+	base := config.stateDir()
+	if base == "" {
+		var err error
+		if base, err = state.BaseDir(); err != nil {
+			return 0, err
+		}
+	}
+
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read state directory: %v", err)
+	}
+
+	found := 0
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(base, e.Name())
+		path, err := store.Path(config.StateBackend, dir)
+		if err != nil {
+			return found, err
+		}
+		if _, err := os.Stat(path); err != nil {
+			if _, err := os.Stat(legacyTombstones(dir)); err != nil {
+				continue
+			}
+		}
+
+		s, err := config.openStore(dir)
+		if err != nil {
+			return found, err
+		}
+		stones, err := tombstone.New(s).Lookup(query)
+		s.Close()
+		if err != nil {
+			return found, err
+		}
+
+		for _, t := range stones {
+			found++
+			fmt.Printf("\n%s %s %s (profile %s)\n", t.Platform, t.Kind, t.ID, e.Name())
+			fmt.Printf("Posted:  %s\n", t.Created.Local().Format("2006-01-02 15:04"))
+			fmt.Printf("Deleted: %s\n", t.Deleted.Local().Format("2006-01-02 15:04"))
+			if t.URL != "" {
+				fmt.Printf("URL:     %s\n", t.URL)
+			}
+			if t.Archive != "" {
+				fmt.Printf("Archive: %s\n", t.Archive)
+			}
+			if t.Text != "" {
+				fmt.Printf("Content: %s\n", logtext.Clean(t.Text))
+			}
+		}
+	}
+
+	return found, nil
+}
+
+// legacyTombstones is where versions before the state store kept the
+// tombstone index of the profile in dir
+func legacyTombstones(dir string) string {
+	return filepath.Join(dir, state.Archives, "tombstones.db")
+}
+
+// openStore opens the state store of the profile in dir, first moving in
+// the tombstones of an older version's index
+func (c *Config) openStore(dir string) (store.Store, error) {
+	s, err := store.Open(c.StateBackend, dir)
+	if err != nil {
+		return nil, err
+	}
+	path := legacyTombstones(dir)
+	n, err := tombstone.Migrate(tombstone.New(s), path)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	if n > 0 {
+		fmt.Printf("Moved %d tombstones from %s into the state store\n", n, path)
+	}
+	return s, nil
+}
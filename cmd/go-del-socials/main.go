@@ -2,32 +2,196 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"go-del-socials/pkg/archive"
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/filter"
+	"go-del-socials/pkg/generic"
+	"go-del-socials/pkg/hook"
+	"go-del-socials/pkg/index"
+	"go-del-socials/pkg/inventory"
+	"go-del-socials/pkg/logfile"
+	"go-del-socials/pkg/manifest"
+	"go-del-socials/pkg/mqtt"
+	"go-del-socials/pkg/pace"
+	"go-del-socials/pkg/pause"
+	"go-del-socials/pkg/plan"
+	"go-del-socials/pkg/progress"
 	"go-del-socials/pkg/reddit"
+	"go-del-socials/pkg/report"
+	"go-del-socials/pkg/secrets"
+	"go-del-socials/pkg/state"
+	"go-del-socials/pkg/store"
+	"go-del-socials/pkg/telemetry"
+	"go-del-socials/pkg/tombstone"
+	"go-del-socials/pkg/trigger"
 	"go-del-socials/pkg/twitter"
+	"go-del-socials/pkg/upload"
+	"go-del-socials/pkg/wayback"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
+type RedditConfig struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	UserAgent    string `json:"user_agent"`
+
+	// RefreshToken signs in installed apps, which have no client_secret,
+	// in place of the password; reddit-authorize gets one at RedirectURI
+	RefreshToken string `json:"refresh_token"`
+	RedirectURI  string `json:"redirect_uri"`
+
+	// APIURL and AuthURL point the provider at a gateway or a
+	// Reddit-compatible server instead of oauth.reddit.com and
+	// www.reddit.com
+	APIURL  string `json:"api_url"`
+	AuthURL string `json:"auth_url"`
+
+	Overwrite reddit.OverwriteTemplates `json:"overwrite"`
+}
+
+type TwitterConfig struct {
+	APIKey            string `json:"api_key"`
+	APIKeySecret      string `json:"api_key_secret"`
+	AccessToken       string `json:"access_token"`
+	AccessTokenSecret string `json:"access_token_secret"`
+	Username          string `json:"username"`
+
+	// DailyWriteBudget caps deletes per UTC day; 0 means unlimited
+	DailyWriteBudget int `json:"daily_write_budget"`
+
+	// AdsAccountID is needed to reach scheduled and draft tweets
+	AdsAccountID string `json:"ads_account_id"`
+}
+
+// defaultWriteBudget approximates the free tier's daily write allowance
+const defaultWriteBudget = 50
+
+// Profile holds the settings of one set of accounts: a section per
+// platform, named after it. Each provider decodes and validates its own
+// section.
+type Profile struct {
+	Sections map[string]json.RawMessage
+}
+
+func (p *Profile) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &p.Sections)
+}
+
 type Config struct {
-	Reddit struct {
-		ClientID     string `json:"client_id"`
-		ClientSecret string `json:"client_secret"`
-		Username     string `json:"username"`
-		Password     string `json:"password"`
-		UserAgent    string `json:"user_agent"`
-	} `json:"reddit"`
-	Twitter struct {
-		APIKey            string `json:"api_key"`
-		APIKeySecret      string `json:"api_key_secret"`
-		AccessToken       string `json:"access_token"`
-		AccessTokenSecret string `json:"access_token_secret"`
-		Username          string `json:"username"`
-	} `json:"twitter"`
+	// Version is the layout of the file; older layouts are migrated when
+	// the config is loaded
+	Version int `json:"version"`
+
+	// The top-level platform sections form the default profile
+	Profile  Profile            `json:"-"`
+	Profiles map[string]Profile `json:"profiles"`
+
+	// StateDir overrides the default ~/.local/state/go-del-socials
+	StateDir string `json:"state_dir"`
+
+	// StateBackend stores checkpoints and indexes in "sqlite"
+	// (the default), "bolt" or "json"
+	StateBackend string `json:"state_backend"`
+
+	// Upload copies archives and reports to remote storage after each run
+	Upload *upload.Config `json:"upload"`
+
+	// ArchiveSigningKey, when set, signs the archive checksum manifest. It
+	// is a base64 Ed25519 seed or a secret reference.
+	ArchiveSigningKey string `json:"archive_signing_key"`
+
+	// PluginDir holds provider plugins for further platforms
+	PluginDir string `json:"plugin_dir"`
+
+	// ProviderDir holds YAML specs of generic providers
+	ProviderDir string `json:"provider_dir"`
+
+	// dir is the directory of the config file, which relative paths in it
+	// are taken from
+	dir string
+
+	// Webhooks are providers backed by a webhook service, by platform name
+	Webhooks map[string]*generic.WebhookConfig `json:"webhooks"`
+
+	// Fake, when set, adds a platform of made-up content for rehearsals
+	Fake *generic.FakeConfig `json:"fake"`
+
+	// Hooks are shell commands run before and after each deletion
+	Hooks *hook.Config `json:"hooks"`
+
+	// LargeDeletion sets when a run must be confirmed by typing the
+	// account name; unset, that is over 500 items or half the account
+	LargeDeletion *LargeDeletion `json:"large_deletion"`
+
+	// RunWindows are the daily hours, local time, in which deletions may
+	// run, e.g. "02:00-06:00"; outside them runs are paused
+	RunWindows []string `json:"run_windows"`
+
+	// Templates are named runs for --template and jobs files
+	Templates map[string]*Template `json:"templates"`
+
+	// Pacing sets the delays, write caps and workers of each platform
+	Pacing map[string]*Pacing `json:"pacing"`
+
+	// MQTT publishes the status and counts of runs to a broker
+	MQTT *mqtt.Config `json:"mqtt"`
+
+	// Triggers fire automation services such as ntfy, IFTTT or Zapier
+	// when runs end
+	Triggers []trigger.Config `json:"triggers"`
+}
+
+const defaultProfile = "default"
+
+// UnmarshalJSON reads the config's own settings. The remaining top-level
+// keys are the default profile's platform sections.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	type plain Config
+	if err := json.Unmarshal(data, (*plain)(c)); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &c.Profile); err != nil {
+		return err
+	}
+	t := reflect.TypeOf(*c)
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		delete(c.Profile.Sections, name)
+	}
+	return nil
+}
+
+// decodeSection decodes a platform's section into v, rejecting unknown
+// settings so typos don't go unnoticed. A missing section is
+// errNotConfigured.
+func decodeSection(section json.RawMessage, v any) error {
+	if section == nil {
+		return errNotConfigured
+	}
+	dec := json.NewDecoder(bytes.NewReader(section))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
 }
 
 func loadConfig() (*Config, error) {
@@ -35,15 +199,91 @@ func loadConfig() (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error reading config file: %v", err)
 	}
+	if file, err = upgradeConfigFile("config.json", file); err != nil {
+		return nil, fmt.Errorf("error upgrading config file: %v", err)
+	}
 
 	var config Config
 	if err := json.Unmarshal(file, &config); err != nil {
 		return nil, fmt.Errorf("error parsing config file: %v", err)
 	}
+	if err := config.validatePacing(); err != nil {
+		return nil, fmt.Errorf("error in config file: %v", err)
+	}
+	abs, err := filepath.Abs("config.json")
+	if err != nil {
+		return nil, fmt.Errorf("error locating config file: %v", err)
+	}
+	config.dir = filepath.Dir(abs)
 
 	return &config, nil
 }
 
+// path resolves a path given in the config file against its directory
+func (c *Config) path(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(c.dir, p)
+}
+
+// stateDir returns the state directory resolved against the config file's
+// directory, or "" for the default
+func (c *Config) stateDir() string {
+	if c.StateDir == "" {
+		return ""
+	}
+	return c.path(c.StateDir)
+}
+
+// empty reports whether nothing is configured in the profile
+func (p *Profile) empty() bool {
+	return len(p.Sections) == 0
+}
+
+// namedProfile pairs a profile with the name it was selected by
+type namedProfile struct {
+	Name string
+	*Profile
+}
+
+// selectProfiles returns the profiles to run. An empty name selects the
+// default profile.
+func (c *Config) selectProfiles(name string, all bool) ([]namedProfile, error) {
+	var selected []namedProfile
+
+	switch {
+	case all:
+		if !c.Profile.empty() {
+			selected = append(selected, namedProfile{defaultProfile, &c.Profile})
+		}
+		names := make([]string, 0, len(c.Profiles))
+		for n := range c.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			p := c.Profiles[n]
+			selected = append(selected, namedProfile{n, &p})
+		}
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("no profiles configured")
+		}
+
+	case name == "" || name == defaultProfile:
+		selected = append(selected, namedProfile{defaultProfile, &c.Profile})
+
+	default:
+		p, ok := c.Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("profile %q not found in config file", name)
+		}
+		selected = append(selected, namedProfile{name, &p})
+	}
+
+	return selected, nil
+}
+
 func promptChoice(prompt string, options []string, defaultOption string) (string, error) {
 	reader := bufio.NewReader(os.Stdin)
 
@@ -97,8 +337,11 @@ func promptDate(prompt string, defaultDate time.Time) (time.Time, error) {
 	if input == "" {
 		return defaultDate, nil
 	}
+	return parseDate(input)
+}
 
-	// Try different date formats
+// parseDate reads a cutoff date given as YYYY, YYYY-MM or YYYY-MM-DD
+func parseDate(input string) (time.Time, error) {
 	var t time.Time
 
 	switch len(strings.Split(input, "-")) {
@@ -133,123 +376,1674 @@ func promptDate(prompt string, defaultDate time.Time) (time.Time, error) {
 	return t, nil
 }
 
-func runRedditDeletion(config *Config) error {
-	redditConfig := &reddit.Config{
-		ClientID:     config.Reddit.ClientID,
-		ClientSecret: config.Reddit.ClientSecret,
-		Username:     config.Reddit.Username,
-		Password:     config.Reddit.Password,
-		UserAgent:    config.Reddit.UserAgent,
+// count is one labelled line of a deletion summary
+type count struct {
+	Label string
+	N     int
+}
+
+// options holds the command-line flags that tune a run
+type options struct {
+	Multireddit string
+	Hide        bool
+	RemovedOnly bool
+	Crossposts  bool
+
+	QuarantineOptIn bool
+	RedditExport    string
+
+	Hashtags    filter.Set
+	KeepThreads bool
+	KeepList    string
+	KeepFile    string
+	OnlyQuotes  bool
+	BudgetPlan  bool
+	BudgetWait  bool
+
+	TwitterArchive       string
+	ArchiveConversations bool
+	ArchiveFormat        string
+
+	// Overwrite, when set, replaces the profile's Reddit overwrite templates
+	Overwrite *reddit.OverwriteTemplates `json:",omitempty"`
+
+	// RedditKeep spares Reddit items by subreddit, ID, score and awards
+	RedditKeep *reddit.KeepRules `json:",omitempty"`
+
+	// Targets is a file listing the items to process instead of the
+	// listings
+	Targets string
+
+	// KeepLast keeps the newest items of each content type by count,
+	// whatever their date
+	KeepLast filter.KeepLast `json:",omitempty"`
+
+	// Retention overrides the cutoff in some scopes, as <scope>=keep or
+	// <scope>=<age or date>
+	Retention []string `json:",omitempty"`
+
+	// Receipts saves the raw API response of every delete to the audit log
+	Receipts bool
+
+	// Incremental only lists content newer than the profile's item index
+	Incremental bool
+
+	// Wayback saves the public pages of the items about to be deleted, or
+	// of those that stay online, to the Wayback Machine
+	Wayback string
+
+	// CheckArchived looks up the deleted items in the Wayback Machine
+	CheckArchived bool
+
+	// VerifyPublic checks this many deleted items, at random, are gone from
+	// their public pages after the run
+	VerifyPublic int
+
+	// Simulate acknowledges deletes without sending them; it isn't part of a
+	// plan so a reviewed plan can be rehearsed and then applied for real
+	Simulate bool `json:"-"`
+
+	// Notify sends desktop notifications when runs finish or stall
+	Notify bool `json:"-"`
+
+	// NoContentLogging leaves the text of items out of the output
+	NoContentLogging bool `json:"-"`
+
+	// ProgressPercent prints how far through an approved plan the run is
+	ProgressPercent bool `json:"-"`
+
+	// ExportKept is where the surviving items are written; it isn't part of
+	// a plan so apply can choose its own
+	ExportKept string `json:"-"`
+
+	// Plan collects matching items instead of deleting them; Approved
+	// restricts deletion to a reviewed plan
+	Plan     *plan.Plan `json:"-"`
+	Approved *plan.Plan `json:"-"`
+
+	// Kept collects the listed items that stay online, for --export-kept
+	Kept *inventory.Inventory `json:"-"`
+
+	// Progress streams run output to --progress-addr clients
+	Progress *progress.Broker `json:"-"`
+
+	// Health backs the health endpoints of the --progress-addr server
+	Health *healthState `json:"-"`
+
+	// Uploader, when set, receives each profile's archives and reports
+	Uploader *upload.Uploader `json:"-"`
+
+	// MQTT, when set, is told when each profile's run starts and ends
+	MQTT *mqtt.Publisher `json:"-"`
+
+	// Triggers are fired when each profile's run ends
+	Triggers []*trigger.Trigger `json:"-"`
+
+	// SigningKey, when set, signs each profile's archive manifest
+	SigningKey ed25519.PrivateKey `json:"-"`
+
+	// Providers are the built-in and plugin platforms
+	Providers []Provider `json:"-"`
+
+	// Hooks, when set, run before and after each deletion
+	Hooks *hook.Hooks `json:"-"`
+
+	// Snapshots saves pages to the Wayback Machine for --wayback
+	Snapshots *wayback.Saver `json:"-"`
+
+	// Pause holds runs between items on SIGUSR1 or the interface's p key
+	Pause *pause.Gate `json:"-"`
+}
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// job is a single platform run for one profile
+type job struct {
+	Options     *options
+	Profile     *Profile
+	State       *state.Dir
+	ContentType string
+	CutoffDate  time.Time
+	Out         io.Writer
+
+	// Report collects what the counts alone don't show: matches, failures
+	// and skipped items
+	Report *report.Report
+
+	// Plan and Approved are this profile's part of a plan being made or
+	// applied
+	Plan     *plan.Set
+	Approved *plan.Set
+
+	// Kept receives this profile's surviving items when exporting them
+	Kept *inventory.List
+
+	// Tombstones records what this profile's run deletes
+	Tombstones *tombstone.Index
+
+	// Receipts is the audit log receiving delete receipts, if enabled
+	Receipts *audit.Log
+
+	// Index is the profile's local copy of everything listed so far
+	Index *index.Index
+
+	// Store holds the profile's checkpoints and indexes
+	Store store.Store
+
+	// Pacing is the platform's pacing from the config, if any
+	Pacing *Pacing
+
+	// Hooks run before and after each deletion
+	Hooks *hook.Hooks
+}
+
+// checkPlannable rejects content types that can't go through plan/apply
+func (j *job) checkPlannable(types ...string) error {
+	if j.Plan == nil && j.Approved == nil {
+		return nil
+	}
+	for _, t := range types {
+		if j.ContentType == t {
+			return fmt.Errorf("the %s content type can't be planned", t)
+		}
 	}
+	return nil
+}
 
-	client, err := reddit.NewClient(redditConfig)
-	if err != nil {
-		return fmt.Errorf("failed to create Reddit client: %v", err)
+// checkKeepLast rejects --keep-last counts of content types other than
+// those the provider counts
+func (j *job) checkKeepLast(counted ...string) error {
+	for contentType := range j.Options.KeepLast {
+		if contentType != "all" && !slices.Contains(counted, contentType) {
+			return fmt.Errorf("--keep-last can't count %s; it counts %s", contentType, strings.Join(counted, ", "))
+		}
+	}
+	return nil
+}
+
+// retention reads the --retention rules, resolving ages from now
+func (j *job) retention() (filter.Retention, error) {
+	return parseRetention(j.Options.Retention, time.Now())
+}
+
+// checkTargetable rejects content types that --targets can't list items of
+func (j *job) checkTargetable(types ...string) error {
+	if j.Options.Targets == "" {
+		return nil
+	}
+	for _, t := range types {
+		if j.ContentType == t {
+			return fmt.Errorf("--targets can't be used with the %s content type", t)
+		}
+	}
+	return nil
+}
+
+type runResult struct {
+	Profile    string
+	Platform   string
+	Title      string
+	Counts     []count
+	Err        error
+	ReportPath string
+
+	// Simulated runs only pretended to delete
+	Simulated bool
+
+	// ResumeAt, when set, is when a run stopped by a daily write limit can
+	// go on
+	ResumeAt *time.Time
+}
+
+func (r *runResult) total() int {
+	total := 0
+	for _, c := range r.Counts {
+		total += c.N
 	}
+	return total
+}
 
+// defaultCutoff is the cutoff date offered when none is given
+var defaultCutoff = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// promptRun asks for the content type and cutoff date shared by every
+// profile. Runs keeping the last items by count delete the rest whatever
+// their date, and profile scrubbing has no dates, so neither is asked for a
+// cutoff.
+func promptRun(contentTypes []string, keepLast bool) (string, time.Time, error) {
 	// Prompt for content type
-	contentType, err := promptChoice("What would you like to delete?", []string{"all", "posts", "comments"}, "all")
+	defaultType := "all"
+	if !slices.Contains(contentTypes, defaultType) {
+		defaultType = contentTypes[0]
+	}
+	contentType, err := promptChoice("What would you like to delete?", contentTypes, defaultType)
 	if err != nil {
-		return fmt.Errorf("failed to get content type choice: %v", err)
+		return "", time.Time{}, fmt.Errorf("failed to get content type choice: %v", err)
+	}
+
+	if keepLast || contentType == "profile" {
+		return contentType, time.Now(), nil
 	}
 
 	// Prompt for cutoff date
-	defaultDate := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
-	cutoffDate, err := promptDate("Enter the date before which to delete content", defaultDate)
+	cutoffDate, err := promptDate("Enter the date before which to delete content", defaultCutoff)
 	if err != nil {
-		return fmt.Errorf("failed to get cutoff date: %v", err)
+		return "", time.Time{}, fmt.Errorf("failed to get cutoff date: %v", err)
 	}
 
-	fmt.Printf("\nDeleting %s before %s...\n\n", contentType, cutoffDate.Format("2006-01-02"))
+	return contentType, cutoffDate, nil
+}
 
-	postsDeleted, commentsDeleted, err := client.DeleteContent(contentType, cutoffDate)
+// validate checks a reddit section without resolving its secrets. A
+// section without credentials is left for other profiles to fill in.
+func (c *RedditConfig) validate() error {
+	if c.ClientID == "" && c.ClientSecret == "" && c.Username == "" && c.Password == "" && c.RefreshToken == "" {
+		return nil
+	}
+	const apps = "https://www.reddit.com/prefs/apps"
+	settings := []setting{
+		{"client_id", c.ClientID, "copy the string under your app's name at " + apps},
+		{"client_secret", c.ClientSecret, "copy the secret shown for your app at " + apps},
+		{"username", c.Username, "set the username of the account the app belongs to"},
+		{"password", c.Password, "set the account's password or a secret reference such as op://vault/reddit/password"},
+		{"user_agent", c.UserAgent, "set it to something like \"script:go-del-socials:v1.0 (by /u/yourname)\""},
+	}
+	// Installed apps have no secret and sign in with a refresh token
+	if c.ClientSecret == "" && c.Password == "" {
+		settings[1] = setting{"refresh_token", c.RefreshToken, "run go-del-socials reddit-authorize to sign in an installed app, or set client_secret and password for a script app"}
+		settings = slices.Delete(settings, 3, 4)
+	}
+	err := checkSettings(settings...)
 	if err != nil {
-		return fmt.Errorf("error during deletion: %v", err)
+		return err
+	}
+	if u := strings.TrimPrefix(c.Username, "/"); strings.HasPrefix(u, "u/") {
+		return fmt.Errorf("username %q starts with u/; set just %q", c.Username, u[2:])
+	}
+	if err := checkURL("api_url", c.APIURL); err != nil {
+		return err
 	}
+	if err := checkURL("auth_url", c.AuthURL); err != nil {
+		return err
+	}
+	return checkRedditUserAgent(c.UserAgent, c.Username)
+}
 
-	fmt.Printf("\nReddit Deletion Summary:\n")
-	fmt.Printf("- Posts deleted: %d\n", postsDeleted)
-	fmt.Printf("- Comments deleted: %d\n", commentsDeleted)
-	fmt.Printf("Total items deleted: %d\n", postsDeleted+commentsDeleted)
+// validateReddit is the reddit provider's check of a profile's section
+func validateReddit(section json.RawMessage) error {
+	var c RedditConfig
+	if err := decodeSection(section, &c); err != nil {
+		return err
+	}
+	return c.validate()
+}
 
-	return nil
+// redditSettings returns the profile's reddit section with its secrets
+// resolved
+func redditSettings(p *Profile) (*RedditConfig, error) {
+	var c RedditConfig
+	if err := decodeSection(p.Sections["reddit"], &c); err != nil {
+		return nil, err
+	}
+	if c.ClientID == "" {
+		return nil, errNotConfigured
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	// Credentials may reference an external secret manager (op://, vault://, pass://)
+	if err := secrets.ResolveAll(&c.ClientID, &c.ClientSecret, &c.Password, &c.RefreshToken); err != nil {
+		return nil, fmt.Errorf("error resolving secret: %v", err)
+	}
+	return &c, nil
 }
 
-func runTwitterDeletion(config *Config) error {
-	twitterConfig := &twitter.Config{
-		Username: config.Twitter.Username,
+func newRedditClient(ctx context.Context, c *RedditConfig, out io.Writer, opts options, pacer *pace.Pacer) (*reddit.Client, error) {
+	_, verified := accountsFrom(ctx).get("reddit", c.Username)
+	redditConfig := &reddit.Config{
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		Username:     c.Username,
+		Password:     c.Password,
+		UserAgent:    c.UserAgent,
+		RefreshToken: c.RefreshToken,
+		APIURL:       c.APIURL,
+		AuthURL:      c.AuthURL,
+		Overwrite:    c.Overwrite,
+		Simulate:     opts.Simulate,
+		HideContent:  opts.NoContentLogging,
+		Verified:     verified,
+		Pace:         pacer,
+		Output:       out,
 	}
 
-	client, err := twitter.NewClient(twitterConfig)
+	client, err := reddit.NewClient(redditConfig)
 	if err != nil {
-		return fmt.Errorf("failed to create Twitter client: %v", err)
+		return nil, fmt.Errorf("failed to create Reddit client: %v", err)
 	}
+	return client, nil
+}
 
-	// Prompt for content type
-	contentType, err := promptChoice("What would you like to delete?", []string{"all", "tweets", "replies"}, "all")
+// checkReddit verifies the profile's Reddit credentials can delete content
+func checkReddit(ctx context.Context, p *Profile) error {
+	c, err := redditSettings(p)
 	if err != nil {
-		return fmt.Errorf("failed to get content type choice: %v", err)
+		return err
 	}
-
-	// Prompt for cutoff date
-	defaultDate := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
-	cutoffDate, err := promptDate("Enter the date before which to delete content", defaultDate)
+	client, err := newRedditClient(ctx, c, io.Discard, options{}, nil)
 	if err != nil {
-		return fmt.Errorf("failed to get cutoff date: %v", err)
+		return err
+	}
+	if err := client.Preflight(ctx); err != nil {
+		return err
 	}
+	accounts := accountsFrom(ctx)
+	if _, ok := accounts.get("reddit", c.Username); !ok {
+		accounts.put("reddit", c.Username, "")
+	}
+	return nil
+}
 
-	fmt.Printf("\nDeleting %s before %s...\n\n", contentType, cutoffDate.Format("2006-01-02"))
+// probeReddit times listing a page of the profile's comments
+func probeReddit(ctx context.Context, p *Profile) (func(ctx context.Context) error, error) {
+	c, err := redditSettings(p)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newRedditClient(ctx, c, io.Discard, options{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.Ping, nil
+}
 
-	tweetsDeleted, repliesDeleted, err := client.DeleteContent(contentType, cutoffDate)
+func runRedditDeletion(ctx context.Context, j *job) ([]count, error) {
+	settings, err := redditSettings(j.Profile)
+	if err != nil {
+		return nil, err
+	}
+	if j.Options.Overwrite != nil {
+		settings.Overwrite = *j.Options.Overwrite
+	}
+	pacer, err := j.pacer("reddit", 0)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newRedditClient(ctx, settings, j.Out, *j.Options, pacer)
 	if err != nil {
-		return fmt.Errorf("error during deletion: %v", err)
+		return nil, err
 	}
+	defer j.learn("reddit", pacer)
+	defer func() { j.Report.RateLimited(client.RateLimitWait()) }()
 
-	fmt.Printf("\nTwitter Deletion Summary:\n")
-	fmt.Printf("- Tweets deleted: %d\n", tweetsDeleted)
-	fmt.Printf("- Replies deleted: %d\n", repliesDeleted)
-	fmt.Printf("Total items deleted: %d\n", tweetsDeleted+repliesDeleted)
+	if err := j.checkPlannable("profile", "chat", "drafts"); err != nil {
+		return nil, err
+	}
+	if err := j.checkTargetable("profile", "chat", "drafts"); err != nil {
+		return nil, err
+	}
+	if err := j.checkKeepLast("posts", "comments"); err != nil {
+		return nil, err
+	}
+	// Comments can't be hidden, and hiding must never turn into deleting
+	if j.Options.Hide && j.ContentType != "posts" {
+		return nil, fmt.Errorf("--hide only works with the posts content type, as comments can't be hidden")
+	}
 
-	return nil
-}
+	if j.ContentType == "profile" {
+		fmt.Fprintf(j.Out, "\nScrubbing profile of u/%s...\n\n", settings.Username)
+		cleared, postsDeleted, err := client.ScrubProfile(ctx)
+		counts := []count{{"Profile elements cleared", cleared}, {"Profile posts deleted", postsDeleted}}
+		if err != nil {
+			return counts, fmt.Errorf("error during profile scrub: %v", err)
+		}
+		return counts, nil
+	}
 
-func main() {
-	// Load configuration
-	config, err := loadConfig()
-	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
+	if j.ContentType == "chat" {
+		fmt.Fprintf(j.Out, "\nDeleting chat messages before %s...\n\n", j.CutoffDate.Format("2006-01-02"))
+		messagesDeleted, err := client.DeleteChatMessages(ctx, j.CutoffDate)
+		counts := []count{{"Chat messages deleted", messagesDeleted}}
+		if err != nil {
+			return counts, fmt.Errorf("error during chat deletion: %v", err)
+		}
+		return counts, nil
 	}
 
-	// Choose platform
-	platform, err := promptChoice("Choose platform:", []string{"reddit", "twitter"}, "")
+	if j.ContentType == "drafts" {
+		fmt.Fprintf(j.Out, "\nDeleting drafts saved before %s...\n\n", j.CutoffDate.Format("2006-01-02"))
+		draftsDeleted, err := client.DeleteDrafts(ctx, j.CutoffDate)
+		counts := []count{{"Drafts deleted", draftsDeleted}}
+		if err != nil {
+			return counts, fmt.Errorf("error during draft deletion: %v", err)
+		}
+		return counts, nil
+	}
+
+	retention, err := j.retention()
 	if err != nil {
-		log.Fatalf("Failed to get platform choice: %v", err)
+		return nil, err
+	}
+	deleteOpts := reddit.DeleteOptions{
+		ContentType: j.ContentType,
+		CutoffDate:  j.CutoffDate,
+		Hide:        j.Options.Hide,
+		RemovedOnly: j.Options.RemovedOnly,
+		Crossposts:  j.Options.Crossposts,
+		KeepLast:    j.Options.KeepLast,
+		Retention:   retention,
+
+		QuarantineOptIn: j.Options.QuarantineOptIn,
+		ExportDir:       j.Options.RedditExport,
+
+		Plan:     j.Plan,
+		Approved: j.Approved,
+		Kept:     j.Kept,
+
+		Tombstones: j.Tombstones,
+		Receipts:   j.Receipts,
+
+		Index:       j.Index,
+		Incremental: j.Options.Incremental,
+
+		Hooks: j.Hooks,
+		Pause: j.Options.Pause,
 	}
 
-	// Run the appropriate deletion function
-	var runErr error
-	switch platform {
-	case "reddit":
-		runErr = runRedditDeletion(config)
-	case "twitter":
-		fmt.Println("\n⚠️  Important Notice about Twitter/X Deletion ⚠️")
-		fmt.Println("Twitter/X has significantly restricted their API access for free accounts.")
-		fmt.Println("As a result, this tool may no longer work reliably with Twitter.")
-		fmt.Println("\nRecommended Alternative:")
-		fmt.Printf("Please use DeleteTweets: %s\n", "https://github.com/Lyfhael/DeleteTweets")
-		fmt.Println("\nWould you like to:")
-		choice, err := promptChoice("", []string{"Continue anyway", "Exit"}, "Exit")
+	if j.Options.RedditKeep != nil {
+		deleteOpts.Keep = *j.Options.RedditKeep
+	}
+	if deleteOpts.Targets, err = redditTargets(j); err != nil {
+		return nil, err
+	}
+	if j.Options.Multireddit != "" {
+		subreddits, err := client.MultiredditSubreddits(ctx, j.Options.Multireddit)
 		if err != nil {
-			log.Fatalf("Failed to get choice: %v", err)
+			return nil, err
+		}
+		deleteOpts.Subreddits = subreddits
+		fmt.Fprintf(j.Out, "Limiting deletion to %d subreddits in %s: %s\n", len(subreddits), j.Options.Multireddit, strings.Join(subreddits, ", "))
+	}
+
+	fmt.Fprintf(j.Out, "\nDeleting %s before %s...\n\n", j.ContentType, j.CutoffDate.Format("2006-01-02"))
+
+	result, err := client.DeleteContent(ctx, deleteOpts)
+	j.Report.Matched, j.Report.Failed = result.Matched, result.Failed
+	j.Report.Skip("not in the plan", result.NotPlanned)
+	j.Report.Skip("already deleted by an earlier run", result.AlreadyDeleted)
+	j.Report.Skip("already gone", result.AlreadyGone)
+	j.Report.Skip("vetoed by the before-delete hook", result.Vetoed)
+	if j.Plan != nil {
+		return planCounts(j, err)
+	}
+
+	counts := []count{{"Posts deleted", result.PostsDeleted}, {"Comments deleted", result.CommentsDeleted}}
+	if j.Options.Hide {
+		counts = []count{{"Posts hidden", result.PostsDeleted}}
+	}
+	if j.Options.Crossposts {
+		counts = append(counts, count{"Crossposts deleted", result.CrosspostsDeleted})
+	}
+	if j.Options.RedditExport != "" {
+		label := "Export-only items deleted"
+		if j.Options.Hide {
+			label = "Export-only posts hidden"
 		}
-		if choice == "Exit" {
-			fmt.Println("Exiting. Please check out the recommended alternative tool.")
-			os.Exit(0)
+		counts = append(counts, count{label, result.ExportOnlyDeleted})
+		if result.ExportOnlyFailed > 0 {
+			fmt.Fprintf(j.Out, "\n%d items from the data export could not be deleted\n", result.ExportOnlyFailed)
+		}
+	}
+	if result.Quarantined > 0 {
+		fmt.Fprintf(j.Out, "\nMatched %d items in quarantined subreddits\n", result.Quarantined)
+	}
+	if result.Archived > 0 || result.Locked > 0 {
+		fmt.Fprintf(j.Out, "\nMatched %d items in archived threads and %d in locked threads; these can be deleted but not edited\n", result.Archived, result.Locked)
+	}
+	if err != nil {
+		return counts, fmt.Errorf("error during deletion: %v", err)
+	}
+
+	return counts, nil
+}
+
+// config returns the client settings of a twitter section
+func (c *TwitterConfig) config() *twitter.Config {
+	return &twitter.Config{
+		APIKey:            c.APIKey,
+		APIKeySecret:      c.APIKeySecret,
+		AccessToken:       c.AccessToken,
+		AccessTokenSecret: c.AccessTokenSecret,
+		Username:          c.Username,
+	}
+}
+
+// validateTwitter is the twitter provider's check of a profile's section
+func validateTwitter(section json.RawMessage) error {
+	var c TwitterConfig
+	if err := decodeSection(section, &c); err != nil {
+		return err
+	}
+	return c.validate()
+}
+
+// validate checks a twitter section without resolving its secrets. A
+// section without credentials is left for other profiles to fill in.
+func (c *TwitterConfig) validate() error {
+	if c.APIKey == "" && c.APIKeySecret == "" && c.AccessToken == "" && c.AccessTokenSecret == "" {
+		return nil
+	}
+	const keys = "in the developer portal at https://developer.x.com, under your app's Keys and tokens"
+	err := checkSettings(
+		setting{"api_key", c.APIKey, "copy the API key " + keys},
+		setting{"api_key_secret", c.APIKeySecret, "copy the API key secret " + keys},
+		setting{"access_token", c.AccessToken, "generate an access token with read and write permissions " + keys},
+		setting{"access_token_secret", c.AccessTokenSecret, "copy the access token secret generated with the access token " + keys},
+		setting{"username", c.Username, "set the username of the account the tokens belong to, without the @"},
+	)
+	if err != nil {
+		return err
+	}
+	if c.AdsAccountID != "" {
+		if err := checkSettings(setting{"ads_account_id", c.AdsAccountID, "copy the account ID from https://ads.x.com"}); err != nil {
+			return err
 		}
-		runErr = runTwitterDeletion(config)
 	}
+	return c.config().Validate()
+}
+
+// twitterSettings returns the profile's twitter section with its secrets
+// resolved
+func twitterSettings(p *Profile) (*TwitterConfig, error) {
+	var c TwitterConfig
+	if err := decodeSection(p.Sections["twitter"], &c); err != nil {
+		return nil, err
+	}
+	if c.APIKey == "" {
+		return nil, errNotConfigured
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	if err := secrets.ResolveAll(&c.APIKey, &c.APIKeySecret, &c.AccessToken, &c.AccessTokenSecret); err != nil {
+		return nil, fmt.Errorf("error resolving secret: %v", err)
+	}
+	return &c, nil
+}
+
+func newTwitterClient(ctx context.Context, c *TwitterConfig, out io.Writer, opts options, pacer *pace.Pacer) (*twitter.Client, error) {
+	twitterConfig := c.config()
+	twitterConfig.Simulate, twitterConfig.Output = opts.Simulate, out
+	twitterConfig.HideContent = opts.NoContentLogging
+	twitterConfig.Pace = pacer
+
+	accounts := accountsFrom(ctx)
+	twitterConfig.UserID, _ = accounts.get("twitter", c.Username)
+	client, err := twitter.NewClient(ctx, twitterConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Twitter client: %v", err)
+	}
+	if twitterConfig.UserID == "" {
+		accounts.put("twitter", c.Username, client.UserID())
+	}
+	return client, nil
+}
 
-	if runErr != nil {
-		log.Fatalf("Error: %v", runErr)
+// checkTwitter verifies the profile's Twitter credentials can delete content
+func checkTwitter(ctx context.Context, p *Profile) error {
+	c, err := twitterSettings(p)
+	if err != nil {
+		return err
+	}
+	client, err := newTwitterClient(ctx, c, io.Discard, options{}, nil)
+	if err != nil {
+		return err
+	}
+	return client.Preflight(ctx)
+}
+
+// probeTwitter times looking up the profile's account
+func probeTwitter(ctx context.Context, p *Profile) (func(ctx context.Context) error, error) {
+	c, err := twitterSettings(p)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newTwitterClient(ctx, c, io.Discard, options{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.Ping, nil
+}
+
+func runTwitterDeletion(ctx context.Context, j *job) ([]count, error) {
+	settings, err := twitterSettings(j.Profile)
+	if err != nil {
+		return nil, err
+	}
+	pacer, err := j.pacer("twitter", 0)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newTwitterClient(ctx, settings, j.Out, *j.Options, pacer)
+	if err != nil {
+		return nil, err
+	}
+	defer j.learn("twitter", pacer)
+	defer func() { j.Report.RateLimited(client.RateLimitWait()) }()
+
+	if err := j.checkPlannable("scheduled"); err != nil {
+		return nil, err
+	}
+	if err := j.checkTargetable("scheduled", "likes"); err != nil {
+		return nil, err
+	}
+	if err := j.checkKeepLast("tweets", "replies", "retweets"); err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(j.Out, "\nDeleting %s before %s...\n\n", j.ContentType, j.CutoffDate.Format("2006-01-02"))
+
+	if j.ContentType == "scheduled" {
+		scheduled, drafts, err := client.DeleteQueued(ctx, settings.AdsAccountID, j.CutoffDate)
+		counts := []count{{"Scheduled tweets deleted", scheduled}, {"Draft tweets deleted", drafts}}
+		if err != nil {
+			return counts, fmt.Errorf("error while deleting scheduled tweets: %v", err)
+		}
+		return counts, nil
+	}
+
+	keep := map[string]bool{}
+	if j.Options.KeepFile != "" {
+		if keep, err = twitter.ReadKeepFile(j.Options.KeepFile); err != nil {
+			return nil, err
+		}
+	}
+	if j.Options.KeepList != "" {
+		listed, err := client.ListTweetIDs(ctx, j.Options.KeepList)
+		if err != nil {
+			return nil, err
+		}
+		for id := range listed {
+			keep[id] = true
+		}
+	}
+	if len(keep) > 0 {
+		fmt.Fprintf(j.Out, "Protecting %d tweets on the keep list\n", len(keep))
+	}
+	retention, err := j.retention()
+	if err != nil {
+		return nil, err
+	}
+
+	deleteOpts := twitter.DeleteOptions{
+		ContentType: j.ContentType,
+		CutoffDate:  j.CutoffDate,
+		Hashtags:    j.Options.Hashtags,
+		KeepThreads: j.Options.KeepThreads,
+		Keep:        keep,
+		OnlyQuotes:  j.Options.OnlyQuotes,
+		KeepLast:    j.Options.KeepLast,
+		Retention:   retention,
+
+		WaitForBudget: j.Options.BudgetWait,
+
+		Plan:     j.Plan,
+		Approved: j.Approved,
+		Kept:     j.Kept,
+
+		Tombstones: j.Tombstones,
+		Receipts:   j.Receipts,
+
+		Index:       j.Index,
+		Incremental: j.Options.Incremental,
+
+		Hooks: j.Hooks,
+		Pause: j.Options.Pause,
+	}
+	if deleteOpts.Targets, err = twitterTargets(j); err != nil {
+		return nil, err
+	}
+	if j.Options.ArchiveConversations {
+		deleteOpts.ConversationDir = j.State.Path(state.Archives, "twitter-conversations")
+		deleteOpts.ArchiveFormat = j.Options.ArchiveFormat
+		fmt.Fprintf(j.Out, "Archiving conversations to %s\n", deleteOpts.ConversationDir)
+	}
+
+	daily := settings.DailyWriteBudget
+	if daily == 0 && (j.Options.BudgetPlan || j.Options.BudgetWait) {
+		daily = defaultWriteBudget
+	}
+	if daily > 0 {
+		deleteOpts.Budget, err = twitter.LoadBudget(j.Store, daily)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if j.ContentType == "likes" {
+		return runTwitterLikes(ctx, client, j, deleteOpts)
+	}
+
+	if j.Options.BudgetPlan && j.Plan == nil {
+		deleteOpts.CountOnly = true
+		result, err := client.DeleteContent(ctx, deleteOpts)
+		if err != nil {
+			return nil, fmt.Errorf("error while counting: %v", err)
+		}
+		j.Report.Matched = result.Matched
+
+		budget := deleteOpts.Budget
+		fmt.Fprintf(j.Out, "\n%d entries match. With a daily budget of %d writes (%d left today), deleting them takes %d day(s).\n",
+			result.Matched, budget.Daily, budget.Remaining(), budget.Days(result.Matched))
+		fmt.Fprintf(j.Out, "Run with --budget-wait to keep going until everything is deleted.\n")
+		return []count{{"Entries matched", result.Matched}}, nil
+	}
+
+	result, err := client.DeleteContent(ctx, deleteOpts)
+	j.Report.Matched, j.Report.Failed = result.Matched, result.Failed
+	j.Report.Skip("on the keep list", result.Protected)
+	j.Report.Skip("would orphan later tweets in the thread", result.ThreadTweetsKept)
+	j.Report.Skip("conversation could not be archived", result.NotArchived)
+	j.Report.Skip("not in the plan", result.NotPlanned)
+	j.Report.Skip("already deleted by an earlier run", result.AlreadyDeleted)
+	j.Report.Skip("already gone", result.AlreadyGone)
+	j.Report.Skip("vetoed by the before-delete hook", result.Vetoed)
+	if j.Plan != nil {
+		return planCounts(j, err)
+	}
+
+	counts := []count{
+		{"Tweets deleted", result.TweetsDeleted},
+		{"Quote tweets deleted", result.QuotesDeleted},
+		{"Replies deleted", result.RepliesDeleted},
+		{"Retweets undone", result.RetweetsUndone},
+	}
+	if result.BudgetExhausted {
+		stopForBudget(j, client.ResumeAt(deleteOpts.Budget))
+	}
+	if result.Protected > 0 {
+		fmt.Fprintf(j.Out, "\nKept %d tweets on the keep list\n", result.Protected)
+	}
+	if result.ThreadTweetsKept > 0 {
+		fmt.Fprintf(j.Out, "\nKept %d tweets to avoid orphaning later tweets in your threads\n", result.ThreadTweetsKept)
+	}
+	if err != nil {
+		return counts, fmt.Errorf("error during deletion: %v", err)
+	}
+
+	return counts, nil
+}
+
+// stopForBudget notes that a run stopped at a daily write limit, and when
+// it can go on
+func stopForBudget(j *job, resume time.Time) {
+	j.Report.ResumeAt = &resume
+	fmt.Fprintf(j.Out, "\nStopped at the daily write limit; the rest can be deleted from %s. "+
+		"Run again then or use --budget-wait; jobs run with `run --jobs` pick up on their own once it has passed\n", resume.Local().Format("2006-01-02 15:04"))
+}
+
+// runTwitterLikes removes old likes listed in the Twitter archive
+func runTwitterLikes(ctx context.Context, client *twitter.Client, j *job, deleteOpts twitter.DeleteOptions) ([]count, error) {
+	if j.Options.TwitterArchive == "" {
+		return nil, fmt.Errorf("deleting likes needs --twitter-archive, since the API only lists recent likes")
+	}
+
+	likes, err := twitter.ReadLikeArchive(j.Options.TwitterArchive)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(j.Out, "Found %d likes in the archive\n", len(likes))
+
+	deleteOpts.CountOnly = j.Options.BudgetPlan && j.Plan == nil
+	result, err := client.DeleteLikes(ctx, likes, deleteOpts)
+	j.Report.Matched, j.Report.Failed = result.Matched, result.Failed
+	j.Report.Skip("not in the plan", result.NotPlanned)
+	j.Report.Skip("already deleted by an earlier run", result.AlreadyDeleted)
+	j.Report.Skip("already gone", result.AlreadyGone)
+	j.Report.Skip("liked tweet is gone and its date unknown", result.Undated)
+	if j.Plan != nil {
+		return planCounts(j, err)
+	}
+	if j.Options.BudgetPlan && err == nil {
+		budget := deleteOpts.Budget
+		fmt.Fprintf(j.Out, "\n%d likes match. With a daily budget of %d writes (%d left today), removing them takes %d day(s).\n",
+			result.Matched, budget.Daily, budget.Remaining(), budget.Days(result.Matched))
+		return []count{{"Likes matched", result.Matched}}, nil
+	}
+
+	counts := []count{{"Likes removed", result.LikesRemoved}}
+	if result.BudgetExhausted {
+		stopForBudget(j, client.ResumeAt(deleteOpts.Budget))
+	}
+	if err != nil {
+		return counts, fmt.Errorf("error while removing likes: %v", err)
+	}
+
+	return counts, nil
+}
+
+// planCounts summarises a planning run
+func planCounts(j *job, err error) ([]count, error) {
+	counts := []count{{"Items planned", len(j.Plan.Items)}}
+	if err != nil {
+		return counts, fmt.Errorf("error while planning: %v", err)
+	}
+	return counts, nil
+}
+
+// runProfiles runs the platform deletion for every profile, sequentially or
+// concurrently. Each account has its own rate limits, so parallel runs don't
+// compete with each other.
+func runProfiles(opts *options, profiles []namedProfile, config *Config, provider Provider, contentType string, cutoffDate time.Time, parallel bool) []*runResult {
+	return runProfilesTo(stdout, opts, profiles, config, provider, contentType, cutoffDate, parallel)
+}
+
+// runProfilesTo is runProfiles with the output going to w
+func runProfilesTo(w io.Writer, opts *options, profiles []namedProfile, config *Config, provider Provider, contentType string, cutoffDate time.Time, parallel bool) []*runResult {
+	platform := provider.Name()
+
+	if opts.Notify {
+		activity := newActivityWriter(w)
+		stop := make(chan struct{})
+		go watchStall(activity, provider.Capabilities().Title, opts.Pause, stop)
+		defer close(stop)
+		w = activity
+	}
+
+	if opts.ExportKept != "" || opts.Wayback == waybackKept {
+		opts.Kept = inventory.New(platform)
+	}
+
+	results := make([]*runResult, len(profiles))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var workers chan struct{}
+	if n := config.Pacing[platform].workers(); n > 0 {
+		workers = make(chan struct{}, n)
+	}
+
+	for i, p := range profiles {
+		out := w
+		if len(profiles) > 1 {
+			out = newPrefixWriter(w, &mu, "["+p.Name+"] ")
+		}
+		if opts.Progress != nil {
+			out = io.MultiWriter(out, opts.Progress.Writer(p.Name), opts.Health)
+		}
+
+		exec := func() {
+			results[i] = &runResult{Profile: p.Name, Platform: platform, Title: provider.Capabilities().Title, Simulated: opts.Simulate}
+
+			// Each profile's state directory is locked for the duration of the run
+			st, err := state.Open(config.stateDir(), p.Name)
+			if err != nil {
+				results[i].Err = err
+				return
+			}
+			defer st.Close()
+
+			s, err := config.openStore(st.Root)
+			if err != nil {
+				results[i].Err = err
+				return
+			}
+			defer s.Close()
+
+			var receipts *audit.Log
+			if opts.Receipts || opts.Simulate {
+				if receipts, err = audit.Open(st.Path(state.Audit, "receipts.jsonl"), s); err != nil {
+					results[i].Err = err
+					return
+				}
+				defer receipts.Close()
+			}
+
+			rep := report.New(p.Name, platform, contentType, cutoffDate)
+			j := &job{
+				Options:     opts,
+				Profile:     p.Profile,
+				State:       st,
+				ContentType: contentType,
+				CutoffDate:  cutoffDate,
+				Out:         out,
+				Report:      rep,
+				Tombstones:  tombstone.New(s),
+				Receipts:    receipts,
+				Index:       index.New(s),
+				Store:       s,
+				Pacing:      config.Pacing[platform],
+			}
+			j.Hooks = waybackHooks(j)
+			if opts.Plan != nil {
+				j.Plan = opts.Plan.Profile(p.Name)
+			}
+			if opts.Approved != nil {
+				j.Approved = opts.Approved.Profile(p.Name)
+			}
+			if opts.Kept != nil {
+				j.Kept = opts.Kept.Profile(p.Name)
+			}
+			if opts.ProgressPercent && j.Approved != nil {
+				watchProgress(j.Approved, out)
+			}
+			if opts.Simulate {
+				// Nothing is really gone, so later runs must not skip it
+				j.Tombstones, j.Index = nil, nil
+				rep.Simulated = true
+				fmt.Fprintf(out, "Simulating: deletes are acknowledged without being sent\n")
+			}
+			ctx, span := telemetry.Start(withAccounts(context.Background(), &accountCache{s}), "run",
+				attribute.String("profile", p.Name), attribute.String("platform", platform), attribute.String("content_type", contentType))
+			if opts.Progress != nil {
+				opts.Progress.Publish(progress.Event{Profile: p.Name, Type: "start"})
+			}
+			publishStart(opts.MQTT, out, p.Name, platform)
+			if opts.Health != nil {
+				opts.Health.started()
+				defer opts.Health.finished()
+			}
+			// An unhealthy platform is skipped rather than failing mid-run
+			err = provider.Check(ctx, p.Profile)
+			if opts.Health != nil {
+				opts.Health.checked(platform, err)
+			}
+			if err != nil {
+				results[i].Err = fmt.Errorf("health check failed: %v", err)
+			} else {
+				started := time.Now()
+				results[i].Counts, results[i].Err = provider.Run(ctx, j)
+				if opts.VerifyPublic > 0 && results[i].Err == nil && j.Tombstones != nil && j.Plan == nil {
+					results[i].Err = verifyPublic(ctx, j, platform, started, opts.VerifyPublic)
+				}
+				if opts.CheckArchived && j.Tombstones != nil && j.Plan == nil && ctx.Err() == nil {
+					if err := checkArchived(ctx, j, platform, started); err != nil && results[i].Err == nil {
+						results[i].Err = err
+					}
+				}
+				if err := archiveKept(ctx, j); err != nil && results[i].Err == nil {
+					results[i].Err = err
+				}
+			}
+			telemetry.End(span, results[i].Err)
+			if opts.Health != nil {
+				opts.Health.ran(platform, results[i].Err)
+			}
+
+			for _, c := range results[i].Counts {
+				rep.Counts[c.Label] = c.N
+			}
+			if !opts.BudgetPlan && opts.Plan == nil {
+				rep.Deleted = results[i].total()
+			}
+			rep.Finish(results[i].Err)
+			results[i].ResumeAt = rep.ResumeAt
+			publishDone(opts.MQTT, out, rep)
+			fireTriggers(opts.Triggers, out, rep)
+			if results[i].ReportPath, err = rep.Write(st.Path(state.Reports)); err != nil {
+				fmt.Fprintf(out, "Warning: %v\n", err)
+			}
+			if added, err := manifest.Update(st.Path(state.Archives), opts.SigningKey); err != nil {
+				fmt.Fprintf(out, "Warning: %v\n", err)
+			} else if added > 0 {
+				fmt.Fprintf(out, "Added %d archive files to the checksum manifest\n", added)
+			}
+			if opts.Uploader != nil {
+				if err := uploadState(opts.Uploader, s, st, out); err != nil && results[i].Err == nil {
+					results[i].Err = err
+				}
+			}
+			if opts.Progress != nil {
+				opts.Progress.Publish(progress.Event{Profile: p.Name, Type: "done", Counts: rep.Counts, Error: rep.Error})
+			}
+		}
+
+		if parallel {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if workers != nil {
+					workers <- struct{}{}
+					defer func() { <-workers }()
+				}
+				exec()
+			}()
+		} else {
+			exec()
+		}
+	}
+	wg.Wait()
+
+	if opts.ExportKept != "" {
+		if err := opts.Kept.Write(opts.ExportKept); err != nil {
+			fmt.Fprintf(w, "Warning: %v\n", err)
+		} else {
+			fmt.Fprintf(w, "\n%d items that stay online written to %s\n", opts.Kept.Len(), opts.ExportKept)
+		}
+	}
+	if opts.Notify {
+		notifyDone(provider.Capabilities().Title, results)
+	}
+
+	return results
+}
+
+// uploadState copies a profile's archives and reports to remote storage, so
+// they survive runs in ephemeral containers, and prunes old uploads
+func uploadState(u *upload.Uploader, s store.Store, st *state.Dir, out io.Writer) error {
+	ctx := context.Background()
+	sent, err := u.Sync(ctx, s, st.Root, st.Profile, state.Archives, state.Reports)
+	if sent > 0 {
+		fmt.Fprintf(out, "Uploaded %d archive and report files\n", sent)
+	}
+	if err != nil {
+		return err
+	}
+
+	pruned, err := u.Prune(ctx, st.Profile)
+	if pruned > 0 {
+		fmt.Fprintf(out, "Removed %d uploads past their retention\n", pruned)
+	}
+	return err
+}
+
+func printSummary(results []*runResult) {
+	if githubActions {
+		defer annotateGitHub(results)
+	}
+	if plain {
+		printPlainSummary(results)
+		return
+	}
+	merged := &runResult{}
+
+	for _, r := range results {
+		if len(results) > 1 {
+			fmt.Fprintf(stdout, "\n%s Deletion Summary (profile %s):\n", r.Title, r.Profile)
+		} else {
+			fmt.Fprintf(stdout, "\n%s Deletion Summary:\n", r.Title)
+		}
+		for _, c := range r.Counts {
+			fmt.Fprintf(stdout, "- %s: %d\n", c.Label, c.N)
+		}
+		fmt.Fprintf(stdout, "Total items: %d\n", r.total())
+		if r.Simulated {
+			fmt.Fprintf(stdout, "Simulated: nothing was actually deleted\n")
+		}
+		if r.Err != nil {
+			fmt.Fprintf(stdout, "Error: %v\n", r.Err)
+		}
+		if r.ReportPath != "" {
+			fmt.Fprintf(stdout, "Report: %s\n", r.ReportPath)
+		}
+
+		for i, c := range r.Counts {
+			if i < len(merged.Counts) {
+				merged.Counts[i].N += c.N
+			} else {
+				merged.Counts = append(merged.Counts, c)
+			}
+		}
+	}
+
+	if len(results) > 1 {
+		fmt.Fprintf(stdout, "\nCombined Summary (%d profiles):\n", len(results))
+		for _, c := range merged.Counts {
+			fmt.Fprintf(stdout, "- %s: %d\n", c.Label, c.N)
+		}
+		fmt.Fprintf(stdout, "Total items: %d\n", merged.total())
+	}
+}
+
+// printPlainSummary prints one line per fact, each naming the profile and
+// platform it is about
+func printPlainSummary(results []*runResult) {
+	merged := map[string]int{}
+	var labels []string
+	for _, r := range results {
+		about := fmt.Sprintf("%s on %s", r.Profile, r.Title)
+		parts := make([]string, 0, len(r.Counts)+1)
+		for _, c := range r.Counts {
+			parts = append(parts, fmt.Sprintf("%s %d", c.Label, c.N))
+			if _, ok := merged[c.Label]; !ok {
+				labels = append(labels, c.Label)
+			}
+			merged[c.Label] += c.N
+		}
+		parts = append(parts, fmt.Sprintf("Total items %d", r.total()))
+		fmt.Fprintf(stdout, "Summary for %s: %s\n", about, strings.Join(parts, "; "))
+		if r.Simulated {
+			fmt.Fprintf(stdout, "Summary for %s: simulated, nothing was actually deleted\n", about)
+		}
+		if r.Err != nil {
+			fmt.Fprintf(stdout, "Error for %s: %v\n", about, r.Err)
+		}
+		if r.ReportPath != "" {
+			fmt.Fprintf(stdout, "Report for %s: %s\n", about, r.ReportPath)
+		}
+	}
+
+	if len(results) > 1 {
+		parts := make([]string, 0, len(labels)+1)
+		total := 0
+		for _, l := range labels {
+			parts = append(parts, fmt.Sprintf("%s %d", l, merged[l]))
+			total += merged[l]
+		}
+		parts = append(parts, fmt.Sprintf("Total items %d", total))
+		fmt.Fprintf(stdout, "Summary for all %d profiles: %s\n", len(results), strings.Join(parts, "; "))
+	}
+}
+
+// applyPlan deletes exactly the items of a reviewed plan file, with the
+// choices the plan was made with
+func applyPlan(config *Config, opts *options, path string, parallel bool) []*runResult {
+	pl, err := plan.Read(path)
+	if err != nil {
+		fatalf("Error: %v", err)
+	}
+	if len(pl.Options) > 0 {
+		if err := json.Unmarshal(pl.Options, opts); err != nil {
+			fatalf("Failed to read plan options: %v", err)
+		}
+	}
+	opts.Approved = pl
+
+	provider := selectProvider(opts.Providers, pl.Platform)
+	if provider == nil {
+		fatalf("The plan is for %s, which no provider or plugin handles", pl.Platform)
+	}
+	if !slices.Contains(provider.Capabilities().ContentTypes, pl.ContentType) {
+		fatalf("%s doesn't support the plan's content type %s", pl.Platform, pl.ContentType)
+	}
+
+	names := make([]string, 0, len(pl.Profiles))
+	for name := range pl.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var profiles []namedProfile
+	for _, name := range names {
+		selected, err := config.selectProfiles(name, false)
+		if err != nil {
+			fatalf("Failed to select profile: %v", err)
+		}
+		profiles = append(profiles, selected...)
+	}
+
+	fmt.Fprintf(stdout, "Applying plan from %s: %d %s items (%s before %s) across %d profiles\n",
+		pl.Created.Format("2006-01-02 15:04"), pl.Len(), pl.Platform, pl.ContentType, pl.Cutoff.Format("2006-01-02"), len(profiles))
+
+	return runProfiles(opts, profiles, config, provider, pl.ContentType, pl.Cutoff, parallel)
+}
+
+func main() {
+	// Subcommands are given before the flags
+	command := ""
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "plan", "apply", "run", "lookup", "import", "export-outbox", "reddit-authorize", "flickr-authorize", "verify-archive", "verify-audit", "doctor", "bench", "tui":
+			command, args = args[0], args[1:]
+		}
+	}
+
+	profileName := flag.String("profile", "", "name of the profile to run (default: top-level credentials)")
+	allProfiles := flag.Bool("all-profiles", false, "run every configured profile")
+	parallel := flag.Bool("parallel", false, "with --all-profiles, process profiles concurrently")
+	logFile := flag.String("log-file", "", "also write run output, timestamped, to this file")
+	logMaxSize := flag.Int("log-max-size", 10, "with --log-file, rotate the log once it exceeds this many megabytes (0 disables)")
+	logMaxAge := flag.Duration("log-max-age", 7*24*time.Hour, "with --log-file, rotate the log once it is older than this (0 disables)")
+	logKeep := flag.Int("log-keep", 5, "with --log-file, number of rotated logs to keep")
+	progressAddr := flag.String("progress-addr", "", "serve live run progress as Server-Sent Events at http://<addr>/events, e.g. localhost:8080")
+	flag.BoolVar(&githubActions, "github-actions", false, "for runs in a GitHub Actions workflow: turn warnings and results into annotations and write a job summary")
+	flag.BoolVar(&plain, "plain", false, "plain output without symbols or decorations, with self-contained summary lines, for screen readers and logs")
+	templateName := flag.String("template", "", "run the named template from the config instead of asking for the platform, content type and cutoff")
+	shredditFile := flag.String("shreddit", "", "run Reddit with the settings of this shreddit config (shreddit.yml) instead of asking for them")
+	jobsFile := flag.String("jobs", "", "with run, the jobs file (YAML or JSON) listing the deletion jobs to run")
+	yes := flag.Bool("yes", false, "skip the review and confirmation before deleting, for unattended runs")
+	actor := flag.String("actor", "", "with export-outbox, the URL of the fediverse account the posts are attributed to, e.g. https://mastodon.social/users/alice")
+	otlpEndpoint := flag.String("otlp-endpoint", "", "send OpenTelemetry traces to this OTLP/HTTP endpoint, e.g. http://localhost:4318")
+
+	var opts options
+	flag.BoolVar(&opts.Hide, "hide", false, "hide matching Reddit posts instead of deleting them")
+	flag.BoolVar(&opts.RemovedOnly, "removed-only", false, "only delete Reddit content already removed by moderators or spam filters")
+	flag.BoolVar(&opts.Crossposts, "crossposts", false, "also delete your crossposts of every deleted Reddit post")
+	flag.BoolVar(&opts.QuarantineOptIn, "quarantine-optin", false, "opt in to quarantined subreddits so your content there can be deleted")
+	flag.StringVar(&opts.RedditExport, "reddit-export", "", "directory of an extracted Reddit data request; deletes items missing from listings, e.g. in banned subreddits")
+	flag.StringVar(&opts.Multireddit, "multireddit", "", "only delete Reddit content posted in this multireddit (user/<name>/m/<multi>)")
+	flag.Var((*stringList)(&opts.Hashtags.Include), "hashtag", "only delete tweets with this hashtag (repeatable)")
+	flag.Var((*stringList)(&opts.Hashtags.Exclude), "exclude-hashtag", "never delete tweets with this hashtag (repeatable)")
+	flag.BoolVar(&opts.KeepThreads, "keep-threads", false, "don't delete tweets that later tweets in your own thread depend on")
+	flag.BoolVar(&opts.OnlyQuotes, "only-quotes", false, "only delete tweets that quote another tweet")
+	flag.BoolVar(&opts.BudgetPlan, "budget-plan", false, "count matching tweets and estimate how many days the daily write budget needs, without deleting")
+	flag.BoolVar(&opts.BudgetWait, "budget-wait", false, "when the daily write budget is used up, wait for the next day and continue until done")
+	flag.StringVar(&opts.KeepList, "keep-list", "", "ID of a Twitter List whose tweets are never deleted")
+	flag.StringVar(&opts.KeepFile, "keep-file", "", "file of tweet URLs or IDs, one per line, that are never deleted")
+	flag.StringVar(&opts.TwitterArchive, "twitter-archive", "", "extracted Twitter archive directory (or its like.js), needed to delete likes")
+	flag.BoolVar(&opts.ArchiveConversations, "archive-conversations", false, "save each tweet's parents and your replies to the state directory before deleting it")
+	flag.StringVar(&opts.ArchiveFormat, "archive-format", "dir", "how archives are written: dir (loose JSON files), zip or tar.zst")
+	flag.BoolVar(&opts.Receipts, "receipts", false, "save the HTTP status and raw response of every delete to the audit log as a receipt")
+	flag.BoolVar(&opts.Simulate, "simulate", false, "go through the whole run but acknowledge deletes without sending them, recording them as simulated")
+	flag.StringVar(&opts.Wayback, "wayback", "", "save public pages to the Wayback Machine: deleted (each item before deleting it) or kept (the items that stay online, after the run)")
+	flag.BoolVar(&opts.CheckArchived, "check-archived", false, "after deleting, look up each deleted item in the Wayback Machine and report those it still has a public copy of")
+	flag.IntVar(&opts.VerifyPublic, "verify-public", 0, "after deleting, fetch the public pages of up to this many deleted items, chosen at random, without signing in and report any strangers can still see")
+	flag.BoolVar(&opts.Incremental, "incremental", false, "only fetch content newer than the last run and apply the cutoff to the local index for the rest")
+	var keepLast stringList
+	flag.Var(&keepLast, "keep-last", "keep the newest N items whatever their date: N of every content type, or <content type>=N such as tweets=200 (repeatable)")
+	flag.Var((*stringList)(&opts.Retention), "retention", "override the cutoff in a subreddit, hashtag, community or channel: <scope>=keep or <scope>=<age or date>, e.g. r/AskHistorians=keep or #work=30d (repeatable)")
+	flag.StringVar(&opts.Targets, "targets", "", "file of item URLs or IDs to process instead of listing your content: plain text, CSV or JSON")
+	flag.BoolVar(&opts.NoContentLogging, "no-content-logging", false, "leave the text of posts, comments and tweets out of the output and log file")
+	flag.BoolVar(&opts.Notify, "notify", false, "send a desktop notification when a run finishes or makes no progress for 10 minutes")
+	flag.BoolVar(&opts.ProgressPercent, "progress-percent", false, "print the share of the reviewed or planned items processed, every 10%")
+	flag.StringVar(&opts.ExportKept, "export-kept", "", "write the listed items that were not deleted, and why, to this file (.csv or .json)")
+	flag.Usage = func() { usage(builtins) }
+	flag.CommandLine.Parse(args)
+
+	if opts.Wayback != "" && opts.Wayback != waybackDeleted && opts.Wayback != waybackKept {
+		fatalf("Unknown --wayback %q (use %s or %s)", opts.Wayback, waybackDeleted, waybackKept)
+	}
+	if !slices.Contains(archive.Formats, opts.ArchiveFormat) {
+		fatalf("Unknown --archive-format %q (use %s)", opts.ArchiveFormat, strings.Join(archive.Formats, ", "))
+	}
+	if len(keepLast) > 0 {
+		kl, err := filter.ParseKeepLast(keepLast)
+		if err != nil {
+			fatalf("Invalid --keep-last: %v", err)
+		}
+		opts.KeepLast = kl
+	}
+	if _, err := parseRetention(opts.Retention, time.Now()); err != nil {
+		fatalf("Invalid --retention: %v", err)
+	}
+
+	if command == "lookup" {
+		if flag.NArg() != 1 {
+			fatalf("Usage: go-del-socials lookup <url or id>")
+		}
+		// Credentials aren't needed, only the state settings
+		config, err := loadConfig()
+		if err != nil {
+			config = &Config{}
+		}
+		found, err := lookup(config, flag.Arg(0))
+		if err != nil {
+			fatalf("Error: %v", err)
+		}
+		if found == 0 {
+			fmt.Println("No deleted item matches")
+			exit(1)
+		}
+		return
+	}
+
+	if command == "verify-archive" {
+		// Credentials aren't needed, only the state settings
+		config, err := loadConfig()
+		if err != nil {
+			config = &Config{}
+		}
+		ok, err := verifyArchive(config, *profileName)
+		if err != nil {
+			fatalf("Error: %v", err)
+		}
+		if !ok {
+			exit(1)
+		}
+		return
+	}
+
+	if command == "verify-audit" {
+		config, err := loadConfig()
+		if err != nil {
+			config = &Config{}
+		}
+		if err := verifyAudit(config, *profileName); err != nil {
+			fatalf("Error: %v", err)
+		}
+		return
+	}
+
+	if command == "import" {
+		if flag.NArg() != 1 {
+			fatalf("Usage: go-del-socials import [--profile <name>] <archive or export directory>")
+		}
+		// Credentials aren't needed, only the state settings
+		config, err := loadConfig()
+		if err != nil {
+			config = &Config{}
+		}
+		if err := importArchive(config, *profileName, flag.Arg(0)); err != nil {
+			fatalf("Error: %v", err)
+		}
+		return
+	}
+
+	if command == "export-outbox" {
+		if flag.NArg() != 1 || *actor == "" {
+			fatalf("Usage: go-del-socials export-outbox [--profile <name>] --actor <account url> <directory>")
+		}
+		// Credentials aren't needed, only the state settings
+		config, err := loadConfig()
+		if err != nil {
+			config = &Config{}
+		}
+		if err := exportOutbox(config, *profileName, *actor, flag.Arg(0)); err != nil {
+			fatalf("Error: %v", err)
+		}
+		return
+	}
+
+	// Load configuration
+	config, err := loadConfig()
+	if err != nil {
+		fatalf("Failed to load configuration: %v", err)
+	}
+	if command == "reddit-authorize" {
+		if err := authorizeReddit(config, *profileName); err != nil {
+			fatalf("Error: %v", err)
+		}
+		return
+	}
+	if command == "flickr-authorize" {
+		if err := authorizeFlickr(config, *profileName); err != nil {
+			fatalf("Error: %v", err)
+		}
+		return
+	}
+	if opts.Uploader, err = upload.New(config.Upload); err != nil {
+		fatalf("Failed to set up uploads: %v", err)
+	}
+	if opts.MQTT, err = mqtt.New(config.MQTT); err != nil {
+		fatalf("Failed to set up MQTT: %v", err)
+	}
+	defer opts.MQTT.Close()
+	if opts.Triggers, err = newTriggers(config.Triggers); err != nil {
+		fatalf("Failed to set up triggers: %v", err)
+	}
+	if opts.SigningKey, err = config.signingKey(); err != nil {
+		fatalf("Failed to load archive signing key: %v", err)
+	}
+
+	var shreddit *Template
+	if *shredditFile != "" {
+		if *templateName != "" {
+			fatalf("Use either --shreddit or --template, not both")
+		}
+		s, err := readShreddit(*shredditFile)
+		if err == nil {
+			shreddit, err = s.template()
+		}
+		if err != nil {
+			fatalf("Error in %s: %v", *shredditFile, err)
+		}
+		for _, note := range s.notes() {
+			fmt.Printf("Note on %s\n", note)
+		}
+		opts.Simulate = opts.Simulate || s.TrialRun
+	}
+
+	opts.Hooks = hook.New(config.Hooks)
+	if opts.Simulate {
+		opts.Hooks = opts.Hooks.Simulating()
+	}
+	if opts.Wayback != "" {
+		opts.Snapshots = wayback.New()
+	}
+	opts.Pause = pause.New()
+	notifyPause(opts.Pause)
+	if opts.Providers, err = loadProviders(config); err != nil {
+		fatalf("Failed to load providers: %v", err)
+	}
+	if err := validateProfiles(config, opts.Providers); err != nil {
+		fatalf("Invalid configuration: %v", err)
+	}
+
+	if command == "doctor" {
+		profiles, err := config.selectProfiles(*profileName, *allProfiles)
+		if err != nil {
+			fatalf("Failed to select profile: %v", err)
+		}
+		if !doctor(opts.Providers, profiles) {
+			exit(1)
+		}
+		return
+	}
+
+	if command == "bench" {
+		profiles, err := config.selectProfiles(*profileName, *allProfiles)
+		if err != nil {
+			fatalf("Failed to select profile: %v", err)
+		}
+		if err := bench(config, opts.Providers, profiles); err != nil {
+			fatalf("Error: %v", err)
+		}
+		return
+	}
+
+	if *logFile != "" {
+		lf, err := logfile.Open(*logFile, int64(*logMaxSize)<<20, *logMaxAge, *logKeep)
+		if err != nil {
+			fatalf("Error: %v", err)
+		}
+		defer lf.Close()
+		logOut = lf
+		stdout = io.MultiWriter(os.Stdout, lf)
+		log.SetOutput(io.MultiWriter(os.Stderr, lf))
+	}
+	if githubActions {
+		stdout = &githubWriter{w: stdout}
+	}
+
+	if *progressAddr != "" {
+		opts.Progress = progress.NewBroker()
+		opts.Health = newHealthState(opts.Pause)
+		mux := http.NewServeMux()
+		mux.Handle("/events", opts.Progress)
+		mux.HandleFunc("/healthz", opts.Health.live)
+		mux.HandleFunc("/readyz", opts.Health.ready)
+		go func() {
+			if err := http.ListenAndServe(*progressAddr, mux); err != nil {
+				log.Printf("Progress server stopped: %v", err)
+			}
+		}()
+	}
+
+	shutdownTracing, err := telemetry.Setup(context.Background(), *otlpEndpoint)
+	if err != nil {
+		fatalf("Failed to set up tracing: %v", err)
+	}
+
+	if len(config.RunWindows) > 0 {
+		windows, err := parseWindows(config.RunWindows)
+		if err != nil {
+			fatalf("Invalid run_windows: %v", err)
+		}
+		watchWindows(opts.Pause, windows)
+	}
+
+	if command == "tui" {
+		if plain {
+			fatalf("The terminal interface draws the whole screen; use the prompts with --plain instead")
+		}
+		opts.Providers = usableProviders(opts.Providers)
+		err := runTUI(config, &opts)
+		shutdownTracing(context.Background())
+		if err != nil {
+			fatalf("Error: %v", err)
+		}
+		return
+	}
+
+	if command == "run" {
+		if *jobsFile == "" {
+			fatalf("Usage: go-del-socials run --jobs <jobs file> [flags]")
+		}
+		ok, err := runJobs(config, &opts, *jobsFile, *parallel)
+		shutdownTracing(context.Background())
+		if err != nil {
+			fatalf("Error: %v", err)
+		}
+		if !ok {
+			exit(1)
+		}
+		return
+	}
+
+	if command == "apply" {
+		if flag.NArg() != 1 {
+			fatalf("Usage: go-del-socials apply [flags] <plan file>")
+		}
+		results := applyPlan(config, &opts, flag.Arg(0), *parallel)
+		printSummary(results)
+		shutdownTracing(context.Background())
+		exitOnError(results)
+		return
+	}
+
+	profiles, err := config.selectProfiles(*profileName, *allProfiles)
+	if err != nil {
+		fatalf("Failed to select profile: %v", err)
+	}
+
+	var provider Provider
+	var contentType string
+	var cutoffDate time.Time
+	if *templateName != "" || shreddit != nil {
+		t, name := shreddit, "from "+*shredditFile
+		if t == nil {
+			if t, err = config.template(*templateName); err != nil {
+				fatalf("Error: %v", err)
+			}
+			name = *templateName
+		}
+		if provider, contentType, cutoffDate, err = t.apply(&opts, time.Now()); err != nil {
+			fatalf("Template %s: %v", name, err)
+		}
+		if err := checkFlags(provider, opts.Providers); err != nil {
+			fatalf("Error: %v", err)
+		}
+		fmt.Printf("Template %s: %s %s before %s\n", name, provider.Name(), contentType, cutoffDate.Format("2006-01-02"))
+	} else {
+		// Choose platform
+		opts.Providers = usableProviders(opts.Providers)
+		platforms := make([]string, len(opts.Providers))
+		for i, p := range opts.Providers {
+			platforms[i] = p.Name()
+			fmt.Println(describe(p))
+		}
+		platform, err := promptChoice("Choose platform:", platforms, "")
+		if err != nil {
+			fatalf("Failed to get platform choice: %v", err)
+		}
+		provider = findProvider(opts.Providers, platform)
+		if err := checkFlags(provider, opts.Providers); err != nil {
+			fatalf("Error: %v", err)
+		}
+
+		if platform == "twitter" {
+			if plain {
+				fmt.Println("\nImportant notice about Twitter/X deletion:")
+			} else {
+				fmt.Println("\n⚠️  Important Notice about Twitter/X Deletion ⚠️")
+			}
+			fmt.Println("Twitter/X has significantly restricted their API access for free accounts.")
+			fmt.Println("As a result, this tool may no longer work reliably with Twitter.")
+			fmt.Println("\nRecommended Alternative:")
+			fmt.Printf("Please use DeleteTweets: %s\n", "https://github.com/Lyfhael/DeleteTweets")
+			fmt.Println("\nWould you like to:")
+			choice, err := promptChoice("", []string{"Continue anyway", "Exit"}, "Exit")
+			if err != nil {
+				fatalf("Failed to get choice: %v", err)
+			}
+			if choice == "Exit" {
+				fmt.Println("Exiting. Please check out the recommended alternative tool.")
+				exit(0)
+			}
+		}
+
+		contentType, cutoffDate, err = promptRun(provider.Capabilities().ContentTypes, len(opts.KeepLast) > 0)
+		if err != nil {
+			fatalf("Error: %v", err)
+		}
+	}
+	platform := provider.Name()
+
+	planPath := ""
+	if command == "plan" {
+		planPath = "deletion-plan.json"
+		if flag.NArg() > 0 {
+			planPath = flag.Arg(0)
+		}
+		opts.Plan = plan.New(platform, contentType, cutoffDate)
+		if opts.Plan.Options, err = json.Marshal(&opts); err != nil {
+			fatalf("Failed to record plan options: %v", err)
+		}
+	}
+
+	// Nothing is deleted until the user has seen what will be
+	if command == "" && !*yes && !opts.Simulate && !opts.BudgetPlan {
+		reviewed, ok, err := reviewRun(&opts, profiles, config, provider, contentType, cutoffDate, *parallel)
+		if err != nil {
+			fatalf("Error: %v", err)
+		}
+		if !ok {
+			fmt.Println("Stopped; nothing was deleted.")
+			shutdownTracing(context.Background())
+			return
+		}
+		opts.Approved = reviewed
+	}
+
+	results := runProfiles(&opts, profiles, config, provider, contentType, cutoffDate, *parallel)
+	printSummary(results)
+
+	if opts.Plan != nil {
+		if err := opts.Plan.Write(planPath); err != nil {
+			fatalf("Error: %v", err)
+		}
+		fmt.Fprintf(stdout, "\nPlan with %d items written to %s. Review it, then run: go-del-socials apply %s\n", opts.Plan.Len(), planPath, planPath)
+		if d := estimate(provider, opts.Plan.Len()); d > 0 {
+			fmt.Fprintf(stdout, "Applying it takes at least %v at %d deletions per minute.\n", d.Round(time.Minute), provider.Capabilities().MaxRate)
+		}
+	}
+
+	shutdownTracing(context.Background())
+	exitOnError(results)
+}
+
+// logOut is the --log-file writer, if any. exit closes it, as deferred
+// calls don't run on the way out.
+var logOut *logfile.Writer
+
+// exit ends the process once the log file has its last lines
+func exit(code int) {
+	if logOut != nil {
+		logOut.Close()
+	}
+	os.Exit(code)
+}
+
+// fatalf logs like log.Fatalf and exits through exit
+func fatalf(format string, args ...any) {
+	log.Printf(format, args...)
+	exit(1)
+}
+
+func exitOnError(results []*runResult) {
+	for _, r := range results {
+		if r.Err != nil {
+			fatalf("Error: %v", r.Err)
+		}
 	}
 }
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"go-del-socials/pkg/generic"
+	"go-del-socials/pkg/secrets"
+)
+
+// validateMastodon is the mastodon provider's check of a profile's section
+func validateMastodon(section json.RawMessage) error {
+	var c generic.MastodonConfig
+	if err := decodeSection(section, &c); err != nil {
+		return err
+	}
+	if c.Instance == "" && c.AccessToken == "" {
+		return nil
+	}
+	if err := checkSettings(
+		setting{"instance", c.Instance, "set the URL of your server, e.g. https://mastodon.social"},
+		setting{"access_token", c.AccessToken, "set an access token with the read and write scopes, or a secret reference such as op://vault/mastodon/token"},
+	); err != nil {
+		return err
+	}
+	return checkURL("instance", c.Instance)
+}
+
+// mastodonClient returns a client for the profile's mastodon section, with
+// the quirks of the software its server runs
+func mastodonClient(ctx context.Context, p *Profile, out io.Writer) (*generic.Client, error) {
+	var c generic.MastodonConfig
+	if err := decodeSection(p.Sections["mastodon"], &c); err != nil {
+		return nil, err
+	}
+	if c.Instance == "" {
+		return nil, errNotConfigured
+	}
+	if err := secrets.ResolveAll(&c.AccessToken); err != nil {
+		return nil, fmt.Errorf("error resolving secret: %v", err)
+	}
+	software, err := generic.DetectSoftware(ctx, c.Instance)
+	if err != nil {
+		return nil, err
+	}
+	return generic.NewMastodonClient(&c, software, out), nil
+}
+
+// checkMastodon verifies the server can be reached and takes the token
+func checkMastodon(ctx context.Context, p *Profile) error {
+	client, err := mastodonClient(ctx, p, io.Discard)
+	if err != nil {
+		return err
+	}
+	return client.Check(ctx)
+}
+
+// probeMastodon times verifying the token
+func probeMastodon(ctx context.Context, p *Profile) (func(ctx context.Context) error, error) {
+	client, err := mastodonClient(ctx, p, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+	return client.Check, nil
+}
+
+func runMastodonDeletion(ctx context.Context, j *job) ([]count, error) {
+	client, err := mastodonClient(ctx, j.Profile, j.Out)
+	if err != nil {
+		return nil, err
+	}
+	return deleteGeneric(ctx, j, client, "Mastodon", generic.MastodonTypes())
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"go-del-socials/pkg/generic"
+	"go-del-socials/pkg/secrets"
+)
+
+// validateMatrix is the matrix provider's check of a profile's section
+func validateMatrix(section json.RawMessage) error {
+	var c generic.MatrixConfig
+	if err := decodeSection(section, &c); err != nil {
+		return err
+	}
+	if c.Homeserver == "" && c.User == "" && c.Password == "" && c.AccessToken == "" {
+		return nil
+	}
+	settings := []setting{{"homeserver", c.Homeserver, "set the URL of your homeserver, e.g. https://matrix.org"}}
+	if c.AccessToken == "" {
+		settings = append(settings,
+			setting{"user", c.User, "set your user ID, e.g. @alice:matrix.org, or an access_token"},
+			setting{"password", c.Password, "set your password or a secret reference such as op://vault/matrix/password"})
+	}
+	if err := checkSettings(settings...); err != nil {
+		return err
+	}
+	if err := checkURL("homeserver", c.Homeserver); err != nil {
+		return err
+	}
+	for _, r := range c.Rooms {
+		if !strings.HasPrefix(r, "!") && !strings.HasPrefix(r, "#") {
+			return fmt.Errorf("room %q must be a room ID (!...) or an alias (#...)", r)
+		}
+	}
+	return nil
+}
+
+// matrixClient returns a client for the profile's matrix section
+func matrixClient(p *Profile, out io.Writer) (*generic.Client, error) {
+	var c generic.MatrixConfig
+	if err := decodeSection(p.Sections["matrix"], &c); err != nil {
+		return nil, err
+	}
+	if c.Homeserver == "" {
+		return nil, errNotConfigured
+	}
+	if err := secrets.ResolveAll(&c.Password, &c.AccessToken); err != nil {
+		return nil, fmt.Errorf("error resolving secret: %v", err)
+	}
+	return generic.NewMatrixClient(&c, out), nil
+}
+
+// checkMatrix verifies the account can sign in and its rooms be found
+func checkMatrix(ctx context.Context, p *Profile) error {
+	client, err := matrixClient(p, io.Discard)
+	if err != nil {
+		return err
+	}
+	return client.Check(ctx)
+}
+
+// probeMatrix times signing in and listing the rooms
+func probeMatrix(ctx context.Context, p *Profile) (func(ctx context.Context) error, error) {
+	client, err := matrixClient(p, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+	return client.Check, nil
+}
+
+func runMatrixDeletion(ctx context.Context, j *job) ([]count, error) {
+	client, err := matrixClient(j.Profile, j.Out)
+	if err != nil {
+		return nil, err
+	}
+	return deleteGeneric(ctx, j, client, "Matrix", generic.MatrixTypes())
+}
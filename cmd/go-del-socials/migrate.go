@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// configVersion is the layout of config.json this build writes and reads
+const configVersion = 3
+
+// A migration upgrades a config from the version before it, returning what
+// it changed
+type migration func(raw map[string]json.RawMessage) ([]string, error)
+
+// migrations[i] upgrades version i to i+1
+var migrations = []migration{
+	migrateFlatReddit,
+	migratePluginSections,
+	migrateRedditUserAgent,
+}
+
+// flatRedditKeys are the Reddit settings the first releases kept at the top
+// level, before Twitter support moved them into a "reddit" section
+var flatRedditKeys = []string{"client_id", "client_secret", "username", "password", "user_agent"}
+
+// migrateFlatReddit moves top-level Reddit settings into the reddit section
+func migrateFlatReddit(raw map[string]json.RawMessage) ([]string, error) {
+	reddit := map[string]json.RawMessage{}
+	if section, ok := raw["reddit"]; ok {
+		if err := json.Unmarshal(section, &reddit); err != nil {
+			return nil, fmt.Errorf("error parsing the reddit section: %v", err)
+		}
+	}
+
+	var changes []string
+	for _, key := range flatRedditKeys {
+		value, ok := raw[key]
+		if !ok {
+			continue
+		}
+		delete(raw, key)
+		if _, taken := reddit[key]; taken {
+			changes = append(changes, fmt.Sprintf("removed top-level %q, which reddit.%s overrides", key, key))
+			continue
+		}
+		reddit[key] = value
+		changes = append(changes, fmt.Sprintf("moved %q into the reddit section", key))
+	}
+
+	if len(changes) > 0 {
+		section, err := json.Marshal(reddit)
+		if err != nil {
+			return nil, err
+		}
+		raw["reddit"] = section
+	}
+	return changes, nil
+}
+
+// migratePluginSections moves the settings of plugin and generic providers
+// out of each profile's plugins section, so every platform's section is
+// named after it
+func migratePluginSections(raw map[string]json.RawMessage) ([]string, error) {
+	changes, err := liftPlugins(raw, "")
+	if err != nil {
+		return nil, err
+	}
+
+	section, ok := raw["profiles"]
+	if !ok {
+		return changes, nil
+	}
+	var profiles map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(section, &profiles); err != nil {
+		return nil, fmt.Errorf("error parsing the profiles section: %v", err)
+	}
+	for name, profile := range profiles {
+		c, err := liftPlugins(profile, "profiles."+name+".")
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c...)
+	}
+	if section, err = json.Marshal(profiles); err != nil {
+		return nil, err
+	}
+	raw["profiles"] = section
+	return changes, nil
+}
+
+// liftPlugins moves the entries of a profile's plugins section up into the
+// profile. prefix locates the profile in messages.
+func liftPlugins(profile map[string]json.RawMessage, prefix string) ([]string, error) {
+	section, ok := profile["plugins"]
+	if !ok {
+		return nil, nil
+	}
+	var plugins map[string]json.RawMessage
+	if err := json.Unmarshal(section, &plugins); err != nil {
+		return nil, fmt.Errorf("error parsing %splugins: %v", prefix, err)
+	}
+
+	var changes []string
+	for _, name := range slices.Sorted(maps.Keys(plugins)) {
+		if _, taken := profile[name]; taken {
+			return nil, fmt.Errorf("%splugins.%s can't be moved, %s%s already exists", prefix, name, prefix, name)
+		}
+		profile[name] = plugins[name]
+		changes = append(changes, fmt.Sprintf("moved %splugins.%s to %s%s", prefix, name, prefix, name))
+	}
+	delete(profile, "plugins")
+	return changes, nil
+}
+
+// oldRedditUserAgent is the user agent the example config used to suggest,
+// which doesn't follow Reddit's API rules
+const oldRedditUserAgent = "RedditDelete/1.0.0"
+
+// migrateRedditUserAgent replaces the old example user agent with one in
+// Reddit's format, made from the section's username. Sections without a
+// usable username are left for the settings check to report.
+func migrateRedditUserAgent(raw map[string]json.RawMessage) ([]string, error) {
+	changes, err := fixRedditUserAgent(raw, "")
+	if err != nil {
+		return nil, err
+	}
+
+	section, ok := raw["profiles"]
+	if !ok {
+		return changes, nil
+	}
+	var profiles map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(section, &profiles); err != nil {
+		return nil, fmt.Errorf("error parsing the profiles section: %v", err)
+	}
+	for _, name := range slices.Sorted(maps.Keys(profiles)) {
+		c, err := fixRedditUserAgent(profiles[name], "profiles."+name+".")
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c...)
+	}
+	if section, err = json.Marshal(profiles); err != nil {
+		return nil, err
+	}
+	raw["profiles"] = section
+	return changes, nil
+}
+
+// fixRedditUserAgent replaces the old user agent in a profile's reddit
+// section. prefix locates the profile in messages.
+func fixRedditUserAgent(profile map[string]json.RawMessage, prefix string) ([]string, error) {
+	section, ok := profile["reddit"]
+	if !ok {
+		return nil, nil
+	}
+	var reddit map[string]json.RawMessage
+	if err := json.Unmarshal(section, &reddit); err != nil {
+		return nil, fmt.Errorf("error parsing %sreddit: %v", prefix, err)
+	}
+	var agent, username string
+	json.Unmarshal(reddit["user_agent"], &agent)
+	json.Unmarshal(reddit["username"], &username)
+	if agent != oldRedditUserAgent || username == "" || placeholderPattern.MatchString(username) {
+		return nil, nil
+	}
+
+	agent = "script:go-del-socials:v1.0 (by /u/" + strings.TrimPrefix(strings.TrimPrefix(username, "/"), "u/") + ")"
+	value, err := json.Marshal(agent)
+	if err != nil {
+		return nil, err
+	}
+	reddit["user_agent"] = value
+	if profile["reddit"], err = json.Marshal(reddit); err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("set %sreddit.user_agent to %q, in the format Reddit's API rules ask for", prefix, agent)}, nil
+}
+
+// migrateConfig upgrades a config to configVersion. It returns the upgraded
+// config, the version it had and what changed, which is nothing when it
+// was current.
+func migrateConfig(data []byte) ([]byte, int, []string, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, 0, nil, err
+	}
+
+	version := 0
+	if v, ok := raw["version"]; ok {
+		if err := json.Unmarshal(v, &version); err != nil {
+			return nil, 0, nil, fmt.Errorf("version must be a number: %v", err)
+		}
+	}
+	if version > configVersion {
+		return nil, version, nil, fmt.Errorf("config version %d is newer than this build supports (%d); please upgrade", version, configVersion)
+	}
+	if version == configVersion {
+		return data, version, nil, nil
+	}
+
+	var changes []string
+	for v := version; v < configVersion; v++ {
+		c, err := migrations[v](raw)
+		if err != nil {
+			return nil, version, nil, fmt.Errorf("error migrating from version %d: %v", v, err)
+		}
+		changes = append(changes, c...)
+	}
+	raw["version"] = json.RawMessage(fmt.Sprint(configVersion))
+	changes = append(changes, fmt.Sprintf("set version to %d", configVersion))
+
+	out, err := json.MarshalIndent(raw, "", "    ")
+	if err != nil {
+		return nil, version, nil, err
+	}
+	return append(out, '\n'), version, changes, nil
+}
+
+// upgradeConfigFile migrates the config file in place, keeping the old one
+// as a backup, and prints what changed
+func upgradeConfigFile(path string, data []byte) ([]byte, error) {
+	upgraded, version, changes, err := migrateConfig(data)
+	if err != nil || len(changes) == 0 {
+		return upgraded, err
+	}
+
+	backup := fmt.Sprintf("%s.v%d.bak", path, version)
+	if err := os.WriteFile(backup, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to back up config before migrating it: %v", err)
+	}
+	if err := os.WriteFile(path, upgraded, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write migrated config: %v", err)
+	}
+
+	fmt.Printf("Migrated %s from version %d to %d (old file kept as %s):\n", path, version, configVersion, backup)
+	for _, c := range slices.Compact(changes) {
+		fmt.Printf("- %s\n", c)
+	}
+	return upgraded, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"go-del-socials/pkg/mqtt"
+	"go-del-socials/pkg/report"
+)
+
+// publishStart tells the MQTT broker, if any, that a profile's run started
+func publishStart(p *mqtt.Publisher, out io.Writer, profile, platform string) {
+	if p == nil {
+		return
+	}
+	if err := p.Status(profile, platform, "running"); err != nil {
+		fmt.Fprintf(out, "Warning: %v\n", err)
+	}
+}
+
+// publishDone sends the outcome of a profile's run to the MQTT broker, if
+// any: its status and counts, and an alert when it failed or was stopped
+// by a platform limit
+func publishDone(p *mqtt.Publisher, out io.Writer, rep *report.Report) {
+	if p == nil {
+		return
+	}
+	status := "done"
+	alert := mqtt.Alert{Profile: rep.Profile, Platform: rep.Platform, ResumeAt: rep.ResumeAt}
+	switch {
+	case rep.ResumeAt != nil:
+		status = "stopped"
+		alert.Message = "stopped by the platform's daily limit; resumes at " + rep.ResumeAt.Local().Format("2006-01-02 15:04")
+	case rep.Error != "":
+		status = "failed"
+		alert.Message = rep.Error
+	}
+
+	err := p.Counts(rep.Profile, rep.Platform, rep.Counts)
+	if err == nil {
+		err = p.Status(rep.Profile, rep.Platform, status)
+	}
+	if err == nil && alert.Message != "" {
+		err = p.Alert(alert)
+	}
+	if err != nil {
+		fmt.Fprintf(out, "Warning: %v\n", err)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+
+	"go-del-socials/pkg/generic"
+	"go-del-socials/pkg/secrets"
+)
+
+// validateNostr is the nostr provider's check of a profile's section
+func validateNostr(section json.RawMessage) error {
+	var c generic.NostrConfig
+	if err := decodeSection(section, &c); err != nil {
+		return err
+	}
+	if c.PrivateKey == "" && len(c.Relays) == 0 {
+		return nil
+	}
+	err := checkSettings(setting{"private_key", c.PrivateKey, "set the nsec1... key you post with, or a secret reference such as op://vault/nostr/nsec"})
+	if err != nil {
+		return err
+	}
+	if len(c.Relays) == 0 {
+		return fmt.Errorf("relays is empty; list the relays you post to, e.g. wss://relay.damus.io")
+	}
+	for _, r := range c.Relays {
+		if u, err := url.Parse(r); err != nil || (u.Scheme != "wss" && u.Scheme != "ws") || u.Host == "" {
+			return fmt.Errorf("relay %q must be a wss:// URL", r)
+		}
+	}
+	return nil
+}
+
+// nostrClient returns a client for the profile's nostr section
+func nostrClient(p *Profile, out io.Writer) (*generic.Client, error) {
+	var c generic.NostrConfig
+	if err := decodeSection(p.Sections["nostr"], &c); err != nil {
+		return nil, err
+	}
+	if c.PrivateKey == "" {
+		return nil, errNotConfigured
+	}
+	if err := secrets.ResolveAll(&c.PrivateKey); err != nil {
+		return nil, fmt.Errorf("error resolving secret: %v", err)
+	}
+	return generic.NewNostrClient(&c, out)
+}
+
+// checkNostr verifies the key is valid and a relay can be reached
+func checkNostr(ctx context.Context, p *Profile) error {
+	client, err := nostrClient(p, io.Discard)
+	if err != nil {
+		return err
+	}
+	return client.Check(ctx)
+}
+
+// probeNostr times asking the relays for the latest note
+func probeNostr(ctx context.Context, p *Profile) (func(ctx context.Context) error, error) {
+	client, err := nostrClient(p, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+	return client.Check, nil
+}
+
+func runNostrDeletion(ctx context.Context, j *job) ([]count, error) {
+	client, err := nostrClient(j.Profile, j.Out)
+	if err != nil {
+		return nil, err
+	}
+	return deleteGeneric(ctx, j, client, "Nostr", generic.NostrTypes())
+}
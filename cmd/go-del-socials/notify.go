@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"go-del-socials/pkg/notify"
+	"go-del-socials/pkg/pause"
+)
+
+// stallAfter is how long a run may go without output before it is reported
+// as stalled
+const stallAfter = 10 * time.Minute
+
+// activityWriter remembers when output was last written
+type activityWriter struct {
+	w    io.Writer
+	last atomic.Int64
+}
+
+func newActivityWriter(w io.Writer) *activityWriter {
+	a := &activityWriter{w: w}
+	a.last.Store(time.Now().UnixNano())
+	return a
+}
+
+func (a *activityWriter) Write(b []byte) (int, error) {
+	a.last.Store(time.Now().UnixNano())
+	return a.w.Write(b)
+}
+
+// watchStall sends a notification once the run writing to a has been
+// silent for stallAfter, as when it waits out a long rate limit, and again
+// for every further silence, until stop is closed. Pauses don't count.
+func watchStall(a *activityWriter, title string, g *pause.Gate, stop <-chan struct{}) {
+	tick := time.NewTicker(time.Minute)
+	defer tick.Stop()
+	notified := int64(0)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-tick.C:
+			last := a.last.Load()
+			if g.Paused() || last == notified || time.Since(time.Unix(0, last)) < stallAfter {
+				continue
+			}
+			notified = last
+			body := fmt.Sprintf("No progress for %v; it may be waiting out a rate limit", time.Since(time.Unix(0, last)).Round(time.Minute))
+			sendNotification(title+" run stalled", body)
+		}
+	}
+}
+
+// notifyDone sends a notification summing up finished runs
+func notifyDone(title string, results []*runResult) {
+	total, failed := 0, 0
+	for _, r := range results {
+		total += r.total()
+		if r.Err != nil {
+			failed++
+		}
+	}
+
+	heading := title + " run finished"
+	body := fmt.Sprintf("%d items", total)
+	if len(results) == 1 {
+		for i, c := range results[0].Counts {
+			if i == 0 {
+				body = ""
+			} else {
+				body += ", "
+			}
+			body += fmt.Sprintf("%s: %d", c.Label, c.N)
+		}
+		body += " (profile " + results[0].Profile + ")"
+	} else {
+		body += fmt.Sprintf(" across %d profiles", len(results))
+	}
+	if failed > 0 {
+		heading = title + " run failed"
+		body += fmt.Sprintf("; %d profiles failed", failed)
+	}
+	if len(results) > 0 && results[0].Simulated {
+		body += "; simulated, nothing was deleted"
+	}
+	sendNotification(heading, body)
+}
+
+func sendNotification(title, body string) {
+	if err := notify.Send(title, body); err != nil {
+		fmt.Fprintf(stdout, "Warning: %v\n", err)
+	}
+}
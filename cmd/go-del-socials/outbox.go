@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"go-del-socials/pkg/outbox"
+	"go-del-socials/pkg/state"
+	"go-del-socials/pkg/tombstone"
+)
+
+// exportOutbox writes the content a profile's runs deleted, as recorded in
+// its tombstone index, to dir as an ActivityPub outbox
+func exportOutbox(config *Config, profile, actor, dir string) error {
+	if profile == "" {
+		profile = defaultProfile
+	} else if _, ok := config.Profiles[profile]; !ok {
+		return fmt.Errorf("profile %q not found in config file", profile)
+	}
+
+	st, err := state.Open(config.stateDir(), profile)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	s, err := config.openStore(st.Root)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	stones, err := tombstone.New(s).Since("", time.Time{})
+	if err != nil {
+		return err
+	}
+	n, err := outbox.Write(dir, actor, stones)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %d of the %d deleted items of profile %s to %s\n", n, len(stones), profile, dir)
+	if skipped := len(stones) - n; skipped > 0 {
+		fmt.Printf("Skipped %d without text, such as likes, retweets and items that were already gone\n", skipped)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"go-del-socials/pkg/pause"
+	"go-del-socials/pkg/plan"
+)
+
+// stdout receives run output; --log-file tees it into the log
+var stdout io.Writer = os.Stdout
+
+// plain leaves out symbols and decorations, and keeps every line of a
+// summary self-contained, for screen readers and log processors
+var plain bool
+
+// togglePause pauses or resumes runs, noting it in the output and so in
+// the log. how says how to resume.
+func togglePause(g *pause.Gate, how string) {
+	if d, ok := g.Resume(); ok {
+		fmt.Fprintf(stdout, "Resumed after a pause of %v\n", d.Round(time.Second))
+		return
+	}
+	g.Pause()
+	fmt.Fprintf(stdout, "Pausing after the current item; %s to resume\n", how)
+}
+
+// watchProgress prints a line each time the run gets another tenth through
+// the plan's items
+func watchProgress(s *plan.Set, out io.Writer) {
+	last := 0
+	s.Watch(func(reached, total int) {
+		if step := reached * 10 / total; step > last {
+			last = step
+			fmt.Fprintf(out, "Progress: %d%% (%d of %d items)\n", step*10, reached, total)
+		}
+	})
+}
+
+// prefixWriter prefixes every line with a label so the output of profiles
+// running in parallel stays readable. Writers sharing mu never interleave
+// within a line.
+type prefixWriter struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	prefix string
+	buf    []byte
+}
+
+func newPrefixWriter(w io.Writer, mu *sync.Mutex, prefix string) *prefixWriter {
+	return &prefixWriter{w: w, mu: mu, prefix: prefix}
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		line := p.buf[:i+1]
+		p.mu.Lock()
+		_, err := io.WriteString(p.w, p.prefix+string(line))
+		p.mu.Unlock()
+		if err != nil {
+			return 0, err
+		}
+		p.buf = p.buf[i+1:]
+	}
+
+	return len(b), nil
+}
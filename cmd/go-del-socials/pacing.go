@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"go-del-socials/pkg/pace"
+)
+
+// Pacing sets how fast a platform is worked through. Unset fields keep the
+// platform's defaults.
+type Pacing struct {
+	// Delay is the wait between listing pages, e.g. "1s"
+	Delay string `json:"delay"`
+
+	// MaxWritesPerMinute or MaxWritesPer15m caps each account's deletes
+	// and edits; -1 lifts the cap. Burst of them may be sent back to back,
+	// by default the whole allowance.
+	MaxWritesPerMinute int `json:"max_writes_per_minute"`
+	MaxWritesPer15m    int `json:"max_writes_per_15m"`
+	Burst              int `json:"burst"`
+
+	// Jitter randomly lengthens or shortens every wait by up to this
+	// fraction of it, 0.2 unless set; 0 turns it off
+	Jitter *float64 `json:"jitter"`
+
+	// Workers is how many profiles run at once with --parallel; 0 runs
+	// them all
+	Workers int `json:"workers"`
+
+	// Learn, true unless set, starts every run at the write rate earlier
+	// runs of the account settled on, as long as no cap is set above.
+	// Tier names the account's API tier, so rates learned on one aren't
+	// used on another.
+	Learn *bool  `json:"learn"`
+	Tier  string `json:"tier"`
+}
+
+// defaultJitter is how much waits vary unless the config says otherwise
+const defaultJitter = 0.2
+
+// writeCap is a number of writes allowed per period; 0 writes is no cap
+type writeCap struct {
+	n   int
+	per time.Duration
+}
+
+// paceDefault is a platform's delay, write cap and burst when the config
+// sets none; a burst of 0 is the whole allowance
+type paceDefault struct {
+	delay  time.Duration
+	writes writeCap
+	burst  int
+}
+
+// paceDefaults are the built-in platforms' defaults
+var paceDefaults = map[string]paceDefault{
+	"reddit":  {2 * time.Second, writeCap{60, time.Minute}, 0},
+	"twitter": {5 * time.Second, writeCap{50, 15 * time.Minute}, 0},
+}
+
+// defaultPacing returns the platform's defaults. Platforms other than the
+// built-in ones default to their own rate limit of deletes a minute, sent
+// one at a time.
+func defaultPacing(platform string, rate int) paceDefault {
+	if def, ok := paceDefaults[platform]; ok {
+		return def
+	}
+	return paceDefault{writes: writeCap{rate, time.Minute}, burst: 1}
+}
+
+// validatePacing checks the pacing of every platform when the config is
+// loaded, so a typo doesn't surface halfway through a run
+func (c *Config) validatePacing() error {
+	for platform, p := range c.Pacing {
+		if _, _, err := p.limits(paceDefaults[platform]); err != nil {
+			return fmt.Errorf("pacing of %s: %v", platform, err)
+		}
+		if p != nil && (p.Burst < 0 || p.Workers < 0) {
+			return fmt.Errorf("pacing of %s: burst and workers can't be negative", platform)
+		}
+		if j := p.jitter(); j < 0 || j > 1 {
+			return fmt.Errorf("pacing of %s: jitter must be between 0 and 1", platform)
+		}
+	}
+	return nil
+}
+
+// limits returns the platform's delay between listing pages and its write
+// cap, from the config where set and the defaults otherwise
+func (p *Pacing) limits(def paceDefault) (time.Duration, writeCap, error) {
+	if p == nil {
+		return def.delay, def.writes, nil
+	}
+
+	delay := def.delay
+	if p.Delay != "" {
+		d, err := time.ParseDuration(p.Delay)
+		if err != nil || d < 0 {
+			return 0, writeCap{}, fmt.Errorf("invalid delay %q", p.Delay)
+		}
+		delay = d
+	}
+
+	writes := def.writes
+	switch {
+	case p.MaxWritesPerMinute != 0:
+		writes = writeCap{p.MaxWritesPerMinute, time.Minute}
+	case p.MaxWritesPer15m != 0:
+		writes = writeCap{p.MaxWritesPer15m, 15 * time.Minute}
+	}
+	if writes.n < 0 {
+		writes.n = 0
+	}
+	return delay, writes, nil
+}
+
+// pacer returns the pacer for an account on the platform, starting at the
+// learned write rate when there is one
+func (p *Pacing) pacer(def paceDefault, learned *pace.Learned) (*pace.Pacer, error) {
+	delay, writes, err := p.limits(def)
+	if err != nil {
+		return nil, err
+	}
+	if learned != nil && learned.PerMinute > 0 && writes.n > 0 && p.learns() {
+		writes.n = max(1, int(math.Round(learned.PerMinute*writes.per.Minutes())))
+	}
+	burst := writes.n
+	if def.burst > 0 {
+		burst = def.burst
+	}
+	if p != nil && p.Burst > 0 {
+		burst = p.Burst
+	}
+	return pace.New(delay, p.jitter(), pace.NewLimiter(writes.n, writes.per, burst)), nil
+}
+
+// learns reports whether the platform's write rate is learned: unless
+// turned off, or a cap is set by hand
+func (p *Pacing) learns() bool {
+	if p == nil {
+		return true
+	}
+	return (p.Learn == nil || *p.Learn) && p.MaxWritesPerMinute == 0 && p.MaxWritesPer15m == 0
+}
+
+// tier returns the API tier rates are learned for
+func (p *Pacing) tier() string {
+	if p == nil || p.Tier == "" {
+		return "default"
+	}
+	return p.Tier
+}
+
+// pacer returns the job's pacer for the platform, starting from what
+// earlier runs learned about its rate limits. rate is the platform's own
+// limit of deletes a minute, for those without built-in defaults.
+func (j *job) pacer(platform string, rate int) (*pace.Pacer, error) {
+	var learned *pace.Learned
+	if j.Pacing.learns() {
+		var err error
+		if learned, err = pace.LoadLearned(j.Store, platform, j.Pacing.tier()); err != nil {
+			return nil, err
+		}
+	}
+	p, err := j.Pacing.pacer(defaultPacing(platform, rate), learned)
+	if err == nil && learned != nil {
+		fmt.Fprintf(j.Out, "Pacing writes at %.4g a minute, as learned over %d earlier runs\n", p.Stats().PerMinute, learned.Runs)
+	}
+	return p, err
+}
+
+// learn keeps what the run's pacer saw of the rate limits for later runs
+func (j *job) learn(platform string, p *pace.Pacer) {
+	if !j.Pacing.learns() || j.Options.Simulate {
+		return
+	}
+	learned, err := pace.LoadLearned(j.Store, platform, j.Pacing.tier())
+	if err == nil && learned == nil {
+		learned = &pace.Learned{}
+	}
+	if err == nil && learned.Learn(p.Stats(), time.Now()) {
+		err = pace.SaveLearned(j.Store, platform, j.Pacing.tier(), learned)
+	}
+	if err != nil {
+		fmt.Fprintf(j.Out, "Warning: %v\n", err)
+	}
+}
+
+// jitter returns how much waits vary
+func (p *Pacing) jitter() float64 {
+	if p == nil || p.Jitter == nil {
+		return defaultJitter
+	}
+	return *p.Jitter
+}
+
+// interval is the average time between writes under the cap, 0 without one
+func (w writeCap) interval() time.Duration {
+	if w.n == 0 {
+		return 0
+	}
+	return w.per / time.Duration(w.n)
+}
+
+// workers returns how many profiles may run at once, 0 for all of them
+func (p *Pacing) workers() int {
+	if p == nil {
+		return 0
+	}
+	return p.Workers
+}
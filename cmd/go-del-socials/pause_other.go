@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+import "go-del-socials/pkg/pause"
+
+// notifyPause does nothing, as there is no SIGUSR1 here
+func notifyPause(g *pause.Gate) {}
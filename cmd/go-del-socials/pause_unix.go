@@ -0,0 +1,22 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go-del-socials/pkg/pause"
+)
+
+// notifyPause toggles g on every SIGUSR1
+func notifyPause(g *pause.Gate) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGUSR1)
+	go func() {
+		for range sig {
+			togglePause(g, "send SIGUSR1 again")
+		}
+	}()
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+
+	"go-del-socials/pkg/generic"
+	"go-del-socials/pkg/inventory"
+	"go-del-socials/pkg/plan"
+	"go-del-socials/pkg/plugin"
+	"go-del-socials/pkg/secrets"
+	"go-del-socials/pkg/tombstone"
+)
+
+// defaultPluginDir is where plugins are found unless the config says otherwise
+const defaultPluginDir = "plugins"
+
+// pluginProvider is a provider implemented by a plugin executable. It is
+// only described once selected or listed, so a broken plugin doesn't get
+// in the way of runs that don't use it.
+type pluginProvider struct {
+	*plugin.Plugin
+
+	described sync.Once
+	err       error
+}
+
+// describe asks the plugin what it supports the first time it's needed,
+// warning if it can't tell
+func (p *pluginProvider) describe() error {
+	p.described.Do(func() {
+		if p.err = p.Describe(context.Background()); p.err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping plugin %s: %v\n", p.Path, p.err)
+		}
+	})
+	return p.err
+}
+
+func (p *pluginProvider) Name() string { return p.Plugin.Name }
+
+func (p *pluginProvider) Capabilities() Capabilities {
+	return Capabilities{
+		Title:        p.Plugin.Name,
+		ContentTypes: p.Info.ContentTypes,
+		Overwrite:    p.Info.Overwrite,
+		Undo:         p.Info.Undo,
+		MaxRate:      p.Info.MaxRate,
+	}
+}
+
+// Validate only checks the section is an object; the plugin's check
+// method sees the settings themselves
+func (p *pluginProvider) Validate(section json.RawMessage) error {
+	return validateObject(section)
+}
+
+func (p *pluginProvider) Check(ctx context.Context, profile *Profile) error {
+	section, ok := profile.Sections[p.Plugin.Name]
+	if !ok {
+		return errNotConfigured
+	}
+	if err := p.describe(); err != nil {
+		return err
+	}
+	if !p.Info.Check {
+		return nil
+	}
+	return p.Plugin.Run(ctx, plugin.Request{Method: "check", Config: section}, io.Discard, func(plugin.Message) error { return nil })
+}
+
+func (p *pluginProvider) Run(ctx context.Context, j *job) ([]count, error) {
+	return runPlugin(ctx, j, p.Plugin)
+}
+
+// loadProviders returns the built-in providers followed by the generic
+// providers, webhooks and plugins that are configured
+func loadProviders(config *Config) ([]Provider, error) {
+	providers := slices.Clone(builtins)
+	if config.Fake != nil {
+		providers = append(providers, fakeProvider{config.Fake})
+	}
+	add := func(p Provider, source string) error {
+		if findProvider(providers, p.Name()) != nil {
+			return fmt.Errorf("%s would replace the %s provider", source, p.Name())
+		}
+		providers = append(providers, p)
+		return nil
+	}
+
+	dir := config.ProviderDir
+	if dir == "" {
+		dir = defaultProviderDir
+	}
+	specs, err := generic.Load(config.path(dir))
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range specs {
+		if err := add(genericProvider{s}, "generic provider "+s.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	names := slices.Sorted(maps.Keys(config.Webhooks))
+	for _, name := range names {
+		w := config.Webhooks[name]
+		if err := w.Check(name); err != nil {
+			return nil, err
+		}
+		if w.Token, err = secrets.Resolve(w.Token); err != nil {
+			return nil, fmt.Errorf("failed to resolve the token of webhook %s: %v", name, err)
+		}
+		if err := add(webhookProvider{name, w}, "webhook "+name); err != nil {
+			return nil, err
+		}
+	}
+
+	dir = config.PluginDir
+	if dir == "" {
+		dir = defaultPluginDir
+	}
+	plugins, err := plugin.Discover(config.path(dir))
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range plugins {
+		if err := add(&pluginProvider{Plugin: p}, "plugin "+p.Path); err != nil {
+			return nil, err
+		}
+	}
+	return providers, nil
+}
+
+// runPlugin runs a deletion through an external provider plugin. Items the
+// plugin reports feed the plan, report, kept inventory and tombstone index
+// like those of the built-in providers.
+func runPlugin(ctx context.Context, j *job, p *plugin.Plugin) ([]count, error) {
+	section, ok := j.Profile.Sections[p.Name]
+	if !ok {
+		return nil, fmt.Errorf("profile has no %s section", p.Name)
+	}
+
+	if j.Options.Simulate && !p.Info.Simulate {
+		return nil, fmt.Errorf("plugin %s does not support --simulate", p.Name)
+	}
+	if j.Options.Targets != "" {
+		return nil, fmt.Errorf("plugin %s does not support --targets", p.Name)
+	}
+	if len(j.Options.KeepLast) > 0 {
+		return nil, fmt.Errorf("plugin %s does not support --keep-last", p.Name)
+	}
+	if len(j.Options.Retention) > 0 {
+		return nil, fmt.Errorf("plugin %s does not support --retention", p.Name)
+	}
+	if j.Options.Wayback == waybackDeleted {
+		return nil, fmt.Errorf("plugin %s does not support --wayback %s", p.Name, waybackDeleted)
+	}
+	if pc := j.Pacing; pc != nil && (pc.Delay != "" || pc.MaxWritesPerMinute != 0 || pc.MaxWritesPer15m != 0 || pc.Burst != 0 || pc.Jitter != nil || pc.Learn != nil) {
+		fmt.Fprintf(j.Out, "Warning: plugin %s paces itself; only workers of its pacing is used\n", p.Name)
+	}
+
+	cutoff := j.CutoffDate
+	req := plugin.Request{
+		Method:      "delete",
+		Config:      section,
+		ContentType: j.ContentType,
+		Cutoff:      &cutoff,
+		DryRun:      j.Plan != nil,
+		Simulate:    j.Options.Simulate,
+	}
+	if j.Approved != nil {
+		req.Approved = []string{}
+		for _, it := range j.Approved.Items {
+			req.Approved = append(req.Approved, it.ID)
+		}
+	}
+
+	var counts []count
+	err := p.Run(ctx, req, j.Out, func(m plugin.Message) error {
+		switch m.Type {
+		case "log":
+			fmt.Fprintln(j.Out, m.Message)
+		case "item":
+			if m.Item == nil {
+				return fmt.Errorf("item message without an item")
+			}
+			return pluginItem(j, p, m.Item, req.DryRun)
+		case "result":
+			for _, c := range m.Counts {
+				counts = append(counts, count{c.Label, c.N})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		err = fmt.Errorf("plugin %s: %v", p.Name, err)
+	}
+
+	if j.Plan != nil {
+		return planCounts(j, err)
+	}
+	return counts, err
+}
+
+// pluginItem records an item the plugin acted on
+func pluginItem(j *job, p *plugin.Plugin, it *plugin.Item, dryRun bool) error {
+	keep := func(reason string) {
+		if j.Kept != nil {
+			j.Kept.Add(inventory.Item{ID: it.ID, Kind: it.Kind, Date: it.Date, URL: it.URL, Text: it.Text, Reason: reason})
+		}
+	}
+
+	switch it.Action {
+	case "matched":
+		if !dryRun {
+			return fmt.Errorf("reported item %s as matched outside a dry run", it.ID)
+		}
+		j.Report.Matched++
+		if j.Plan != nil {
+			j.Plan.Add(plan.Item{ID: it.ID, Kind: it.Kind, Date: it.Date, Text: it.Text})
+		}
+
+	case "deleted":
+		if dryRun {
+			return fmt.Errorf("deleted item %s during a dry run", it.ID)
+		}
+		j.Report.Matched++
+		if j.Approved != nil && !j.Approved.Has(it.ID) {
+			// Too late to stop, but the run must not pass as following the plan
+			return fmt.Errorf("deleted item %s, which is not in the plan", it.ID)
+		}
+		if j.Tombstones == nil {
+			break
+		}
+		err := j.Tombstones.Record(tombstone.Tombstone{
+			Platform: p.Name,
+			ID:       it.ID,
+			Kind:     it.Kind,
+			URL:      it.URL,
+			Text:     it.Text,
+			Created:  it.Date,
+		})
+		if err != nil {
+			fmt.Fprintf(j.Out, "Warning: %v\n", err)
+		}
+
+	case "failed":
+		j.Report.Matched++
+		j.Report.Failed++
+		keep("delete failed")
+
+	case "kept":
+		keep(it.Reason)
+
+	default:
+		return fmt.Errorf("unknown action %q for item %s", it.Action, it.ID)
+	}
+	return nil
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/generic"
+)
+
+// Provider is a platform deletions can be run on
+type Provider interface {
+	// Name is the platform's name in prompts, plans and reports
+	Name() string
+	Capabilities() Capabilities
+
+	// Validate checks the provider's section of a profile, which only the
+	// provider knows the layout of, without contacting the platform
+	Validate(section json.RawMessage) error
+
+	// Check validates connectivity, credentials and permissions for the
+	// profile. Runs only start after it succeeds. It returns
+	// errNotConfigured when the profile has no account on the platform.
+	Check(ctx context.Context, p *Profile) error
+
+	Run(ctx context.Context, j *job) ([]count, error)
+}
+
+// prober is a provider that can time a read-only request to its platform,
+// for bench
+type prober interface {
+	// Probe returns a read-only request for the profile's account, to be
+	// repeated. Like Check, it returns errNotConfigured for profiles
+	// without an account on the platform.
+	Probe(ctx context.Context, p *Profile) (func(ctx context.Context) error, error)
+}
+
+// errNotConfigured is returned by Check for profiles without an account on
+// the platform
+var errNotConfigured = errors.New("not configured for this profile")
+
+// Capabilities describes what a provider supports. The prompts, flag help
+// and validation are built from it.
+type Capabilities struct {
+	Title        string
+	ContentTypes []string
+
+	// Overwrite is set when content is edited before it is deleted, and
+	// Undo when deleted content can be restored
+	Overwrite bool
+	Undo      bool
+
+	// MaxRate is the most deletions per minute, 0 if unknown
+	MaxRate int
+
+	// Flags are the command-line flags that only apply to this provider
+	Flags []string
+}
+
+// builtin is a provider compiled into the binary
+type builtin struct {
+	name     string
+	caps     Capabilities
+	validate func(section json.RawMessage) error
+	check    func(ctx context.Context, p *Profile) error
+	probe    func(ctx context.Context, p *Profile) (func(ctx context.Context) error, error)
+	run      func(ctx context.Context, j *job) ([]count, error)
+}
+
+func (b *builtin) Name() string                                     { return b.name }
+func (b *builtin) Capabilities() Capabilities                       { return b.caps }
+func (b *builtin) Validate(section json.RawMessage) error           { return b.validate(section) }
+func (b *builtin) Check(ctx context.Context, p *Profile) error      { return b.check(ctx, p) }
+func (b *builtin) Run(ctx context.Context, j *job) ([]count, error) { return b.run(ctx, j) }
+
+func (b *builtin) Probe(ctx context.Context, p *Profile) (func(ctx context.Context) error, error) {
+	return b.probe(ctx, p)
+}
+
+// builtins are the providers compiled into the binary
+var builtins = []Provider{
+	&builtin{
+		name: "reddit",
+		caps: Capabilities{
+			Title:        "Reddit",
+			ContentTypes: []string{"all", "posts", "comments", "drafts", "chat", "profile"},
+			Overwrite:    true,
+			MaxRate:      30,
+			Flags:        []string{"hide", "removed-only", "crossposts", "quarantine-optin", "reddit-export", "multireddit", "incremental", "receipts", "shreddit"},
+		},
+		validate: validateReddit,
+		check:    checkReddit,
+		probe:    probeReddit,
+		run:      runRedditDeletion,
+	},
+	&builtin{
+		name: "twitter",
+		caps: Capabilities{
+			Title:        "Twitter",
+			ContentTypes: []string{"all", "tweets", "replies", "retweets", "likes", "scheduled"},
+			MaxRate:      12,
+			Flags: []string{"hashtag", "exclude-hashtag", "keep-threads", "only-quotes", "budget-plan", "budget-wait",
+				"keep-list", "keep-file", "twitter-archive", "archive-conversations", "incremental", "receipts"},
+		},
+		validate: validateTwitter,
+		check:    checkTwitter,
+		probe:    probeTwitter,
+		run:      runTwitterDeletion,
+	},
+	&builtin{
+		name: "nostr",
+		caps: Capabilities{
+			Title:        "Nostr",
+			ContentTypes: generic.NostrTypes(),
+			MaxRate:      60,
+			Flags:        []string{"receipts"},
+		},
+		validate: validateNostr,
+		check:    checkNostr,
+		probe:    probeNostr,
+		run:      runNostrDeletion,
+	},
+	&builtin{
+		name: "matrix",
+		caps: Capabilities{
+			Title:        "Matrix",
+			ContentTypes: generic.MatrixTypes(),
+			MaxRate:      30,
+			Flags:        []string{"receipts"},
+		},
+		validate: validateMatrix,
+		check:    checkMatrix,
+		probe:    probeMatrix,
+		run:      runMatrixDeletion,
+	},
+	&builtin{
+		name: "slack",
+		caps: Capabilities{
+			Title:        "Slack",
+			ContentTypes: generic.SlackTypes(),
+			MaxRate:      50,
+			Flags:        []string{"receipts"},
+		},
+		validate: validateSlack,
+		check:    checkSlack,
+		probe:    probeSlack,
+		run:      runSlackDeletion,
+	},
+	&builtin{
+		name: "mastodon",
+		caps: Capabilities{
+			Title:        "Mastodon",
+			ContentTypes: generic.MastodonTypes(),
+			MaxRate:      40,
+			Flags:        []string{"receipts"},
+		},
+		validate: validateMastodon,
+		check:    checkMastodon,
+		probe:    probeMastodon,
+		run:      runMastodonDeletion,
+	},
+	&builtin{
+		name: "substack",
+		caps: Capabilities{
+			Title:        "Substack",
+			ContentTypes: generic.SubstackTypes(),
+			MaxRate:      20,
+			Flags:        []string{"receipts"},
+		},
+		validate: validateSubstack,
+		check:    checkSubstack,
+		probe:    probeSubstack,
+		run:      runSubstackDeletion,
+	},
+	&builtin{
+		name: "twitch",
+		caps: Capabilities{
+			Title:        "Twitch",
+			ContentTypes: generic.TwitchTypes(),
+			MaxRate:      60,
+			Flags:        []string{"receipts"},
+		},
+		validate: validateTwitch,
+		check:    checkTwitch,
+		probe:    probeTwitch,
+		run:      runTwitchDeletion,
+	},
+	&builtin{
+		name: "flickr",
+		caps: Capabilities{
+			Title:        "Flickr",
+			ContentTypes: generic.FlickrTypes(),
+			MaxRate:      30,
+			Flags:        []string{"receipts"},
+		},
+		validate: validateFlickr,
+		check:    checkFlickr,
+		probe:    probeFlickr,
+		run:      runFlickrDeletion,
+	},
+	&builtin{
+		name: "letterboxd",
+		caps: Capabilities{
+			Title:        "Letterboxd",
+			ContentTypes: generic.LetterboxdTypes(),
+			MaxRate:      30,
+			Flags:        []string{"receipts"},
+		},
+		validate: validateLetterboxd,
+		check:    checkLetterboxd,
+		probe:    probeLetterboxd,
+		run:      runLetterboxdDeletion,
+	},
+	&builtin{
+		name: "goodreads",
+		caps: Capabilities{
+			Title:        "Goodreads",
+			ContentTypes: generic.GoodreadsTypes(),
+		},
+		validate: validateGoodreads,
+		check:    checkGoodreads,
+		probe:    probeGoodreads,
+		run:      runGoodreadsDeletion,
+	},
+}
+
+// findProvider returns the provider with the given name, or nil
+func findProvider(providers []Provider, name string) Provider {
+	for _, p := range providers {
+		if p.Name() == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// selectProvider returns the provider with the given name, or nil. A plugin
+// is described first, and left out if that fails.
+func selectProvider(providers []Provider, name string) Provider {
+	p := findProvider(providers, name)
+	if pp, ok := p.(*pluginProvider); ok && pp.describe() != nil {
+		return nil
+	}
+	return p
+}
+
+// usableProviders returns the providers to list, leaving out plugins that
+// fail to describe themselves
+func usableProviders(providers []Provider) []Provider {
+	return slices.DeleteFunc(slices.Clone(providers), func(p Provider) bool {
+		pp, ok := p.(*pluginProvider)
+		return ok && pp.describe() != nil
+	})
+}
+
+// validateObject accepts any JSON object, for providers that pass their
+// settings on without reading them
+func validateObject(section json.RawMessage) error {
+	var settings map[string]json.RawMessage
+	if err := json.Unmarshal(section, &settings); err != nil || settings == nil {
+		return fmt.Errorf("settings must be an object")
+	}
+	return nil
+}
+
+// validateProfiles has each provider check its sections of the profiles.
+// Sections of unknown platforms are only warned about, since they may
+// belong to a plugin that was removed.
+func validateProfiles(config *Config, providers []Provider) error {
+	profiles := map[string]*Profile{defaultProfile: &config.Profile}
+	for name, p := range config.Profiles {
+		profiles[name] = &p
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(profiles)) {
+		sections := profiles[name].Sections
+		for _, platform := range slices.Sorted(maps.Keys(sections)) {
+			p := findProvider(providers, platform)
+			if p == nil {
+				fmt.Fprintf(os.Stderr, "Warning: profile %s has settings for %s, which is not a known platform\n", name, platform)
+				continue
+			}
+			if err := p.Validate(sections[platform]); err != nil {
+				return fmt.Errorf("profile %s: invalid %s settings: %v", name, platform, err)
+			}
+		}
+	}
+	return nil
+}
+
+// describe sums up a provider's capabilities in a line
+func describe(p Provider) string {
+	caps := p.Capabilities()
+	desc := []string{strings.Join(caps.ContentTypes, ", ")}
+	if caps.Overwrite {
+		desc = append(desc, "overwrites before deleting")
+	}
+	if caps.MaxRate > 0 {
+		desc = append(desc, fmt.Sprintf("up to %d deletions/min", caps.MaxRate))
+	}
+	if !caps.Undo {
+		desc = append(desc, "can't be undone")
+	}
+	return fmt.Sprintf("%s: %s", caps.Title, strings.Join(desc, "; "))
+}
+
+// checkFlags rejects flags set on the command line that belong to other
+// providers than p only
+func checkFlags(p Provider, providers []Provider) error {
+	owner := map[string][]string{}
+	for _, q := range providers {
+		for _, f := range q.Capabilities().Flags {
+			owner[f] = append(owner[f], q.Name())
+		}
+	}
+
+	var err error
+	flag.Visit(func(f *flag.Flag) {
+		owners := owner[f.Name]
+		if err == nil && len(owners) > 0 && !slices.Contains(owners, p.Name()) {
+			err = fmt.Errorf("--%s only applies to %s", f.Name, strings.Join(owners, " and "))
+		}
+	})
+	return err
+}
+
+// estimate is roughly how long deleting n items takes at the provider's
+// rate, or 0 if the rate isn't known
+func estimate(p Provider, n int) time.Duration {
+	rate := p.Capabilities().MaxRate
+	if rate == 0 {
+		return 0
+	}
+	return time.Duration(n) * time.Minute / time.Duration(rate)
+}
+
+// usage prints the flags grouped into general ones and those of each
+// provider
+func usage(providers []Provider) {
+	out := flag.CommandLine.Output()
+	owned := map[string]bool{}
+	for _, p := range providers {
+		for _, f := range p.Capabilities().Flags {
+			owned[f] = true
+		}
+	}
+
+	fmt.Fprintf(out, "Usage: go-del-socials [plan|apply|run|lookup|import|export-outbox|reddit-authorize|flickr-authorize|verify-archive|verify-audit|doctor|bench|tui] [flags]\n\nFlags:\n")
+	printFlags(out, func(name string) bool { return !owned[name] })
+	for _, p := range providers {
+		caps := p.Capabilities()
+		if len(caps.Flags) == 0 {
+			continue
+		}
+		fmt.Fprintf(out, "\n%s flags:\n", caps.Title)
+		printFlags(out, func(name string) bool { return slices.Contains(caps.Flags, name) })
+	}
+}
+
+// printFlags prints the defaults of the flags keep selects
+func printFlags(out io.Writer, keep func(name string) bool) {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(out)
+	flag.VisitAll(func(f *flag.Flag) {
+		if keep(f.Name) {
+			fs.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	fs.PrintDefaults()
+}
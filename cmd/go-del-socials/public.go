@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"go-del-socials/pkg/httpclient"
+	"go-del-socials/pkg/pace"
+	"go-del-socials/pkg/tombstone"
+	"go-del-socials/pkg/visibility"
+)
+
+// publicDelay spaces out the checks of a platform's public pages; Reddit
+// answers few requests from visitors who aren't signed in
+var publicDelay = map[string]time.Duration{"reddit": 6 * time.Second}
+
+// publicRecheck is how long items still visible get to drop out of caches
+// before they are checked once more
+const publicRecheck = 30 * time.Second
+
+// ownPageless are the kinds whose page shows someone else's content, which
+// rightly stays up
+var ownPageless = []string{"retweet", "like"}
+
+// verifyPublic checks up to n of the items the run deleted since started,
+// chosen at random, by fetching their public pages without credentials. It
+// fails when strangers can still see any of them.
+func verifyPublic(ctx context.Context, j *job, platform string, started time.Time, n int) error {
+	deleted, err := j.Tombstones.Since(platform, started)
+	if err != nil {
+		return err
+	}
+	deleted = slices.DeleteFunc(deleted, func(t tombstone.Tombstone) bool {
+		return t.URL == "" || slices.Contains(ownPageless, t.Kind)
+	})
+	if len(deleted) == 0 {
+		return nil
+	}
+	rand.Shuffle(len(deleted), func(a, b int) { deleted[a], deleted[b] = deleted[b], deleted[a] })
+	deleted = deleted[:min(n, len(deleted))]
+
+	fmt.Fprintf(j.Out, "\nChecking that %d deleted items are gone from public view...\n", len(deleted))
+	hc := httpclient.New()
+	delay, ok := publicDelay[platform]
+	if !ok {
+		delay = time.Second
+	}
+	pacer := pace.New(delay, defaultJitter, nil)
+
+	unchecked := 0
+	check := func(items []tombstone.Tombstone) (visible []tombstone.Tombstone) {
+		for i, t := range items {
+			if i > 0 && pacer.Pause(ctx) != nil {
+				return visible
+			}
+			public, err := visibility.Public(ctx, hc, platform, t.ID, t.URL)
+			if err != nil {
+				fmt.Fprintf(j.Out, "Warning: couldn't check %s: %v\n", t.URL, err)
+				unchecked++
+				continue
+			}
+			if public {
+				visible = append(visible, t)
+			}
+		}
+		return visible
+	}
+
+	visible := check(deleted)
+	if len(visible) > 0 {
+		fmt.Fprintf(j.Out, "%d still visible; checking them again in %v in case a cache still serves them\n", len(visible), publicRecheck)
+		if pacer.Sleep(ctx, publicRecheck) == nil {
+			visible = check(visible)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for _, t := range visible {
+		fmt.Fprintf(j.Out, "Still public: %s %s\n", t.Kind, t.URL)
+		j.Report.StillPublic = append(j.Report.StillPublic, t.URL)
+	}
+	if len(visible) > 0 {
+		return fmt.Errorf("%d of %d checked items are still publicly visible", len(visible), len(deleted))
+	}
+	if checked := len(deleted) - unchecked; checked > 0 {
+		fmt.Fprintf(j.Out, "None of the %d checked items can be seen publicly\n", checked)
+	}
+	return nil
+}
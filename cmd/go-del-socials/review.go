@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/index"
+	"go-del-socials/pkg/plan"
+	"go-del-socials/pkg/state"
+	"go-del-socials/pkg/twitter"
+)
+
+// unplannable lists the content types some platform can't plan, so a run of
+// them can't be counted before it starts
+var unplannable = []string{"profile", "chat", "drafts", "scheduled"}
+
+// placeNames says what the places items are posted in are called
+var placeNames = map[string]string{"reddit": "subreddits"}
+
+// reviewRun counts what a run would delete without deleting anything, shows
+// it and asks for an explicit phrase. It returns the counted plan, which the
+// run is then held to, or nil when the content type can't be counted. ok is
+// false when the user didn't confirm.
+func reviewRun(opts *options, profiles []namedProfile, config *Config, provider Provider, contentType string, cutoffDate time.Time, parallel bool) (reviewed *plan.Plan, ok bool, err error) {
+	title := provider.Capabilities().Title
+	before := cutoffDate.Format("2006-01-02")
+
+	if slices.Contains(unplannable, contentType) {
+		fmt.Printf("\nReview:\n- %s: delete %s before %s in %d profiles (can't be counted in advance)\n", title, contentType, before, len(profiles))
+		return nil, confirmPhrase("delete " + contentType), nil
+	}
+
+	fmt.Println("\nCounting what would be deleted...")
+	count := *opts
+	count.Plan = plan.New(provider.Name(), contentType, cutoffDate)
+	count.ExportKept, count.Progress, count.Uploader, count.Notify = "", nil, nil, false
+	count.MQTT, count.Triggers, count.Health = nil, nil, nil
+
+	out := stdout
+	stdout = io.Discard
+	results := runProfiles(&count, profiles, config, provider, contentType, cutoffDate, parallel)
+	stdout = out
+	for _, r := range results {
+		if r.Err != nil {
+			return nil, false, fmt.Errorf("failed to count profile %s: %v", r.Profile, r.Err)
+		}
+	}
+
+	total := count.Plan.Len()
+	if total == 0 {
+		fmt.Printf("\nNothing on %s matches, so there is nothing to delete.\n", title)
+		return nil, false, nil
+	}
+
+	fmt.Println("\nReview:")
+	for _, p := range profiles {
+		label := title
+		if len(profiles) > 1 {
+			label += " (" + p.Name + ")"
+		}
+		set := count.Plan.Profile(p.Name)
+		fmt.Printf("- %s: %s\n", label, describeSet(set, provider.Name(), before))
+		note, err := budgetNote(opts, config, provider, p, len(set.Items))
+		if err != nil {
+			return nil, false, err
+		}
+		if note != "" {
+			fmt.Printf("  %s\n", note)
+		}
+	}
+	if cutoffDate.After(time.Now()) {
+		fmt.Println("Note: the cutoff date is in the future, so everything posted until now matches.")
+	}
+	if !confirmPhrase(fmt.Sprintf("delete %d items", total)) {
+		return nil, false, nil
+	}
+
+	// Large deletions need each account named, so a mistyped year can't
+	// erase years of history on autopilot
+	limits := config.largeDeletion()
+	for _, p := range profiles {
+		n := len(count.Plan.Profile(p.Name).Items)
+		live, err := liveItems(config, p.Name, provider.Name())
+		if err != nil {
+			return nil, false, err
+		}
+		var why string
+		switch {
+		case limits.Items > 0 && n > limits.Items:
+			why = fmt.Sprintf("more than %s", thousands(limits.Items))
+		case limits.Percent > 0 && live > 0 && n*100 > live*limits.Percent:
+			why = fmt.Sprintf("%d%% of the %s known", n*100/live, thousands(live))
+		default:
+			continue
+		}
+		account := accountName(provider, p)
+		fmt.Printf("\nThis deletes %s items from %s on %s, %s.", thousands(n), account, title, why)
+		if !confirmPhrase(account) {
+			return nil, false, nil
+		}
+	}
+	return count.Plan, true, nil
+}
+
+// budgetNote says how many days a profile's items take under its daily
+// write budget, or nothing when it has none or they fit in today's
+func budgetNote(opts *options, config *Config, provider Provider, p namedProfile, n int) (string, error) {
+	if provider.Name() != "twitter" || n == 0 {
+		return "", nil
+	}
+	var c TwitterConfig
+	if decodeSection(p.Sections["twitter"], &c) != nil {
+		return "", nil
+	}
+	daily := c.DailyWriteBudget
+	if daily == 0 && opts.BudgetWait {
+		daily = defaultWriteBudget
+	}
+	if daily == 0 {
+		return "", nil
+	}
+
+	st, err := state.Open(config.stateDir(), p.Name)
+	if err != nil {
+		return "", err
+	}
+	defer st.Close()
+	s, err := config.openStore(st.Root)
+	if err != nil {
+		return "", err
+	}
+	defer s.Close()
+	budget, err := twitter.LoadBudget(s, daily)
+	if err != nil {
+		return "", err
+	}
+
+	days := budget.Days(n)
+	if days <= 1 && budget.Remaining() > 0 {
+		return "", nil
+	}
+	note := fmt.Sprintf("At %d writes a day (%d left today) this takes %d days", daily, budget.Remaining(), days)
+	if opts.BudgetWait {
+		return note + "; the run waits for each day's writes and keeps going until done.", nil
+	}
+	return note + "; the run stops when today's writes are used up, and the next run goes on from there. Add --budget-wait to keep one run going.", nil
+}
+
+// LargeDeletion holds the limits above which a run must be confirmed by
+// typing the account name. 0 turns a limit off.
+type LargeDeletion struct {
+	Items   int `json:"items"`
+	Percent int `json:"percent"`
+}
+
+func (c *Config) largeDeletion() LargeDeletion {
+	if c.LargeDeletion == nil {
+		return LargeDeletion{Items: 500, Percent: 50}
+	}
+	return *c.LargeDeletion
+}
+
+// liveItems counts a profile's items on a platform that the local index
+// knows and that haven't been deleted, or 0 if it knows none
+func liveItems(config *Config, profile, platform string) (int, error) {
+	st, err := state.Open(config.stateDir(), profile)
+	if err != nil {
+		return 0, err
+	}
+	defer st.Close()
+	s, err := config.openStore(st.Root)
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close()
+
+	created, err := index.New(s).Created(platform)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, times := range created {
+		n += len(times)
+	}
+	return n, nil
+}
+
+// accountName is what the user types to confirm deleting from a profile's
+// account: the username where the platform has one, else the profile name
+func accountName(provider Provider, p namedProfile) string {
+	// Secrets aren't needed, so the sections are read without resolving them
+	switch provider.Name() {
+	case "reddit":
+		var c RedditConfig
+		if decodeSection(p.Sections["reddit"], &c) == nil && c.Username != "" {
+			return "u/" + c.Username
+		}
+	case "twitter":
+		var c TwitterConfig
+		if decodeSection(p.Sections["twitter"], &c) == nil && c.Username != "" {
+			return "@" + strings.TrimPrefix(c.Username, "@")
+		}
+	}
+	return p.Name
+}
+
+// describeSet sums up a profile's planned items, e.g. "delete 1,243
+// comments and 87 posts before 2020-01-01 in 14 subreddits"
+func describeSet(s *plan.Set, platform, before string) string {
+	if len(s.Items) == 0 {
+		return "nothing to delete"
+	}
+
+	n := map[string]int{}
+	var kinds []string
+	places := map[string]bool{}
+	for _, it := range s.Items {
+		if n[it.Kind] == 0 {
+			kinds = append(kinds, it.Kind)
+		}
+		n[it.Kind]++
+		if it.Where != "" {
+			places[it.Where] = true
+		}
+	}
+	slices.SortStableFunc(kinds, func(a, b string) int { return n[b] - n[a] })
+
+	parts := make([]string, len(kinds))
+	for i, k := range kinds {
+		parts[i] = thousands(n[k]) + " " + plural(k, n[k])
+	}
+	text := "delete " + parts[0]
+	if len(parts) > 1 {
+		text = "delete " + strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+	}
+	text += " before " + before
+	if len(places) > 0 {
+		name := placeNames[platform]
+		if name == "" {
+			name = "places"
+		}
+		text += fmt.Sprintf(" in %s %s", thousands(len(places)), name)
+	}
+	return text
+}
+
+// plural names n items of a kind; kinds of generic providers are often
+// plural already
+func plural(kind string, n int) string {
+	switch {
+	case n == 1 || strings.HasSuffix(kind, "s"):
+		return kind
+	case strings.HasSuffix(kind, "y"):
+		return kind[:len(kind)-1] + "ies"
+	default:
+		return kind + "s"
+	}
+}
+
+// thousands formats n with thousands separators
+func thousands(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// confirmPhrase asks the user to type phrase and reports whether they did
+func confirmPhrase(phrase string) bool {
+	fmt.Printf("\nNothing has been deleted yet. Type %q to go ahead, or anything else to stop: ", phrase)
+	input, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(input) == phrase
+}
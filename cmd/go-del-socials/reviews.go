@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"go-del-socials/pkg/generic"
+	"go-del-socials/pkg/secrets"
+)
+
+// validateLetterboxd is the letterboxd provider's check of a profile's
+// section. The API's settings are optional, but go together.
+func validateLetterboxd(section json.RawMessage) error {
+	var c generic.LetterboxdConfig
+	if err := decodeSection(section, &c); err != nil {
+		return err
+	}
+	if c.APIKey == "" && c.APISecret == "" && c.Username == "" && c.Password == "" {
+		return nil
+	}
+	return checkSettings(
+		setting{"api_key", c.APIKey, "set the key of your approved API app, or leave out the API settings to delete on the website"},
+		setting{"api_secret", c.APISecret, "set the secret of your API app, or a secret reference such as op://vault/letterboxd/secret"},
+		setting{"username", c.Username, "set your Letterboxd username"},
+		setting{"password", c.Password, "set your password or a secret reference such as op://vault/letterboxd/password"},
+	)
+}
+
+// letterboxdClient returns a client for the profile's letterboxd section
+func letterboxdClient(p *Profile, out io.Writer) (*generic.Client, error) {
+	section, ok := p.Sections["letterboxd"]
+	if !ok {
+		return nil, errNotConfigured
+	}
+	var c generic.LetterboxdConfig
+	if err := decodeSection(section, &c); err != nil {
+		return nil, err
+	}
+	if err := secrets.ResolveAll(&c.APISecret, &c.Password); err != nil {
+		return nil, fmt.Errorf("error resolving secret: %v", err)
+	}
+	return generic.NewLetterboxdClient(&c, out), nil
+}
+
+// checkLetterboxd signs in to the API, when it is configured
+func checkLetterboxd(ctx context.Context, p *Profile) error {
+	client, err := letterboxdClient(p, io.Discard)
+	if err != nil {
+		return err
+	}
+	return client.Check(ctx)
+}
+
+// probeLetterboxd times signing in
+func probeLetterboxd(ctx context.Context, p *Profile) (func(ctx context.Context) error, error) {
+	client, err := letterboxdClient(p, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+	return client.Check, nil
+}
+
+func runLetterboxdDeletion(ctx context.Context, j *job) ([]count, error) {
+	client, err := letterboxdClient(j.Profile, j.Out)
+	if err != nil {
+		return nil, err
+	}
+	return deleteGeneric(ctx, j, client, "Letterboxd", generic.LetterboxdTypes())
+}
+
+// validateGoodreads is the goodreads provider's check of a profile's
+// section, which has nothing to set: it only marks the profile as having
+// an imported export
+func validateGoodreads(section json.RawMessage) error {
+	var c struct{}
+	return decodeSection(section, &c)
+}
+
+// goodreadsClient returns a client for the profile's imported Goodreads
+// export
+func goodreadsClient(p *Profile, out io.Writer) (*generic.Client, error) {
+	if _, ok := p.Sections["goodreads"]; !ok {
+		return nil, errNotConfigured
+	}
+	return generic.NewGoodreadsClient(out), nil
+}
+
+// checkGoodreads has nothing to check beyond the section
+func checkGoodreads(ctx context.Context, p *Profile) error {
+	_, err := goodreadsClient(p, io.Discard)
+	return err
+}
+
+// probeGoodreads has no requests to time
+func probeGoodreads(ctx context.Context, p *Profile) (func(ctx context.Context) error, error) {
+	client, err := goodreadsClient(p, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+	return client.Check, nil
+}
+
+func runGoodreadsDeletion(ctx context.Context, j *job) ([]count, error) {
+	client, err := goodreadsClient(j.Profile, j.Out)
+	if err != nil {
+		return nil, err
+	}
+	return deleteGeneric(ctx, j, client, "Goodreads", generic.GoodreadsTypes())
+}
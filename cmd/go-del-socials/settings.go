@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// setting is a field of a platform section, checked before anything is
+// sent so a mistake is reported by name
+type setting struct {
+	name  string
+	value string
+
+	// fix says where the value comes from
+	fix string
+}
+
+// placeholderPattern matches whole values of the example config and the
+// README, such as YOUR_CLIENT_ID, which are easily left in place
+var placeholderPattern = regexp.MustCompile(`(?i)^(your[_-]?(reddit[_-]|twitter[_-])?(client|api|access|user|pass|app|secret|token|key|id)(([_-]?(id|key|secret|token|name|word))*)|<.+>|changeme|x{3,})$`)
+
+// checkSettings reports the first setting that is empty or still holds a
+// placeholder
+func checkSettings(settings ...setting) error {
+	for _, s := range settings {
+		switch {
+		case strings.TrimSpace(s.value) == "":
+			return fmt.Errorf("%s is empty; %s", s.name, s.fix)
+		case placeholderPattern.MatchString(s.value):
+			return fmt.Errorf("%s is still the placeholder %q; %s, or remove the section to leave the platform out", s.name, s.value, s.fix)
+		}
+	}
+	return nil
+}
+
+// checkURL checks an optional setting holding an http or https URL
+func checkURL(name, value string) error {
+	if value == "" {
+		return nil
+	}
+	if u, err := url.Parse(value); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("%s %q must be an http or https URL, such as https://gateway.example.com", name, value)
+	}
+	return nil
+}
+
+// redditUserAgent is the format Reddit's API rules ask for, e.g.
+// "script:go-del-socials:v1.0 (by /u/name)". Requests with generic user
+// agents are throttled or blocked.
+var redditUserAgent = regexp.MustCompile(`^[^:\s]+:[^:\s]+:[^:\s]+ \(by /?u/([A-Za-z0-9_-]{3,20})\)$`)
+
+// checkRedditUserAgent checks a user agent against Reddit's rules
+func checkRedditUserAgent(agent, username string) error {
+	if username == "" || placeholderPattern.MatchString(username) {
+		username = "yourname"
+	}
+	if m := redditUserAgent.FindStringSubmatch(agent); m != nil {
+		if placeholderPattern.MatchString(m[1]) {
+			return fmt.Errorf("user_agent %q still holds a placeholder; put in your username, as in %q", agent, "script:go-del-socials:v1.0 (by /u/"+username+")")
+		}
+		return nil
+	}
+	return fmt.Errorf("user_agent %q doesn't follow Reddit's API rules, which throttle or block other formats; set it to something like %q",
+		agent, "script:go-del-socials:v1.0 (by /u/"+username+")")
+}
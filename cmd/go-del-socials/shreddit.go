@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"go-del-socials/pkg/reddit"
+
+	"gopkg.in/yaml.v3"
+)
+
+// shredditConfig is the YAML configuration of shreddit, the Python tool,
+// so people moving over can run with theirs as it is. Its credentials stay
+// in praw.ini; go-del-socials takes them from config.json.
+type shredditConfig struct {
+	Hours     *int `yaml:"hours"`
+	NukeHours int  `yaml:"nuke_hours"`
+	MaxScore  *int `yaml:"max_score"`
+
+	// Item is "comments", "submitted" or "overview" for both
+	Item string `yaml:"item"`
+
+	Whitelist              []string `yaml:"whitelist"`
+	WhitelistIDs           []string `yaml:"whitelist_ids"`
+	WhitelistDistinguished bool     `yaml:"whitelist_distinguished"`
+	WhitelistGilded        bool     `yaml:"whitelist_gilded"`
+
+	// ReplacementFormat is "random", "dot" or the text itself
+	ReplacementFormat string `yaml:"replacement_format"`
+
+	TrialRun  bool `yaml:"trial_run"`
+	KeepACopy bool `yaml:"keep_a_copy"`
+	ClearVote bool `yaml:"clear_vote"`
+	EditOnly  bool `yaml:"edit_only"`
+
+	MultiWhitelist []string `yaml:"multi_whitelist"`
+	MultiBlacklist []string `yaml:"multi_blacklist"`
+}
+
+// readShreddit reads a shreddit config. Settings that would change what
+// gets deleted but have no counterpart here are refused rather than
+// ignored.
+func readShreddit(path string) (*shredditConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shreddit config: %v", err)
+	}
+	var s shredditConfig
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		return nil, fmt.Errorf("failed to parse shreddit config: %v", err)
+	}
+
+	switch {
+	case s.EditOnly:
+		return nil, fmt.Errorf("edit_only isn't supported: items are always deleted after they are overwritten")
+	case len(s.MultiWhitelist) > 0 || len(s.MultiBlacklist) > 0:
+		return nil, fmt.Errorf("multi_whitelist and multi_blacklist aren't supported; list their subreddits in whitelist, or use --multireddit to only delete in one")
+	case s.Hours != nil && *s.Hours < 0, s.NukeHours < 0:
+		return nil, fmt.Errorf("hours and nuke_hours can't be negative")
+	}
+	return &s, nil
+}
+
+// template turns the config into a template of a Reddit run
+func (s *shredditConfig) template() (*Template, error) {
+	t := &Template{Platform: "reddit", OlderThan: "24h"}
+	if s.Hours != nil {
+		t.OlderThan = fmt.Sprintf("%dh", *s.Hours)
+	}
+
+	switch s.Item {
+	case "", "overview":
+		t.ContentType = "all"
+	case "comments":
+		t.ContentType = "comments"
+	case "submitted":
+		t.ContentType = "posts"
+	default:
+		return nil, fmt.Errorf("unknown item %q; use comments, submitted or overview", s.Item)
+	}
+
+	var opts struct {
+		RedditKeep *reddit.KeepRules          `json:",omitempty"`
+		Overwrite  *reddit.OverwriteTemplates `json:",omitempty"`
+	}
+	opts.RedditKeep = &reddit.KeepRules{
+		Subreddits:    s.Whitelist,
+		IDs:           s.WhitelistIDs,
+		MaxScore:      s.MaxScore,
+		Distinguished: s.WhitelistDistinguished,
+		Gilded:        s.WhitelistGilded,
+		NukeHours:     s.NukeHours,
+	}
+
+	// shreddit always overwrites before deleting, with random text unless
+	// told otherwise
+	text := s.ReplacementFormat
+	switch text {
+	case "", "random":
+		text = "{random}"
+	case "dot":
+		text = "."
+	}
+	opts.Overwrite = &reddit.OverwriteTemplates{Posts: text, Comments: text}
+
+	raw, err := json.Marshal(&opts)
+	if err != nil {
+		return nil, err
+	}
+	t.Options = raw
+	return t, nil
+}
+
+// notes lists the settings that are taken differently here
+func (s *shredditConfig) notes() []string {
+	var notes []string
+	if s.KeepACopy {
+		notes = append(notes, "keep_a_copy: deleted items are kept in the tombstone index instead of save_directory; find them with lookup")
+	}
+	if s.ClearVote {
+		notes = append(notes, "clear_vote: votes are left as they are")
+	}
+	return notes
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"go-del-socials/pkg/generic"
+	"go-del-socials/pkg/secrets"
+)
+
+// validateSlack is the slack provider's check of a profile's section
+func validateSlack(section json.RawMessage) error {
+	var c generic.SlackConfig
+	if err := decodeSection(section, &c); err != nil {
+		return err
+	}
+	names := map[string]bool{}
+	for i, w := range c.Workspaces {
+		err := checkSettings(
+			setting{"name", w.Name, fmt.Sprintf("name workspace %d, e.g. after its slack.com subdomain", i+1)},
+			setting{"token", w.Token, "create a Slack app with a user token (xoxp-...) and the search:read, chat:write, files:read and files:write scopes"},
+		)
+		if err != nil {
+			return fmt.Errorf("workspace %d: %v", i+1, err)
+		}
+		if strings.Contains(w.Name, "/") || names[w.Name] {
+			return fmt.Errorf("workspace name %q must be unique and without slashes", w.Name)
+		}
+		names[w.Name] = true
+	}
+	return nil
+}
+
+// slackClient returns a client for the profile's slack section
+func slackClient(p *Profile, out io.Writer) (*generic.Client, error) {
+	var c generic.SlackConfig
+	if err := decodeSection(p.Sections["slack"], &c); err != nil {
+		return nil, err
+	}
+	if len(c.Workspaces) == 0 {
+		return nil, errNotConfigured
+	}
+	for i := range c.Workspaces {
+		if err := secrets.ResolveAll(&c.Workspaces[i].Token); err != nil {
+			return nil, fmt.Errorf("error resolving secret: %v", err)
+		}
+	}
+	return generic.NewSlackClient(&c, out), nil
+}
+
+// checkSlack verifies every workspace's token
+func checkSlack(ctx context.Context, p *Profile) error {
+	client, err := slackClient(p, io.Discard)
+	if err != nil {
+		return err
+	}
+	return client.Check(ctx)
+}
+
+// probeSlack times checking the workspaces' tokens
+func probeSlack(ctx context.Context, p *Profile) (func(ctx context.Context) error, error) {
+	client, err := slackClient(p, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+	return client.Check, nil
+}
+
+func runSlackDeletion(ctx context.Context, j *job) ([]count, error) {
+	client, err := slackClient(j.Profile, j.Out)
+	if err != nil {
+		return nil, err
+	}
+	return deleteGeneric(ctx, j, client, "Slack", generic.SlackTypes())
+}
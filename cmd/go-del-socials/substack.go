@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"go-del-socials/pkg/generic"
+	"go-del-socials/pkg/secrets"
+)
+
+// validateSubstack is the substack provider's check of a profile's section
+func validateSubstack(section json.RawMessage) error {
+	var c generic.SubstackConfig
+	if err := decodeSection(section, &c); err != nil {
+		return err
+	}
+	if c.Cookie == "" {
+		return nil
+	}
+	return checkSettings(setting{"cookie", c.Cookie, "set the substack.sid cookie of a signed-in browser, or a secret reference such as op://vault/substack/cookie"})
+}
+
+// substackClient returns a client for the profile's substack section
+func substackClient(p *Profile, out io.Writer) (*generic.Client, error) {
+	var c generic.SubstackConfig
+	if err := decodeSection(p.Sections["substack"], &c); err != nil {
+		return nil, err
+	}
+	if c.Cookie == "" {
+		return nil, errNotConfigured
+	}
+	if err := secrets.ResolveAll(&c.Cookie); err != nil {
+		return nil, fmt.Errorf("error resolving secret: %v", err)
+	}
+	return generic.NewSubstackClient(&c, out), nil
+}
+
+// checkSubstack verifies the cookie signs in
+func checkSubstack(ctx context.Context, p *Profile) error {
+	client, err := substackClient(p, io.Discard)
+	if err != nil {
+		return err
+	}
+	return client.Check(ctx)
+}
+
+// probeSubstack times signing in
+func probeSubstack(ctx context.Context, p *Profile) (func(ctx context.Context) error, error) {
+	client, err := substackClient(p, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+	return client.Check, nil
+}
+
+func runSubstackDeletion(ctx context.Context, j *job) ([]count, error) {
+	client, err := substackClient(j.Profile, j.Out)
+	if err != nil {
+		return nil, err
+	}
+	return deleteGeneric(ctx, j, client, "Substack", generic.SubstackTypes())
+}
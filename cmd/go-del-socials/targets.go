@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"go-del-socials/pkg/generic"
+	"go-del-socials/pkg/reddit"
+	"go-del-socials/pkg/targets"
+	"go-del-socials/pkg/twitter"
+)
+
+// redditTargets reads --targets as Reddit fullnames
+func redditTargets(j *job) ([]string, error) {
+	return readTargets(j, func(t targets.Target) (string, error) {
+		return reddit.Fullname(t.Ref, t.Kind)
+	})
+}
+
+// twitterTargets reads --targets as tweet IDs
+func twitterTargets(j *job) ([]string, error) {
+	return readTargets(j, func(t targets.Target) (string, error) {
+		return twitter.TweetID(t.Ref)
+	})
+}
+
+// genericTargets reads --targets as items of a generic provider. An item's
+// ID is the last part of its URL, and its kind the list's or else the run's
+// content type.
+func genericTargets(j *job) ([]generic.Item, error) {
+	byID := map[string]generic.Item{}
+	ids, err := readTargets(j, func(t targets.Target) (string, error) {
+		it := generic.Item{ID: t.Ref, Kind: t.Kind, URL: t.Ref}
+		if u, err := url.Parse(t.Ref); err == nil && u.Host != "" {
+			it.ID = path.Base(strings.TrimSuffix(u.Path, "/"))
+		} else {
+			it.URL = ""
+		}
+		if it.Kind == "" && j.ContentType != "all" {
+			it.Kind = j.ContentType
+		}
+		if it.Kind == "" {
+			return "", fmt.Errorf("%s has no kind; give the list a kind column or choose a content type", t.Ref)
+		}
+		byID[it.ID] = it
+		return it.ID, nil
+	})
+	items := make([]generic.Item, len(ids))
+	for i, id := range ids {
+		items[i] = byID[id]
+	}
+	return items, err
+}
+
+// readTargets reads the --targets list, if any, resolving each entry with
+// resolve. Duplicates are dropped.
+func readTargets(j *job, resolve func(targets.Target) (string, error)) ([]string, error) {
+	if j.Options.Targets == "" {
+		return nil, nil
+	}
+	list, err := targets.Read(j.Options.Targets)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	seen := map[string]bool{}
+	for _, t := range list {
+		id, err := resolve(t)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", j.Options.Targets, err)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	fmt.Fprintf(j.Out, "Processing the %d items listed in %s instead of listing your content\n", len(ids), j.Options.Targets)
+	return ids, nil
+}
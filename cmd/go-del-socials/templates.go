@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"go-del-socials/pkg/filter"
+)
+
+// Template is a named run: the platform, what to delete and the options to
+// delete it with, so they needn't be typed again every time
+type Template struct {
+	Platform    string `json:"platform"`
+	ContentType string `json:"content_type"`
+
+	// Cutoff is a date (YYYY, YYYY-MM or YYYY-MM-DD); OlderThan is an age
+	// such as 180d, counted back from the start of the run
+	Cutoff    string `json:"cutoff"`
+	OlderThan string `json:"older_than"`
+
+	// Options override the command-line flags, named as in a plan's options
+	Options json.RawMessage `json:"options"`
+}
+
+// template looks up a template by name
+func (c *Config) template(name string) (*Template, error) {
+	t, ok := c.Templates[name]
+	if !ok {
+		return nil, fmt.Errorf("template %s not found in config", name)
+	}
+	return t, nil
+}
+
+// apply resolves the template against the providers, setting its options
+// on opts. It returns the provider, content type and cutoff to run with.
+func (t *Template) apply(opts *options, now time.Time) (Provider, string, time.Time, error) {
+	provider := selectProvider(opts.Providers, t.Platform)
+	if provider == nil {
+		return nil, "", time.Time{}, fmt.Errorf("unknown platform %q", t.Platform)
+	}
+
+	contentType := t.ContentType
+	if contentType == "" {
+		contentType = "all"
+	}
+	if !slices.Contains(provider.Capabilities().ContentTypes, contentType) {
+		return nil, "", time.Time{}, fmt.Errorf("%s doesn't support the content type %s", t.Platform, contentType)
+	}
+
+	if err := applyOptions(opts, t.Options); err != nil {
+		return nil, "", time.Time{}, err
+	}
+	cutoff, err := resolveCutoff(t.Cutoff, t.OlderThan, now, len(opts.KeepLast) > 0)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	if _, err := parseRetention(opts.Retention, now); err != nil {
+		return nil, "", time.Time{}, fmt.Errorf("invalid retention: %v", err)
+	}
+	return provider, contentType, cutoff, nil
+}
+
+// resolveCutoff turns a cutoff date or an age into the cutoff time. Runs
+// that keep the last items by count may leave both out to delete the rest
+// whatever its age.
+func resolveCutoff(cutoff, olderThan string, now time.Time, keepLast bool) (time.Time, error) {
+	switch {
+	case cutoff != "" && olderThan != "":
+		return time.Time{}, fmt.Errorf("set either cutoff or older_than, not both")
+	case olderThan != "":
+		age, err := parseAge(olderThan)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid older_than: %v", err)
+		}
+		return now.Add(-age), nil
+	case cutoff != "":
+		t, err := parseDate(cutoff)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid cutoff: %v", err)
+		}
+		return t, nil
+	case keepLast:
+		return now, nil
+	default:
+		return time.Time{}, fmt.Errorf("cutoff or older_than is required")
+	}
+}
+
+// parseRetention reads retention rules, whose cutoffs are ages before now
+// or dates
+func parseRetention(values []string, now time.Time) (filter.Retention, error) {
+	return filter.ParseRetention(values, func(when string) (time.Time, error) {
+		if age, err := parseAge(when); err == nil {
+			return now.Add(-age), nil
+		}
+		t, err := parseDate(when)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("give keep, an age such as 90d or a date")
+		}
+		return t, nil
+	})
+}
+
+// applyOptions sets the options named in data, a JSON object, on opts
+func applyOptions(opts *options, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(opts); err != nil {
+		return fmt.Errorf("invalid options: %v", err)
+	}
+	return nil
+}
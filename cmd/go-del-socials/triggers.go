@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"go-del-socials/pkg/report"
+	"go-del-socials/pkg/trigger"
+)
+
+// triggerTimeout bounds firing one trigger, so a slow service can't hold
+// up the next profile
+const triggerTimeout = 30 * time.Second
+
+// newTriggers sets up the triggers of the configuration
+func newTriggers(cfgs []trigger.Config) ([]*trigger.Trigger, error) {
+	var ts []*trigger.Trigger
+	for i, cfg := range cfgs {
+		t, err := trigger.New(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("trigger %d: %v", i+1, err)
+		}
+		ts = append(ts, t)
+	}
+	return ts, nil
+}
+
+// fireTriggers tells the configured automation services how a profile's
+// run ended. A failing service is warned about without failing the run.
+func fireTriggers(ts []*trigger.Trigger, out io.Writer, rep *report.Report) {
+	if len(ts) == 0 {
+		return
+	}
+	e := trigger.Event{
+		Kind:      trigger.RunFinished,
+		Profile:   rep.Profile,
+		Platform:  rep.Platform,
+		Deleted:   rep.Deleted,
+		Failed:    rep.Failed,
+		Counts:    rep.Counts,
+		Error:     rep.Error,
+		ResumeAt:  rep.ResumeAt,
+		Simulated: rep.Simulated,
+	}
+	switch {
+	case rep.ResumeAt != nil:
+		e.Kind = trigger.BudgetExhausted
+	case rep.Error != "":
+		e.Kind = trigger.RunFailed
+	}
+
+	for _, t := range ts {
+		ctx, cancel := context.WithTimeout(context.Background(), triggerTimeout)
+		if err := t.Fire(ctx, e); err != nil {
+			fmt.Fprintf(out, "Warning: %v\n", err)
+		}
+		cancel()
+	}
+}
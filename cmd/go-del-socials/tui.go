@@ -0,0 +1,561 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/index"
+	"go-del-socials/pkg/state"
+	"go-del-socials/pkg/store"
+	"go-del-socials/pkg/tui"
+)
+
+// tuiLogSize bounds the run output kept for the run view
+const tuiLogSize = 2000
+
+// tuiLog collects run output for the run view
+type tuiLog struct {
+	mu    sync.Mutex
+	lines []string
+	buf   []byte
+}
+
+func (l *tuiLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.buf = append(l.buf, p...)
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			break
+		}
+		l.lines = append(l.lines, strings.TrimRight(string(l.buf[:i]), "\r"))
+		l.buf = l.buf[i+1:]
+	}
+	if len(l.lines) > tuiLogSize {
+		l.lines = l.lines[len(l.lines)-tuiLogSize:]
+	}
+	return len(p), nil
+}
+
+// tail returns the last n lines
+func (l *tuiLog) tail(n int) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.lines) > n {
+		return slices.Clone(l.lines[len(l.lines)-n:])
+	}
+	return slices.Clone(l.lines)
+}
+
+// The views of the interface
+const (
+	viewHome = iota
+	viewFilters
+	viewRun
+	viewAudit
+)
+
+// tuiApp is the state of the tui subcommand
+type tuiApp struct {
+	screen   *tui.Screen
+	config   *Config
+	opts     *options
+	profiles []namedProfile
+
+	view   int
+	status string
+
+	// Home: the focused list and the cursor in each
+	focus    int
+	profile  int
+	platform int
+
+	// Filters: the focused field, the chosen content type and cutoff, and
+	// the creation times of the indexed items by listing
+	field       int
+	contentType int
+	cutoff      time.Time
+	created     map[string][]time.Time
+	confirm     bool
+
+	// Run
+	log     *tuiLog
+	running bool
+	done    chan []*runResult
+	results []*runResult
+
+	// Audit: receipts newest first, the chain's state and the cursor
+	receipts []audit.Receipt
+	chain    string
+	cursor   int
+}
+
+// runTUI runs the full-screen interface until the user quits
+func runTUI(config *Config, opts *options) error {
+	// Without any settings there is still the default profile, e.g. for
+	// the fake platform
+	profiles, err := config.selectProfiles("", true)
+	if err != nil {
+		if profiles, err = config.selectProfiles("", false); err != nil {
+			return err
+		}
+	}
+
+	screen, err := tui.Open()
+	if err != nil {
+		return err
+	}
+	defer screen.Close()
+
+	// Runs send their output to the run view while the interface is up
+	out, logOut := stdout, log.Writer()
+	defer func() {
+		stdout = out
+		log.SetOutput(logOut)
+	}()
+
+	a := &tuiApp{
+		screen:   screen,
+		config:   config,
+		opts:     opts,
+		profiles: profiles,
+		cutoff:   defaultCutoff,
+		done:     make(chan []*runResult, 1),
+	}
+
+	tick := time.NewTicker(250 * time.Millisecond)
+	defer tick.Stop()
+
+	for {
+		if err := screen.Draw(a.render()); err != nil {
+			return err
+		}
+		select {
+		case k, ok := <-screen.Keys():
+			if !ok || a.key(k) {
+				return nil
+			}
+		case a.results = <-a.done:
+			a.running = false
+			a.loadIndex()
+		case <-tick.C:
+			// Redraw, for run output and terminal resizes
+		}
+	}
+}
+
+func (a *tuiApp) provider() Provider {
+	return a.opts.Providers[a.platform]
+}
+
+func (a *tuiApp) selected() namedProfile {
+	return a.profiles[a.profile]
+}
+
+// key handles a key press and reports whether to quit
+func (a *tuiApp) key(k string) bool {
+	if k == tui.CtrlC {
+		return true
+	}
+	a.status = ""
+
+	switch a.view {
+	case viewHome:
+		return a.homeKey(k)
+	case viewFilters:
+		a.filtersKey(k)
+	case viewRun:
+		if a.running && k == "p" {
+			togglePause(a.opts.Pause, "press p again")
+		} else if !a.running && (k == tui.Enter || k == tui.Escape) {
+			a.view = viewFilters
+		} else if !a.running && k == "a" {
+			a.openAudit()
+		}
+	case viewAudit:
+		a.auditKey(k)
+	}
+	return false
+}
+
+func (a *tuiApp) homeKey(k string) bool {
+	cursor, n := &a.profile, len(a.profiles)
+	if a.focus == 1 {
+		cursor, n = &a.platform, len(a.opts.Providers)
+	}
+
+	switch k {
+	case "q", tui.Escape:
+		return true
+	case tui.Up, "k":
+		*cursor = max(*cursor-1, 0)
+	case tui.Down, "j":
+		*cursor = min(*cursor+1, n-1)
+	case tui.Tab, tui.Left, tui.Right:
+		a.focus = 1 - a.focus
+	case "a":
+		a.openAudit()
+	case tui.Enter:
+		if a.focus == 0 {
+			a.focus = 1
+			return false
+		}
+		if err := checkFlags(a.provider(), a.opts.Providers); err != nil {
+			a.status = err.Error()
+			return false
+		}
+		types := a.provider().Capabilities().ContentTypes
+		a.contentType = max(slices.Index(types, "all"), 0)
+		a.field, a.confirm = 0, false
+		a.loadIndex()
+		a.view = viewFilters
+	}
+	return false
+}
+
+func (a *tuiApp) filtersKey(k string) {
+	types := a.provider().Capabilities().ContentTypes
+
+	if a.confirm {
+		a.confirm = false
+		if k == "y" {
+			a.start()
+		}
+		return
+	}
+
+	step := 0
+	switch k {
+	case tui.Escape, "q":
+		a.view = viewHome
+	case tui.Up, "k", tui.Down, "j", tui.Tab:
+		a.field = 1 - a.field
+	case tui.Left, "h", "-":
+		step = -1
+	case tui.Right, "l", "+":
+		step = 1
+	case tui.Enter:
+		a.confirm = true
+	case "a":
+		a.openAudit()
+	}
+	if step == 0 {
+		return
+	}
+	if a.field == 0 {
+		a.contentType = (a.contentType + step + len(types)) % len(types)
+		return
+	}
+	a.cutoff = a.cutoff.AddDate(0, step, 0)
+	if a.cutoff.After(time.Now()) {
+		a.cutoff = a.cutoff.AddDate(0, -step, 0)
+	}
+}
+
+func (a *tuiApp) auditKey(k string) {
+	_, h := a.screen.Size()
+	page := max(h-8, 1)
+	last := len(a.receipts) - 1
+
+	switch k {
+	case tui.Escape, "q", tui.Enter:
+		a.view = viewHome
+	case tui.Up, "k":
+		a.cursor = max(a.cursor-1, 0)
+	case tui.Down, "j":
+		a.cursor = max(min(a.cursor+1, last), 0)
+	case tui.PageUp:
+		a.cursor = max(a.cursor-page, 0)
+	case tui.PageDown:
+		a.cursor = max(min(a.cursor+page, last), 0)
+	}
+}
+
+// profileDir returns the state directory of a profile
+func (a *tuiApp) profileDir(profile string) (string, error) {
+	base := a.config.stateDir()
+	if base == "" {
+		var err error
+		if base, err = state.BaseDir(); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(base, profile), nil
+}
+
+// openStore opens a profile's state store without locking its directory,
+// or returns nil if the profile has never run
+func (a *tuiApp) openStore(profile string) (string, store.Store, error) {
+	dir, err := a.profileDir(profile)
+	if err != nil {
+		return "", nil, err
+	}
+	path, err := store.Path(a.config.StateBackend, dir)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return dir, nil, nil
+	}
+	s, err := store.Open(a.config.StateBackend, dir)
+	return dir, s, err
+}
+
+// loadIndex reads what the local item index knows of the chosen profile
+// and platform, for the match counts
+func (a *tuiApp) loadIndex() {
+	a.created = nil
+	_, s, err := a.openStore(a.selected().Name)
+	if err != nil {
+		a.status = err.Error()
+		return
+	}
+	if s == nil {
+		return
+	}
+	defer s.Close()
+
+	if a.created, err = index.New(s).Created(a.provider().Name()); err != nil {
+		a.status = err.Error()
+	}
+}
+
+// openAudit loads the chosen profile's audit log, newest receipt first
+func (a *tuiApp) openAudit() {
+	dir, s, err := a.openStore(a.selected().Name)
+	if err != nil {
+		a.status = err.Error()
+		return
+	}
+	a.receipts, a.chain, a.cursor = nil, "no audit log yet", 0
+	a.view = viewAudit
+	if s == nil {
+		return
+	}
+	defer s.Close()
+
+	path := filepath.Join(dir, state.Audit, "receipts.jsonl")
+	if a.receipts, err = audit.Read(path); err != nil {
+		a.status = err.Error()
+	}
+	slices.Reverse(a.receipts)
+	if n, err := audit.Verify(path, s); err != nil {
+		a.chain = fmt.Sprintf("%v (the first %d entries are intact)", err, n)
+	} else if n > 0 {
+		a.chain = fmt.Sprintf("%d entries, chain intact", n)
+	}
+}
+
+// start runs the chosen deletion in the background, showing its output in
+// the run view
+func (a *tuiApp) start() {
+	types := a.provider().Capabilities().ContentTypes
+	a.log = &tuiLog{}
+	a.running, a.results = true, nil
+	a.view = viewRun
+
+	stdout = a.log
+	log.SetOutput(a.log)
+
+	p, provider, contentType, cutoff := a.selected(), a.provider(), types[a.contentType], a.cutoff
+	go func() {
+		results := runProfiles(a.opts, []namedProfile{p}, a.config, provider, contentType, cutoff, false)
+		printSummary(results)
+		a.done <- results
+	}()
+}
+
+// matches counts the indexed items older than the cutoff, by listing
+func (a *tuiApp) matches() []string {
+	if len(a.created) == 0 {
+		return []string{"No local index for this platform yet: counts appear after a run lists it"}
+	}
+	var lines []string
+	total := 0
+	for _, listing := range slices.Sorted(maps.Keys(a.created)) {
+		n := 0
+		for _, t := range a.created[listing] {
+			if t.Before(a.cutoff) {
+				n++
+			}
+		}
+		total += n
+		lines = append(lines, fmt.Sprintf("  %-12s %6d of %d", listing, n, len(a.created[listing])))
+	}
+	return append(lines, fmt.Sprintf("  %-12s %6d", "total", total))
+}
+
+func (a *tuiApp) render() []tui.Line {
+	title := "go-del-socials"
+	if a.opts.Simulate {
+		title += " (simulating)"
+	}
+	lines := []tui.Line{{Text: title, Style: tui.Bold}, {}}
+
+	switch a.view {
+	case viewHome:
+		lines = append(lines, a.renderHome()...)
+	case viewFilters:
+		lines = append(lines, a.renderFilters()...)
+	case viewRun:
+		lines = append(lines, a.renderRun()...)
+	case viewAudit:
+		lines = append(lines, a.renderAudit()...)
+	}
+
+	if a.status != "" {
+		lines = append(lines, tui.Line{}, tui.Line{Text: a.status, Style: tui.Bold})
+	}
+	return lines
+}
+
+// pad fills the screen up to its last line, where help goes
+func (a *tuiApp) pad(lines []tui.Line, help string) []tui.Line {
+	_, h := a.screen.Size()
+	for len(lines) < h-3 {
+		lines = append(lines, tui.Line{})
+	}
+	return append(lines, tui.Line{Text: help, Style: tui.Dim})
+}
+
+func (a *tuiApp) renderHome() []tui.Line {
+	var lines []tui.Line
+	header := func(text string, focused bool) {
+		if focused {
+			text = "> " + text
+		} else {
+			text = "  " + text
+		}
+		lines = append(lines, tui.Line{Text: text, Style: tui.Bold})
+	}
+	item := func(text string, selected, focused bool) {
+		style := tui.Plain
+		if selected && focused {
+			style = tui.Selected
+		} else if selected {
+			text = "* " + text
+		}
+		lines = append(lines, tui.Line{Text: "    " + text, Style: style})
+	}
+
+	header("Profile", a.focus == 0)
+	for i, p := range a.profiles {
+		item(p.Name, i == a.profile, a.focus == 0)
+	}
+	lines = append(lines, tui.Line{})
+	header("Platform", a.focus == 1)
+	for i, p := range a.opts.Providers {
+		item(describe(p), i == a.platform, a.focus == 1)
+	}
+
+	return a.pad(lines, "↑↓ move  tab switch list  enter choose  a audit log  q quit")
+}
+
+func (a *tuiApp) renderFilters() []tui.Line {
+	provider := a.provider()
+	types := provider.Capabilities().ContentTypes
+	lines := []tui.Line{
+		{Text: fmt.Sprintf("Profile %s, %s", a.selected().Name, provider.Capabilities().Title)},
+		{},
+	}
+
+	fields := []string{
+		fmt.Sprintf("Content type  ‹ %s ›", types[a.contentType]),
+		fmt.Sprintf("Delete before ‹ %s ›", a.cutoff.Format("2006-01-02")),
+	}
+	for i, f := range fields {
+		style := tui.Plain
+		if i == a.field {
+			style = tui.Selected
+		}
+		lines = append(lines, tui.Line{Text: "  " + f, Style: style})
+	}
+
+	lines = append(lines, tui.Line{}, tui.Line{Text: "Indexed items older than the cutoff", Style: tui.Bold})
+	for _, m := range a.matches() {
+		lines = append(lines, tui.Line{Text: m})
+	}
+
+	if provider.Name() == "twitter" {
+		lines = append(lines, tui.Line{}, tui.Line{Text: "Twitter/X restricts its API for free accounts, so deletion may not work reliably."})
+	}
+	if a.confirm {
+		verb := "Delete"
+		if a.opts.Simulate {
+			verb = "Simulate deleting"
+		}
+		lines = append(lines, tui.Line{}, tui.Line{
+			Text:  fmt.Sprintf("%s %s before %s for profile %s? Press y to start", verb, types[a.contentType], a.cutoff.Format("2006-01-02"), a.selected().Name),
+			Style: tui.Bold,
+		})
+	}
+
+	return a.pad(lines, "↑↓ field  ←→ change (the cutoff moves by a month)  enter run  a audit log  esc back")
+}
+
+func (a *tuiApp) renderRun() []tui.Line {
+	state := "Running"
+	if a.running && a.opts.Pause.Paused() {
+		state = "Paused"
+	} else if !a.running {
+		state = "Finished"
+		for _, r := range a.results {
+			if r.Err != nil {
+				state = "Failed"
+			}
+		}
+	}
+	lines := []tui.Line{{Text: fmt.Sprintf("%s: %s for profile %s", state, a.provider().Capabilities().Title, a.selected().Name), Style: tui.Bold}, {}}
+
+	_, h := a.screen.Size()
+	for _, l := range a.log.tail(max(h-6, 1)) {
+		lines = append(lines, tui.Line{Text: l})
+	}
+
+	help := "p pause/resume  running... the output is also in the run report"
+	if !a.running {
+		help = "enter back  a audit log"
+	}
+	return a.pad(lines, help)
+}
+
+func (a *tuiApp) renderAudit() []tui.Line {
+	lines := []tui.Line{
+		{Text: fmt.Sprintf("Audit log of profile %s: %s", a.selected().Name, a.chain), Style: tui.Bold},
+		{},
+	}
+
+	_, h := a.screen.Size()
+	rows := max(h-10, 1)
+	first := max(min(a.cursor-rows/2, len(a.receipts)-rows), 0)
+	for i := first; i < len(a.receipts) && i < first+rows; i++ {
+		r := a.receipts[i]
+		text := fmt.Sprintf("%s  %-8s %-10s %-20s %s %d", r.Time.Local().Format("2006-01-02 15:04"), r.Platform, r.Kind, r.ID, r.Method, r.Status)
+		if r.Simulated {
+			text += "  simulated"
+		}
+		style := tui.Plain
+		if i == a.cursor {
+			style = tui.Selected
+		}
+		lines = append(lines, tui.Line{Text: text, Style: style})
+	}
+
+	if a.cursor < len(a.receipts) {
+		r := a.receipts[a.cursor]
+		lines = append(lines, tui.Line{}, tui.Line{Text: r.URL, Style: tui.Dim}, tui.Line{Text: r.Body, Style: tui.Dim})
+	}
+
+	return a.pad(lines, "↑↓ pgup pgdown scroll  esc back")
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"go-del-socials/pkg/generic"
+	"go-del-socials/pkg/secrets"
+	"go-del-socials/pkg/state"
+)
+
+// validateTwitch is the twitch provider's check of a profile's section
+func validateTwitch(section json.RawMessage) error {
+	var c generic.TwitchConfig
+	if err := decodeSection(section, &c); err != nil {
+		return err
+	}
+	if c.ClientID == "" && c.AccessToken == "" {
+		return nil
+	}
+	return checkSettings(
+		setting{"client_id", c.ClientID, "set the client ID of your application from https://dev.twitch.tv/console/apps"},
+		setting{"access_token", c.AccessToken, "set a user access token of the application, or a secret reference such as op://vault/twitch/token"},
+	)
+}
+
+// twitchConfig reads the profile's twitch section, with its secrets
+// resolved
+func twitchConfig(p *Profile) (*generic.TwitchConfig, error) {
+	var c generic.TwitchConfig
+	if err := decodeSection(p.Sections["twitch"], &c); err != nil {
+		return nil, err
+	}
+	if c.ClientID == "" {
+		return nil, errNotConfigured
+	}
+	if err := secrets.ResolveAll(&c.AccessToken); err != nil {
+		return nil, fmt.Errorf("error resolving secret: %v", err)
+	}
+	return &c, nil
+}
+
+// checkTwitch verifies the token belongs to a user of the application
+func checkTwitch(ctx context.Context, p *Profile) error {
+	c, err := twitchConfig(p)
+	if err != nil {
+		return err
+	}
+	return generic.NewTwitchClient(c, io.Discard).Check(ctx)
+}
+
+// probeTwitch times validating the token
+func probeTwitch(ctx context.Context, p *Profile) (func(ctx context.Context) error, error) {
+	c, err := twitchConfig(p)
+	if err != nil {
+		return nil, err
+	}
+	return generic.NewTwitchClient(c, io.Discard).Check, nil
+}
+
+// runTwitchDeletion deletes the channel's videos and, as neither clips nor
+// chat can be deleted through the API, exports what Helix has on the chat
+func runTwitchDeletion(ctx context.Context, j *job) ([]count, error) {
+	c, err := twitchConfig(j.Profile)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := deleteGeneric(ctx, j, generic.NewTwitchClient(c, j.Out), "Twitch", generic.TwitchTypes())
+	if err != nil || j.Plan != nil {
+		return counts, err
+	}
+
+	chat, err := generic.ExportTwitchChat(ctx, c)
+	if err == nil {
+		var path string
+		path, err = writeTwitchChat(j.State.Path(state.Archives, "twitch"), chat)
+		if err == nil {
+			fmt.Fprintf(j.Out, "\nChat settings, emotes, blocked terms, bans, moderators and VIPs can't be deleted through the API; exported them to %s\n", path)
+		}
+		for _, m := range chat.Missing {
+			fmt.Fprintf(j.Out, "Left %s out of the chat export\n", m)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(j.Out, "Warning: failed to export the chat: %v\n", err)
+	}
+	return counts, nil
+}
+
+// writeTwitchChat saves a chat export to dir, named after its time, and
+// returns its path
+func writeTwitchChat(dir string, chat *generic.TwitchChat) (string, error) {
+	data, err := json.MarshalIndent(chat, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "chat-"+chat.Exported.Format("20060102T150405Z")+".json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write the chat export: %v", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"fmt"
+	"path/filepath"
+
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/manifest"
+	"go-del-socials/pkg/secrets"
+	"go-del-socials/pkg/state"
+	"go-del-socials/pkg/store"
+)
+
+// signingKey returns the key archive manifests are signed with, or nil when
+// none is configured
+func (c *Config) signingKey() (ed25519.PrivateKey, error) {
+	if c.ArchiveSigningKey == "" {
+		return nil, nil
+	}
+	key, err := secrets.Resolve(c.ArchiveSigningKey)
+	if err != nil {
+		return nil, err
+	}
+	return manifest.ParseKey(key)
+}
+
+// verifyArchive checks a profile's archive against its checksum manifest and
+// prints what is wrong. It reports whether the archive is intact.
+func verifyArchive(config *Config, profile string) (bool, error) {
+	if profile == "" {
+		profile = defaultProfile
+	}
+	base := config.stateDir()
+	if base == "" {
+		var err error
+		if base, err = state.BaseDir(); err != nil {
+			return false, err
+		}
+	}
+
+	key, err := config.signingKey()
+	if err != nil {
+		return false, err
+	}
+	var pub ed25519.PublicKey
+	if key != nil {
+		pub = key.Public().(ed25519.PublicKey)
+	}
+
+	dir := filepath.Join(base, profile, state.Archives)
+	r, err := manifest.Verify(dir, pub)
+	if err != nil {
+		return false, err
+	}
+
+	for _, path := range r.Corrupted {
+		fmt.Printf("CORRUPTED  %s\n", path)
+	}
+	for _, path := range r.Missing {
+		fmt.Printf("MISSING    %s\n", path)
+	}
+	for _, path := range r.Unlisted {
+		fmt.Printf("UNLISTED   %s (added after the last run)\n", path)
+	}
+
+	fmt.Printf("\nChecked %d files in %s: %d corrupted, %d missing\n", r.Checked, dir, len(r.Corrupted), len(r.Missing))
+	if r.Signature != "" {
+		fmt.Printf("Manifest signature: %s\n", r.Signature)
+	}
+	return r.OK(), nil
+}
+
+// verifyAudit checks the hash chain of a profile's audit log
+func verifyAudit(config *Config, profile string) error {
+	if profile == "" {
+		profile = defaultProfile
+	}
+	base := config.stateDir()
+	if base == "" {
+		var err error
+		if base, err = state.BaseDir(); err != nil {
+			return err
+		}
+	}
+	dir := filepath.Join(base, profile)
+
+	s, err := store.Open(config.StateBackend, dir)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	path := filepath.Join(dir, state.Audit, "receipts.jsonl")
+	n, err := audit.Verify(path, s)
+	if err != nil {
+		return fmt.Errorf("%s: %v (the first %d entries are intact)", path, err, n)
+	}
+	fmt.Printf("%s: %d entries, chain intact\n", path, n)
+	return nil
+}
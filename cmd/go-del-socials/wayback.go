@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"go-del-socials/pkg/hook"
+	"go-del-socials/pkg/httpclient"
+	"go-del-socials/pkg/pace"
+	"go-del-socials/pkg/report"
+	"go-del-socials/pkg/tombstone"
+	"go-del-socials/pkg/wayback"
+)
+
+// Ways --wayback picks the items to save to the Wayback Machine
+const (
+	waybackDeleted = "deleted"
+	waybackKept    = "kept"
+)
+
+// waybackHooks returns the job's hooks, saving each item to the Wayback
+// Machine before it is deleted when --wayback is deleted. Plans and
+// simulated runs save nothing, as nothing is deleted.
+func waybackHooks(j *job) *hook.Hooks {
+	if j.Options.Wayback != waybackDeleted || j.Plan != nil || j.Options.Simulate {
+		return j.Options.Hooks
+	}
+	return j.Options.Hooks.Archiving(func(ctx context.Context, it hook.Item) error {
+		// A retweet's or like's page shows someone else's content
+		if slices.Contains(ownPageless, it.Kind) {
+			return nil
+		}
+		if it.URL == "" {
+			return fmt.Errorf("it has no public URL")
+		}
+		return saveSnapshot(ctx, j, it.URL)
+	})
+}
+
+// archiveKept saves the items the run left online to the Wayback Machine,
+// when --wayback is kept
+func archiveKept(ctx context.Context, j *job) error {
+	if j.Options.Wayback != waybackKept || j.Kept == nil || j.Plan != nil || j.Options.Simulate {
+		return nil
+	}
+	var links []string
+	for _, it := range j.Kept.Items {
+		if it.URL != "" && !slices.Contains(ownPageless, it.Kind) {
+			links = append(links, it.URL)
+		}
+	}
+	if len(links) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(j.Out, "\nSaving %d items that stay online to the Wayback Machine...\n", len(links))
+	failed := 0
+	for _, link := range links {
+		if err := saveSnapshot(ctx, j, link); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(j.Out, "Warning: couldn't save %s: %v\n", link, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d items couldn't be saved to the Wayback Machine", failed, len(links))
+	}
+	return nil
+}
+
+// saveSnapshot saves a page to the Wayback Machine and records the snapshot
+// in the run report
+func saveSnapshot(ctx context.Context, j *job, link string) error {
+	snapshot, err := j.Options.Snapshots.Save(ctx, link)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(j.Out, "Saved %s to the Wayback Machine: %s\n", link, snapshot)
+	j.Report.Archived = append(j.Report.Archived, snapshot)
+	return nil
+}
+
+// checkArchived looks up the items the run deleted since started in the
+// Wayback Machine and reports those it still has a copy of. Deleting them
+// from the platform doesn't remove those copies.
+func checkArchived(ctx context.Context, j *job, platform string, started time.Time) error {
+	deleted, err := j.Tombstones.Since(platform, started)
+	if err != nil {
+		return err
+	}
+	deleted = slices.DeleteFunc(deleted, func(t tombstone.Tombstone) bool {
+		return t.URL == "" || slices.Contains(ownPageless, t.Kind)
+	})
+	if len(deleted) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(j.Out, "\nLooking up %d deleted items in the Wayback Machine...\n", len(deleted))
+	hc := httpclient.New()
+	pacer := pace.New(time.Second, defaultJitter, nil)
+	unchecked := 0
+	for i, t := range deleted {
+		if i > 0 {
+			if err := pacer.Pause(ctx); err != nil {
+				return err
+			}
+		}
+		snapshot, err := wayback.Snapshot(ctx, hc, t.URL)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(j.Out, "Warning: couldn't look up %s: %v\n", t.URL, err)
+			unchecked++
+			continue
+		}
+		if snapshot != "" {
+			fmt.Fprintf(j.Out, "Publicly archived elsewhere: %s %s at %s\n", t.Kind, t.URL, snapshot)
+			j.Report.ArchivedElsewhere = append(j.Report.ArchivedElsewhere, report.ArchivedCopy{URL: t.URL, Snapshot: snapshot})
+		}
+	}
+
+	if n := len(j.Report.ArchivedElsewhere); n > 0 {
+		fmt.Fprintf(j.Out, "%d of the %d deleted items can still be read on the Wayback Machine; ask the Internet Archive to exclude them if they must go\n", n, len(deleted)-unchecked)
+	} else if checked := len(deleted) - unchecked; checked > 0 {
+		fmt.Fprintf(j.Out, "None of the %d deleted items is in the Wayback Machine\n", checked)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"go-del-socials/pkg/pause"
+)
+
+// parseWindows reads the run_windows setting
+func parseWindows(specs []string) ([]pause.Window, error) {
+	windows := make([]pause.Window, len(specs))
+	for i, s := range specs {
+		w, err := pause.ParseWindow(s)
+		if err != nil {
+			return nil, err
+		}
+		windows[i] = w
+	}
+	return windows, nil
+}
+
+// watchWindows pauses runs outside the allowed hours and resumes them when
+// a window opens. Pauses and resumes by hand in between are left alone.
+func watchWindows(g *pause.Gate, windows []pause.Window) {
+	inside := func(t time.Time) bool {
+		for _, w := range windows {
+			if w.Contains(t) {
+				return true
+			}
+		}
+		return false
+	}
+	next := func(t time.Time) string {
+		var first time.Time
+		for _, w := range windows {
+			if s := w.NextStart(t); first.IsZero() || s.Before(first) {
+				first = s
+			}
+		}
+		return first.Format("Mon 15:04")
+	}
+
+	was := inside(time.Now())
+	if !was {
+		g.Pause()
+		fmt.Fprintf(stdout, "Outside the allowed hours; deletions wait until %s\n", next(time.Now()))
+	}
+
+	go func() {
+		tick := time.NewTicker(30 * time.Second)
+		defer tick.Stop()
+		for now := range tick.C {
+			in := inside(now)
+			if in == was {
+				continue
+			}
+			was = in
+			if in {
+				if d, ok := g.Resume(); ok {
+					fmt.Fprintf(stdout, "Allowed hours began; resuming after a pause of %v\n", d.Round(time.Second))
+				}
+			} else if g.Pause() {
+				fmt.Fprintf(stdout, "Allowed hours ended; pausing after the current item until %s\n", next(now))
+			}
+		}
+	}()
+}
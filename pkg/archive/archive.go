@@ -0,0 +1,161 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Formats lists the supported archive formats. "dir" writes loose files.
+var Formats = []string{"dir", "zip", "tar.zst"}
+
+// Writer stores archive files
+type Writer interface {
+	// Write stores a file and returns where it can be found. A file an
+	// earlier run wrote is kept as it is, as the manifest has its checksum.
+	Write(name string, data []byte) (string, error)
+	Close() error
+}
+
+// Open returns a writer for the archive called name in dir. The "dir"
+// format, or an empty one, writes files to dir/name. The compressed
+// formats stream each run's files into dir/name-<time>.<format>, which is
+// only created once something is written.
+func Open(format, dir, name string) (Writer, error) {
+	switch format {
+	case "", "dir":
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(path, 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create archive: %v", err)
+		}
+		return &dirWriter{dir: path}, nil
+	case "zip", "tar.zst":
+		path := filepath.Join(dir, fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102-150405"), format))
+		return &streamWriter{format: format, path: path}, nil
+	}
+	return nil, fmt.Errorf("unknown archive format %q", format)
+}
+
+type dirWriter struct {
+	dir string
+}
+
+func (w *dirWriter) Write(name string, data []byte) (string, error) {
+	path := filepath.Join(w.dir, name)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		return path, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write archive: %v", err)
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("failed to write archive: %v", err)
+	}
+	return path, nil
+}
+
+func (w *dirWriter) Close() error {
+	return nil
+}
+
+// streamWriter compresses files into a single archive as they are written
+type streamWriter struct {
+	format string
+	path   string
+
+	mu   sync.Mutex
+	f    *os.File
+	zip  *zip.Writer
+	tar  *tar.Writer
+	zstd *zstd.Encoder
+}
+
+func (w *streamWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %v", err)
+	}
+	w.f = f
+
+	if w.format == "zip" {
+		w.zip = zip.NewWriter(f)
+		return nil
+	}
+	if w.zstd, err = zstd.NewWriter(f); err != nil {
+		f.Close()
+		return err
+	}
+	w.tar = tar.NewWriter(w.zstd)
+	return nil
+}
+
+// Write adds a file and returns its location as <archive>#<name>
+func (w *streamWriter) Write(name string, data []byte) (string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil {
+		if err := w.open(); err != nil {
+			return "", err
+		}
+	}
+
+	var out io.Writer
+	var err error
+	now := time.Now()
+	if w.zip != nil {
+		out, err = w.zip.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+	} else {
+		err = w.tar.WriteHeader(&tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: now, Typeflag: tar.TypeReg})
+		out = w.tar
+	}
+	if err == nil {
+		_, err = out.Write(data)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write archive: %v", err)
+	}
+	return w.path + "#" + name, nil
+}
+
+// Close finishes the archive. Zip files can only be read once this is done.
+func (w *streamWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.f == nil {
+		return nil
+	}
+
+	var err error
+	if w.zip != nil {
+		err = w.zip.Close()
+	} else {
+		err = w.tar.Close()
+		if zerr := w.zstd.Close(); err == nil {
+			err = zerr
+		}
+	}
+	if ferr := w.f.Close(); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to finish archive %s: %v", w.path, err)
+	}
+	return nil
+}
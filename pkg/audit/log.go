@@ -0,0 +1,227 @@
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"go-del-socials/pkg/store"
+)
+
+// entry is one line of the audit log. Each entry carries the hash of the
+// one before it, so editing, removing or reordering lines breaks the chain.
+type entry struct {
+	Seq     int     `json:"seq"`
+	Prev    string  `json:"prev"`
+	Receipt Receipt `json:"receipt"`
+	Hash    string  `json:"hash,omitempty"`
+}
+
+// head is the last entry written. It is also kept in the state store, so
+// cutting entries off the end of the file is detected too.
+type head struct {
+	Seq  int    `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+const headKey = "audit-head"
+
+// genesis is the previous hash of the first entry
+var genesis = strings.Repeat("0", 64)
+
+// sum returns the hash of an entry, computed over its JSON without the hash
+func (e entry) sum() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// Log is an append-only, hash-chained file of receipts
+type Log struct {
+	mu   sync.Mutex
+	f    *os.File
+	s    store.Store
+	head head
+}
+
+// Open opens the log at path for appending. It refuses a log that no longer
+// matches the head recorded in s, since appending would bury the damage. A
+// log whose chain goes on past the recorded head, as a crash right after
+// writing an entry leaves it, moves the head up.
+func Open(path string, s store.Store) (*Log, error) {
+	last, ahead, err := verify(path, s)
+	if err != nil {
+		return nil, err
+	}
+	if ahead {
+		if err := putHead(s, last); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	return &Log{f: f, s: s, head: last}, nil
+}
+
+// Record appends a receipt and syncs it to disk, since receipts are only
+// worth anything if they survive a crash
+func (l *Log) Record(r Receipt) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e := entry{Seq: l.head.Seq + 1, Prev: l.head.Hash, Receipt: r}
+	if e.Prev == "" {
+		e.Prev = genesis
+	}
+	var err error
+	if e.Hash, err = e.sum(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	if _, err := l.f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write receipt: %v", err)
+	}
+	if err := l.f.Sync(); err != nil {
+		return fmt.Errorf("failed to write receipt: %v", err)
+	}
+
+	l.head = head{Seq: e.Seq, Hash: e.Hash}
+	return putHead(l.s, l.head)
+}
+
+// putHead records the last entry written in s
+func putHead(s store.Store, h head) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return s.Put("checkpoints", headKey, data)
+}
+
+func (l *Log) Close() error {
+	return l.f.Close()
+}
+
+// Verify checks the hash chain of the log at path and that it ends at the
+// head recorded in s. It returns the number of intact entries.
+func Verify(path string, s store.Store) (int, error) {
+	last, _, err := verify(path, s)
+	return last.Seq, err
+}
+
+// Read returns the receipts of the log at path, oldest first, without
+// checking the chain. A missing log has none.
+func Read(path string) ([]Receipt, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	defer f.Close()
+
+	var receipts []Receipt
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 16<<20)
+	for sc.Scan() {
+		var e entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return receipts, fmt.Errorf("audit log entry %d is not valid JSON", len(receipts)+1)
+		}
+		receipts = append(receipts, e.Receipt)
+	}
+	if err := sc.Err(); err != nil {
+		return receipts, fmt.Errorf("failed to read audit log: %v", err)
+	}
+	return receipts, nil
+}
+
+// verify returns the last intact entry, and whether the chain goes on past
+// the recorded head; a missing log is empty
+func verify(path string, s store.Store) (head, bool, error) {
+	var recorded *head
+	if data, err := s.Get("checkpoints", headKey); err == nil {
+		recorded = &head{}
+		if err := json.Unmarshal(data, recorded); err != nil {
+			return head{}, false, fmt.Errorf("corrupt audit log head: %v", err)
+		}
+	} else if !errors.Is(err, store.ErrNotFound) {
+		return head{}, false, err
+	}
+
+	var last head
+	reached := false
+	f, err := os.Open(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return head{}, false, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	if err == nil {
+		defer f.Close()
+
+		sc := bufio.NewScanner(f)
+		// Receipts carry whole response bodies
+		sc.Buffer(nil, 16<<20)
+		for sc.Scan() {
+			var e entry
+			if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+				return last, false, fmt.Errorf("audit log entry %d is not valid JSON", last.Seq+1)
+			}
+
+			prev := last.Hash
+			if prev == "" {
+				prev = genesis
+			}
+			if e.Seq != last.Seq+1 {
+				return last, false, fmt.Errorf("audit log entry %d is followed by entry %d: entries were removed or reordered", last.Seq, e.Seq)
+			}
+			if e.Prev != prev {
+				return last, false, fmt.Errorf("audit log entry %d doesn't follow entry %d: the chain is broken", e.Seq, last.Seq)
+			}
+			sum, err := e.sum()
+			if err != nil {
+				return last, false, err
+			}
+			if sum != e.Hash {
+				return last, false, fmt.Errorf("audit log entry %d was altered", e.Seq)
+			}
+			last = head{Seq: e.Seq, Hash: e.Hash}
+			if recorded != nil && last == *recorded {
+				reached = true
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return last, false, fmt.Errorf("failed to read audit log: %v", err)
+		}
+	}
+
+	if recorded == nil || *recorded == last {
+		return last, false, nil
+	}
+	if recorded.Seq > last.Seq {
+		return last, false, fmt.Errorf("audit log ends at entry %d, but %d were written: it was truncated", last.Seq, recorded.Seq)
+	}
+	// The entries after the head were written, but the run stopped before
+	// recording them; the chain vouches for them
+	if reached {
+		return last, true, nil
+	}
+	return last, false, fmt.Errorf("audit log ends at entry %d, which doesn't match the recorded head", last.Seq)
+}
@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"go-del-socials/pkg/httpclient"
+)
+
+// Receipt is the evidence of one deletion request: what was asked of the
+// platform and exactly what it answered
+type Receipt struct {
+	Time     time.Time `json:"time"`
+	Platform string    `json:"platform"`
+	Kind     string    `json:"kind"`
+	ID       string    `json:"id"`
+	Method   string    `json:"method"`
+	URL      string    `json:"url"`
+	Status   int       `json:"status"`
+	Body     string    `json:"body"`
+
+	// Simulated receipts are for deletes acknowledged by --simulate without
+	// reaching the platform
+	Simulated bool `json:"simulated,omitempty"`
+}
+
+// Capture is an http.RoundTripper that copies the responses to requests
+// tagged by Expect, so receipts can be taken from API clients that don't
+// expose raw responses
+type Capture struct {
+	Transport http.RoundTripper
+}
+
+// expectKey tags the context of requests whose response makes a receipt
+type expectKey struct{}
+
+// Expect returns a context for a delete and the receipt a Capture fills in
+// with the response to it. Requests made alongside it on the same client
+// don't touch the receipt.
+func Expect(ctx context.Context) (context.Context, *Receipt) {
+	r := &Receipt{}
+	return context.WithValue(ctx, expectKey{}, r), r
+}
+
+// NewCapture wraps the transport of hc, or the shared transport when it has
+// none, and returns the capture
+func NewCapture(hc *http.Client) *Capture {
+	if c, ok := hc.Transport.(*Capture); ok {
+		return c
+	}
+	c := &Capture{Transport: hc.Transport}
+	if c.Transport == nil {
+		c.Transport = httpclient.Transport
+	}
+	hc.Transport = c
+	return c
+}
+
+func (c *Capture) RoundTrip(req *http.Request) (*http.Response, error) {
+	r, ok := req.Context().Value(expectKey{}).(*Receipt)
+	resp, err := c.Transport.RoundTrip(req)
+	if err != nil || !ok {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// A retried delete leaves the receipt of its last attempt
+	*r = Receipt{
+		Time:   time.Now(),
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Status: resp.StatusCode,
+		Body:   string(body),
+
+		Simulated: resp.Header.Get(simulatedHeader) != "",
+	}
+
+	return resp, nil
+}
+
+// Captured reports whether a response was copied into r
+func (r *Receipt) Captured() bool {
+	return r != nil && r.Method != ""
+}
@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"go-del-socials/pkg/httpclient"
+)
+
+// simulatedHeader marks responses made up by a Simulator, so captured
+// receipts say the request never reached the platform
+const simulatedHeader = "X-Go-Del-Socials-Simulated"
+
+// simulatedBody satisfies the delete, unlike, unretweet and edit responses
+// of the built-in platforms
+const simulatedBody = `{"data":{"deleted":true,"liked":false,"retweeted":false},"json":{"errors":[]}}`
+
+// Simulator is an http.RoundTripper that acknowledges every write without
+// sending it. Reads go through, so listings are real.
+type Simulator struct {
+	Transport http.RoundTripper
+
+	// Pass, when set, lets through the writes that don't change anything,
+	// such as token requests
+	Pass func(*http.Request) bool
+}
+
+// Simulate wraps the transport of hc, or the shared transport when it has
+// none, in a Simulator
+func Simulate(hc *http.Client, pass func(*http.Request) bool) {
+	s := &Simulator{Transport: hc.Transport, Pass: pass}
+	if s.Transport == nil {
+		s.Transport = httpclient.Transport
+	}
+	hc.Transport = s
+}
+
+func (s *Simulator) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead || (s.Pass != nil && s.Pass(req)) {
+		return s.Transport.RoundTrip(req)
+	}
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set(simulatedHeader, "true")
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(simulatedBody)),
+		ContentLength: int64(len(simulatedBody)),
+		Request:       req,
+	}, nil
+}
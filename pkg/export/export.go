@@ -0,0 +1,166 @@
+// Package export reads the data exports platforms hand out, such as Reddit
+// data requests, Twitter archives, Facebook and Instagram downloads,
+// Mastodon exports and Letterboxd and Goodreads exports, into one kind of
+// item. Importing an export or deleting
+// from it then doesn't need a parser of its own for every provider.
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/index"
+	"go-del-socials/pkg/plan"
+)
+
+// Item is a post, comment or other piece of content listed in an export
+type Item struct {
+	Platform string `json:"platform"`
+
+	// Kind is what the platform's provider calls the item, e.g. "post" on
+	// Reddit, "tweet" on Twitter and a content type such as "statuses" on
+	// platforms served by generic providers
+	Kind string    `json:"kind"`
+	ID   string    `json:"id"`
+	Date time.Time `json:"date"`
+
+	Title string `json:"title,omitempty"`
+	Text  string `json:"text,omitempty"`
+	URL   string `json:"url,omitempty"`
+
+	// Where is the place it was posted in, e.g. its subreddit
+	Where string `json:"where,omitempty"`
+
+	// ReplyTo is the ID or URL of the item it answers, and ReplyToUser that
+	// item's author, where the export records them
+	ReplyTo     string `json:"reply_to,omitempty"`
+	ReplyToUser string `json:"reply_to_user,omitempty"`
+
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Plan returns the item as plans list it
+func (it *Item) Plan() plan.Item {
+	text := it.Text
+	if it.Title != "" {
+		text = it.Title
+	}
+	return plan.Item{ID: it.ID, Kind: it.Kind, Date: it.Date, Text: text, Where: it.Where}
+}
+
+// Format is a kind of export and how to read it
+type Format struct {
+	Name     string
+	Platform string
+
+	// files are where the format's data files are, relative to the
+	// extracted export, as glob patterns. An export is of the format when
+	// one of them matches.
+	files []string
+
+	// read calls fn with every item of the export at path until fn
+	// returns false
+	read func(path string, fn func(Item) (bool, error)) error
+}
+
+// Formats are the exports that can be read. Letterboxd exports come before
+// Reddit's, as they also have a comments.csv.
+var Formats = []*Format{Letterboxd, Reddit, Twitter, Facebook, Instagram, Mastodon, Goodreads}
+
+// Detect finds the format of the export at path, which may be the extracted
+// export directory or, for single-file formats, the data file itself
+func Detect(path string) (*Format, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to open export: %v", err)
+	}
+	var names []string
+	for _, f := range Formats {
+		if len(find(path, f.files...)) > 0 {
+			return f, nil
+		}
+		names = append(names, f.Name)
+	}
+	return nil, fmt.Errorf("%s isn't an export that can be read; supported are: %s", path, strings.Join(names, ", "))
+}
+
+// Read calls fn with every item of the export at path, in the order the
+// export lists them, until fn returns false
+func (f *Format) Read(path string, fn func(Item) (bool, error)) error {
+	return f.read(path, fn)
+}
+
+// find returns the files matching patterns in the export at path, in the
+// order of the patterns. When path is a file, it is the only one that can
+// match.
+func find(path string, patterns ...string) []string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	if !info.IsDir() {
+		for _, pattern := range patterns {
+			if ok, _ := filepath.Match(filepath.Base(pattern), filepath.Base(path)); ok {
+				return []string{path}
+			}
+		}
+		return nil
+	}
+
+	var found []string
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(filepath.Join(path, filepath.FromSlash(pattern)))
+		for _, m := range matches {
+			if !slices.Contains(found, m) {
+				found = append(found, m)
+			}
+		}
+	}
+	return found
+}
+
+// readJSON decodes a data file
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", filepath.Base(path), err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// Import adds the items of the export at path to the item index, by kind,
+// so runs of the platform can process them without listing them. Each is
+// stored as an Item; Reddit and Twitter import their exports in the form
+// their listings return instead.
+func (f *Format) Import(path string, ix *index.Index) (int, error) {
+	imported := 0
+	err := f.Read(path, func(it Item) (bool, error) {
+		raw, err := json.Marshal(&it)
+		if err != nil {
+			return false, err
+		}
+		if err := ix.Put(it.Platform, it.Kind, it.ID, it.Date, raw); err != nil {
+			return false, err
+		}
+		imported++
+		return true, nil
+	})
+	return imported, err
+}
+
+// madeUpID stands in for the ID of items whose export has none. It is made
+// from what the export does record, so importing the same export again
+// yields the same IDs.
+func madeUpID(kind string, date time.Time, text string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%s", kind, date.Unix(), text)))
+	return hex.EncodeToString(sum[:8])
+}
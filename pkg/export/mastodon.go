@@ -0,0 +1,100 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Mastodon is a Mastodon account export, whose outbox.json holds the
+// account's posts as ActivityPub activities. Kinds are the content types of
+// Mastodon's API: "statuses", and "reblogs" for boosts.
+var Mastodon = &Format{
+	Name:     "Mastodon export",
+	Platform: "mastodon",
+	files:    mastodonOutbox,
+	read:     readMastodon,
+}
+
+var mastodonOutbox = []string{"outbox.json"}
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// PlainText turns a status's HTML into text
+func PlainText(content string) string {
+	return strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(content, " ")))
+}
+
+// statusID returns the status ID in an activity or status URI, e.g. 1234
+// in https://mastodon.social/users/alice/statuses/1234/activity
+func statusID(uri string) string {
+	parts := strings.Split(uri, "/")
+	for i := len(parts) - 2; i >= 0; i-- {
+		if parts[i] == "statuses" {
+			return parts[i+1]
+		}
+	}
+	return path.Base(uri)
+}
+
+func readMastodon(path string, fn func(Item) (bool, error)) error {
+	files := find(path, mastodonOutbox...)
+	if len(files) == 0 {
+		return fmt.Errorf("no outbox.json in %s", path)
+	}
+	var outbox struct {
+		OrderedItems []struct {
+			ID        string          `json:"id"`
+			Type      string          `json:"type"`
+			Published time.Time       `json:"published"`
+			Object    json.RawMessage `json:"object"`
+		} `json:"orderedItems"`
+	}
+	if err := readJSON(files[0], &outbox); err != nil {
+		return err
+	}
+
+	for _, a := range outbox.OrderedItems {
+		it := Item{Platform: "mastodon", ID: statusID(a.ID), Date: a.Published}
+		switch a.Type {
+		case "Create":
+			var note struct {
+				ID        string `json:"id"`
+				URL       string `json:"url"`
+				Summary   string `json:"summary"`
+				Content   string `json:"content"`
+				InReplyTo string `json:"inReplyTo"`
+				Tag       []struct {
+					Type string `json:"type"`
+					Name string `json:"name"`
+				} `json:"tag"`
+			}
+			if err := json.Unmarshal(a.Object, &note); err != nil {
+				return fmt.Errorf("failed to parse status %s: %v", a.ID, err)
+			}
+			it.Kind, it.ID, it.URL = "statuses", statusID(note.ID), note.URL
+			it.Title, it.Text = PlainText(note.Summary), PlainText(note.Content)
+			it.ReplyTo = note.InReplyTo
+			for _, t := range note.Tag {
+				if t.Type == "Hashtag" {
+					it.Tags = append(it.Tags, strings.TrimPrefix(t.Name, "#"))
+				}
+			}
+		case "Announce":
+			// Boosts are statuses of their own, which deleting undoes
+			var boosted string
+			json.Unmarshal(a.Object, &boosted)
+			it.Kind, it.URL = "reblogs", boosted
+		default:
+			continue
+		}
+		if more, err := fn(it); err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+	"unicode/utf8"
+)
+
+// Facebook and Instagram downloads (in JSON format) give their items no
+// IDs, so they get made-up ones. Those are stable across imports and work
+// for plans and the index, but a provider that deletes them has to find
+// each one by its date and text.
+
+// Facebook is a Facebook "Download your information" export in JSON
+var Facebook = &Format{
+	Name:     "Facebook download",
+	Platform: "facebook",
+	files:    slices.Concat(facebookPosts, facebookComments),
+	read:     readFacebook,
+}
+
+var (
+	facebookPosts = []string{
+		"your_facebook_activity/posts/your_posts__check_ins__photos_and_videos_*.json",
+		"posts/your_posts_*.json",
+	}
+	facebookComments = []string{
+		"your_facebook_activity/comments_and_reactions/comments.json",
+		"comments_and_reactions/comments.json",
+		"comments/comments.json",
+	}
+)
+
+// Instagram is an Instagram "Download your information" export in JSON
+var Instagram = &Format{
+	Name:     "Instagram download",
+	Platform: "instagram",
+	files:    slices.Concat(instagramPosts, instagramComments),
+	read:     readInstagram,
+}
+
+var (
+	instagramPosts = []string{
+		"your_instagram_activity/content/posts_*.json",
+		"content/posts_*.json",
+	}
+	instagramComments = []string{
+		"your_instagram_activity/comments/post_comments_*.json",
+		"comments/post_comments_*.json",
+	}
+)
+
+// unmangle undoes the way Meta's exports encode text: every byte of the
+// UTF-8 is escaped as a character of its own, so "é" reads as "Ã©"
+func unmangle(s string) string {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			return s
+		}
+		b = append(b, byte(r))
+	}
+	if !utf8.Valid(b) {
+		return s
+	}
+	return string(b)
+}
+
+func readFacebook(path string, fn func(Item) (bool, error)) error {
+	for _, file := range find(path, facebookPosts...) {
+		var posts []struct {
+			Timestamp int64  `json:"timestamp"`
+			Title     string `json:"title"`
+			Data      []struct {
+				Post string `json:"post"`
+			} `json:"data"`
+		}
+		if err := readJSON(file, &posts); err != nil {
+			return err
+		}
+		for _, p := range posts {
+			text := p.Title
+			for _, d := range p.Data {
+				if d.Post != "" {
+					text = d.Post
+				}
+			}
+			if more, err := fn(metaItem("facebook", "posts", p.Timestamp, unmangle(text))); err != nil || !more {
+				return err
+			}
+		}
+	}
+
+	for _, file := range find(path, facebookComments...) {
+		type comment struct {
+			Timestamp int64  `json:"timestamp"`
+			Title     string `json:"title"`
+			Data      []struct {
+				Comment struct {
+					Comment string `json:"comment"`
+				} `json:"comment"`
+			} `json:"data"`
+		}
+		// Older downloads hold the comments in "comments", newer ones in
+		// "comments_v2"
+		var wrapped struct {
+			Comments   []comment `json:"comments"`
+			CommentsV2 []comment `json:"comments_v2"`
+		}
+		if err := readJSON(file, &wrapped); err != nil {
+			return err
+		}
+		for _, c := range append(wrapped.Comments, wrapped.CommentsV2...) {
+			text := c.Title
+			for _, d := range c.Data {
+				if d.Comment.Comment != "" {
+					text = d.Comment.Comment
+				}
+			}
+			if more, err := fn(metaItem("facebook", "comments", c.Timestamp, unmangle(text))); err != nil || !more {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func readInstagram(path string, fn func(Item) (bool, error)) error {
+	for _, file := range find(path, instagramPosts...) {
+		// Posts of a single photo or video only date and caption the media
+		var posts []struct {
+			Title             string `json:"title"`
+			CreationTimestamp int64  `json:"creation_timestamp"`
+			Media             []struct {
+				Title             string `json:"title"`
+				CreationTimestamp int64  `json:"creation_timestamp"`
+			} `json:"media"`
+		}
+		if err := readJSON(file, &posts); err != nil {
+			return err
+		}
+		for _, p := range posts {
+			if p.CreationTimestamp == 0 && len(p.Media) > 0 {
+				p.Title, p.CreationTimestamp = p.Media[0].Title, p.Media[0].CreationTimestamp
+			}
+			if more, err := fn(metaItem("instagram", "posts", p.CreationTimestamp, unmangle(p.Title))); err != nil || !more {
+				return err
+			}
+		}
+	}
+
+	for _, file := range find(path, instagramComments...) {
+		type comment struct {
+			Data map[string]struct {
+				Value     string `json:"value"`
+				Timestamp int64  `json:"timestamp"`
+			} `json:"string_map_data"`
+		}
+		// Comments are a list, or in newer downloads wrapped in an object
+		var raw json.RawMessage
+		if err := readJSON(file, &raw); err != nil {
+			return err
+		}
+		var comments []comment
+		var wrapped struct {
+			Comments []comment `json:"comments_media_comments"`
+		}
+		target := any(&wrapped)
+		if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+			target = &comments
+		}
+		if err := json.Unmarshal(raw, target); err != nil {
+			return fmt.Errorf("failed to parse %s: %v", filepath.Base(file), err)
+		}
+		comments = append(comments, wrapped.Comments...)
+		for _, c := range comments {
+			it := metaItem("instagram", "comments", c.Data["Time"].Timestamp, unmangle(c.Data["Comment"].Value))
+			it.ReplyToUser = c.Data["Media Owner"].Value
+			if more, err := fn(it); err != nil || !more {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// metaItem makes an item of a Facebook or Instagram download
+func metaItem(platform, kind string, timestamp int64, text string) Item {
+	date := time.Unix(timestamp, 0).UTC()
+	return Item{
+		Platform: platform,
+		Kind:     kind,
+		ID:       madeUpID(kind, date, text),
+		Date:     date,
+		Text:     text,
+	}
+}
@@ -0,0 +1,100 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Reddit is an extracted Reddit data request (GDPR export). It includes
+// content in banned subreddits that user listings no longer return.
+var Reddit = &Format{
+	Name:     "Reddit data request",
+	Platform: "reddit",
+	files:    []string{"posts.csv", "comments.csv"},
+	read:     readReddit,
+}
+
+// readReddit reads posts.csv and then comments.csv. Item IDs are without
+// their t3_ or t1_ prefix.
+func readReddit(path string, fn func(Item) (bool, error)) error {
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		path = filepath.Dir(path)
+	}
+	for _, src := range []struct{ file, kind string }{{"posts.csv", "post"}, {"comments.csv", "comment"}} {
+		more, err := readRedditCSV(filepath.Join(path, src.file), src.kind, fn)
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
+
+// readRedditCSV reads one file of the export. A missing file yields no
+// items.
+func readRedditCSV(path, kind string, fn func(Item) (bool, error)) (bool, error) {
+	file := filepath.Base(path)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to open export: %v", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s header: %v", file, err)
+	}
+	col := map[string]int{}
+	for i, name := range header {
+		col[name] = i
+	}
+	for _, name := range []string{"id", "date"} {
+		if _, ok := col[name]; !ok {
+			return false, fmt.Errorf("%s has no %q column", file, name)
+		}
+	}
+
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to read %s: %v", file, err)
+		}
+
+		date, err := time.Parse("2006-01-02 15:04:05 MST", rec[col["date"]])
+		if err != nil {
+			return false, fmt.Errorf("invalid date in %s for %s: %v", file, rec[col["id"]], err)
+		}
+		field := func(name string) string {
+			if i, ok := col[name]; ok && i < len(rec) {
+				return rec[i]
+			}
+			return ""
+		}
+
+		it := Item{
+			Platform: "reddit",
+			Kind:     kind,
+			ID:       rec[col["id"]],
+			Date:     date,
+			Title:    field("title"),
+			Text:     field("body"),
+			URL:      field("permalink"),
+			Where:    field("subreddit"),
+		}
+		if more, err := fn(it); err != nil || !more {
+			return false, err
+		}
+	}
+}
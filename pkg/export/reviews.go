@@ -0,0 +1,150 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Letterboxd is an extracted Letterboxd export, from Settings → Data →
+// Export your data
+var Letterboxd = &Format{
+	Name:     "Letterboxd export",
+	Platform: "letterboxd",
+	files:    []string{"reviews.csv", "ratings.csv", "diary.csv"},
+	read:     readLetterboxd,
+}
+
+// Goodreads is the library export of Goodreads, from My Books → Import and
+// export
+var Goodreads = &Format{
+	Name:     "Goodreads library export",
+	Platform: "goodreads",
+	files:    []string{"goodreads_library_export.csv"},
+	read:     readGoodreads,
+}
+
+// readLetterboxd reads reviews.csv, ratings.csv and comments.csv. Reviews
+// are identified by the code of their boxd.it link and ratings by that of
+// the film's, which are the IDs Letterboxd's API takes; comments have none,
+// so they get made-up ones.
+func readLetterboxd(dir string, fn func(Item) (bool, error)) error {
+	if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	for _, kind := range []string{"reviews", "ratings", "comments"} {
+		more, err := readCSV(filepath.Join(dir, kind+".csv"), func(field func(string) string) (bool, error) {
+			date, err := time.Parse("2006-01-02", field("Date"))
+			if err != nil {
+				return false, fmt.Errorf("invalid date in %s.csv: %v", kind, err)
+			}
+			it := Item{Platform: "letterboxd", Kind: kind, Date: date}
+			switch kind {
+			case "comments":
+				it.Text = field("Content")
+				it.URL = field("Comment on")
+				it.ID = madeUpID(kind, date, it.Text)
+			default:
+				it.URL = field("Letterboxd URI")
+				it.ID = path.Base(it.URL)
+				it.Title = field("Name")
+				it.Text = field("Review")
+				if kind == "ratings" {
+					it.Text = field("Rating") + " stars"
+				}
+			}
+			if it.ID == "" || it.ID == "." {
+				return true, nil
+			}
+			return fn(it)
+		})
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
+
+// readGoodreads reads the shelved books that have a rating or a review.
+// Goodreads keeps a rating as a review without text, one per book, so
+// each book is one item: a review if it has text and a rating if not. It
+// is identified by the book's ID and dated when the book was read, or
+// else when it was shelved.
+func readGoodreads(file string, fn func(Item) (bool, error)) error {
+	if info, err := os.Stat(file); err == nil && info.IsDir() {
+		file = filepath.Join(file, "goodreads_library_export.csv")
+	}
+	_, err := readCSV(file, func(field func(string) string) (bool, error) {
+		id := field("Book Id")
+		date, err := time.Parse("2006/01/02", field("Date Read"))
+		if err != nil {
+			if date, err = time.Parse("2006/01/02", field("Date Added")); err != nil {
+				return false, fmt.Errorf("invalid date in goodreads_library_export.csv for book %s: %v", id, err)
+			}
+		}
+		it := Item{Platform: "goodreads", ID: id, Date: date, Title: field("Title"), URL: "https://www.goodreads.com/book/show/" + id}
+		stars, _ := strconv.Atoi(field("My Rating"))
+		switch review := strings.TrimSpace(field("My Review")); {
+		case review != "":
+			it.Kind, it.Text = "reviews", review
+		case stars > 0:
+			it.Kind, it.Text = "ratings", field("My Rating")+" stars"
+		default:
+			return true, nil
+		}
+		return fn(it)
+	})
+	return err
+}
+
+// readCSV calls fn with every record of a CSV file with a header, until fn
+// returns false. fn reads the record's fields by column name. A missing
+// file yields no records.
+func readCSV(path string, fn func(field func(name string) string) (bool, error)) (bool, error) {
+	file := filepath.Base(path)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to open export: %v", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s header: %v", file, err)
+	}
+	// Exports saved from spreadsheets may start with a byte order mark
+	col := map[string]int{}
+	for i, name := range header {
+		col[strings.TrimPrefix(name, "\ufeff")] = i
+	}
+
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to read %s: %v", file, err)
+		}
+		more, err := fn(func(name string) string {
+			if i, ok := col[name]; ok && i < len(rec) {
+				return rec[i]
+			}
+			return ""
+		})
+		if err != nil || !more {
+			return false, err
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Twitter is a Twitter archive. Tweets are in tweets.js, or tweet.js in
+// archives from before 2020.
+var Twitter = &Format{
+	Name:     "Twitter archive",
+	Platform: "twitter",
+	files:    []string{"data/tweets.js", "data/tweet.js"},
+	read:     readTwitter,
+}
+
+// ReadArchiveFile reads a data file of a Twitter archive. path may be the
+// extracted archive directory or the file itself.
+func ReadArchiveFile(path, name string) ([]byte, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, "data", name)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// The file is JavaScript: window.YTD.<name>.part0 = [ ... ]
+	if i := bytes.IndexByte(data, '='); i >= 0 && bytes.HasPrefix(bytes.TrimSpace(data), []byte("window.")) {
+		data = data[i+1:]
+	}
+	return data, nil
+}
+
+// archivedTweet is a tweet as tweets.js stores it, in the format of the old
+// v1.1 API
+type archivedTweet struct {
+	ID                string `json:"id_str"`
+	FullText          string `json:"full_text"`
+	CreatedAt         string `json:"created_at"`
+	InReplyToStatusID string `json:"in_reply_to_status_id_str"`
+	InReplyToUserID   string `json:"in_reply_to_user_id_str"`
+	Entities          struct {
+		Hashtags []struct {
+			Text string `json:"text"`
+		} `json:"hashtags"`
+	} `json:"entities"`
+}
+
+// readTwitter reads the tweets of an archive. Kinds are "tweet", "reply" and
+// "retweet"; the archive doesn't say which tweet a retweet was of.
+func readTwitter(path string, fn func(Item) (bool, error)) error {
+	data, err := ReadArchiveFile(path, "tweets.js")
+	if os.IsNotExist(err) && !strings.HasSuffix(path, ".js") {
+		data, err = ReadArchiveFile(path, "tweet.js")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read tweet archive: %v", err)
+	}
+
+	var entries []struct {
+		Tweet archivedTweet `json:"tweet"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to parse tweet archive: %v", err)
+	}
+
+	for _, e := range entries {
+		a := e.Tweet
+		created, err := time.Parse(time.RubyDate, a.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("invalid date for tweet %s: %v", a.ID, err)
+		}
+
+		it := Item{
+			Platform:    "twitter",
+			Kind:        "tweet",
+			ID:          a.ID,
+			Date:        created,
+			Text:        a.FullText,
+			ReplyTo:     a.InReplyToStatusID,
+			ReplyToUser: a.InReplyToUserID,
+		}
+		switch {
+		case strings.HasPrefix(a.FullText, "RT @"):
+			it.Kind = "retweet"
+		case a.InReplyToStatusID != "":
+			it.Kind = "reply"
+		}
+		for _, h := range a.Entities.Hashtags {
+			it.Tags = append(it.Tags, h.Text)
+		}
+		if more, err := fn(it); err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
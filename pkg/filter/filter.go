@@ -0,0 +1,50 @@
+package filter
+
+import "strings"
+
+// Set selects items by tag-like values such as hashtags or subreddit names.
+// Values are compared case-insensitively and a leading # is ignored.
+type Set struct {
+	Include []string
+	Exclude []string
+}
+
+// Empty reports whether the set lets every item through
+func (s Set) Empty() bool {
+	return len(s.Include) == 0 && len(s.Exclude) == 0
+}
+
+// Allows reports whether an item carrying values passes the set: it must not
+// carry an excluded value and, when Include is non-empty, must carry at least
+// one included value.
+func (s Set) Allows(values []string) bool {
+	for _, v := range values {
+		if containsFold(s.Exclude, v) {
+			return false
+		}
+	}
+
+	if len(s.Include) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if containsFold(s.Include, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalize(v string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(v), "#"))
+}
+
+func containsFold(list []string, v string) bool {
+	v = normalize(v)
+	for _, s := range list {
+		if normalize(s) == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KeepLast keeps the newest items of each content type by count, whatever
+// their date, e.g. the last 200 tweets. The count of "all" holds for the
+// content types without one of their own.
+type KeepLast map[string]int
+
+// ParseKeepLast reads counts given as "200", for every content type, or as
+// "<content type>=200"
+func ParseKeepLast(values []string) (KeepLast, error) {
+	k := KeepLast{}
+	for _, v := range values {
+		contentType, count, ok := strings.Cut(v, "=")
+		if !ok {
+			contentType, count = "all", v
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid count %q: give a number of items, or <content type>=<number>", v)
+		}
+		k[strings.TrimSpace(contentType)] = n
+	}
+	return k, nil
+}
+
+// Count returns how many of the newest items of a content type are kept
+func (k KeepLast) Count(contentType string) int {
+	if n, ok := k[contentType]; ok {
+		return n
+	}
+	return k["all"]
+}
+
+// Reason says why an item kept by the count stays
+func (k KeepLast) Reason(contentType string) string {
+	return fmt.Sprintf("one of the newest %d %s", k.Count(contentType), contentType)
+}
+
+// Newest counts the items of listings that come newest first, to tell the
+// kept ones from the rest. A nil Newest keeps nothing.
+type Newest struct {
+	keep KeepLast
+	seen map[string]int
+}
+
+// Newest returns a count of listed items starting from zero, or nil when
+// no content type is kept by count
+func (k KeepLast) Newest() *Newest {
+	if len(k) == 0 {
+		return nil
+	}
+	return &Newest{keep: k, seen: map[string]int{}}
+}
+
+// Keep counts an item of a content type and reports whether it is among
+// the newest kept by count
+func (n *Newest) Keep(contentType string) bool {
+	if n == nil {
+		return false
+	}
+	n.seen[contentType]++
+	return n.seen[contentType] <= n.keep.Count(contentType)
+}
@@ -0,0 +1,66 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Retention overrides the cutoff for the items of some scopes: a subreddit
+// (r/AskHistorians), a hashtag (#work), or a community or channel as the
+// provider names it. Scopes are compared like the values of a Set.
+type Retention []Rule
+
+// Rule keeps the items of a scope forever, or until their own cutoff
+type Rule struct {
+	Scope  string
+	Keep   bool
+	Cutoff time.Time
+}
+
+// ParseRetention reads rules given as "<scope>=keep" or "<scope>=<when>",
+// where cutoff turns when, an age or a date, into the scope's cutoff
+func ParseRetention(values []string, cutoff func(string) (time.Time, error)) (Retention, error) {
+	var r Retention
+	for _, v := range values {
+		scope, when, ok := strings.Cut(v, "=")
+		scope, when = strings.TrimSpace(scope), strings.TrimSpace(when)
+		if !ok || scope == "" || when == "" {
+			return nil, fmt.Errorf("invalid rule %q: give <scope>=keep or <scope>=<age or date>, e.g. r/AskHistorians=keep or #work=30d", v)
+		}
+		rule := Rule{Scope: scope, Keep: strings.EqualFold(when, "keep")}
+		if !rule.Keep {
+			t, err := cutoff(when)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rule %q: %v", v, err)
+			}
+			rule.Cutoff = t
+		}
+		r = append(r, rule)
+	}
+	return r, nil
+}
+
+// Cutoff returns the cutoff of an item in scopes, which is the run's own
+// unless a rule covers one of them, and why an item newer than it is kept.
+// Of several rules, the one keeping the item longest applies.
+func (r Retention) Cutoff(cutoff time.Time, scopes ...string) (time.Time, string) {
+	reason := "newer than the cutoff"
+	var applied *Rule
+	for i := range r {
+		rule := &r[i]
+		if !containsFold(scopes, rule.Scope) {
+			continue
+		}
+		if applied == nil || rule.Keep || (!applied.Keep && rule.Cutoff.Before(applied.Cutoff)) {
+			applied = rule
+		}
+	}
+	switch {
+	case applied == nil:
+		return cutoff, reason
+	case applied.Keep:
+		return time.Time{}, "kept forever in " + applied.Scope
+	}
+	return applied.Cutoff, reason + " for " + applied.Scope
+}
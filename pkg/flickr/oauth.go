@@ -0,0 +1,123 @@
+// Package flickr signs requests to the Flickr API with OAuth 1.0a and
+// authorizes the tool to an account.
+package flickr
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/httpclient"
+)
+
+// Addresses of Flickr's API and OAuth endpoints
+const (
+	API            = "https://www.flickr.com/services/rest/"
+	requestToken   = "https://www.flickr.com/services/oauth/request_token"
+	authorizeURL   = "https://www.flickr.com/services/oauth/authorize"
+	accessTokenURL = "https://www.flickr.com/services/oauth/access_token"
+)
+
+// Credentials are an app's key and a user's token, each with its secret.
+// The token is empty while authorizing.
+type Credentials struct {
+	Key, Secret        string
+	Token, TokenSecret string
+}
+
+// Sign adds the OAuth parameters and the HMAC-SHA1 signature of a request
+// with params to params, which are then sent in the query or the form body
+func (c Credentials) Sign(method, u string, params url.Values) {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	params.Set("oauth_consumer_key", c.Key)
+	params.Set("oauth_nonce", hex.EncodeToString(nonce))
+	params.Set("oauth_signature_method", "HMAC-SHA1")
+	params.Set("oauth_timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+	params.Set("oauth_version", "1.0")
+	if c.Token != "" {
+		params.Set("oauth_token", c.Token)
+	}
+	params.Del("oauth_signature")
+
+	var pairs []string
+	for k, vs := range params {
+		for _, v := range vs {
+			pairs = append(pairs, escape(k)+"="+escape(v))
+		}
+	}
+	slices.Sort(pairs)
+	base := strings.ToUpper(method) + "&" + escape(u) + "&" + escape(strings.Join(pairs, "&"))
+
+	mac := hmac.New(sha1.New, []byte(escape(c.Secret)+"&"+escape(c.TokenSecret)))
+	mac.Write([]byte(base))
+	params.Set("oauth_signature", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+// escape percent-encodes s as OAuth requires, leaving only the unreserved
+// characters of RFC 3986
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// token gets a request or access token, signed with creds
+func token(ctx context.Context, u string, creds Credentials, params url.Values) (url.Values, error) {
+	creds.Sign(http.MethodGet, u, params)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpclient.New().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Flickr refused the token request with status %d: %.200s", resp.StatusCode, body)
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil || values.Get("oauth_token") == "" {
+		return nil, fmt.Errorf("unreadable token from Flickr: %.200s", body)
+	}
+	return values, nil
+}
+
+// Authorize signs the app of key and secret in to an account with delete
+// permission and returns its access token. show is given the address to
+// open; code then asks for the verification code Flickr shows once access
+// is allowed.
+func Authorize(ctx context.Context, key, secret string, show func(link string), code func() (string, error)) (Credentials, string, error) {
+	creds := Credentials{Key: key, Secret: secret}
+	req, err := token(ctx, requestToken, creds, url.Values{"oauth_callback": {"oob"}})
+	if err != nil {
+		return creds, "", err
+	}
+	show(authorizeURL + "?" + url.Values{"oauth_token": {req.Get("oauth_token")}, "perms": {"delete"}}.Encode())
+
+	verifier, err := code()
+	if err != nil {
+		return creds, "", err
+	}
+	creds.Token, creds.TokenSecret = req.Get("oauth_token"), req.Get("oauth_token_secret")
+	access, err := token(ctx, accessTokenURL, creds, url.Values{"oauth_verifier": {strings.TrimSpace(verifier)}})
+	if err != nil {
+		return creds, "", err
+	}
+	creds.Token, creds.TokenSecret = access.Get("oauth_token"), access.Get("oauth_token_secret")
+	return creds, access.Get("username"), nil
+}
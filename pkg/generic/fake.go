@@ -0,0 +1,133 @@
+package generic
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/spool"
+)
+
+// FakeConfig sets up the fake provider, which makes up content instead of
+// calling an API, for rehearsing filters, plans and schedules
+type FakeConfig struct {
+	// Volumes of each content type
+	Posts    int `json:"posts"`
+	Comments int `json:"comments"`
+	Tweets   int `json:"tweets"`
+
+	// Years is how far back the content goes, 10 by default
+	Years int `json:"years"`
+
+	// FailureRate is the share of deletes that fail, from 0 to 1
+	FailureRate float64 `json:"failure_rate"`
+
+	// Seed makes the same content every run
+	Seed uint64 `json:"seed"`
+
+	// RateLimit is the most deletions per minute, 6000 by default
+	RateLimit int `json:"rate_limit"`
+}
+
+// Rate returns the rate limit with its default
+func (f *FakeConfig) Rate() int {
+	if f.RateLimit == 0 {
+		return 6000
+	}
+	return f.RateLimit
+}
+
+// Types returns the fake content types, with "all" first
+func (f *FakeConfig) Types() []string {
+	return withAll([]string{"posts", "comments", "tweets"})
+}
+
+var fakeWords = strings.Fields("the a my this that just really never always today yesterday " +
+	"cat dog coffee code bug release weekend game movie book train rain server " +
+	"love hate think wish found broke fixed shipped read watched tried")
+
+// fakeBackend generates content from the seed, so a profile sees the same
+// items on every run
+type fakeBackend struct {
+	cfg *FakeConfig
+	end time.Time
+	rnd *rand.Rand
+}
+
+// NewFakeClient returns a client for made-up content
+func NewFakeClient(cfg *FakeConfig, output io.Writer) *Client {
+	b := &fakeBackend{
+		cfg: cfg,
+		end: time.Now().UTC().Truncate(24 * time.Hour),
+		rnd: rand.New(rand.NewPCG(cfg.Seed, cfg.Seed^0x5eed)),
+	}
+	return newClient("fake", cfg.Types(), cfg.Rate(), b, output)
+}
+
+func (b *fakeBackend) count(kind string) int {
+	switch kind {
+	case "posts":
+		return b.cfg.Posts
+	case "comments":
+		return b.cfg.Comments
+	default:
+		return b.cfg.Tweets
+	}
+}
+
+func (b *fakeBackend) list(ctx context.Context, c *Client, kind string, q *spool.Queue[Item]) error {
+	years := b.cfg.Years
+	if years == 0 {
+		years = 10
+	}
+	span := b.end.Sub(b.end.AddDate(-years, 0, 0))
+
+	// Each type has its own sequence, so changing one volume leaves the
+	// others alone
+	rnd := rand.New(rand.NewPCG(b.cfg.Seed, uint64(slices.Index(b.cfg.Types(), kind))))
+	for i := range b.count(kind) {
+		words := make([]string, 3+rnd.IntN(12))
+		for w := range words {
+			words[w] = fakeWords[rnd.IntN(len(fakeWords))]
+		}
+		id := fmt.Sprintf("%s-%06d", strings.TrimSuffix(kind, "s"), i+1)
+		err := q.Push(Item{
+			ID:   id,
+			Kind: kind,
+			Date: b.end.Add(-time.Duration(rnd.Int64N(int64(span)))),
+			Text: strings.Join(words, " "),
+			URL:  "https://fake.invalid/" + id,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *fakeBackend) delete(ctx context.Context, c *Client, it Item) (audit.Receipt, error) {
+	r := audit.Receipt{
+		Time:     time.Now(),
+		Platform: c.name,
+		Kind:     it.Kind,
+		ID:       it.ID,
+		Method:   "DELETE",
+		URL:      it.URL,
+		Status:   200,
+		Body:     `{"deleted":true}`,
+	}
+	if b.rnd.Float64() < b.cfg.FailureRate {
+		r.Status, r.Body = 500, `{"error":"simulated failure"}`
+		return r, fmt.Errorf("simulated failure")
+	}
+	return r, nil
+}
+
+func (b *fakeBackend) check(ctx context.Context, c *Client, kind string) error {
+	return nil
+}
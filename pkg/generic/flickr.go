@@ -0,0 +1,348 @@
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/flickr"
+	"go-del-socials/pkg/spool"
+)
+
+// FlickrConfig is a profile's flickr section
+type FlickrConfig struct {
+	// APIKey and APISecret are the key of a Flickr app
+	APIKey    string `json:"api_key"`
+	APISecret string `json:"api_secret"`
+
+	// OAuthToken and OAuthTokenSecret authorize the app to the account
+	// with delete permission, as flickr-authorize prints them
+	OAuthToken       string `json:"oauth_token"`
+	OAuthTokenSecret string `json:"oauth_token_secret"`
+
+	// Albums, when set, limits photo deletion to the photos in these
+	// albums, given by title or ID
+	Albums []string `json:"albums"`
+
+	// ExcludeAlbums are albums, by title or ID, whose photos are never
+	// deleted
+	ExcludeAlbums []string `json:"exclude_albums"`
+
+	// RateLimit is the most deletions per minute, 30 by default
+	RateLimit int `json:"rate_limit"`
+}
+
+// Rate returns the rate limit with its default
+func (f *FlickrConfig) Rate() int {
+	if f.RateLimit == 0 {
+		return 30
+	}
+	return f.RateLimit
+}
+
+// FlickrTypes are the content types of Flickr, with "all" first
+func FlickrTypes() []string {
+	return withAll([]string{"photos", "comments"})
+}
+
+// flickrPage is the most photos a page of people.getPhotos holds
+const flickrPage = 500
+
+// flickrBackend deletes the account's photos and its comments on photos.
+// Flickr can't list the comments a user made, so they are found on the
+// photos of the account's recent comment activity.
+type flickrBackend struct {
+	cfg   *FlickrConfig
+	creds flickr.Credentials
+	user  string
+
+	// included and excluded are the photos of the album filters, loaded
+	// with the first listing of photos
+	included, excluded map[string]bool
+}
+
+// NewFlickrClient returns a client for the account of cfg, whose secrets
+// must already be resolved
+func NewFlickrClient(cfg *FlickrConfig, output io.Writer) *Client {
+	b := &flickrBackend{cfg: cfg, creds: flickr.Credentials{
+		Key: cfg.APIKey, Secret: cfg.APISecret, Token: cfg.OAuthToken, TokenSecret: cfg.OAuthTokenSecret,
+	}}
+	return newClient("flickr", FlickrTypes(), cfg.Rate(), b, output)
+}
+
+// flickrError is a call Flickr answered with stat "fail", which it does
+// with status 200
+type flickrError struct {
+	Method  string
+	Code    int
+	Message string
+}
+
+func (e *flickrError) Error() string {
+	return fmt.Sprintf("%s failed: %s (code %d)", e.Method, e.Message, e.Code)
+}
+
+// call signs and sends an API method, decoding its answer into out. Writes
+// are POSTed as forms.
+func (b *flickrBackend) call(ctx context.Context, c *Client, httpMethod, method string, params url.Values, out any) (string, error) {
+	params.Set("method", method)
+	params.Set("format", "json")
+	params.Set("nojsoncallback", "1")
+	b.creds.Sign(httpMethod, flickr.API, params)
+
+	u, body, header := flickr.API+"?"+params.Encode(), "", http.Header(nil)
+	if httpMethod != http.MethodGet {
+		u, body = flickr.API, params.Encode()
+		header = http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}
+	}
+	resp, _, err := c.request(ctx, httpMethod, u, body, header)
+	if err != nil {
+		return resp, err
+	}
+	// Writes of simulated runs aren't sent, so there's no answer to read
+	if c.simulate && httpMethod != http.MethodGet {
+		return resp, nil
+	}
+
+	var stat struct {
+		Stat    string `json:"stat"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(resp), &stat); err != nil {
+		return resp, fmt.Errorf("unreadable answer to %s: %.200s", method, resp)
+	}
+	if stat.Stat != "ok" {
+		return resp, &flickrError{method, stat.Code, stat.Message}
+	}
+	if out != nil {
+		if err := json.Unmarshal([]byte(resp), out); err != nil {
+			return resp, fmt.Errorf("unreadable answer to %s: %v", method, err)
+		}
+	}
+	return resp, nil
+}
+
+// login finds the token's account
+func (b *flickrBackend) login(ctx context.Context, c *Client) error {
+	var login struct {
+		User struct {
+			ID       string `json:"id"`
+			Username struct {
+				Content string `json:"_content"`
+			} `json:"username"`
+		} `json:"user"`
+	}
+	if _, err := b.call(ctx, c, http.MethodGet, "flickr.test.login", url.Values{}, &login); err != nil {
+		return fmt.Errorf("OAuth token rejected: %v", err)
+	}
+	b.user = login.User.ID
+	c.printf("Signed in to Flickr as %s\n", login.User.Username.Content)
+	return nil
+}
+
+// flickrAlbum is an album as photosets.getList gives it
+type flickrAlbum struct {
+	ID    string `json:"id"`
+	Title struct {
+		Content string `json:"_content"`
+	} `json:"title"`
+}
+
+// albums loads the photos of the included and excluded albums
+func (b *flickrBackend) albums(ctx context.Context, c *Client) error {
+	if len(b.cfg.Albums) == 0 && len(b.cfg.ExcludeAlbums) == 0 {
+		return nil
+	}
+	var sets struct {
+		Photosets struct {
+			Photoset []flickrAlbum `json:"photoset"`
+		} `json:"photosets"`
+	}
+	if _, err := b.call(ctx, c, http.MethodGet, "flickr.photosets.getList", url.Values{"user_id": {b.user}, "per_page": {"500"}}, &sets); err != nil {
+		return err
+	}
+	load := func(names []string) (map[string]bool, error) {
+		if len(names) == 0 {
+			return nil, nil
+		}
+		photos := map[string]bool{}
+		for _, name := range names {
+			i := slices.IndexFunc(sets.Photosets.Photoset, func(a flickrAlbum) bool {
+				return a.ID == name || a.Title.Content == name
+			})
+			if i < 0 {
+				return nil, fmt.Errorf("no album %q", name)
+			}
+			for page := 1; ; page++ {
+				var set struct {
+					Photoset struct {
+						Photo []struct {
+							ID string `json:"id"`
+						} `json:"photo"`
+						Pages int `json:"pages"`
+					} `json:"photoset"`
+				}
+				params := url.Values{"photoset_id": {sets.Photosets.Photoset[i].ID}, "user_id": {b.user}, "per_page": {"500"}, "page": {strconv.Itoa(page)}}
+				if _, err := b.call(ctx, c, http.MethodGet, "flickr.photosets.getPhotos", params, &set); err != nil {
+					return nil, err
+				}
+				for _, p := range set.Photoset.Photo {
+					photos[p.ID] = true
+				}
+				if page >= set.Photoset.Pages {
+					break
+				}
+			}
+		}
+		return photos, nil
+	}
+	var err error
+	if b.included, err = load(b.cfg.Albums); err != nil {
+		return err
+	}
+	b.excluded, err = load(b.cfg.ExcludeAlbums)
+	return err
+}
+
+// unixTime reads the Unix times in strings that Flickr dates are given as
+func unixTime(s string) time.Time {
+	secs, _ := strconv.ParseInt(s, 10, 64)
+	return time.Unix(secs, 0).UTC()
+}
+
+func (b *flickrBackend) list(ctx context.Context, c *Client, kind string, q *spool.Queue[Item]) error {
+	if b.user == "" {
+		if err := b.login(ctx, c); err != nil {
+			return err
+		}
+	}
+	if kind == "comments" {
+		return b.listComments(ctx, c, q)
+	}
+
+	if b.included == nil && b.excluded == nil {
+		if err := b.albums(ctx, c); err != nil {
+			return err
+		}
+	}
+	filtered := 0
+	for page := 1; ; page++ {
+		var photos struct {
+			Photos struct {
+				Pages int `json:"pages"`
+				Photo []struct {
+					ID         string `json:"id"`
+					Title      string `json:"title"`
+					DateUpload string `json:"dateupload"`
+				} `json:"photo"`
+			} `json:"photos"`
+		}
+		params := url.Values{"user_id": {"me"}, "extras": {"date_upload"}, "per_page": {strconv.Itoa(flickrPage)}, "page": {strconv.Itoa(page)}}
+		if _, err := b.call(ctx, c, http.MethodGet, "flickr.people.getPhotos", params, &photos); err != nil {
+			return err
+		}
+		for _, p := range photos.Photos.Photo {
+			if (b.included != nil && !b.included[p.ID]) || b.excluded[p.ID] {
+				filtered++
+				continue
+			}
+			it := Item{ID: p.ID, Kind: "photos", Date: unixTime(p.DateUpload), Text: p.Title, URL: fmt.Sprintf("https://www.flickr.com/photos/%s/%s", b.user, p.ID)}
+			if err := q.Push(it); err != nil {
+				return err
+			}
+		}
+		if page >= photos.Photos.Pages {
+			break
+		}
+	}
+	if filtered > 0 {
+		c.printf("Left out %d photos by the album filters\n", filtered)
+	}
+	return nil
+}
+
+// listComments finds the account's comments on the photos it recently
+// commented on
+func (b *flickrBackend) listComments(ctx context.Context, c *Client, q *spool.Queue[Item]) error {
+	for page := 1; ; page++ {
+		var activity struct {
+			Items struct {
+				Pages int `json:"pages"`
+				Item  []struct {
+					Type string `json:"type"`
+					ID   string `json:"id"`
+				} `json:"item"`
+			} `json:"items"`
+		}
+		params := url.Values{"per_page": {"50"}, "page": {strconv.Itoa(page)}}
+		if _, err := b.call(ctx, c, http.MethodGet, "flickr.activity.userComments", params, &activity); err != nil {
+			return err
+		}
+		for _, photo := range activity.Items.Item {
+			if photo.Type != "photo" {
+				continue
+			}
+			var comments struct {
+				Comments struct {
+					Comment []struct {
+						ID         string `json:"id"`
+						Author     string `json:"author"`
+						DateCreate string `json:"datecreate"`
+						Permalink  string `json:"permalink"`
+						Content    string `json:"_content"`
+					} `json:"comment"`
+				} `json:"comments"`
+			}
+			if _, err := b.call(ctx, c, http.MethodGet, "flickr.photos.comments.getList", url.Values{"photo_id": {photo.ID}}, &comments); err != nil {
+				return err
+			}
+			for _, cm := range comments.Comments.Comment {
+				if cm.Author != b.user {
+					continue
+				}
+				it := Item{ID: cm.ID, Kind: "comments", Date: unixTime(cm.DateCreate), Text: cm.Content, URL: cm.Permalink}
+				if err := q.Push(it); err != nil {
+					return err
+				}
+			}
+		}
+		if page >= activity.Items.Pages {
+			return nil
+		}
+	}
+}
+
+func (b *flickrBackend) delete(ctx context.Context, c *Client, it Item) (audit.Receipt, error) {
+	method, params := "flickr.photos.delete", url.Values{"photo_id": {it.ID}}
+	if it.Kind == "comments" {
+		method, params = "flickr.photos.comments.deleteComment", url.Values{"comment_id": {it.ID}}
+	}
+	r := audit.Receipt{Time: time.Now(), Platform: c.name, Kind: it.Kind, ID: it.ID, Method: http.MethodPost, URL: flickr.API + "?method=" + method}
+	body, err := b.call(ctx, c, http.MethodPost, method, params, nil)
+	r.Body = body
+	var e *flickrError
+	switch {
+	case err == nil:
+		r.Status = http.StatusOK
+	case errors.As(err, &e) && e.Code == 1:
+		// Code 1 is "Photo not found" or "Comment not found"
+		r.Status = http.StatusNotFound
+	}
+	return r, err
+}
+
+func (b *flickrBackend) check(ctx context.Context, c *Client, kind string) error {
+	if b.cfg.OAuthToken == "" {
+		return errors.New("no OAuth token")
+	}
+	return b.login(ctx, c)
+}
@@ -0,0 +1,590 @@
+package generic
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/export"
+	"go-del-socials/pkg/filter"
+	"go-del-socials/pkg/hook"
+	"go-del-socials/pkg/httpclient"
+	"go-del-socials/pkg/index"
+	"go-del-socials/pkg/inventory"
+	"go-del-socials/pkg/pace"
+	"go-del-socials/pkg/pause"
+	"go-del-socials/pkg/pipeline"
+	"go-del-socials/pkg/plan"
+	"go-del-socials/pkg/spool"
+	"go-del-socials/pkg/tombstone"
+)
+
+// A listing keeps up to spoolItems items in memory and spills the rest to
+// disk; it is deleted pageSize items at a time
+const (
+	spoolItems = 10000
+	pageSize   = 100
+)
+
+type DeleteOptions struct {
+	ContentType string
+	CutoffDate  time.Time
+
+	// Plan, when set, records matching items instead of acting on them
+	Plan *plan.Set
+
+	// Approved, when set, limits deletion to the items of a reviewed plan
+	Approved *plan.Set
+
+	// Kept, when set, receives the listed items that stay online
+	Kept *inventory.List
+
+	// Tombstones, when set, records every deleted item
+	Tombstones *tombstone.Index
+
+	// Index, when set, adds the items of an imported data export that the
+	// listings didn't return, and records which are deleted
+	Index *index.Index
+
+	// Receipts, when set, records the API's raw answer to every delete
+	Receipts *audit.Log
+
+	// Hooks, when set, may veto each deletion and are told about every
+	// deleted item
+	Hooks *hook.Hooks
+
+	// Pause, when set, holds the run before each item while it is paused
+	Pause *pause.Gate
+
+	// Simulate acknowledges every delete without sending it. Receipts are
+	// marked as simulated.
+	Simulate bool
+
+	// Pace spaces out the listing pages and the deletes. Without it the
+	// deletes are only held to the client's rate limit.
+	Pace *pace.Pacer
+
+	// Targets, when set, are the items to delete instead of the listings.
+	// There's no looking them up, so they carry no date and the cutoff
+	// doesn't spare them.
+	Targets []Item
+
+	// KeepLast keeps the newest items of each content type by count,
+	// whatever the cutoff
+	KeepLast filter.KeepLast
+
+	// Retention overrides the cutoff for the items of some scopes
+	Retention filter.Retention
+}
+
+// Result counts what a DeleteContent run did
+type Result struct {
+	// Deleted counts deleted items by content type
+	Deleted map[string]int
+
+	Matched        int
+	Failed         int
+	NotPlanned     int
+	AlreadyDeleted int
+	AlreadyGone    int
+	Vetoed         int
+
+	// Unremovable counts items the platform doesn't let the API delete
+	Unremovable int
+}
+
+// Item is a piece of content returned by a list endpoint
+type Item struct {
+	ID   string
+	Kind string
+	Date time.Time
+	Text string
+	URL  string
+
+	// Scopes are the channel, room, community or hashtags the item belongs
+	// to, for retention rules
+	Scopes []string `json:",omitempty"`
+}
+
+// backend lists and deletes a platform's content for the client
+type backend interface {
+	// list queues every item of a content type
+	list(ctx context.Context, c *Client, kind string, q *spool.Queue[Item]) error
+	delete(ctx context.Context, c *Client, it Item) (audit.Receipt, error)
+
+	// check fetches the first page of a listing
+	check(ctx context.Context, c *Client, kind string) error
+}
+
+// unremovable is a delete the platform refuses for good, as opposed to one
+// that failed and may work another time
+type unremovable struct {
+	reason string
+}
+
+func (e *unremovable) Error() string {
+	return e.reason
+}
+
+// refuser is a backend that knows of some items that they can't be
+// deleted, without spending a delete on trying
+type refuser interface {
+	// refuse returns why it can't be deleted, or "" if it may be
+	refuse(it Item) string
+}
+
+// finisher is a backend with something to do once a run is over
+type finisher interface {
+	finish(c *Client)
+}
+
+// Client filters, paces and reports deletions, leaving the API calls to
+// its backend
+type Client struct {
+	name      string
+	types     []string
+	rateLimit int
+	backend   backend
+	http      *http.Client
+	output    io.Writer
+
+	// pace spaces out the deletes of a run to the rate limit
+	pace *pace.Pacer
+
+	// reads, when set, picks out the non-GET requests that only read, which
+	// go through in simulated runs
+	reads func(*http.Request) bool
+
+	// simulate is set for simulated runs, for backends that don't go
+	// through http
+	simulate bool
+
+	// newest holds the IDs of the items kept by count, by content type
+	newest map[string]map[string]bool
+}
+
+// NewClient returns a client for the spec. vars override the spec's own and
+// must already have their secrets resolved.
+func NewClient(spec *Spec, vars map[string]string, output io.Writer) *Client {
+	all := maps.Clone(spec.Vars)
+	if all == nil {
+		all = map[string]string{}
+	}
+	maps.Copy(all, vars)
+	return newClient(spec.Name, spec.ContentTypes(), spec.RateLimit, &specBackend{spec, all}, output)
+}
+
+func newClient(name string, types []string, rateLimit int, b backend, output io.Writer) *Client {
+	if output == nil {
+		output = os.Stdout
+	}
+	return &Client{
+		name:      name,
+		types:     types,
+		rateLimit: rateLimit,
+		backend:   b,
+		http:      httpclient.New(),
+		output:    output,
+	}
+}
+
+// Name returns the platform the client deletes from
+func (c *Client) Name() string {
+	return c.name
+}
+
+// RateLimit returns the platform's deletes per minute, 0 without a limit
+func (c *Client) RateLimit() int {
+	return c.rateLimit
+}
+
+func (c *Client) printf(format string, args ...any) {
+	fmt.Fprintf(c.output, format, args...)
+}
+
+// Check fetches the first page of a listing, which shows the API is
+// reachable and accepts the credentials
+func (c *Client) Check(ctx context.Context) error {
+	return c.backend.check(ctx, c, c.types[len(c.types)-1])
+}
+
+// DeleteContent deletes the items of the chosen content type posted before
+// the cutoff. Each type is listed in full before anything is deleted, so
+// deletions don't shift the pages still to be read.
+func (c *Client) DeleteContent(ctx context.Context, opts DeleteOptions) (*Result, error) {
+	result := &Result{Deleted: map[string]int{}}
+	c.pace = opts.Pace
+	if c.pace == nil {
+		c.pace = pace.New(0, 0, pace.NewLimiter(c.rateLimit, time.Minute, 1))
+	}
+	c.simulate = opts.Simulate
+	c.newest = map[string]map[string]bool{}
+	if opts.Simulate {
+		audit.Simulate(c.http, c.reads)
+	}
+	if f, ok := c.backend.(finisher); ok {
+		defer f.finish(c)
+	}
+
+	types := []string{opts.ContentType}
+	if opts.ContentType == "all" {
+		types = slices.DeleteFunc(slices.Clone(c.types), func(t string) bool { return t == "all" })
+	}
+
+	for _, kind := range types {
+		if !slices.Contains(c.types, kind) {
+			return result, fmt.Errorf("%s has no content type %s", c.name, kind)
+		}
+	}
+	for _, it := range opts.Targets {
+		if !slices.Contains(types, it.Kind) {
+			return result, fmt.Errorf("target %s is of content type %q, which the run doesn't delete", it.ID, it.Kind)
+		}
+	}
+
+	// A content type is listed in full before any of it is deleted, as
+	// deleting shifts the pages of some listings. The listing waits in a
+	// queue that spills to disk, and is handed on a page at a time; the
+	// cursor is the index of the type.
+	q := spool.New[Item](spoolItems)
+	defer q.Close()
+	listed := -1
+	p := &pipeline.Pipeline[Item]{
+		Fetch: func(ctx context.Context, cursor string) ([]Item, string, error) {
+			i, _ := strconv.Atoi(cursor)
+			if listed != i {
+				if err := c.queue(ctx, &opts, types[i], q); err != nil {
+					return nil, "", err
+				}
+				listed = i
+			}
+
+			var page []Item
+			for len(page) < pageSize {
+				it, ok, err := q.Pop()
+				if err != nil {
+					return page, "", err
+				}
+				if !ok {
+					break
+				}
+				page = append(page, it)
+			}
+			switch {
+			case q.Len() > 0:
+				return page, strconv.Itoa(i), nil
+			case i == len(types)-1:
+				return page, "", nil
+			}
+			return page, strconv.Itoa(i + 1), nil
+		},
+	}
+	// Imported items come after the listing and are skipped when it
+	// already had them
+	seen := map[string]bool{}
+	var processErr error
+	err := p.Run(ctx, func(ctx context.Context, items []Item) bool {
+		for _, it := range items {
+			if opts.Index != nil {
+				if seen[it.ID] {
+					continue
+				}
+				seen[it.ID] = true
+			}
+			if processErr = c.process(ctx, &opts, it, result); processErr != nil {
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return result, err
+	}
+	return result, processErr
+}
+
+func (c *Client) process(ctx context.Context, opts *DeleteOptions, it Item, result *Result) error {
+	if c.newest[it.Kind][it.ID] {
+		c.keep(opts, it, opts.KeepLast.Reason(it.Kind))
+		return nil
+	}
+	if cutoff, reason := opts.Retention.Cutoff(opts.CutoffDate, it.Scopes...); !it.Date.Before(cutoff) {
+		c.keep(opts, it, reason)
+		return nil
+	}
+	if opts.Tombstones != nil {
+		gone, err := opts.Tombstones.Has(c.name, it.ID)
+		if err != nil {
+			c.printf("Warning: %v\n", err)
+		}
+		if gone {
+			c.printf("Skipping %s %s: already deleted by an earlier run\n", it.Kind, it.ID)
+			result.AlreadyDeleted++
+			return nil
+		}
+	}
+
+	result.Matched++
+	if opts.Plan != nil {
+		opts.Plan.Add(plan.Item{ID: it.ID, Kind: it.Kind, Date: it.Date, Text: it.Text})
+		return nil
+	}
+	if opts.Approved != nil && !opts.Approved.Has(it.ID) {
+		c.printf("Refusing %s %s: it is not in the plan\n", it.Kind, it.ID)
+		result.NotPlanned++
+		c.keep(opts, it, "not in the plan")
+		return nil
+	}
+
+	opts.Pause.Wait(ctx)
+	hi := hook.Item{Platform: c.name, Kind: it.Kind, ID: it.ID, Date: it.Date, URL: it.URL, Text: it.Text}
+	if d := opts.Hooks.Before(ctx, hi); d.Veto {
+		c.printf("Keeping %s %s: %s\n", it.Kind, it.ID, d.Reason)
+		result.Vetoed++
+		c.keep(opts, it, d.Reason)
+		return nil
+	}
+
+	if r, ok := c.backend.(refuser); ok {
+		if reason := r.refuse(it); reason != "" {
+			c.refused(opts, it, reason, result)
+			return nil
+		}
+	}
+	if err := c.pace.Write(ctx); err != nil {
+		return err
+	}
+	c.printf("Attempting to delete %s %s (posted on %s)\n", it.Kind, it.ID, it.Date.Format("2006-01-02"))
+	receipt, err := c.backend.delete(ctx, c, it)
+	var u *unremovable
+	switch {
+	case errors.As(err, &u):
+		c.refused(opts, it, u.reason, result)
+	case err != nil && gone(receipt.Status):
+		c.printf("Skipping %s %s: already gone\n", it.Kind, it.ID)
+		result.AlreadyGone++
+		c.tombstone(opts, it)
+	case err != nil:
+		c.printf("Error deleting %s %s: %v\n", it.Kind, it.ID, err)
+		result.Failed++
+		c.keep(opts, it, "delete failed")
+	default:
+		result.Deleted[it.Kind]++
+		c.bury(opts, it, receipt)
+		if err := opts.Hooks.After(ctx, hi); err != nil {
+			c.printf("Warning: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// queue lists a content type into q, or takes its items from the targets
+func (c *Client) queue(ctx context.Context, opts *DeleteOptions, kind string, q *spool.Queue[Item]) error {
+	if len(opts.Targets) > 0 {
+		for _, it := range opts.Targets {
+			if it.Kind != kind {
+				continue
+			}
+			if err := q.Push(it); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	c.printf("\nListing %s...\n", kind)
+	if err := c.backend.list(ctx, c, kind, q); err != nil {
+		return err
+	}
+	if err := c.queueImported(opts, kind, q); err != nil {
+		return err
+	}
+	if n := opts.KeepLast.Count(kind); n > 0 {
+		newest, err := newestOf(q, n)
+		if err != nil {
+			return err
+		}
+		c.newest[kind] = newest
+	}
+	return nil
+}
+
+// newestOf returns the IDs of the n newest items in q. Listings don't all
+// come newest first, so the whole queue is read once and put back in order.
+func newestOf(q *spool.Queue[Item], n int) (map[string]bool, error) {
+	h := &byDate{}
+	held := map[string]bool{}
+	for range q.Len() {
+		it, _, err := q.Pop()
+		if err != nil {
+			return nil, err
+		}
+		if err := q.Push(it); err != nil {
+			return nil, err
+		}
+		// Imported items repeat the listed ones
+		if held[it.ID] {
+			continue
+		}
+		if h.Len() < n {
+			heap.Push(h, it)
+			held[it.ID] = true
+		} else if it.Date.After((*h)[0].Date) {
+			delete(held, (*h)[0].ID)
+			(*h)[0] = it
+			heap.Fix(h, 0)
+			held[it.ID] = true
+		}
+	}
+	return held, nil
+}
+
+// byDate is a heap of items with the oldest on top
+type byDate []Item
+
+func (h byDate) Len() int           { return len(h) }
+func (h byDate) Less(i, j int) bool { return h[i].Date.Before(h[j].Date) }
+func (h byDate) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *byDate) Push(x any)        { *h = append(*h, x.(Item)) }
+func (h *byDate) Pop() any {
+	old := *h
+	it := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return it
+}
+
+// queueImported queues the items of a content type that were imported into
+// the index from a data export and haven't been deleted yet
+func (c *Client) queueImported(opts *DeleteOptions, kind string, q *spool.Queue[Item]) error {
+	if opts.Index == nil {
+		return nil
+	}
+	return opts.Index.Live(c.name, kind, func(raw []byte) (bool, error) {
+		var it export.Item
+		if err := json.Unmarshal(raw, &it); err != nil {
+			return false, fmt.Errorf("corrupt imported item: %v", err)
+		}
+		text := it.Text
+		if text == "" {
+			text = it.Title
+		}
+		return true, q.Push(Item{ID: it.ID, Kind: kind, Date: it.Date, Text: text, URL: it.URL})
+	})
+}
+
+// refused records an item the platform doesn't let the API delete
+func (c *Client) refused(opts *DeleteOptions, it Item, reason string, result *Result) {
+	c.printf("Can't delete %s %s: %s\n", it.Kind, it.ID, reason)
+	result.Unremovable++
+	c.keep(opts, it, reason)
+}
+
+// gone reports whether a delete's status says the item no longer exists. A
+// bad request says nothing of the item, so it stays a failure.
+func gone(status int) bool {
+	return status == http.StatusNotFound || status == http.StatusGone
+}
+
+// keep records an item that stays online, and why, in the kept inventory
+func (c *Client) keep(opts *DeleteOptions, it Item, reason string) {
+	if opts.Kept == nil {
+		return
+	}
+	opts.Kept.Add(inventory.Item{ID: it.ID, Kind: it.Kind, Date: it.Date, URL: it.URL, Text: it.Text, Reason: reason})
+}
+
+// bury records a deleted item and its receipt
+func (c *Client) bury(opts *DeleteOptions, it Item, receipt audit.Receipt) {
+	if opts.Receipts != nil {
+		receipt.Simulated = opts.Simulate
+		if err := opts.Receipts.Record(receipt); err != nil {
+			c.printf("Warning: %v\n", err)
+		}
+	}
+	c.tombstone(opts, it)
+}
+
+// tombstone records that an item is gone, so later runs skip it
+func (c *Client) tombstone(opts *DeleteOptions, it Item) {
+	if opts.Index != nil {
+		if err := opts.Index.MarkDeleted(c.name, it.ID); err != nil {
+			c.printf("Warning: %v\n", err)
+		}
+	}
+	if opts.Tombstones == nil {
+		return
+	}
+	err := opts.Tombstones.Record(tombstone.Tombstone{
+		Platform: c.name,
+		ID:       it.ID,
+		Kind:     it.Kind,
+		URL:      it.URL,
+		Text:     it.Text,
+		Created:  it.Date,
+	})
+	if err != nil {
+		c.printf("Warning: %v\n", err)
+	}
+}
+
+// request sends a request with the given headers and returns the response
+// body. Reads wait the pacer's delay first. Rate limited requests are
+// retried after the time the server asks for.
+func (c *Client) request(ctx context.Context, method, u, body string, header http.Header) (string, int, error) {
+	if method == http.MethodGet {
+		if err := c.pace.Pause(ctx); err != nil {
+			return "", 0, err
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, u, strings.NewReader(body))
+		if err != nil {
+			return "", 0, err
+		}
+		req.Header.Set("Accept", "application/json")
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return "", 0, err
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return "", resp.StatusCode, err
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < 3 {
+			c.pace.Limited()
+			wait, ok := httpclient.RetryAfter(resp.Header)
+			if !ok {
+				wait = time.Minute
+			}
+			c.printf("Rate limit reached. Waiting %v...\n", wait)
+			if err := pace.Wait(ctx, wait); err != nil {
+				return "", 0, err
+			}
+			continue
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return string(data), resp.StatusCode, fmt.Errorf("%s %s returned status %d: %.200s", method, u, resp.StatusCode, data)
+		}
+		return string(data), resp.StatusCode, nil
+	}
+}
@@ -0,0 +1,41 @@
+package generic
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/spool"
+)
+
+// GoodreadsTypes are the content types of Goodreads, with "all" first
+func GoodreadsTypes() []string {
+	return withAll([]string{"reviews", "ratings"})
+}
+
+// goodreadsBackend goes through the reviews and ratings of an imported
+// Goodreads library export. Goodreads closed its API, so none can be
+// deleted; each is reported with the page to delete it on.
+type goodreadsBackend struct{}
+
+// NewGoodreadsClient returns a client for an imported Goodreads export
+func NewGoodreadsClient(output io.Writer) *Client {
+	return newClient("goodreads", GoodreadsTypes(), 0, goodreadsBackend{}, output)
+}
+
+func (goodreadsBackend) list(ctx context.Context, c *Client, kind string, q *spool.Queue[Item]) error {
+	return nil
+}
+
+func (goodreadsBackend) refuse(it Item) string {
+	return "Goodreads has no API to delete it; edit or delete it on https://www.goodreads.com/review/edit/" + it.ID
+}
+
+func (goodreadsBackend) delete(ctx context.Context, c *Client, it Item) (audit.Receipt, error) {
+	return audit.Receipt{}, errors.New("Goodreads has no API to delete with")
+}
+
+func (goodreadsBackend) check(ctx context.Context, c *Client, kind string) error {
+	return nil
+}
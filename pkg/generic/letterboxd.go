@@ -0,0 +1,174 @@
+package generic
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/spool"
+)
+
+// LetterboxdConfig is a profile's letterboxd section. The API is only open
+// to approved apps; without its key, items are reported for deleting on
+// the website.
+type LetterboxdConfig struct {
+	// APIKey and APISecret are the key of an approved API app
+	APIKey    string `json:"api_key"`
+	APISecret string `json:"api_secret"`
+
+	// Username and Password sign in to the account
+	Username string `json:"username"`
+	Password string `json:"password"`
+
+	// RateLimit is the most deletions per minute, 30 by default
+	RateLimit int `json:"rate_limit"`
+}
+
+// Rate returns the rate limit with its default
+func (l *LetterboxdConfig) Rate() int {
+	if l.RateLimit == 0 {
+		return 30
+	}
+	return l.RateLimit
+}
+
+// LetterboxdTypes are the content types of Letterboxd, with "all" first
+func LetterboxdTypes() []string {
+	return withAll([]string{"reviews", "ratings", "comments"})
+}
+
+// letterboxdAPI is the base of Letterboxd's API
+const letterboxdAPI = "https://api.letterboxd.com/api/v0/"
+
+// letterboxdBackend deletes the reviews and ratings of an imported
+// Letterboxd export. Neither the API nor the export can find a member's
+// comments by ID, so they are reported with the page they're on.
+type letterboxdBackend struct {
+	cfg   *LetterboxdConfig
+	token string
+
+	// warned is set once the run was told there's no API key
+	warned bool
+}
+
+// NewLetterboxdClient returns a client for the account of cfg, whose
+// secrets must already be resolved
+func NewLetterboxdClient(cfg *LetterboxdConfig, output io.Writer) *Client {
+	return newClient("letterboxd", LetterboxdTypes(), cfg.Rate(), &letterboxdBackend{cfg: cfg}, output)
+}
+
+// api reports whether the API's key is configured
+func (b *letterboxdBackend) api() bool {
+	return b.cfg.APIKey != ""
+}
+
+// call signs and sends an API request. Every request carries the app's key,
+// a nonce and a timestamp, and is signed with the app's secret over its
+// method, URL and body.
+func (b *letterboxdBackend) call(ctx context.Context, c *Client, method, path string, params url.Values, body, contentType string) (string, int, error) {
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("apikey", b.cfg.APIKey)
+	params.Set("nonce", hex.EncodeToString(nonce))
+	params.Set("timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+	u := letterboxdAPI + path + "?" + params.Encode()
+
+	mac := hmac.New(sha256.New, []byte(b.cfg.APISecret))
+	mac.Write([]byte(method + "\x00" + u + "\x00" + body))
+	u += "&signature=" + hex.EncodeToString(mac.Sum(nil))
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	if b.token != "" {
+		header.Set("Authorization", "Bearer "+b.token)
+	}
+	return c.request(ctx, method, u, body, header)
+}
+
+// login signs in to the account
+func (b *letterboxdBackend) login(ctx context.Context, c *Client) error {
+	form := url.Values{"grant_type": {"password"}, "username": {b.cfg.Username}, "password": {b.cfg.Password}}
+	body, status, err := b.call(ctx, c, http.MethodPost, "auth/token", nil, form.Encode(), "application/x-www-form-urlencoded")
+	if status == http.StatusBadRequest || status == http.StatusUnauthorized {
+		return fmt.Errorf("sign in refused: check the username and password, and the API key")
+	}
+	if err != nil {
+		return err
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal([]byte(body), &token); err != nil || token.AccessToken == "" {
+		return fmt.Errorf("unreadable token from Letterboxd: %.200s", body)
+	}
+	b.token = token.AccessToken
+	return nil
+}
+
+// list adds nothing: the items come from an imported export, as the API
+// can't list a member's ratings by their date or its comments at all
+func (b *letterboxdBackend) list(ctx context.Context, c *Client, kind string, q *spool.Queue[Item]) error {
+	if !b.api() && !b.warned {
+		c.printf("No Letterboxd API key: items of the imported export are listed for deleting on the website\n")
+		b.warned = true
+	} else if b.api() && b.token == "" {
+		if err := b.login(ctx, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *letterboxdBackend) refuse(it Item) string {
+	switch {
+	case it.Kind == "comments":
+		return "Letterboxd's export gives comments no ID; delete it on " + it.URL
+	case !b.api():
+		return "no Letterboxd API key; delete it on " + it.URL
+	}
+	return ""
+}
+
+func (b *letterboxdBackend) delete(ctx context.Context, c *Client, it Item) (audit.Receipt, error) {
+	r := audit.Receipt{Time: time.Now(), Platform: c.name, Kind: it.Kind, ID: it.ID}
+	var body string
+	var err error
+	if it.Kind == "reviews" {
+		r.Method, r.URL = http.MethodDelete, letterboxdAPI+"log-entry/"+url.PathEscape(it.ID)
+		body, r.Status, err = b.call(ctx, c, http.MethodDelete, "log-entry/"+url.PathEscape(it.ID), nil, "", "")
+	} else {
+		r.Method, r.URL = http.MethodPatch, letterboxdAPI+"film/"+url.PathEscape(it.ID)+"/me"
+		body, r.Status, err = b.call(ctx, c, http.MethodPatch, "film/"+url.PathEscape(it.ID)+"/me", nil, `{"rating":null}`, "application/json")
+	}
+	r.Body = body
+	return r, err
+}
+
+func (b *letterboxdBackend) check(ctx context.Context, c *Client, kind string) error {
+	if !b.api() {
+		return nil
+	}
+	if b.cfg.Username == "" || b.cfg.Password == "" {
+		return errors.New("no username or password")
+	}
+	if err := b.login(ctx, c); err != nil {
+		return err
+	}
+	_, _, err := b.call(ctx, c, http.MethodGet, "me", nil, "", "")
+	return err
+}
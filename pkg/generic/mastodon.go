@@ -0,0 +1,256 @@
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/export"
+	"go-del-socials/pkg/spool"
+)
+
+// MastodonConfig is a profile's mastodon section. Pleroma, Akkoma and
+// GoToSocial serve the same API, with quirks of their own.
+type MastodonConfig struct {
+	// Instance is the server's base URL, e.g. https://mastodon.social
+	Instance string `json:"instance"`
+
+	// AccessToken has the read and write scopes
+	AccessToken string `json:"access_token"`
+
+	// RateLimit is the most deletions per minute; the default depends on
+	// the server's software
+	RateLimit int `json:"rate_limit"`
+}
+
+// MastodonTypes are the content types of the Mastodon API, named as in
+// account exports, with "all" first
+func MastodonTypes() []string {
+	return withAll([]string{"statuses", "reblogs"})
+}
+
+// Software is the server software of a fediverse instance
+type Software struct {
+	Name    string
+	Version string
+}
+
+// The software the provider knows the quirks of
+const (
+	Mastodon   = "mastodon"
+	Pleroma    = "pleroma"
+	Akkoma     = "akkoma"
+	GoToSocial = "gotosocial"
+)
+
+// rate is the most deletions a minute the software allows by default.
+// Mastodon allows 30 deletes per 30 minutes; Pleroma and Akkoma don't limit
+// deletes apart from other requests, and GoToSocial allows 300 requests per
+// 5 minutes in all, which listing shares.
+func (s Software) rate() int {
+	switch s.Name {
+	case Pleroma, Akkoma:
+		return 30
+	case GoToSocial:
+		return 40
+	default:
+		return 1
+	}
+}
+
+// DetectSoftware finds the software an instance runs from its nodeinfo,
+// falling back to the version of /api/v1/instance, which Pleroma and
+// Akkoma give as "2.7.2 (compatible; Pleroma 2.5.0)". Unknown software is
+// treated as Mastodon.
+func DetectSoftware(ctx context.Context, instance string) (Software, error) {
+	c := newClient("mastodon", nil, 0, nil, io.Discard)
+	base := strings.TrimRight(instance, "/")
+
+	var links struct {
+		Links []struct {
+			Rel  string `json:"rel"`
+			Href string `json:"href"`
+		} `json:"links"`
+	}
+	if body, _, err := c.request(ctx, http.MethodGet, base+"/.well-known/nodeinfo", "", nil); err == nil && json.Unmarshal([]byte(body), &links) == nil {
+		for _, l := range links.Links {
+			if !strings.HasPrefix(l.Rel, "http://nodeinfo.diaspora.software/ns/schema/") {
+				continue
+			}
+			var info struct {
+				Software Software `json:"software"`
+			}
+			body, _, err := c.request(ctx, http.MethodGet, l.Href, "", nil)
+			if err == nil && json.Unmarshal([]byte(body), &info) == nil && info.Software.Name != "" {
+				info.Software.Name = strings.ToLower(info.Software.Name)
+				return info.Software, nil
+			}
+		}
+	}
+
+	var inst struct {
+		Version string `json:"version"`
+	}
+	body, _, err := c.request(ctx, http.MethodGet, base+"/api/v1/instance", "", nil)
+	if err != nil {
+		return Software{}, fmt.Errorf("failed to reach %s: %v", base, err)
+	}
+	if err := json.Unmarshal([]byte(body), &inst); err != nil {
+		return Software{}, fmt.Errorf("%s doesn't serve the Mastodon API: %v", base, err)
+	}
+	for _, name := range []string{Pleroma, Akkoma} {
+		if i := strings.Index(strings.ToLower(inst.Version), name+" "); i >= 0 {
+			return Software{name, strings.TrimSuffix(inst.Version[i+len(name)+1:], ")")}, nil
+		}
+	}
+	return Software{Mastodon, inst.Version}, nil
+}
+
+// mastodonPage is the most statuses a page of an account's statuses holds
+const mastodonPage = 40
+
+// mastodonBackend deletes the account's statuses and undoes its boosts.
+// IDs are opaque: Mastodon's are numbers, but Pleroma's and Akkoma's are
+// flake IDs and GoToSocial's ULIDs, so pages are followed from the last
+// ID of the one before rather than by comparing them.
+type mastodonBackend struct {
+	cfg      *MastodonConfig
+	base     string
+	software Software
+	account  string
+
+	// reblogged is the boosted status of each listed boost, which Pleroma
+	// and Akkoma need to undo it
+	reblogged map[string]string
+}
+
+// NewMastodonClient returns a client for the account of cfg on an instance
+// running software, whose access token must already have its secret
+// resolved
+func NewMastodonClient(cfg *MastodonConfig, software Software, output io.Writer) *Client {
+	rate := cfg.RateLimit
+	if rate == 0 {
+		rate = software.rate()
+	}
+	b := &mastodonBackend{cfg: cfg, base: strings.TrimRight(cfg.Instance, "/"), software: software, reblogged: map[string]string{}}
+	return newClient("mastodon", MastodonTypes(), rate, b, output)
+}
+
+func (b *mastodonBackend) header() http.Header {
+	return http.Header{"Authorization": {"Bearer " + b.cfg.AccessToken}}
+}
+
+// verify finds the token's account
+func (b *mastodonBackend) verify(ctx context.Context, c *Client) error {
+	var account struct {
+		ID string `json:"id"`
+	}
+	body, _, err := c.request(ctx, http.MethodGet, b.base+"/api/v1/accounts/verify_credentials", "", b.header())
+	if err != nil {
+		return fmt.Errorf("access token rejected: %v", err)
+	}
+	if err := json.Unmarshal([]byte(body), &account); err != nil || account.ID == "" {
+		return fmt.Errorf("unreadable account from %s: %.200s", b.base, body)
+	}
+	b.account = account.ID
+	return nil
+}
+
+func (b *mastodonBackend) list(ctx context.Context, c *Client, kind string, q *spool.Queue[Item]) error {
+	if b.account == "" {
+		if err := b.verify(ctx, c); err != nil {
+			return err
+		}
+		c.printf("Server runs %s %s\n", b.software.Name, b.software.Version)
+	}
+
+	maxID := ""
+	for {
+		params := url.Values{"limit": {fmt.Sprint(mastodonPage)}}
+		if kind == "statuses" {
+			params.Set("exclude_reblogs", "true")
+		}
+		if maxID != "" {
+			params.Set("max_id", maxID)
+		}
+		body, _, err := c.request(ctx, http.MethodGet, b.base+"/api/v1/accounts/"+url.PathEscape(b.account)+"/statuses?"+params.Encode(), "", b.header())
+		if err != nil {
+			return err
+		}
+		var statuses []struct {
+			ID        string    `json:"id"`
+			CreatedAt time.Time `json:"created_at"`
+			URL       string    `json:"url"`
+			URI       string    `json:"uri"`
+			Content   string    `json:"content"`
+			Tags      []struct {
+				Name string `json:"name"`
+			} `json:"tags"`
+			Reblog *struct {
+				ID      string `json:"id"`
+				URL     string `json:"url"`
+				URI     string `json:"uri"`
+				Content string `json:"content"`
+			} `json:"reblog"`
+		}
+		if err := json.Unmarshal([]byte(body), &statuses); err != nil {
+			return fmt.Errorf("unreadable statuses from %s: %v", b.base, err)
+		}
+		if len(statuses) == 0 {
+			return nil
+		}
+
+		for _, s := range statuses {
+			it := Item{ID: s.ID, Kind: kind, Date: s.CreatedAt, URL: s.URL, Text: export.PlainText(s.Content)}
+			for _, t := range s.Tags {
+				it.Scopes = append(it.Scopes, t.Name)
+			}
+			if it.URL == "" {
+				it.URL = s.URI
+			}
+			switch {
+			case s.Reblog == nil && kind == "statuses":
+			case s.Reblog != nil && kind == "reblogs":
+				b.reblogged[s.ID] = s.Reblog.ID
+				it.URL, it.Text = s.Reblog.URL, export.PlainText(s.Reblog.Content)
+				if it.URL == "" {
+					it.URL = s.Reblog.URI
+				}
+			default:
+				continue
+			}
+			if err := q.Push(it); err != nil {
+				return err
+			}
+		}
+		maxID = statuses[len(statuses)-1].ID
+	}
+}
+
+func (b *mastodonBackend) delete(ctx context.Context, c *Client, it Item) (audit.Receipt, error) {
+	method, u := http.MethodDelete, b.base+"/api/v1/statuses/"+url.PathEscape(it.ID)
+	// Pleroma and Akkoma tie a boost to the boosted status, and undo it
+	// from there. A boost only known from an imported export is deleted
+	// like a status, which Mastodon and GoToSocial take as undoing it.
+	if original, ok := b.reblogged[it.ID]; ok && (b.software.Name == Pleroma || b.software.Name == Akkoma) {
+		method, u = http.MethodPost, b.base+"/api/v1/statuses/"+url.PathEscape(original)+"/unreblog"
+	}
+	r := audit.Receipt{Time: time.Now(), Platform: c.name, Kind: it.Kind, ID: it.ID, Method: method, URL: u}
+	body, status, err := c.request(ctx, method, u, "", b.header())
+	r.Status, r.Body = status, body
+	return r, err
+}
+
+func (b *mastodonBackend) check(ctx context.Context, c *Client, kind string) error {
+	if b.cfg.AccessToken == "" {
+		return errors.New("no access token")
+	}
+	return b.verify(ctx, c)
+}
@@ -0,0 +1,227 @@
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/matrix"
+	"go-del-socials/pkg/spool"
+)
+
+// MatrixConfig is a profile's matrix section
+type MatrixConfig struct {
+	// Homeserver is the base URL of the account's homeserver, e.g.
+	// https://matrix.org
+	Homeserver string `json:"homeserver"`
+
+	// User and Password log in, unless AccessToken is set
+	User        string `json:"user"`
+	Password    string `json:"password"`
+	AccessToken string `json:"access_token"`
+
+	// Rooms are the room IDs or aliases to redact in; all joined rooms
+	// if empty
+	Rooms []string `json:"rooms"`
+
+	// RateLimit is the most redactions per minute, 30 by default
+	RateLimit int `json:"rate_limit"`
+}
+
+// Rate returns the rate limit with its default
+func (m *MatrixConfig) Rate() int {
+	if m.RateLimit == 0 {
+		return 30
+	}
+	return m.RateLimit
+}
+
+// MatrixTypes are the content types of Matrix, with "all" first
+func MatrixTypes() []string {
+	return withAll([]string{"messages"})
+}
+
+// matrixPage is how many events a /messages request asks for
+const matrixPage = 100
+
+// matrixBackend redacts the user's messages room by room. Items are
+// identified as <room id>/<event id>, as redacting needs both.
+type matrixBackend struct {
+	cfg    *MatrixConfig
+	client *matrix.Client
+
+	// loggedIn is set when the run logged in, and so made a device to
+	// log out of again
+	loggedIn bool
+}
+
+// NewMatrixClient returns a client for the account of cfg, whose secrets
+// must already be resolved
+func NewMatrixClient(cfg *MatrixConfig, output io.Writer) *Client {
+	c := newClient("matrix", MatrixTypes(), cfg.Rate(), &matrixBackend{cfg: cfg}, output)
+	// Logging in and out only manage the run's session, so they go
+	// through in simulated runs
+	c.reads = func(req *http.Request) bool {
+		return strings.HasSuffix(req.URL.Path, "/login") || strings.HasSuffix(req.URL.Path, "/logout")
+	}
+	return c
+}
+
+// connect logs in, or checks the access token, once per run
+func (b *matrixBackend) connect(ctx context.Context, c *Client) (*matrix.Client, error) {
+	if b.client != nil {
+		return b.client, nil
+	}
+	if b.cfg.AccessToken != "" {
+		mc := &matrix.Client{BaseURL: strings.TrimRight(b.cfg.Homeserver, "/"), AccessToken: b.cfg.AccessToken, HTTPClient: c.http}
+		if err := mc.WhoAmI(ctx); err != nil {
+			return nil, fmt.Errorf("access token rejected: %v", err)
+		}
+		b.client = mc
+		return mc, nil
+	}
+
+	mc, err := matrix.Login(ctx, b.cfg.Homeserver, map[string]any{
+		"type":                        "m.login.password",
+		"identifier":                  map[string]any{"type": "m.id.user", "user": b.cfg.User},
+		"password":                    b.cfg.Password,
+		"initial_device_display_name": "go-del-socials",
+	}, c.http)
+	if err != nil {
+		return nil, err
+	}
+	b.client, b.loggedIn = mc, true
+	return mc, nil
+}
+
+// rooms returns the IDs of the rooms to redact in
+func (b *matrixBackend) rooms(ctx context.Context, mc *matrix.Client) ([]string, error) {
+	if len(b.cfg.Rooms) == 0 {
+		rooms, err := mc.JoinedRooms(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list rooms: %v", err)
+		}
+		return rooms, nil
+	}
+	var rooms []string
+	for _, r := range b.cfg.Rooms {
+		if strings.HasPrefix(r, "#") {
+			id, err := mc.ResolveAlias(ctx, r)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find room %s: %v", r, err)
+			}
+			r = id
+		}
+		rooms = append(rooms, r)
+	}
+	return rooms, nil
+}
+
+func (b *matrixBackend) list(ctx context.Context, c *Client, kind string, q *spool.Queue[Item]) error {
+	mc, err := b.connect(ctx, c)
+	if err != nil {
+		return err
+	}
+	rooms, err := b.rooms(ctx, mc)
+	if err != nil {
+		return err
+	}
+	c.printf("Found %d rooms\n", len(rooms))
+
+	for _, room := range rooms {
+		// Rooms can raise the level needed to redact even one's own
+		// messages above the user's; nothing there could be redacted
+		ok, have, need, err := mc.CanRedactOwn(ctx, room)
+		if err != nil {
+			c.printf("Warning: skipping room %s: can't read its power levels: %v\n", room, err)
+			continue
+		}
+		if !ok {
+			c.printf("Skipping room %s: redacting needs power level %d, and you have %d\n", room, need, have)
+			continue
+		}
+
+		from := ""
+		for {
+			events, next, err := mc.MessagesBy(ctx, room, mc.UserID, from, matrixPage)
+			if err != nil {
+				return fmt.Errorf("failed to read room %s: %v", room, err)
+			}
+			for _, ev := range events {
+				if ev.Sender != mc.UserID || ev.Type != "m.room.message" || ev.Redacted() {
+					continue
+				}
+				var content struct {
+					Body string `json:"body"`
+				}
+				json.Unmarshal(ev.Content, &content)
+				err := q.Push(Item{
+					ID:   room + "/" + ev.EventID,
+					Kind: kind,
+					Date: ev.Time(),
+					Text: content.Body,
+					URL:  "https://matrix.to/#/" + room + "/" + ev.EventID,
+
+					Scopes: []string{room},
+				})
+				if err != nil {
+					return err
+				}
+			}
+			if next == "" {
+				break
+			}
+			from = next
+		}
+	}
+	return nil
+}
+
+func (b *matrixBackend) delete(ctx context.Context, c *Client, it Item) (audit.Receipt, error) {
+	r := audit.Receipt{Time: time.Now(), Platform: c.name, Kind: it.Kind, ID: it.ID, Method: "PUT", URL: it.URL}
+	room, event, ok := strings.Cut(it.ID, "/")
+	if !ok {
+		return r, fmt.Errorf("%s is not a <room id>/<event id>", it.ID)
+	}
+	mc, err := b.connect(ctx, c)
+	if err != nil {
+		return r, err
+	}
+
+	err = mc.Redact(ctx, room, event, "")
+	var apiErr *matrix.Error
+	switch {
+	case errors.As(err, &apiErr):
+		r.Status, r.Body = apiErr.StatusCode, fmt.Sprintf(`{"errcode":%q,"error":%q}`, apiErr.ErrCode, apiErr.Message)
+	case err != nil:
+	default:
+		r.Status, r.Body = http.StatusOK, "{}"
+	}
+	return r, err
+}
+
+func (b *matrixBackend) check(ctx context.Context, c *Client, kind string) error {
+	mc, err := b.connect(ctx, c)
+	if err != nil {
+		return err
+	}
+	defer b.finish(c)
+	_, err = b.rooms(ctx, mc)
+	return err
+}
+
+// finish logs out of the device the run logged in with
+func (b *matrixBackend) finish(c *Client) {
+	if b.loggedIn {
+		if err := b.client.Logout(context.Background()); err != nil {
+			c.printf("Warning: failed to log out of Matrix: %v\n", err)
+		}
+	}
+	b.client, b.loggedIn = nil, false
+}
@@ -0,0 +1,227 @@
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/nostr"
+	"go-del-socials/pkg/spool"
+)
+
+// NostrConfig is a profile's nostr section
+type NostrConfig struct {
+	// PrivateKey is the nsec1... or hex key the notes were posted with
+	PrivateKey string `json:"private_key"`
+
+	// Relays are the ws:// or wss:// relays to list from and send
+	// deletion requests to
+	Relays []string `json:"relays"`
+
+	// RateLimit is the most deletion requests per minute, 60 by default
+	RateLimit int `json:"rate_limit"`
+}
+
+// Rate returns the rate limit with its default
+func (n *NostrConfig) Rate() int {
+	if n.RateLimit == 0 {
+		return 60
+	}
+	return n.RateLimit
+}
+
+// NostrTypes are the content types of Nostr, with "all" first
+func NostrTypes() []string {
+	return withAll([]string{"notes", "reposts", "reactions"})
+}
+
+// nostrKinds are the event kinds of the content types
+var nostrKinds = map[string]int{
+	"notes":     nostr.KindNote,
+	"reposts":   nostr.KindRepost,
+	"reactions": nostr.KindReaction,
+}
+
+// relayFailures is how many requests in a row a relay may fail before the
+// rest of the run leaves it out
+const relayFailures = 3
+
+// nostrBackend lists the key's events on every relay and asks each to
+// delete them. A relay that can't be reached is warned about and skipped.
+type nostrBackend struct {
+	key    *nostr.Key
+	relays []*nostr.Relay
+
+	// acks counts the deletion requests each relay accepted, and failures
+	// the requests in a row it couldn't be sent, by URL
+	acks     map[string]int
+	failures map[string]int
+	sent     int
+}
+
+// NewNostrClient returns a client for the key and relays of cfg, whose
+// private key must already have its secret resolved
+func NewNostrClient(cfg *NostrConfig, output io.Writer) (*Client, error) {
+	key, err := nostr.ParseKey(cfg.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	b := &nostrBackend{key: key, acks: map[string]int{}, failures: map[string]int{}}
+	for _, u := range cfg.Relays {
+		b.relays = append(b.relays, &nostr.Relay{URL: u})
+	}
+	return newClient("nostr", NostrTypes(), cfg.Rate(), b, output), nil
+}
+
+func (b *nostrBackend) list(ctx context.Context, c *Client, kind string, q *spool.Queue[Item]) error {
+	filter := map[string]any{"authors": []string{b.key.Public()}, "kinds": []int{nostrKinds[kind]}}
+
+	// Relays hold overlapping copies of the same events
+	seen := map[string]bool{}
+	reached := 0
+	for _, r := range b.relays {
+		err := r.Query(ctx, filter, func(e *nostr.Event) error {
+			if seen[e.ID] || e.PubKey != b.key.Public() {
+				return nil
+			}
+			seen[e.ID] = true
+			return q.Push(Item{
+				ID:   e.ID,
+				Kind: kind,
+				Date: e.Time(),
+				Text: e.Content,
+				URL:  "https://njump.me/" + nostr.NoteID(e.ID),
+
+				Scopes: nostrHashtags(e),
+			})
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			c.printf("Warning: skipping relay: %v\n", err)
+			continue
+		}
+		reached++
+	}
+	if reached == 0 {
+		return fmt.Errorf("none of the %d relays could be listed", len(b.relays))
+	}
+	return nil
+}
+
+// nostrHashtags returns the hashtags of an event, its "t" tags
+func nostrHashtags(e *nostr.Event) []string {
+	var tags []string
+	for _, t := range e.Tags {
+		if len(t) > 1 && t[0] == "t" {
+			tags = append(tags, t[1])
+		}
+	}
+	return tags
+}
+
+// relayAnswer is what one relay said to a deletion request, kept in the
+// receipt
+type relayAnswer struct {
+	Relay    string `json:"relay"`
+	Accepted bool   `json:"accepted"`
+	Message  string `json:"message,omitempty"`
+}
+
+func (b *nostrBackend) delete(ctx context.Context, c *Client, it Item) (audit.Receipt, error) {
+	r := audit.Receipt{Time: time.Now(), Platform: c.name, Kind: it.Kind, ID: it.ID, Method: "EVENT", URL: it.URL}
+	id, err := nostr.ParseID(it.ID)
+	if err != nil {
+		return r, err
+	}
+	e := nostr.Deletion(id, nostrKinds[it.Kind])
+	if err := b.key.Sign(e); err != nil {
+		return r, err
+	}
+	if c.simulate {
+		r.Status, r.Body = 200, `{"simulated":true}`
+		return r, nil
+	}
+
+	b.sent++
+	var answers []relayAnswer
+	var accepted, refused []string
+	for _, relay := range b.relays {
+		if b.failures[relay.URL] >= relayFailures {
+			continue
+		}
+		ok, msg, err := relay.Publish(ctx, e)
+		if err != nil {
+			if ctx.Err() != nil {
+				return r, ctx.Err()
+			}
+			msg = err.Error()
+			if b.failures[relay.URL]++; b.failures[relay.URL] == relayFailures {
+				c.printf("Warning: leaving out %s for the rest of the run after %d failed requests\n", relay.URL, relayFailures)
+			}
+		} else {
+			b.failures[relay.URL] = 0
+		}
+		answers = append(answers, relayAnswer{relay.URL, ok, msg})
+		if ok {
+			b.acks[relay.URL]++
+			accepted = append(accepted, relay.URL)
+		} else {
+			refused = append(refused, relay.URL+" ("+msg+")")
+		}
+	}
+	body, _ := json.Marshal(answers)
+	r.Body = string(body)
+
+	if len(refused) > 0 {
+		c.printf("  Refused by %s\n", strings.Join(refused, ", "))
+	}
+	if len(accepted) == 0 {
+		r.Status = 502
+		return r, errors.New("no relay accepted the deletion request")
+	}
+	r.Status = 200
+	c.printf("  Accepted by %d of %d relays\n", len(accepted), len(b.relays))
+	return r, nil
+}
+
+func (b *nostrBackend) check(ctx context.Context, c *Client, kind string) error {
+	filter := map[string]any{"authors": []string{b.key.Public()}, "kinds": []int{nostr.KindNote}, "limit": 1}
+	defer func() {
+		for _, r := range b.relays {
+			r.Close()
+		}
+	}()
+	var errs []error
+	for _, r := range b.relays {
+		// One page is enough, so the query stops after it
+		stop := errors.New("stop")
+		if err := r.Query(ctx, filter, func(*nostr.Event) error { return stop }); err != nil && !errors.Is(err, stop) {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == len(b.relays) {
+		return fmt.Errorf("no relay could be reached: %v", errors.Join(errs...))
+	}
+	return nil
+}
+
+// finish reports how many deletion requests each relay accepted and closes
+// the connections
+func (b *nostrBackend) finish(c *Client) {
+	if b.sent > 0 {
+		c.printf("\nDeletion requests accepted by each relay:\n")
+		for _, r := range b.relays {
+			c.printf("- %s: %d of %d\n", r.URL, b.acks[r.URL], b.sent)
+		}
+	}
+	for _, r := range b.relays {
+		r.Close()
+	}
+}
@@ -0,0 +1,367 @@
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/pace"
+	"go-del-socials/pkg/spool"
+)
+
+// SlackConfig is a profile's slack section
+type SlackConfig struct {
+	Workspaces []SlackWorkspace `json:"workspaces"`
+
+	// RateLimit is the most deletions per minute, 50 by default, Slack's
+	// tier 3 limit for deleting
+	RateLimit int `json:"rate_limit"`
+}
+
+// SlackWorkspace is one workspace to delete from
+type SlackWorkspace struct {
+	// Name identifies the workspace in item IDs
+	Name string `json:"name"`
+
+	// Token is a user token (xoxp-...) with the search:read, chat:write,
+	// files:read and files:write scopes
+	Token string `json:"token"`
+
+	// Channels are the channel names or IDs to delete in; every
+	// conversation if empty
+	Channels []string `json:"channels"`
+}
+
+// Rate returns the rate limit with its default
+func (s *SlackConfig) Rate() int {
+	if s.RateLimit == 0 {
+		return 50
+	}
+	return s.RateLimit
+}
+
+// SlackTypes are the content types of Slack, with "all" first
+func SlackTypes() []string {
+	return withAll([]string{"messages", "files"})
+}
+
+// slackAPI is the base of Slack's Web API
+const slackAPI = "https://slack.com/api/"
+
+// Listing goes through search.messages, a tier 2 method allowing about 20
+// requests a minute, and files.list, tier 3 at about 50. Pages are spaced
+// out to stay under them.
+const (
+	searchInterval = 3 * time.Second
+	filesInterval  = 1200 * time.Millisecond
+)
+
+// slackBackend deletes a user's messages and files in each workspace.
+// Messages are identified as <workspace>/<channel id>/<ts> and files as
+// <workspace>/<file id>, as deleting needs the workspace's token.
+type slackBackend struct {
+	cfg *SlackConfig
+
+	// users are the IDs of the token's user in each workspace, found with
+	// auth.test
+	users map[string]string
+}
+
+// NewSlackClient returns a client for the workspaces of cfg, whose tokens
+// must already have their secrets resolved
+func NewSlackClient(cfg *SlackConfig, output io.Writer) *Client {
+	return newClient("slack", SlackTypes(), cfg.Rate(), &slackBackend{cfg: cfg, users: map[string]string{}}, output)
+}
+
+// slackError is the error Slack answered a call with, always with status
+// 200
+type slackError struct {
+	Method string
+	Code   string
+}
+
+func (e *slackError) Error() string {
+	return fmt.Sprintf("%s failed: %s", e.Method, e.Code)
+}
+
+// call sends a Web API method and decodes its answer into out. GET methods
+// take params in the query, the others as JSON.
+func (b *slackBackend) call(ctx context.Context, c *Client, w *SlackWorkspace, httpMethod, method string, params map[string]string, out any) (string, error) {
+	header := http.Header{"Authorization": {"Bearer " + w.Token}}
+	u, body := slackAPI+method, ""
+	if httpMethod == http.MethodGet {
+		q := url.Values{}
+		for k, v := range params {
+			q.Set(k, v)
+		}
+		u += "?" + q.Encode()
+	} else {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return "", err
+		}
+		body = string(data)
+		header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+
+	resp, _, err := c.request(ctx, httpMethod, u, body, header)
+	if err != nil {
+		return resp, err
+	}
+	// Writes of simulated runs aren't sent, so there's no answer to read
+	if c.simulate && httpMethod != http.MethodGet {
+		return resp, nil
+	}
+	var answer struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(resp), &answer); err != nil {
+		return resp, fmt.Errorf("%s returned an unreadable answer: %v", method, err)
+	}
+	if !answer.OK {
+		return resp, &slackError{method, answer.Error}
+	}
+	if out != nil {
+		return resp, json.Unmarshal([]byte(resp), out)
+	}
+	return resp, nil
+}
+
+// user returns the ID of the token's user in a workspace
+func (b *slackBackend) user(ctx context.Context, c *Client, w *SlackWorkspace) (string, error) {
+	if id, ok := b.users[w.Name]; ok {
+		return id, nil
+	}
+	var auth struct {
+		UserID string `json:"user_id"`
+	}
+	if _, err := b.call(ctx, c, w, http.MethodGet, "auth.test", nil, &auth); err != nil {
+		return "", fmt.Errorf("workspace %s: %v", w.Name, err)
+	}
+	b.users[w.Name] = auth.UserID
+	return auth.UserID, nil
+}
+
+func (b *slackBackend) workspace(name string) *SlackWorkspace {
+	for i := range b.cfg.Workspaces {
+		if b.cfg.Workspaces[i].Name == name {
+			return &b.cfg.Workspaces[i]
+		}
+	}
+	return nil
+}
+
+// slackTime reads a Slack timestamp, seconds with microseconds after a dot
+func slackTime(ts string) time.Time {
+	sec, frac, _ := strings.Cut(ts, ".")
+	s, _ := strconv.ParseInt(sec, 10, 64)
+	us, _ := strconv.ParseInt(frac, 10, 64)
+	return time.Unix(s, us*1000)
+}
+
+func (b *slackBackend) list(ctx context.Context, c *Client, kind string, q *spool.Queue[Item]) error {
+	for i := range b.cfg.Workspaces {
+		w := &b.cfg.Workspaces[i]
+		user, err := b.user(ctx, c, w)
+		if err != nil {
+			return err
+		}
+		if kind == "files" {
+			err = b.listFiles(ctx, c, w, user, q)
+		} else {
+			err = b.listMessages(ctx, c, w, user, q)
+		}
+		if err != nil {
+			return fmt.Errorf("workspace %s: %v", w.Name, err)
+		}
+	}
+	return nil
+}
+
+// listMessages searches for the user's messages, in each selected channel
+// or everywhere
+func (b *slackBackend) listMessages(ctx context.Context, c *Client, w *SlackWorkspace, user string, q *spool.Queue[Item]) error {
+	queries := []string{"from:<@" + user + ">"}
+	if len(w.Channels) > 0 {
+		queries = queries[:0]
+		for _, ch := range w.Channels {
+			in := "#" + strings.TrimPrefix(ch, "#")
+			if isSlackID(ch) {
+				in = "<#" + ch + ">"
+			}
+			queries = append(queries, "from:<@"+user+"> in:"+in)
+		}
+	}
+
+	for _, query := range queries {
+		for page, pages := 1, 1; page <= pages; page++ {
+			var res struct {
+				Messages struct {
+					Matches []struct {
+						TS        string `json:"ts"`
+						Text      string `json:"text"`
+						Permalink string `json:"permalink"`
+						User      string `json:"user"`
+						Channel   struct {
+							ID   string `json:"id"`
+							Name string `json:"name"`
+						} `json:"channel"`
+					} `json:"matches"`
+					Paging struct {
+						Pages int `json:"pages"`
+					} `json:"paging"`
+				} `json:"messages"`
+			}
+			params := map[string]string{"query": query, "count": "100", "page": strconv.Itoa(page), "sort": "timestamp", "sort_dir": "asc"}
+			if _, err := b.call(ctx, c, w, http.MethodGet, "search.messages", params, &res); err != nil {
+				return err
+			}
+			pages = res.Messages.Paging.Pages
+			for _, m := range res.Messages.Matches {
+				if m.User != user {
+					continue
+				}
+				err := q.Push(Item{
+					ID:   w.Name + "/" + m.Channel.ID + "/" + m.TS,
+					Kind: "messages",
+					Date: slackTime(m.TS),
+					Text: m.Text,
+					URL:  m.Permalink,
+
+					Scopes: []string{m.Channel.ID, m.Channel.Name},
+				})
+				if err != nil {
+					return err
+				}
+			}
+			if page < pages {
+				if err := pace.Wait(ctx, searchInterval); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// listFiles lists the files the user uploaded, in each selected channel or
+// everywhere
+func (b *slackBackend) listFiles(ctx context.Context, c *Client, w *SlackWorkspace, user string, q *spool.Queue[Item]) error {
+	channels := []string{""}
+	if len(w.Channels) > 0 {
+		channels = w.Channels
+	}
+	for _, ch := range channels {
+		if ch != "" && !isSlackID(ch) {
+			c.printf("Warning: listing files needs channel IDs, so %s is skipped; use its ID, e.g. C0123ABCD\n", ch)
+			continue
+		}
+		for page, pages := 1, 1; page <= pages; page++ {
+			var res struct {
+				Files []struct {
+					ID        string   `json:"id"`
+					Created   int64    `json:"created"`
+					Name      string   `json:"name"`
+					Title     string   `json:"title"`
+					Permalink string   `json:"permalink"`
+					Channels  []string `json:"channels"`
+				} `json:"files"`
+				Paging struct {
+					Pages int `json:"pages"`
+				} `json:"paging"`
+			}
+			params := map[string]string{"user": user, "count": "100", "page": strconv.Itoa(page)}
+			if ch != "" {
+				params["channel"] = ch
+			}
+			if _, err := b.call(ctx, c, w, http.MethodGet, "files.list", params, &res); err != nil {
+				return err
+			}
+			pages = res.Paging.Pages
+			for _, f := range res.Files {
+				text := f.Title
+				if text == "" {
+					text = f.Name
+				}
+				err := q.Push(Item{
+					ID:   w.Name + "/" + f.ID,
+					Kind: "files",
+					Date: time.Unix(f.Created, 0),
+					Text: text,
+					URL:  f.Permalink,
+
+					Scopes: f.Channels,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			if page < pages {
+				if err := pace.Wait(ctx, filesInterval); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// isSlackID reports whether a channel is given by ID, such as C0123ABCD,
+// rather than by name
+func isSlackID(ch string) bool {
+	if len(ch) < 9 || strings.ToUpper(ch) != ch {
+		return false
+	}
+	return strings.ContainsRune("CGD", rune(ch[0]))
+}
+
+func (b *slackBackend) delete(ctx context.Context, c *Client, it Item) (audit.Receipt, error) {
+	r := audit.Receipt{Time: time.Now(), Platform: c.name, Kind: it.Kind, ID: it.ID, Method: "POST", URL: it.URL}
+	parts := strings.SplitN(it.ID, "/", 3)
+	w := b.workspace(parts[0])
+	if w == nil {
+		return r, fmt.Errorf("%s is not in a configured workspace", it.ID)
+	}
+
+	var err error
+	switch {
+	case it.Kind == "messages" && len(parts) == 3:
+		r.Body, err = b.call(ctx, c, w, http.MethodPost, "chat.delete", map[string]string{"channel": parts[1], "ts": parts[2]}, nil)
+	case it.Kind == "files" && len(parts) == 2:
+		r.Body, err = b.call(ctx, c, w, http.MethodPost, "files.delete", map[string]string{"file": parts[1]}, nil)
+	default:
+		return r, fmt.Errorf("%s is not a <workspace>/<channel id>/<ts> message or a <workspace>/<file id> file", it.ID)
+	}
+
+	var e *slackError
+	switch {
+	case err == nil:
+		r.Status = http.StatusOK
+	case !errors.As(err, &e):
+	case e.Code == "message_not_found" || e.Code == "file_not_found" || e.Code == "file_deleted":
+		r.Status = http.StatusNotFound
+	default:
+		r.Status = http.StatusForbidden
+	}
+	return r, err
+}
+
+func (b *slackBackend) check(ctx context.Context, c *Client, kind string) error {
+	for i := range b.cfg.Workspaces {
+		w := &b.cfg.Workspaces[i]
+		delete(b.users, w.Name)
+		if _, err := b.user(ctx, c, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,412 @@
+// Package generic deletes content on platforms that have no provider of
+// their own: simple JSON APIs described in a YAML file and webhook services.
+// It also makes up content for rehearsals.
+package generic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/spool"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultRateLimit keeps to about one delete every two seconds, like the
+// built-in providers
+const defaultRateLimit = 30
+
+// Spec describes a platform's API. It is read from <name>.yaml.
+type Spec struct {
+	Name  string `yaml:"-"`
+	Title string `yaml:"title"`
+
+	// Vars are available to every template. Profiles can override them,
+	// and values may be secret references.
+	Vars map[string]string `yaml:"vars"`
+
+	// Auth is a header sent with every request, e.g. Authorization with
+	// "Bearer {{.token}}"
+	Auth struct {
+		Header string `yaml:"header"`
+		Value  string `yaml:"value"`
+	} `yaml:"auth"`
+
+	// RateLimit is the most deletions per minute
+	RateLimit int `yaml:"rate_limit"`
+
+	// Content maps each content type to the endpoints that list and delete it
+	Content map[string]*Endpoints `yaml:"content"`
+}
+
+// Endpoints list and delete one type of content
+type Endpoints struct {
+	List   List   `yaml:"list"`
+	Delete Delete `yaml:"delete"`
+}
+
+// List is a paginated endpoint returning items. The URL template gets .page
+// (counting from 1) and .cursor, the value at Next in the previous page. A
+// Next value that is a URL is fetched as is.
+type List struct {
+	URL string `yaml:"url"`
+
+	// Items is the JSON path of the array of items, empty for the root
+	Items string `yaml:"items"`
+
+	// Paths of each item's fields, relative to the item
+	ID   string `yaml:"id"`
+	Date string `yaml:"date"`
+	Text string `yaml:"text"`
+	Link string `yaml:"link"`
+
+	// Scope is the path of the item's channel or community, or of an
+	// array of them, for retention rules
+	Scope string `yaml:"scope"`
+
+	// DateFormat is a Go time layout, or "unix" for seconds. By default
+	// strings are RFC 3339 and numbers Unix seconds.
+	DateFormat string `yaml:"date_format"`
+
+	// Next is the path of the next page's cursor or URL. Without it, pages
+	// are requested until one is empty if the URL uses .page.
+	Next string `yaml:"next"`
+}
+
+// Delete is the request that deletes an item. The templates get .id.
+type Delete struct {
+	Method string `yaml:"method"`
+	URL    string `yaml:"url"`
+	Body   string `yaml:"body"`
+}
+
+// Load reads every spec in dir. A missing directory has none.
+func Load(dir string) ([]*Spec, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider directory: %v", err)
+	}
+
+	var specs []*Spec
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		s := &Spec{Name: strings.TrimSuffix(e.Name(), ext)}
+		if err := yaml.Unmarshal(data, s); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %v", e.Name(), err)
+		}
+		if err := s.check(); err != nil {
+			return nil, fmt.Errorf("%s: %v", e.Name(), err)
+		}
+		specs = append(specs, s)
+	}
+	return specs, nil
+}
+
+// check fills in defaults and rejects incomplete specs
+func (s *Spec) check() error {
+	if s.Title == "" {
+		s.Title = s.Name
+	}
+	if s.RateLimit == 0 {
+		s.RateLimit = defaultRateLimit
+	}
+	if len(s.Content) == 0 {
+		return fmt.Errorf("no content types")
+	}
+
+	templates := []string{s.Auth.Value}
+	for name, e := range s.Content {
+		if e == nil || e.List.URL == "" || e.List.ID == "" || e.List.Date == "" || e.Delete.URL == "" {
+			return fmt.Errorf("content type %s needs list.url, list.id, list.date and delete.url", name)
+		}
+		if e.Delete.Method == "" {
+			e.Delete.Method = "DELETE"
+		}
+		templates = append(templates, e.List.URL, e.Delete.URL, e.Delete.Body)
+	}
+	for _, t := range templates {
+		if _, err := template.New("").Option("missingkey=error").Parse(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ContentTypes returns the spec's content types, with "all" first when
+// there are several
+func (s *Spec) ContentTypes() []string {
+	types := make([]string, 0, len(s.Content)+1)
+	for name := range s.Content {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return withAll(types)
+}
+
+// withAll puts "all" in front of several content types
+func withAll(types []string) []string {
+	if len(types) > 1 {
+		return append([]string{"all"}, types...)
+	}
+	return types
+}
+
+// render executes a template with the variables
+func render(text string, vars map[string]string) (string, error) {
+	t, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// lookup returns the value at a dotted path of object keys and array
+// indexes, e.g. "data.children" or "attributes.0.name"
+func lookup(v any, path string) any {
+	if path == "" {
+		return v
+	}
+	for _, part := range strings.Split(path, ".") {
+		switch x := v.(type) {
+		case map[string]any:
+			v = x[part]
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(x) {
+				return nil
+			}
+			v = x[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// str formats a JSON value as text
+func str(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case json.Number:
+		return x.String()
+	default:
+		return fmt.Sprint(x)
+	}
+}
+
+// strs returns a JSON value as strings: each element of an array, or the
+// value itself
+func strs(v any) []string {
+	a, ok := v.([]any)
+	if !ok {
+		a = []any{v}
+	}
+	var s []string
+	for _, x := range a {
+		if x := str(x); x != "" {
+			s = append(s, x)
+		}
+	}
+	return s
+}
+
+// date parses a JSON value in the list's date format
+func (l *List) date(v any) (time.Time, error) {
+	if n, ok := v.(json.Number); ok && (l.DateFormat == "" || l.DateFormat == "unix") {
+		secs, err := n.Float64()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(int64(secs), 0).UTC(), nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("date %v is not a string or number", v)
+	}
+	switch l.DateFormat {
+	case "":
+		return time.Parse(time.RFC3339, s)
+	case "unix":
+		secs, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(secs, 0).UTC(), nil
+	default:
+		return time.Parse(l.DateFormat, s)
+	}
+}
+
+// specBackend calls the endpoints described by a spec
+type specBackend struct {
+	spec *Spec
+	vars map[string]string
+}
+
+// header returns the auth header of the spec
+func (b *specBackend) header() (http.Header, error) {
+	h := http.Header{}
+	if b.spec.Auth.Header == "" {
+		return h, nil
+	}
+	value, err := render(b.spec.Auth.Value, b.vars)
+	if err != nil {
+		return nil, err
+	}
+	h.Set(b.spec.Auth.Header, value)
+	return h, nil
+}
+
+// list fetches every page of a list endpoint
+func (b *specBackend) list(ctx context.Context, c *Client, kind string, q *spool.Queue[Item]) error {
+	l := &b.spec.Content[kind].List
+	header, err := b.header()
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	vars := maps.Clone(b.vars)
+	next := ""
+
+	for page := 1; ; page++ {
+		vars["page"] = strconv.Itoa(page)
+		u := next
+		if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+			vars["cursor"] = next
+			if u, err = render(l.URL, vars); err != nil {
+				return err
+			}
+		}
+
+		body, _, err := c.request(ctx, "GET", u, "", header)
+		if err != nil {
+			return fmt.Errorf("error listing %s: %v", kind, err)
+		}
+		dec := json.NewDecoder(strings.NewReader(body))
+		dec.UseNumber()
+		var doc any
+		if err := dec.Decode(&doc); err != nil {
+			return fmt.Errorf("error decoding %s listing: %v", kind, err)
+		}
+
+		raw, _ := lookup(doc, l.Items).([]any)
+		added := 0
+		for _, r := range raw {
+			it := Item{
+				ID:   str(lookup(r, l.ID)),
+				Kind: kind,
+			}
+			if l.Text != "" {
+				it.Text = str(lookup(r, l.Text))
+			}
+			if l.Link != "" {
+				it.URL = str(lookup(r, l.Link))
+			}
+			if l.Scope != "" {
+				it.Scopes = strs(lookup(r, l.Scope))
+			}
+			if it.ID == "" || seen[it.ID] {
+				continue
+			}
+			if it.Date, err = l.date(lookup(r, l.Date)); err != nil {
+				return fmt.Errorf("%s %s: %v", kind, it.ID, err)
+			}
+			seen[it.ID] = true
+			if err := q.Push(it); err != nil {
+				return err
+			}
+			added++
+		}
+
+		// Stop at the last page, or when a page brings nothing new
+		if added == 0 {
+			return nil
+		}
+		if l.Next != "" {
+			if next = str(lookup(doc, l.Next)); next == "" {
+				return nil
+			}
+		} else if !strings.Contains(l.URL, ".page") {
+			return nil
+		}
+	}
+}
+
+func (b *specBackend) check(ctx context.Context, c *Client, kind string) error {
+	header, err := b.header()
+	if err != nil {
+		return err
+	}
+	vars := maps.Clone(b.vars)
+	vars["page"], vars["cursor"] = "1", ""
+	u, err := render(b.spec.Content[kind].List.URL, vars)
+	if err != nil {
+		return err
+	}
+	_, _, err = c.request(ctx, "GET", u, "", header)
+	return err
+}
+
+// delete sends the delete request for an item
+func (b *specBackend) delete(ctx context.Context, c *Client, it Item) (audit.Receipt, error) {
+	d := &b.spec.Content[it.Kind].Delete
+	header, err := b.header()
+	if err != nil {
+		return audit.Receipt{}, err
+	}
+
+	vars := maps.Clone(b.vars)
+	vars["id"] = it.ID
+	u, err := render(d.URL, vars)
+	if err != nil {
+		return audit.Receipt{}, err
+	}
+	body, err := render(d.Body, vars)
+	if err != nil {
+		return audit.Receipt{}, err
+	}
+
+	answer, status, err := c.request(ctx, d.Method, u, body, header)
+	return audit.Receipt{
+		Time:     time.Now(),
+		Platform: c.name,
+		Kind:     it.Kind,
+		ID:       it.ID,
+		Method:   d.Method,
+		URL:      u,
+		Status:   status,
+		Body:     answer,
+	}, err
+}
@@ -0,0 +1,194 @@
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/pace"
+	"go-del-socials/pkg/spool"
+)
+
+// SubstackConfig is a profile's substack section. Substack has no public
+// API, so the provider signs in as the web app does, with its session
+// cookie.
+type SubstackConfig struct {
+	// Cookie is the value of the substack.sid cookie of a signed-in
+	// browser
+	Cookie string `json:"cookie"`
+
+	// RateLimit is the most deletions per minute, 20 by default
+	RateLimit int `json:"rate_limit"`
+}
+
+// Rate returns the rate limit with its default
+func (s *SubstackConfig) Rate() int {
+	if s.RateLimit == 0 {
+		return 20
+	}
+	return s.RateLimit
+}
+
+// SubstackTypes are the content types of Substack, with "all" first
+func SubstackTypes() []string {
+	return withAll([]string{"notes", "comments"})
+}
+
+// substackAPI is the base of the API behind substack.com
+const substackAPI = "https://substack.com/api/v1/"
+
+// substackInterval spaces out the pages of the profile feed, which Substack
+// rate limits like any page view
+const substackInterval = 2 * time.Second
+
+// substackBackend deletes the user's Notes and comments on posts, both of
+// which Substack keeps as comments. They are listed from the user's
+// profile activity; comments are identified as <publication>/<comment id>,
+// as they're deleted on the publication's host.
+type substackBackend struct {
+	cfg  *SubstackConfig
+	user int64
+}
+
+// NewSubstackClient returns a client for the account of cfg, whose cookie
+// must already have its secret resolved
+func NewSubstackClient(cfg *SubstackConfig, output io.Writer) *Client {
+	return newClient("substack", SubstackTypes(), cfg.Rate(), &substackBackend{cfg: cfg}, output)
+}
+
+func (b *substackBackend) header() http.Header {
+	return http.Header{"Cookie": {"substack.sid=" + b.cfg.Cookie}}
+}
+
+// self finds the signed-in user
+func (b *substackBackend) self(ctx context.Context, c *Client) error {
+	var user struct {
+		ID     int64  `json:"id"`
+		Handle string `json:"handle"`
+	}
+	body, status, err := c.request(ctx, http.MethodGet, substackAPI+"user/profile/self", "", b.header())
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		return errors.New("cookie rejected: sign in to substack.com again and copy the new substack.sid cookie")
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(body), &user); err != nil || user.ID == 0 {
+		return fmt.Errorf("unreadable profile from Substack: %.200s", body)
+	}
+	b.user = user.ID
+	c.printf("Signed in to Substack as %s\n", user.Handle)
+	return nil
+}
+
+// substackComment is a Note or comment as the profile feed gives it
+type substackComment struct {
+	ID          int64     `json:"id"`
+	Body        string    `json:"body"`
+	Date        time.Time `json:"date"`
+	PostID      int64     `json:"post_id"`
+	Deleted     bool      `json:"deleted"`
+	Publication *struct {
+		Subdomain string `json:"subdomain"`
+	} `json:"publication"`
+}
+
+func (b *substackBackend) list(ctx context.Context, c *Client, kind string, q *spool.Queue[Item]) error {
+	if b.user == 0 {
+		if err := b.self(ctx, c); err != nil {
+			return err
+		}
+	}
+
+	cursor := ""
+	for page := 0; ; page++ {
+		if page > 0 {
+			if err := pace.Wait(ctx, substackInterval); err != nil {
+				return err
+			}
+		}
+		params := url.Values{"types[]": {"comment"}}
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+		body, _, err := c.request(ctx, http.MethodGet, fmt.Sprintf("%sreader/feed/profile/%d?%s", substackAPI, b.user, params.Encode()), "", b.header())
+		if err != nil {
+			return err
+		}
+		var feed struct {
+			Items []struct {
+				Type    string           `json:"type"`
+				Comment *substackComment `json:"comment"`
+			} `json:"items"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := json.Unmarshal([]byte(body), &feed); err != nil {
+			return fmt.Errorf("unreadable profile feed from Substack: %v", err)
+		}
+
+		for _, fi := range feed.Items {
+			cm := fi.Comment
+			if cm == nil || cm.Deleted {
+				continue
+			}
+			it := Item{Kind: kind, Date: cm.Date, Text: cm.Body}
+			switch {
+			case cm.PostID == 0 && kind == "notes":
+				it.ID = fmt.Sprint(cm.ID)
+				it.URL = fmt.Sprintf("https://substack.com/note/c-%d", cm.ID)
+			case cm.PostID != 0 && kind == "comments" && cm.Publication != nil:
+				it.ID = fmt.Sprintf("%s/%d", cm.Publication.Subdomain, cm.ID)
+				it.Scopes = []string{cm.Publication.Subdomain}
+				it.URL = fmt.Sprintf("https://%s.substack.com/p/-/comment/%d", cm.Publication.Subdomain, cm.ID)
+			default:
+				continue
+			}
+			if err := q.Push(it); err != nil {
+				return err
+			}
+		}
+		if feed.NextCursor == "" || feed.NextCursor == cursor {
+			return nil
+		}
+		cursor = feed.NextCursor
+	}
+}
+
+func (b *substackBackend) delete(ctx context.Context, c *Client, it Item) (audit.Receipt, error) {
+	u := substackAPI + "comment/" + it.ID
+	if pub, id, ok := strings.Cut(it.ID, "/"); ok {
+		u = "https://" + pub + ".substack.com/api/v1/comment/" + id
+	}
+	r := audit.Receipt{Time: time.Now(), Platform: c.name, Kind: it.Kind, ID: it.ID, Method: http.MethodDelete, URL: u}
+	body, status, err := c.request(ctx, http.MethodDelete, u, "", b.header())
+	r.Status, r.Body = status, body
+	// Substack refuses comments on posts that were taken down and in
+	// publications that banned the user; only the publication can remove
+	// those
+	if status == http.StatusForbidden {
+		var e struct {
+			Error string `json:"error"`
+		}
+		json.Unmarshal([]byte(body), &e)
+		reason := "Substack refuses to delete it; only the publication can"
+		if e.Error != "" {
+			reason += " (" + e.Error + ")"
+		}
+		return r, &unremovable{reason}
+	}
+	return r, err
+}
+
+func (b *substackBackend) check(ctx context.Context, c *Client, kind string) error {
+	if b.cfg.Cookie == "" {
+		return errors.New("no cookie")
+	}
+	return b.self(ctx, c)
+}
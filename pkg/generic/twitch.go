@@ -0,0 +1,252 @@
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/spool"
+)
+
+// TwitchConfig is a profile's twitch section
+type TwitchConfig struct {
+	// ClientID is the ID of the Twitch application the token was issued
+	// to
+	ClientID string `json:"client_id"`
+
+	// AccessToken is a user access token with the channel:manage:videos
+	// scope, and the moderation:read, moderator:read:blocked_terms and
+	// channel:read:vips scopes for the chat export
+	AccessToken string `json:"access_token"`
+
+	// RateLimit is the most deletions per minute, 60 by default
+	RateLimit int `json:"rate_limit"`
+}
+
+// Rate returns the rate limit with its default
+func (t *TwitchConfig) Rate() int {
+	if t.RateLimit == 0 {
+		return 60
+	}
+	return t.RateLimit
+}
+
+// TwitchTypes are the content types of Twitch, with "all" first
+func TwitchTypes() []string {
+	return withAll([]string{"videos", "clips"})
+}
+
+// Bases of the Helix API and of Twitch's OAuth server
+const (
+	twitchAPI      = "https://api.twitch.tv/helix/"
+	twitchValidate = "https://id.twitch.tv/oauth2/validate"
+)
+
+// twitchBackend deletes the videos of the token's channel: past
+// broadcasts, highlights and uploads. Helix can list the channel's clips
+// but not delete them, so they are reported as staying online.
+type twitchBackend struct {
+	cfg   *TwitchConfig
+	user  string
+	login string
+}
+
+// NewTwitchClient returns a client for the channel of cfg, whose token must
+// already have its secret resolved
+func NewTwitchClient(cfg *TwitchConfig, output io.Writer) *Client {
+	return newClient("twitch", TwitchTypes(), cfg.Rate(), &twitchBackend{cfg: cfg}, output)
+}
+
+func (b *twitchBackend) header() http.Header {
+	return http.Header{"Authorization": {"Bearer " + b.cfg.AccessToken}, "Client-Id": {b.cfg.ClientID}}
+}
+
+// validate finds the token's user
+func (b *twitchBackend) validate(ctx context.Context, c *Client) error {
+	var token struct {
+		ClientID string `json:"client_id"`
+		Login    string `json:"login"`
+		UserID   string `json:"user_id"`
+	}
+	body, _, err := c.request(ctx, http.MethodGet, twitchValidate, "", http.Header{"Authorization": {"OAuth " + b.cfg.AccessToken}})
+	if err != nil {
+		return fmt.Errorf("access token rejected: %v", err)
+	}
+	if err := json.Unmarshal([]byte(body), &token); err != nil || token.UserID == "" {
+		return fmt.Errorf("the access token is not a user access token: %.200s", body)
+	}
+	if token.ClientID != b.cfg.ClientID {
+		return fmt.Errorf("the access token was issued to client %s, not %s", token.ClientID, b.cfg.ClientID)
+	}
+	b.user, b.login = token.UserID, token.Login
+	return nil
+}
+
+// pages gets every page of a Helix listing, handing each page's data on
+func (b *twitchBackend) pages(ctx context.Context, c *Client, path string, params url.Values, page func(data json.RawMessage) error) error {
+	params.Set("first", "100")
+	for {
+		body, status, err := c.request(ctx, http.MethodGet, twitchAPI+path+"?"+params.Encode(), "", b.header())
+		if err != nil {
+			return &twitchError{status, err}
+		}
+		var resp struct {
+			Data       json.RawMessage `json:"data"`
+			Pagination struct {
+				Cursor string `json:"cursor"`
+			} `json:"pagination"`
+		}
+		if err := json.Unmarshal([]byte(body), &resp); err != nil {
+			return fmt.Errorf("unreadable %s from Twitch: %v", path, err)
+		}
+		if err := page(resp.Data); err != nil {
+			return err
+		}
+		if resp.Pagination.Cursor == "" || resp.Pagination.Cursor == params.Get("after") {
+			return nil
+		}
+		params.Set("after", resp.Pagination.Cursor)
+	}
+}
+
+// twitchError is a failed Helix request with its status
+type twitchError struct {
+	status int
+	err    error
+}
+
+func (e *twitchError) Error() string {
+	return e.err.Error()
+}
+
+func (b *twitchBackend) list(ctx context.Context, c *Client, kind string, q *spool.Queue[Item]) error {
+	if b.user == "" {
+		if err := b.validate(ctx, c); err != nil {
+			return err
+		}
+	}
+
+	var path string
+	params := url.Values{}
+	switch kind {
+	case "videos":
+		path = "videos"
+		params.Set("user_id", b.user)
+	case "clips":
+		path = "clips"
+		params.Set("broadcaster_id", b.user)
+	}
+	return b.pages(ctx, c, path, params, func(data json.RawMessage) error {
+		var items []struct {
+			ID        string    `json:"id"`
+			Title     string    `json:"title"`
+			URL       string    `json:"url"`
+			CreatedAt time.Time `json:"created_at"`
+		}
+		if err := json.Unmarshal(data, &items); err != nil {
+			return fmt.Errorf("unreadable %s from Twitch: %v", kind, err)
+		}
+		for _, it := range items {
+			if err := q.Push(Item{ID: it.ID, Kind: kind, Date: it.CreatedAt, Text: it.Title, URL: it.URL}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *twitchBackend) refuse(it Item) string {
+	if it.Kind == "clips" {
+		return fmt.Sprintf("Twitch's API can't delete clips; delete it at https://dashboard.twitch.tv/u/%s/content/clips", b.login)
+	}
+	return ""
+}
+
+func (b *twitchBackend) delete(ctx context.Context, c *Client, it Item) (audit.Receipt, error) {
+	u := twitchAPI + "videos?" + url.Values{"id": {it.ID}}.Encode()
+	r := audit.Receipt{Time: time.Now(), Platform: c.name, Kind: it.Kind, ID: it.ID, Method: http.MethodDelete, URL: u}
+	body, status, err := c.request(ctx, http.MethodDelete, u, "", b.header())
+	r.Status, r.Body = status, body
+	return r, err
+}
+
+func (b *twitchBackend) check(ctx context.Context, c *Client, kind string) error {
+	if b.cfg.AccessToken == "" {
+		return errors.New("no access token")
+	}
+	return b.validate(ctx, c)
+}
+
+// TwitchChat is what Helix tells about a channel's chat. None of it can be
+// deleted through the API; it is exported so it can be reviewed and
+// cleaned up in the dashboard.
+type TwitchChat struct {
+	Exported time.Time `json:"exported"`
+	Channel  string    `json:"channel"`
+
+	Settings     json.RawMessage   `json:"settings,omitempty"`
+	Emotes       []json.RawMessage `json:"emotes,omitempty"`
+	BlockedTerms []json.RawMessage `json:"blocked_terms,omitempty"`
+	Banned       []json.RawMessage `json:"banned,omitempty"`
+	Moderators   []json.RawMessage `json:"moderators,omitempty"`
+	VIPs         []json.RawMessage `json:"vips,omitempty"`
+
+	// Missing are the parts the token lacked the scope for
+	Missing []string `json:"missing,omitempty"`
+}
+
+// ExportTwitchChat reads the chat settings, custom emotes, blocked terms,
+// bans, moderators and VIPs of the channel of cfg. Parts the token has no
+// scope for are left out and named in Missing.
+func ExportTwitchChat(ctx context.Context, cfg *TwitchConfig) (*TwitchChat, error) {
+	b := &twitchBackend{cfg: cfg}
+	c := newClient("twitch", TwitchTypes(), cfg.Rate(), b, io.Discard)
+	if err := b.validate(ctx, c); err != nil {
+		return nil, err
+	}
+
+	chat := &TwitchChat{Exported: time.Now().UTC(), Channel: b.login}
+	parts := []struct {
+		path, scope string
+		params      url.Values
+		into        *[]json.RawMessage
+	}{
+		{"chat/settings", "", url.Values{"broadcaster_id": {b.user}}, nil},
+		{"chat/emotes", "", url.Values{"broadcaster_id": {b.user}}, &chat.Emotes},
+		{"moderation/blocked_terms", "moderator:read:blocked_terms", url.Values{"broadcaster_id": {b.user}, "moderator_id": {b.user}}, &chat.BlockedTerms},
+		{"moderation/banned", "moderation:read", url.Values{"broadcaster_id": {b.user}}, &chat.Banned},
+		{"moderation/moderators", "moderation:read", url.Values{"broadcaster_id": {b.user}}, &chat.Moderators},
+		{"channels/vips", "channel:read:vips", url.Values{"broadcaster_id": {b.user}}, &chat.VIPs},
+	}
+	for _, p := range parts {
+		err := b.pages(ctx, c, p.path, p.params, func(data json.RawMessage) error {
+			var items []json.RawMessage
+			if err := json.Unmarshal(data, &items); err != nil {
+				return fmt.Errorf("unreadable %s from Twitch: %v", p.path, err)
+			}
+			if p.into == nil {
+				if len(items) > 0 {
+					chat.Settings = items[0]
+				}
+				return nil
+			}
+			*p.into = append(*p.into, items...)
+			return nil
+		})
+		var te *twitchError
+		if errors.As(err, &te) && (te.status == http.StatusUnauthorized || te.status == http.StatusForbidden) && p.scope != "" {
+			chat.Missing = append(chat.Missing, fmt.Sprintf("%s (needs the %s scope)", p.path, p.scope))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return chat, nil
+}
@@ -0,0 +1,160 @@
+package generic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/spool"
+)
+
+// WebhookConfig points to a service that lists and deletes content for the
+// tool, such as a headless-browser bridge to a platform without an API.
+// Filtering, pacing and reporting stay with the client.
+type WebhookConfig struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+
+	// Token, when set, is sent as a bearer token
+	Token string `json:"token"`
+
+	ContentTypes []string `json:"content_types"`
+
+	// RateLimit is the most deletions per minute
+	RateLimit int `json:"rate_limit"`
+}
+
+// Check fills in defaults and rejects incomplete webhooks
+func (w *WebhookConfig) Check(name string) error {
+	if w.URL == "" || len(w.ContentTypes) == 0 {
+		return fmt.Errorf("webhook %s needs a url and content_types", name)
+	}
+	if w.Title == "" {
+		w.Title = name
+	}
+	if w.RateLimit == 0 {
+		w.RateLimit = defaultRateLimit
+	}
+	return nil
+}
+
+// Types returns the webhook's content types, with "all" first when there
+// are several
+func (w *WebhookConfig) Types() []string {
+	return withAll(w.ContentTypes)
+}
+
+// webhookRequest is the JSON body posted to <url>/list and <url>/delete
+type webhookRequest struct {
+	ContentType string          `json:"content_type"`
+	Cursor      string          `json:"cursor,omitempty"`
+	ID          string          `json:"id,omitempty"`
+	Account     json.RawMessage `json:"account,omitempty"`
+}
+
+// webhookPage is the answer to a list request
+type webhookPage struct {
+	Items []struct {
+		ID   string    `json:"id"`
+		Date time.Time `json:"date"`
+		URL  string    `json:"url"`
+		Text string    `json:"text"`
+
+		// Scopes are the channels, communities or hashtags of the item
+		Scopes []string `json:"scopes"`
+	} `json:"items"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// webhookBackend forwards list and delete calls to a webhook service
+type webhookBackend struct {
+	cfg     *WebhookConfig
+	account json.RawMessage
+}
+
+// NewWebhookClient returns a client for the webhook. account, the profile's
+// settings for it, is passed along with every request.
+func NewWebhookClient(name string, cfg *WebhookConfig, account json.RawMessage, output io.Writer) *Client {
+	c := newClient(name, cfg.Types(), cfg.RateLimit, &webhookBackend{cfg, account}, output)
+	c.reads = func(req *http.Request) bool { return !strings.HasSuffix(req.URL.Path, "/delete") }
+	return c
+}
+
+// post sends a request to an endpoint of the service
+func (b *webhookBackend) post(ctx context.Context, c *Client, endpoint string, req webhookRequest) (string, string, int, error) {
+	req.Account = b.account
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", "", 0, err
+	}
+
+	header := http.Header{}
+	if b.cfg.Token != "" {
+		header.Set("Authorization", "Bearer "+b.cfg.Token)
+	}
+	u := strings.TrimSuffix(b.cfg.URL, "/") + "/" + endpoint
+	answer, status, err := c.request(ctx, "POST", u, string(body), header)
+	return u, answer, status, err
+}
+
+func (b *webhookBackend) list(ctx context.Context, c *Client, kind string, q *spool.Queue[Item]) error {
+	seen := map[string]bool{}
+	cursor := ""
+	for {
+		_, answer, _, err := b.post(ctx, c, "list", webhookRequest{ContentType: kind, Cursor: cursor})
+		if err != nil {
+			return fmt.Errorf("error listing %s: %v", kind, err)
+		}
+		var page webhookPage
+		if err := json.Unmarshal([]byte(answer), &page); err != nil {
+			return fmt.Errorf("error decoding %s listing: %v", kind, err)
+		}
+
+		added := 0
+		for _, it := range page.Items {
+			if it.ID == "" || seen[it.ID] {
+				continue
+			}
+			seen[it.ID] = true
+			if err := q.Push(Item{ID: it.ID, Kind: kind, Date: it.Date, URL: it.URL, Text: it.Text, Scopes: it.Scopes}); err != nil {
+				return err
+			}
+			added++
+		}
+
+		// Stop at the last page, or when a page brings nothing new
+		if page.NextCursor == "" || added == 0 {
+			return nil
+		}
+		cursor = page.NextCursor
+		// The service's pages are posted for, so request doesn't wait
+		// between them
+		if err := c.pace.Pause(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+func (b *webhookBackend) check(ctx context.Context, c *Client, kind string) error {
+	_, _, _, err := b.post(ctx, c, "list", webhookRequest{ContentType: kind})
+	return err
+}
+
+func (b *webhookBackend) delete(ctx context.Context, c *Client, it Item) (audit.Receipt, error) {
+	u, answer, status, err := b.post(ctx, c, "delete", webhookRequest{ContentType: it.Kind, ID: it.ID})
+	return audit.Receipt{
+		Time:     time.Now(),
+		Platform: c.name,
+		Kind:     it.Kind,
+		ID:       it.ID,
+		Method:   "POST",
+		URL:      u,
+		Status:   status,
+		Body:     answer,
+	}, err
+}
@@ -0,0 +1,161 @@
+// Package hook runs user scripts before and after each deletion, so runs
+// can be customized without a flag for every need
+package hook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// timeout bounds a single hook call, so a stuck script can't stall the run
+const timeout = time.Minute
+
+// Config holds the shell commands to run. Each gets the item as JSON on
+// stdin.
+type Config struct {
+	// BeforeDelete may veto the deletion or supply the overwrite text by
+	// printing a Decision
+	BeforeDelete string `json:"before_delete"`
+
+	// AfterDelete runs once the item is gone, e.g. to keep a journal
+	AfterDelete string `json:"after_delete"`
+}
+
+// Item is the content a hook is called for
+type Item struct {
+	Event    string    `json:"event"`
+	Platform string    `json:"platform"`
+	Kind     string    `json:"kind"`
+	ID       string    `json:"id"`
+	Date     time.Time `json:"date"`
+	URL      string    `json:"url,omitempty"`
+	Text     string    `json:"text,omitempty"`
+
+	// Simulated is set during --simulate runs, when nothing is really deleted
+	Simulated bool `json:"simulated,omitempty"`
+}
+
+// Decision is what a before-delete hook prints. No output means the item
+// is deleted as usual.
+type Decision struct {
+	// Veto keeps the item online, for Reason
+	Veto   bool   `json:"veto"`
+	Reason string `json:"reason,omitempty"`
+
+	// Overwrite replaces the configured overwrite text for this item, on
+	// platforms that overwrite before deleting
+	Overwrite string `json:"overwrite,omitempty"`
+}
+
+// Hooks runs the configured hooks
+type Hooks struct {
+	cfg       Config
+	simulated bool
+
+	// archive, when set, is given each item before the before-delete hook
+	archive func(context.Context, Item) error
+}
+
+// New returns the hooks of cfg, or nil if there are none
+func New(cfg *Config) *Hooks {
+	if cfg == nil || (cfg.BeforeDelete == "" && cfg.AfterDelete == "") {
+		return nil
+	}
+	return &Hooks{cfg: *cfg}
+}
+
+// Simulating returns hooks that tell the scripts the run is simulated
+func (h *Hooks) Simulating() *Hooks {
+	if h == nil {
+		return nil
+	}
+	s := *h
+	s.simulated = true
+	return &s
+}
+
+// Archiving returns hooks that first pass each item to archive. An item
+// that fails to be archived is kept online.
+func (h *Hooks) Archiving(archive func(context.Context, Item) error) *Hooks {
+	a := &Hooks{}
+	if h != nil {
+		*a = *h
+	}
+	a.archive = archive
+	return a
+}
+
+// Before runs the before-delete hook. A failing hook vetoes the deletion,
+// since it may have been meant to.
+func (h *Hooks) Before(ctx context.Context, it Item) Decision {
+	if h == nil {
+		return Decision{}
+	}
+	if h.archive != nil {
+		if err := h.archive(ctx, it); err != nil {
+			return Decision{Veto: true, Reason: fmt.Sprintf("could not be archived: %v", err)}
+		}
+	}
+	if h.cfg.BeforeDelete == "" {
+		return Decision{}
+	}
+	it.Event, it.Simulated = "before_delete", h.simulated
+	out, err := run(ctx, h.cfg.BeforeDelete, it)
+	if err != nil {
+		return Decision{Veto: true, Reason: fmt.Sprintf("before-delete hook failed: %v", err)}
+	}
+
+	var d Decision
+	if out = bytes.TrimSpace(out); len(out) == 0 {
+		return d
+	}
+	if err := json.Unmarshal(out, &d); err != nil {
+		return Decision{Veto: true, Reason: fmt.Sprintf("before-delete hook printed invalid JSON: %v", err)}
+	}
+	if d.Veto && d.Reason == "" {
+		d.Reason = "vetoed by the before-delete hook"
+	}
+	return d
+}
+
+// After runs the after-delete hook
+func (h *Hooks) After(ctx context.Context, it Item) error {
+	if h == nil || h.cfg.AfterDelete == "" {
+		return nil
+	}
+	it.Event, it.Simulated = "after_delete", h.simulated
+	if _, err := run(ctx, h.cfg.AfterDelete, it); err != nil {
+		return fmt.Errorf("after-delete hook failed: %v", err)
+	}
+	return nil
+}
+
+// run starts command in a shell with the item on stdin and returns its
+// output
+func run(ctx context.Context, command string, it Item) ([]byte, error) {
+	data, err := json.Marshal(it)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
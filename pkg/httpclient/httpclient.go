@@ -0,0 +1,56 @@
+// Package httpclient provides the HTTP clients used for every request to a
+// platform, sharing one tuned transport so connections are reused
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Timeout bounds a whole request, so a stuck connection can't hang a run
+const Timeout = 30 * time.Second
+
+// Transport is shared by every client. It keeps connections to a platform
+// alive between requests and speaks HTTP/2 where the server does.
+var Transport http.RoundTripper = &http.Transport{
+	Proxy: http.ProxyFromEnvironment,
+	DialContext: (&net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext,
+	ForceAttemptHTTP2:     true,
+	MaxIdleConns:          100,
+	MaxIdleConnsPerHost:   10,
+	IdleConnTimeout:       90 * time.Second,
+	TLSHandshakeTimeout:   10 * time.Second,
+	ResponseHeaderTimeout: Timeout,
+	ExpectContinueTimeout: time.Second,
+}
+
+// New returns a client over the shared transport. Each profile gets its own,
+// as simulation and receipts wrap a client's transport for one account.
+func New() *http.Client {
+	return &http.Client{Transport: Transport, Timeout: Timeout}
+}
+
+// RetryAfter reads a Retry-After header, given in seconds or as a date, or
+// else the time in an X-RateLimit-Reset header as the Mastodon API and
+// its kin send it. ok is false when both are missing or unreadable.
+func RetryAfter(h http.Header) (wait time.Duration, ok bool) {
+	v := h.Get("Retry-After")
+	if v == "" {
+		if t, err := time.Parse(time.RFC3339, h.Get("X-RateLimit-Reset")); err == nil {
+			return max(time.Until(t), 0), true
+		}
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,211 @@
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-del-socials/pkg/store"
+)
+
+// Index is a local copy of every listed item, so incremental runs can apply
+// retention without listing the whole account again
+type Index struct {
+	s store.Store
+}
+
+// item is an indexed item. Items are keyed by creation time within their
+// listing's bucket, so scans return them oldest first.
+type item struct {
+	Created time.Time       `json:"created"`
+	Deleted bool            `json:"deleted,omitempty"`
+	Raw     json.RawMessage `json:"raw"`
+}
+
+// ref locates an item by platform and ID
+type ref struct {
+	Listing string `json:"listing"`
+	Key     string `json:"key"`
+}
+
+func New(s store.Store) *Index {
+	return &Index{s: s}
+}
+
+func itemBucket(platform, listing string) string {
+	return "items/" + platform + "/" + listing
+}
+
+func refBucket(platform string) string {
+	return "item-refs/" + platform
+}
+
+// Put stores an item as listed. raw is the item as the platform returned
+// it, so it can be processed again later.
+func (ix *Index) Put(platform, listing, id string, created time.Time, raw []byte) error {
+	r := ref{Listing: listing, Key: fmt.Sprintf("%020d/%s", created.Unix(), id)}
+	it := item{Created: created, Raw: raw}
+
+	// Listing an item again doesn't bring it back
+	if old, err := ix.get(platform, r); err == nil {
+		it.Deleted = old.Deleted
+	} else if !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+
+	data, err := json.Marshal(it)
+	if err != nil {
+		return err
+	}
+	if err := ix.s.Put(itemBucket(platform, listing), r.Key, data); err != nil {
+		return fmt.Errorf("failed to index %s: %v", id, err)
+	}
+
+	data, err = json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := ix.s.Put(refBucket(platform), id, data); err != nil {
+		return fmt.Errorf("failed to index %s: %v", id, err)
+	}
+	return nil
+}
+
+func (ix *Index) get(platform string, r ref) (*item, error) {
+	data, err := ix.s.Get(itemBucket(platform, r.Listing), r.Key)
+	if err != nil {
+		return nil, err
+	}
+	var it item
+	if err := json.Unmarshal(data, &it); err != nil {
+		return nil, fmt.Errorf("corrupt item index entry %s: %v", r.Key, err)
+	}
+	return &it, nil
+}
+
+// MarkDeleted records that an item is gone
+func (ix *Index) MarkDeleted(platform, id string) error {
+	data, err := ix.s.Get(refBucket(platform), id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update index for %s: %v", id, err)
+	}
+
+	var r ref
+	if err := json.Unmarshal(data, &r); err != nil {
+		return fmt.Errorf("corrupt item index entry %s: %v", id, err)
+	}
+	it, err := ix.get(platform, r)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	it.Deleted = true
+	if data, err = json.Marshal(it); err != nil {
+		return err
+	}
+	if err := ix.s.Put(itemBucket(platform, r.Listing), r.Key, data); err != nil {
+		return fmt.Errorf("failed to update index for %s: %v", id, err)
+	}
+	return nil
+}
+
+// scan calls fn with the items of a listing, oldest first, one at a time
+func (ix *Index) scan(platform, listing string, fn func(key string, it *item) error) error {
+	err := ix.s.Scan(itemBucket(platform, listing), func(key string, value []byte) error {
+		var it item
+		if err := json.Unmarshal(value, &it); err != nil {
+			return fmt.Errorf("corrupt item index entry %s: %v", key, err)
+		}
+		return fn(key, &it)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read item index: %v", err)
+	}
+	return nil
+}
+
+// Newest returns the creation time of the newest indexed item of a listing.
+// ok is false while the listing has never been indexed.
+func (ix *Index) Newest(platform, listing string) (time.Time, bool, error) {
+	key, data, err := ix.s.Last(itemBucket(platform, listing))
+	if errors.Is(err, store.ErrNotFound) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to read item index: %v", err)
+	}
+	var it item
+	if err := json.Unmarshal(data, &it); err != nil {
+		return time.Time{}, false, fmt.Errorf("corrupt item index entry %s: %v", key, err)
+	}
+	return it.Created, true, nil
+}
+
+// Live calls fn with the raw items of a listing that haven't been deleted,
+// newest first like the platform listings, until fn returns false. Only
+// the keys are held in memory; each item is read as fn gets to it.
+func (ix *Index) Live(platform, listing string, fn func(raw []byte) (bool, error)) error {
+	var keys []string
+	err := ix.s.Keys(itemBucket(platform, listing), func(key string) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read item index: %v", err)
+	}
+
+	for i := len(keys) - 1; i >= 0; i-- {
+		it, err := ix.get(platform, ref{Listing: listing, Key: keys[i]})
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if it.Deleted {
+			continue
+		}
+		if ok, err := fn(it.Raw); err != nil || !ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// Created returns the creation times of the items that haven't been
+// deleted, by listing, so a cutoff's matches can be counted offline
+func (ix *Index) Created(platform string) (map[string][]time.Time, error) {
+	listings := map[string]bool{}
+	err := ix.s.Scan(refBucket(platform), func(key string, value []byte) error {
+		var r ref
+		if err := json.Unmarshal(value, &r); err != nil {
+			return fmt.Errorf("corrupt item index entry %s: %v", key, err)
+		}
+		listings[r.Listing] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read item index: %v", err)
+	}
+
+	created := map[string][]time.Time{}
+	for listing := range listings {
+		err := ix.scan(platform, listing, func(_ string, it *item) error {
+			if !it.Deleted {
+				created[listing] = append(created[listing], it.Created)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return created, nil
+}
@@ -0,0 +1,79 @@
+package index
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go-del-socials/pkg/store"
+)
+
+// counting counts the values read from a store
+type counting struct {
+	store.Store
+	values int
+}
+
+func (c *counting) Get(bucket, key string) ([]byte, error) {
+	c.values++
+	return c.Store.Get(bucket, key)
+}
+
+func (c *counting) Scan(bucket string, fn func(key string, value []byte) error) error {
+	return c.Store.Scan(bucket, func(key string, value []byte) error {
+		c.values++
+		return fn(key, value)
+	})
+}
+
+func (c *counting) Last(bucket string) (string, []byte, error) {
+	c.values++
+	return c.Store.Last(bucket)
+}
+
+// Live must read items as it hands them on, not the whole listing first
+func TestLiveReadsItemsLazily(t *testing.T) {
+	s, err := store.Open("json", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	ix := New(s)
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 500; i++ {
+		raw := []byte(fmt.Sprintf(`{"id": %d}`, i))
+		if err := ix.Put("reddit", "posts", fmt.Sprint(i), start.Add(time.Duration(i)*time.Hour), raw); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ix.MarkDeleted("reddit", "499"); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &counting{Store: s}
+	var got []string
+	err = New(c).Live("reddit", "posts", func(raw []byte) (bool, error) {
+		got = append(got, string(raw))
+		return len(got) < 2, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`{"id":498}`, `{"id":497}`}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Live handed %v, want %v", got, want)
+	}
+	// The deleted newest item and the two handed on
+	if c.values != 3 {
+		t.Errorf("Live read %d values for 2 items, want 3", c.values)
+	}
+
+	c.values = 0
+	newest, ok, err := New(c).Newest("reddit", "posts")
+	if err != nil || !ok || !newest.Equal(start.Add(499*time.Hour)) {
+		t.Errorf("Newest() = %v, %v, %v", newest, ok, err)
+	}
+	if c.values != 1 {
+		t.Errorf("Newest read %d values, want 1", c.values)
+	}
+}
@@ -0,0 +1,111 @@
+package inventory
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Item is a piece of content still online after a run
+type Item struct {
+	ID     string    `json:"id"`
+	Kind   string    `json:"kind"`
+	Date   time.Time `json:"date"`
+	URL    string    `json:"url,omitempty"`
+	Text   string    `json:"text,omitempty"`
+	Reason string    `json:"reason"`
+}
+
+// List is the surviving content of one profile
+type List struct {
+	Items []Item `json:"items"`
+
+	mu sync.Mutex
+}
+
+// Add records a surviving item and why it was kept
+func (l *List) Add(item Item) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Items = append(l.Items, item)
+}
+
+// Inventory collects what survived a run across profiles
+type Inventory struct {
+	Platform string           `json:"platform"`
+	Created  time.Time        `json:"created"`
+	Profiles map[string]*List `json:"profiles"`
+
+	mu sync.Mutex
+}
+
+func New(platform string) *Inventory {
+	return &Inventory{Platform: platform, Created: time.Now(), Profiles: map[string]*List{}}
+}
+
+// Profile returns the list of a profile, creating it as needed
+func (inv *Inventory) Profile(name string) *List {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	if inv.Profiles[name] == nil {
+		inv.Profiles[name] = &List{}
+	}
+	return inv.Profiles[name]
+}
+
+// Len returns the number of surviving items across profiles
+func (inv *Inventory) Len() int {
+	n := 0
+	for _, l := range inv.Profiles {
+		n += len(l.Items)
+	}
+	return n
+}
+
+// Write saves the inventory to path, as CSV when the name ends in .csv and
+// as JSON otherwise
+func (inv *Inventory) Write(path string) error {
+	var data []byte
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		data, err = inv.csv()
+	} else {
+		data, err = json.MarshalIndent(inv, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write kept content: %v", err)
+	}
+	return nil
+}
+
+func (inv *Inventory) csv() ([]byte, error) {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write([]string{"profile", "kind", "id", "date", "url", "reason", "text"})
+
+	names := make([]string, 0, len(inv.Profiles))
+	for name := range inv.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, it := range inv.Profiles[name].Items {
+			w.Write([]string{name, it.Kind, it.ID, it.Date.UTC().Format(time.RFC3339), it.URL, it.Reason, it.Text})
+		}
+	}
+
+	w.Flush()
+	return []byte(b.String()), w.Error()
+}
@@ -0,0 +1,127 @@
+package logfile
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const backupSuffix = "20060102-150405.000000"
+
+// Writer appends timestamped lines to a log file, rotating it once it grows
+// past MaxSize bytes or gets older than MaxAge. Rotated files are renamed
+// with their rotation time and the oldest beyond Keep are removed.
+type Writer struct {
+	path    string
+	maxSize int64
+	maxAge  time.Duration
+	keep    int
+
+	mu      sync.Mutex
+	f       *os.File
+	size    int64
+	started time.Time
+	buf     []byte
+}
+
+// Open opens or creates the log file at path. A zero maxSize or maxAge
+// disables that kind of rotation.
+func Open(path string, maxSize int64, maxAge time.Duration, keep int) (*Writer, error) {
+	w := &Writer{path: path, maxSize: maxSize, maxAge: maxAge, keep: keep}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+
+	w.f, w.size, w.started = f, info.Size(), time.Now()
+	if info.Size() > 0 {
+		// An existing log is as old as its last write, for want of better
+		w.started = info.ModTime()
+	}
+	return nil
+}
+
+// Write buffers partial lines and writes complete ones with a timestamp
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.writeLine(w.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+func (w *Writer) writeLine(line []byte) error {
+	now := time.Now()
+	if w.size > 0 && ((w.maxSize > 0 && w.size+int64(len(line)) > w.maxSize) || (w.maxAge > 0 && now.Sub(w.started) > w.maxAge)) {
+		if err := w.rotate(now); err != nil {
+			return err
+		}
+	}
+
+	n, err := fmt.Fprintf(w.f, "%s %s", now.Format(time.RFC3339), line)
+	w.size += int64(n)
+	return err
+}
+
+func (w *Writer) rotate(now time.Time) error {
+	if err := w.f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(w.path, w.path+"."+now.Format(backupSuffix)); err != nil {
+		return fmt.Errorf("failed to rotate log file: %v", err)
+	}
+	w.prune()
+	return w.open()
+}
+
+// prune removes the oldest rotated files beyond keep
+func (w *Writer) prune() {
+	if w.keep <= 0 {
+		return
+	}
+	backups, _ := filepath.Glob(w.path + ".[0-9]*")
+	// The suffix sorts chronologically
+	sort.Strings(backups)
+	for len(backups) > w.keep {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+}
+
+// Close writes any unterminated last line and closes the file
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		w.writeLine(append(w.buf, '\n'))
+		w.buf = nil
+	}
+	return w.f.Close()
+}
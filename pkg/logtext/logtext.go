@@ -0,0 +1,37 @@
+// Package logtext makes the text of posts, comments and tweets safe to echo
+// in console output and log files
+package logtext
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MaxLen is how many characters of an item's text are shown
+const MaxLen = 80
+
+// Hidden stands in for text when content logging is off
+const Hidden = "[content hidden]"
+
+// Clean replaces control characters with spaces and collapses runs of
+// whitespace. That covers newlines, the escape sequences that could restyle
+// or rewrite a terminal, and the bidirectional overrides that could make a
+// line read differently from what it says.
+func Clean(text string) string {
+	return strings.Join(strings.FieldsFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r) || unicode.Is(unicode.Bidi_Control, r)
+	}), " ")
+}
+
+// Short cleans text and cuts it to MaxLen characters, or returns Hidden when
+// hide is set
+func Short(text string, hide bool) string {
+	if hide {
+		return Hidden
+	}
+	text = Clean(text)
+	if r := []rune(text); len(r) > MaxLen {
+		return strings.TrimSpace(string(r[:MaxLen-1])) + "…"
+	}
+	return text
+}
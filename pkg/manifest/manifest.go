@@ -0,0 +1,217 @@
+package manifest
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Manifest and signature file names in the archive directory. The manifest
+// uses the sha256sum format, so `sha256sum -c` can check it too.
+const (
+	File          = "MANIFEST.sha256"
+	SignatureFile = "MANIFEST.sha256.sig"
+)
+
+// read returns the checksums recorded in dir's manifest by relative path
+func read(dir string) (map[string]string, error) {
+	sums := map[string]string{}
+
+	f, err := os.Open(filepath.Join(dir, File))
+	if errors.Is(err, os.ErrNotExist) {
+		return sums, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %v", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		sum, path, ok := strings.Cut(sc.Text(), "  ")
+		if !ok {
+			return nil, fmt.Errorf("invalid manifest line %q", sc.Text())
+		}
+		sums[path] = sum
+	}
+	return sums, sc.Err()
+}
+
+func hash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// files returns the relative paths of the archive files below dir
+func files(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel != File && rel != SignatureFile {
+			paths = append(paths, rel)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// Update adds checksums of files new to the archive in dir to its manifest
+// and signs it when key is set. Files already listed keep their recorded
+// checksum, so later corruption is caught instead of recorded.
+func Update(dir string, key ed25519.PrivateKey) (added int, err error) {
+	sums, err := read(dir)
+	if err != nil {
+		return 0, err
+	}
+	paths, err := files(dir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list archive: %v", err)
+	}
+
+	for _, rel := range paths {
+		if _, ok := sums[rel]; ok {
+			continue
+		}
+		if sums[rel], err = hash(filepath.Join(dir, rel)); err != nil {
+			return added, fmt.Errorf("failed to hash %s: %v", rel, err)
+		}
+		added++
+	}
+	if added == 0 && (key == nil || signed(dir)) {
+		return 0, nil
+	}
+
+	listed := make([]string, 0, len(sums))
+	for rel := range sums {
+		listed = append(listed, rel)
+	}
+	sort.Strings(listed)
+
+	var b strings.Builder
+	for _, rel := range listed {
+		fmt.Fprintf(&b, "%s  %s\n", sums[rel], rel)
+	}
+	data := []byte(b.String())
+	if err := os.WriteFile(filepath.Join(dir, File), data, 0o600); err != nil {
+		return added, fmt.Errorf("failed to write manifest: %v", err)
+	}
+
+	if key != nil {
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, data))
+		if err := os.WriteFile(filepath.Join(dir, SignatureFile), []byte(sig+"\n"), 0o600); err != nil {
+			return added, fmt.Errorf("failed to write manifest signature: %v", err)
+		}
+	}
+	return added, nil
+}
+
+func signed(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, SignatureFile))
+	return err == nil
+}
+
+// Result lists what Verify found wrong with an archive
+type Result struct {
+	Checked int
+
+	Corrupted []string // contents changed since they were recorded
+	Missing   []string // listed in the manifest but gone
+	Unlisted  []string // not in the manifest yet
+
+	// Signature is "valid", "invalid", "missing" or "" when no key was given
+	Signature string
+}
+
+// OK reports whether every listed file is intact and the signature, if
+// checked, is valid
+func (r *Result) OK() bool {
+	return len(r.Corrupted) == 0 && len(r.Missing) == 0 && (r.Signature == "" || r.Signature == "valid")
+}
+
+// Verify checks the archive in dir against its manifest, and the manifest
+// against its signature when pub is set
+func Verify(dir string, pub ed25519.PublicKey) (*Result, error) {
+	sums, err := read(dir)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := files(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archive: %v", err)
+	}
+
+	r := &Result{}
+	present := map[string]bool{}
+	for _, rel := range paths {
+		present[rel] = true
+		want, ok := sums[rel]
+		if !ok {
+			r.Unlisted = append(r.Unlisted, rel)
+			continue
+		}
+		got, err := hash(filepath.Join(dir, rel))
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %v", rel, err)
+		}
+		r.Checked++
+		if got != want {
+			r.Corrupted = append(r.Corrupted, rel)
+		}
+	}
+	for rel := range sums {
+		if !present[rel] {
+			r.Missing = append(r.Missing, rel)
+		}
+	}
+	sort.Strings(r.Missing)
+
+	if pub != nil {
+		r.Signature = "missing"
+		data, err := os.ReadFile(filepath.Join(dir, File))
+		sigText, sigErr := os.ReadFile(filepath.Join(dir, SignatureFile))
+		if err == nil && sigErr == nil {
+			r.Signature = "invalid"
+			sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sigText)))
+			if err == nil && ed25519.Verify(pub, data, sig) {
+				r.Signature = "valid"
+			}
+		}
+	}
+	return r, nil
+}
+
+// ParseKey decodes a base64 Ed25519 private key seed, as generated by
+// `openssl rand -base64 32`
+func ParseKey(s string) (ed25519.PrivateKey, error) {
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("archive signing key must be %d base64-encoded bytes", ed25519.SeedSize)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
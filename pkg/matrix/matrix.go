@@ -0,0 +1,257 @@
+package matrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go-del-socials/pkg/httpclient"
+)
+
+// Client is a minimal Matrix client-server API client covering what is
+// needed to find and redact a user's own messages.
+type Client struct {
+	BaseURL     string
+	AccessToken string
+	UserID      string
+	HTTPClient  *http.Client
+
+	txnID atomic.Int64
+}
+
+type Event struct {
+	EventID        string          `json:"event_id"`
+	Type           string          `json:"type"`
+	Sender         string          `json:"sender"`
+	OriginServerTS int64           `json:"origin_server_ts"`
+	Content        json.RawMessage `json:"content"`
+}
+
+// Time returns when the event was sent
+func (e *Event) Time() time.Time {
+	return time.UnixMilli(e.OriginServerTS)
+}
+
+// Redacted reports whether the event content has already been removed
+func (e *Event) Redacted() bool {
+	c := bytes.TrimSpace(e.Content)
+	return len(c) == 0 || string(c) == "{}" || string(c) == "null"
+}
+
+// Error is an error response from the homeserver
+type Error struct {
+	StatusCode   int
+	ErrCode      string `json:"errcode"`
+	Message      string `json:"error"`
+	RetryAfterMS int64  `json:"retry_after_ms"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d): %s", e.ErrCode, e.StatusCode, e.Message)
+}
+
+// Login authenticates against baseURL with the given login body, e.g.
+// {"type": "m.login.password", ...}, and returns a ready client.
+func Login(ctx context.Context, baseURL string, body map[string]any, httpClient *http.Client) (*Client, error) {
+	c := &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: httpClient,
+	}
+	if c.HTTPClient == nil {
+		c.HTTPClient = httpclient.New()
+	}
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		UserID      string `json:"user_id"`
+	}
+	if err := c.do(ctx, "POST", "/_matrix/client/v3/login", body, &resp); err != nil {
+		return nil, fmt.Errorf("login failed: %v", err)
+	}
+
+	c.AccessToken = resp.AccessToken
+	c.UserID = resp.UserID
+	return c, nil
+}
+
+// WhoAmI sets UserID to the owner of the access token
+func (c *Client) WhoAmI(ctx context.Context) error {
+	var resp struct {
+		UserID string `json:"user_id"`
+	}
+	if err := c.do(ctx, "GET", "/_matrix/client/v3/account/whoami", nil, &resp); err != nil {
+		return err
+	}
+	c.UserID = resp.UserID
+	return nil
+}
+
+// Logout invalidates the access token, removing the device Login created
+func (c *Client) Logout(ctx context.Context) error {
+	return c.do(ctx, "POST", "/_matrix/client/v3/logout", map[string]any{}, nil)
+}
+
+// ResolveAlias returns the room ID of an alias such as #room:example.org
+func (c *Client) ResolveAlias(ctx context.Context, alias string) (string, error) {
+	var resp struct {
+		RoomID string `json:"room_id"`
+	}
+	if err := c.do(ctx, "GET", "/_matrix/client/v3/directory/room/"+url.PathEscape(alias), nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.RoomID, nil
+}
+
+// CanRedactOwn reports whether the room's power levels let the user redact
+// their own events, and the levels compared
+func (c *Client) CanRedactOwn(ctx context.Context, roomID string) (ok bool, have, need int, err error) {
+	var levels struct {
+		Users         map[string]int `json:"users"`
+		UsersDefault  int            `json:"users_default"`
+		Events        map[string]int `json:"events"`
+		EventsDefault int            `json:"events_default"`
+	}
+	err = c.do(ctx, "GET", "/_matrix/client/v3/rooms/"+url.PathEscape(roomID)+"/state/m.room.power_levels", nil, &levels)
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		// Rooms without power levels let everyone do everything
+		return true, 0, 0, nil
+	}
+	if err != nil {
+		return false, 0, 0, err
+	}
+
+	have, ok = levels.Users[c.UserID]
+	if !ok {
+		have = levels.UsersDefault
+	}
+	// Redactions are sent like messages, so they need the level for
+	// m.room.redaction events, or for events in general
+	need, ok = levels.Events["m.room.redaction"]
+	if !ok {
+		need = levels.EventsDefault
+	}
+	return have >= need, have, need, nil
+}
+
+func (c *Client) JoinedRooms(ctx context.Context) ([]string, error) {
+	var resp struct {
+		JoinedRooms []string `json:"joined_rooms"`
+	}
+	if err := c.do(ctx, "GET", "/_matrix/client/v3/joined_rooms", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.JoinedRooms, nil
+}
+
+// Messages returns a page of room events, newest first, starting at from
+// (empty for the latest). The returned token continues pagination and is
+// empty once the start of the room is reached.
+func (c *Client) Messages(ctx context.Context, roomID, from string, limit int) ([]Event, string, error) {
+	return c.messages(ctx, roomID, from, limit, url.Values{})
+}
+
+// MessagesBy is Messages limited to the m.room.message events of a sender,
+// so the homeserver skips everyone else's
+func (c *Client) MessagesBy(ctx context.Context, roomID, sender, from string, limit int) ([]Event, string, error) {
+	filter, err := json.Marshal(map[string]any{"senders": []string{sender}, "types": []string{"m.room.message"}})
+	if err != nil {
+		return nil, "", err
+	}
+	return c.messages(ctx, roomID, from, limit, url.Values{"filter": {string(filter)}})
+}
+
+func (c *Client) messages(ctx context.Context, roomID, from string, limit int, q url.Values) ([]Event, string, error) {
+	q.Set("dir", "b")
+	q.Set("limit", strconv.Itoa(limit))
+	if from != "" {
+		q.Set("from", from)
+	}
+
+	var resp struct {
+		Chunk []Event `json:"chunk"`
+		End   string  `json:"end"`
+	}
+	path := "/_matrix/client/v3/rooms/" + url.PathEscape(roomID) + "/messages?" + q.Encode()
+	if err := c.do(ctx, "GET", path, nil, &resp); err != nil {
+		return nil, "", err
+	}
+
+	// A filtered page can be empty with more history behind it; the end
+	// token is left out at the start of the room
+	if resp.End == from || (len(resp.Chunk) == 0 && !q.Has("filter")) {
+		return resp.Chunk, "", nil
+	}
+	return resp.Chunk, resp.End, nil
+}
+
+// Redact removes the content of an event
+func (c *Client) Redact(ctx context.Context, roomID, eventID, reason string) error {
+	txn := strconv.FormatInt(time.Now().UnixNano(), 36) + "." + strconv.FormatInt(c.txnID.Add(1), 10)
+	path := "/_matrix/client/v3/rooms/" + url.PathEscape(roomID) + "/redact/" + url.PathEscape(eventID) + "/" + txn
+
+	body := map[string]any{}
+	if reason != "" {
+		body["reason"] = reason
+	}
+	return c.do(ctx, "PUT", path, body, nil)
+}
+
+// do sends a request, waiting and retrying when the homeserver rate limits it
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	for {
+		var reader io.Reader
+		if body != nil {
+			data, err := json.Marshal(body)
+			if err != nil {
+				return err
+			}
+			reader = bytes.NewReader(data)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if c.AccessToken != "" {
+			req.Header.Set("Authorization", "Bearer "+c.AccessToken)
+		}
+
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			apiErr := &Error{StatusCode: resp.StatusCode}
+			json.NewDecoder(resp.Body).Decode(apiErr)
+			resp.Body.Close()
+
+			if resp.StatusCode == http.StatusTooManyRequests && apiErr.RetryAfterMS > 0 {
+				select {
+				case <-time.After(time.Duration(apiErr.RetryAfterMS) * time.Millisecond):
+					continue
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return apiErr
+		}
+
+		if out != nil {
+			err = json.NewDecoder(resp.Body).Decode(out)
+		}
+		resp.Body.Close()
+		return err
+	}
+}
@@ -0,0 +1,256 @@
+// Package mqtt publishes run status to an MQTT broker, so home automation
+// such as Home Assistant or Node-RED can show and alert on runs. It speaks
+// just enough MQTT 3.1.1 to publish at QoS 0.
+package mqtt
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"go-del-socials/pkg/secrets"
+)
+
+// Config is the broker and the topics to publish under
+type Config struct {
+	// Broker is the broker's URL: mqtt://host:1883, or mqtts:// for TLS
+	Broker string `json:"broker"`
+
+	Username string `json:"username"`
+	Password string `json:"password"`
+
+	// ClientID defaults to go-del-socials
+	ClientID string `json:"client_id"`
+
+	// Topic is put in front of every topic, default go-del-socials.
+	// Status goes to <topic>/<profile>/<platform>/status and counts to
+	// .../counts, both retained.
+	Topic string `json:"topic"`
+
+	// AlertTopic receives failed and stopped runs, default <topic>/alerts
+	AlertTopic string `json:"alert_topic"`
+}
+
+// dialTimeout bounds connecting, so an unreachable broker can't hold up a run
+const dialTimeout = 10 * time.Second
+
+// Publisher publishes to the broker of a config. It connects when first
+// used and again after a failure.
+type Publisher struct {
+	cfg  Config
+	addr string
+	tls  bool
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// New returns a publisher for cfg, or nil if there is none
+func New(cfg *Config) (*Publisher, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	c := *cfg
+	if err := secrets.ResolveAll(&c.Password); err != nil {
+		return nil, err
+	}
+	if c.ClientID == "" {
+		c.ClientID = "go-del-socials"
+	}
+	if c.Topic == "" {
+		c.Topic = "go-del-socials"
+	}
+	if c.AlertTopic == "" {
+		c.AlertTopic = c.Topic + "/alerts"
+	}
+
+	u, err := url.Parse(c.Broker)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("mqtt broker must be a URL such as mqtt://localhost:1883")
+	}
+	p := &Publisher{cfg: c, addr: u.Host}
+	switch u.Scheme {
+	case "mqtt", "tcp":
+		if u.Port() == "" {
+			p.addr = net.JoinHostPort(u.Hostname(), "1883")
+		}
+	case "mqtts", "ssl", "tls":
+		p.tls = true
+		if u.Port() == "" {
+			p.addr = net.JoinHostPort(u.Hostname(), "8883")
+		}
+	default:
+		return nil, fmt.Errorf("unknown mqtt broker scheme %q (use mqtt or mqtts)", u.Scheme)
+	}
+	return p, nil
+}
+
+// Status publishes a run's status, e.g. "running" or "done", retained
+func (p *Publisher) Status(profile, platform, status string) error {
+	return p.publish(p.topic(profile, platform, "status"), []byte(status), true)
+}
+
+// Counts publishes a run's counts as JSON, retained
+func (p *Publisher) Counts(profile, platform string, counts map[string]int) error {
+	data, err := json.Marshal(counts)
+	if err != nil {
+		return err
+	}
+	return p.publish(p.topic(profile, platform, "counts"), data, true)
+}
+
+// Alert is a run that needs attention
+type Alert struct {
+	Profile  string     `json:"profile"`
+	Platform string     `json:"platform"`
+	Message  string     `json:"message"`
+	ResumeAt *time.Time `json:"resume_at,omitempty"`
+}
+
+// Alert publishes an alert as JSON
+func (p *Publisher) Alert(a Alert) error {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return p.publish(p.cfg.AlertTopic, data, false)
+}
+
+// Close disconnects from the broker
+func (p *Publisher) Close() error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == nil {
+		return nil
+	}
+	p.conn.Write([]byte{0xe0, 0}) // DISCONNECT
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
+
+func (p *Publisher) topic(profile, platform, name string) string {
+	return p.cfg.Topic + "/" + profile + "/" + platform + "/" + name
+}
+
+// publish sends a message, reconnecting once if the connection was lost
+func (p *Publisher) publish(topic string, payload []byte, retain bool) error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	msg := publishPacket(topic, payload, retain)
+	for attempt := 0; attempt < 2; attempt++ {
+		if p.conn == nil {
+			if err := p.connect(); err != nil {
+				return fmt.Errorf("failed to connect to mqtt broker: %v", err)
+			}
+		}
+		p.conn.SetWriteDeadline(time.Now().Add(dialTimeout))
+		if _, err := p.conn.Write(msg); err == nil {
+			return nil
+		}
+		p.conn.Close()
+		p.conn = nil
+	}
+	return fmt.Errorf("failed to publish to %s", topic)
+}
+
+// connect opens a connection and waits for the broker to accept it
+func (p *Publisher) connect() error {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	var conn net.Conn
+	var err error
+	if p.tls {
+		conn, err = tls.DialWithDialer(dialer, "tcp", p.addr, nil)
+	} else {
+		conn, err = dialer.Dial("tcp", p.addr)
+	}
+	if err != nil {
+		return err
+	}
+
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+	if _, err := conn.Write(connectPacket(p.cfg)); err != nil {
+		conn.Close()
+		return err
+	}
+	var ack [4]byte
+	if _, err := io.ReadFull(bufio.NewReader(conn), ack[:]); err != nil {
+		conn.Close()
+		return err
+	}
+	if ack[0] != 0x20 {
+		conn.Close()
+		return errors.New("broker didn't acknowledge the connection")
+	}
+	if ack[3] != 0 {
+		conn.Close()
+		return fmt.Errorf("broker refused the connection (code %d)", ack[3])
+	}
+	conn.SetDeadline(time.Time{})
+	p.conn = conn
+	return nil
+}
+
+// connectPacket is a CONNECT with a clean session and no keep-alive, as
+// the connection only lives for a run
+func connectPacket(cfg Config) []byte {
+	flags := byte(0x02)
+	body := append(str("MQTT"), 4) // protocol level 3.1.1
+	var payload []byte
+	payload = append(payload, str(cfg.ClientID)...)
+	if cfg.Username != "" {
+		flags |= 0x80
+		payload = append(payload, str(cfg.Username)...)
+		if cfg.Password != "" {
+			flags |= 0x40
+			payload = append(payload, str(cfg.Password)...)
+		}
+	}
+	body = append(body, flags, 0, 0)
+	return packet(0x10, append(body, payload...))
+}
+
+func publishPacket(topic string, payload []byte, retain bool) []byte {
+	header := byte(0x30)
+	if retain {
+		header |= 0x01
+	}
+	return packet(header, append(str(topic), payload...))
+}
+
+// packet adds the fixed header with the remaining length
+func packet(header byte, body []byte) []byte {
+	out := []byte{header}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(out, body...)
+}
+
+// str encodes a length-prefixed UTF-8 string
+func str(s string) []byte {
+	return append([]byte{byte(len(s) >> 8), byte(len(s))}, s...)
+}
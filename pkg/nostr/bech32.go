@@ -0,0 +1,99 @@
+package nostr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Nostr shows keys and note IDs in bech32 (NIP-19), e.g. nsec1... and
+// note1...
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := range 5 {
+			if (top>>i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32Expand(hrp string) []byte {
+	out := make([]byte, 0, len(hrp)*2+1)
+	for i := range len(hrp) {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := range len(hrp) {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+// convertBits regroups data from groups of from bits into groups of to bits
+func convertBits(data []byte, from, to uint, pad bool) ([]byte, error) {
+	var acc, bits uint
+	var out []byte
+	max := uint(1)<<to - 1
+	for _, b := range data {
+		if uint(b)>>from != 0 {
+			return nil, fmt.Errorf("invalid data")
+		}
+		acc = acc<<from | uint(b)
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte(acc>>bits&max))
+		}
+	}
+	if pad && bits > 0 {
+		out = append(out, byte(acc<<(to-bits)&max))
+	} else if !pad && (bits >= from || acc<<(to-bits)&max != 0) {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	return out, nil
+}
+
+func decodeBech32(s string) (string, []byte, error) {
+	s = strings.ToLower(s)
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+7 > len(s) {
+		return "", nil, fmt.Errorf("invalid bech32 string")
+	}
+	hrp := s[:sep]
+	values := make([]byte, 0, len(s)-sep-1)
+	for _, c := range s[sep+1:] {
+		i := strings.IndexRune(bech32Charset, c)
+		if i < 0 {
+			return "", nil, fmt.Errorf("invalid bech32 character %q", c)
+		}
+		values = append(values, byte(i))
+	}
+	if bech32Polymod(append(bech32Expand(hrp), values...)) != 1 {
+		return "", nil, fmt.Errorf("invalid bech32 checksum")
+	}
+	data, err := convertBits(values[:len(values)-6], 5, 8, false)
+	return hrp, data, err
+}
+
+func encodeBech32(hrp string, data []byte) string {
+	values, _ := convertBits(data, 8, 5, true)
+	poly := bech32Polymod(append(append(bech32Expand(hrp), values...), 0, 0, 0, 0, 0, 0)) ^ 1
+	var b strings.Builder
+	b.WriteString(hrp)
+	b.WriteByte('1')
+	for _, v := range values {
+		b.WriteByte(bech32Charset[v])
+	}
+	for i := range 6 {
+		b.WriteByte(bech32Charset[poly>>(5*(5-i))&31])
+	}
+	return b.String()
+}
@@ -0,0 +1,164 @@
+package nostr
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// The secp256k1 curve that Nostr keys are on
+var (
+	curveP, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", 16)
+	curveN, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	curveG    = point{
+		x: bigHex("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"),
+		y: bigHex("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8"),
+	}
+)
+
+func bigHex(s string) *big.Int {
+	n, _ := new(big.Int).SetString(s, 16)
+	return n
+}
+
+// point is an affine point of the curve; a nil x is the point at infinity
+type point struct {
+	x, y *big.Int
+}
+
+func (p point) add(q point) point {
+	switch {
+	case p.x == nil:
+		return q
+	case q.x == nil:
+		return p
+	}
+	var l *big.Int
+	if p.x.Cmp(q.x) == 0 {
+		if p.y.Cmp(q.y) != 0 || p.y.Sign() == 0 {
+			return point{}
+		}
+		// Doubling: l = 3x² / 2y
+		l = new(big.Int).Mul(p.x, p.x)
+		l.Mul(l, big.NewInt(3))
+		l.Mul(l, new(big.Int).ModInverse(new(big.Int).Lsh(p.y, 1), curveP))
+	} else {
+		// l = (qy - py) / (qx - px)
+		l = new(big.Int).Sub(q.y, p.y)
+		d := new(big.Int).Sub(q.x, p.x)
+		l.Mul(l, d.ModInverse(d.Mod(d, curveP), curveP))
+	}
+	l.Mod(l, curveP)
+
+	x := new(big.Int).Mul(l, l)
+	x.Sub(x, p.x).Sub(x, q.x).Mod(x, curveP)
+	y := new(big.Int).Sub(p.x, x)
+	y.Mul(y, l).Sub(y, p.y).Mod(y, curveP)
+	return point{x, y}
+}
+
+// mul returns k·p by double-and-add. It doesn't run in constant time, which
+// is fine for signing a few events on the user's own machine.
+func (p point) mul(k *big.Int) point {
+	var r point
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		r = r.add(r)
+		if k.Bit(i) == 1 {
+			r = r.add(p)
+		}
+	}
+	return r
+}
+
+// bytes32 is n as 32 big-endian bytes
+func bytes32(n *big.Int) []byte {
+	return n.FillBytes(make([]byte, 32))
+}
+
+// taggedHash is BIP-340's hash of data under a tag
+func taggedHash(tag string, data ...[]byte) []byte {
+	t := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(t[:])
+	h.Write(t[:])
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// Key is a private key, which signs events
+type Key struct {
+	d      *big.Int
+	public point
+}
+
+// ParseKey reads a private key given as nsec1... or as 64 hex digits
+func ParseKey(s string) (*Key, error) {
+	s = strings.TrimSpace(s)
+	var raw []byte
+	if strings.HasPrefix(s, "nsec1") {
+		hrp, data, err := decodeBech32(s)
+		if err != nil || hrp != "nsec" || len(data) != 32 {
+			return nil, fmt.Errorf("invalid nsec private key")
+		}
+		raw = data
+	} else {
+		var err error
+		if raw, err = hex.DecodeString(s); err != nil || len(raw) != 32 {
+			return nil, fmt.Errorf("private key must be an nsec1... key or 64 hex digits")
+		}
+	}
+	d := new(big.Int).SetBytes(raw)
+	if d.Sign() == 0 || d.Cmp(curveN) >= 0 {
+		return nil, fmt.Errorf("private key is out of range")
+	}
+	return &Key{d: d, public: curveG.mul(d)}, nil
+}
+
+// Public returns the public key as hex, the form events carry
+func (k *Key) Public() string {
+	return hex.EncodeToString(bytes32(k.public.x))
+}
+
+// sign makes a BIP-340 Schnorr signature of a 32-byte message
+func (k *Key) sign(msg []byte) ([]byte, error) {
+	aux := make([]byte, 32)
+	if _, err := rand.Read(aux); err != nil {
+		return nil, err
+	}
+	return k.signAux(msg, aux)
+}
+
+// signAux signs with the given auxiliary randomness
+func (k *Key) signAux(msg, aux []byte) ([]byte, error) {
+	d := new(big.Int).Set(k.d)
+	if k.public.y.Bit(0) == 1 {
+		d.Sub(curveN, d)
+	}
+	px := bytes32(k.public.x)
+
+	t := bytes32(d)
+	for i, b := range taggedHash("BIP0340/aux", aux) {
+		t[i] ^= b
+	}
+	nonce := new(big.Int).SetBytes(taggedHash("BIP0340/nonce", t, px, msg))
+	nonce.Mod(nonce, curveN)
+	if nonce.Sign() == 0 {
+		return nil, fmt.Errorf("failed to sign: nonce is zero")
+	}
+	r := curveG.mul(nonce)
+	if r.y.Bit(0) == 1 {
+		nonce.Sub(curveN, nonce)
+	}
+	rx := bytes32(r.x)
+
+	e := new(big.Int).SetBytes(taggedHash("BIP0340/challenge", rx, px, msg))
+	e.Mod(e, curveN)
+	s := e.Mul(e, d)
+	s.Add(s, nonce).Mod(s, curveN)
+	return append(rx, bytes32(s)...), nil
+}
@@ -0,0 +1,273 @@
+// Package nostr lists a key's events on Nostr relays and asks the relays to
+// delete them, with kind-5 deletion events (NIP-09)
+package nostr
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Event kinds
+const (
+	KindNote     = 1
+	KindRepost   = 6
+	KindReaction = 7
+	KindDeletion = 5
+)
+
+// opTimeout bounds connecting to a relay, a page of a query and waiting for
+// a relay to acknowledge an event
+const opTimeout = 20 * time.Second
+
+// pageSize is how many events a query asks for at a time
+const pageSize = 500
+
+// noDeadline clears a connection's deadline
+var noDeadline time.Time
+
+// Event is a signed Nostr event (NIP-01)
+type Event struct {
+	ID        string     `json:"id"`
+	PubKey    string     `json:"pubkey"`
+	CreatedAt int64      `json:"created_at"`
+	Kind      int        `json:"kind"`
+	Tags      [][]string `json:"tags"`
+	Content   string     `json:"content"`
+	Sig       string     `json:"sig"`
+}
+
+// Time is when the event was created
+func (e *Event) Time() time.Time {
+	return time.Unix(e.CreatedAt, 0)
+}
+
+// NoteID returns the event's ID as note1..., the form clients link to
+func NoteID(id string) string {
+	raw, err := hex.DecodeString(id)
+	if err != nil {
+		return id
+	}
+	return encodeBech32("note", raw)
+}
+
+// ParseID reads an event ID given as note1... or hex
+func ParseID(s string) (string, error) {
+	if hrp, data, err := decodeBech32(s); err == nil && hrp == "note" && len(data) == 32 {
+		return hex.EncodeToString(data), nil
+	}
+	if raw, err := hex.DecodeString(s); err == nil && len(raw) == 32 {
+		return s, nil
+	}
+	return "", fmt.Errorf("%q is not a Nostr event ID", s)
+}
+
+// Sign sets the event's pubkey, ID and signature
+func (k *Key) Sign(e *Event) error {
+	e.PubKey = k.Public()
+	if e.Tags == nil {
+		e.Tags = [][]string{}
+	}
+	// The ID is the hash of the serialized fields, which NIP-01 wants
+	// without HTML escaping
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode([]any{0, e.PubKey, e.CreatedAt, e.Kind, e.Tags, e.Content}); err != nil {
+		return err
+	}
+	id := sha256.Sum256(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	sig, err := k.sign(id[:])
+	if err != nil {
+		return err
+	}
+	e.ID, e.Sig = hex.EncodeToString(id[:]), hex.EncodeToString(sig)
+	return nil
+}
+
+// Deletion returns a deletion request for an event (NIP-09), to be signed
+func Deletion(id string, kind int) *Event {
+	return &Event{
+		CreatedAt: time.Now().Unix(),
+		Kind:      KindDeletion,
+		Tags:      [][]string{{"e", id}, {"k", strconv.Itoa(kind)}},
+	}
+}
+
+// Relay is a connection to one relay, opened when first used and again
+// after it fails. It isn't safe for concurrent use.
+type Relay struct {
+	URL  string
+	conn *conn
+}
+
+func (r *Relay) connect(ctx context.Context) (*conn, error) {
+	if r.conn != nil {
+		return r.conn, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, opTimeout)
+	defer cancel()
+	c, err := dial(ctx, r.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", r.URL, err)
+	}
+	r.conn = c
+	return c, nil
+}
+
+// fail drops a connection that failed, so the next use opens a new one
+func (r *Relay) fail(err error) error {
+	if r.conn != nil {
+		r.conn.Close()
+		r.conn = nil
+	}
+	return fmt.Errorf("%s: %v", r.URL, err)
+}
+
+// Close closes the connection, if open
+func (r *Relay) Close() error {
+	if r.conn == nil {
+		return nil
+	}
+	r.conn.write(opClose, nil)
+	err := r.conn.Close()
+	r.conn = nil
+	return err
+}
+
+// exchange writes a message and reads replies until done says the exchange is
+// over, all within opTimeout
+func (r *Relay) exchange(ctx context.Context, msg []any, done func(reply []json.RawMessage) (bool, error)) error {
+	c, err := r.connect(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(opTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	c.SetDeadline(deadline)
+	defer c.SetDeadline(noDeadline)
+	// Closing the connection is the only way to interrupt a read
+	stop := context.AfterFunc(ctx, func() { c.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err := c.writeText(data); err != nil {
+		return r.fail(err)
+	}
+	for {
+		raw, err := c.read()
+		if err != nil {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			return r.fail(err)
+		}
+		var reply []json.RawMessage
+		if json.Unmarshal(raw, &reply) != nil || len(reply) == 0 {
+			continue
+		}
+		finished, err := done(reply)
+		if err != nil || finished {
+			return err
+		}
+	}
+}
+
+// Query calls fn with every event matching filter, newest first, a page at
+// a time until the relay has no more
+func (r *Relay) Query(ctx context.Context, filter map[string]any, fn func(*Event) error) error {
+	seen := map[string]bool{}
+	until := time.Now().Unix() + 1
+	for {
+		f := map[string]any{"limit": pageSize, "until": until}
+		for k, v := range filter {
+			f[k] = v
+		}
+		sub := subscriptionID()
+		fresh, oldest := 0, until
+		err := r.exchange(ctx, []any{"REQ", sub, f}, func(reply []json.RawMessage) (bool, error) {
+			var typ, id string
+			json.Unmarshal(reply[0], &typ)
+			if len(reply) > 1 {
+				json.Unmarshal(reply[1], &id)
+			}
+			if id != sub {
+				return false, nil
+			}
+			switch typ {
+			case "EVENT":
+				var e Event
+				if len(reply) < 3 || json.Unmarshal(reply[2], &e) != nil || seen[e.ID] {
+					return false, nil
+				}
+				seen[e.ID] = true
+				fresh++
+				oldest = min(oldest, e.CreatedAt)
+				return false, fn(&e)
+			case "EOSE":
+				return true, nil
+			case "CLOSED":
+				var msg string
+				if len(reply) > 2 {
+					json.Unmarshal(reply[2], &msg)
+				}
+				return false, fmt.Errorf("%s refused the query: %s", r.URL, msg)
+			}
+			return false, nil
+		})
+		if err != nil {
+			return err
+		}
+		if c := r.conn; c != nil {
+			if data, err := json.Marshal([]any{"CLOSE", sub}); err == nil {
+				c.writeText(data)
+			}
+		}
+		// The next page starts at the oldest second seen, as more events
+		// may share it; those already seen are skipped
+		if fresh == 0 {
+			return nil
+		}
+		until = oldest
+	}
+}
+
+// Publish sends a signed event and returns whether the relay accepted it,
+// with its message if it gave one (NIP-20)
+func (r *Relay) Publish(ctx context.Context, e *Event) (bool, string, error) {
+	var accepted bool
+	var message string
+	err := r.exchange(ctx, []any{"EVENT", e}, func(reply []json.RawMessage) (bool, error) {
+		var typ, id string
+		json.Unmarshal(reply[0], &typ)
+		if typ != "OK" || len(reply) < 3 {
+			return false, nil
+		}
+		if json.Unmarshal(reply[1], &id); id != e.ID {
+			return false, nil
+		}
+		json.Unmarshal(reply[2], &accepted)
+		if len(reply) > 3 {
+			json.Unmarshal(reply[3], &message)
+		}
+		return true, nil
+	})
+	return accepted, message, err
+}
+
+func subscriptionID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,202 @@
+package nostr
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// Relays speak JSON over WebSockets. conn is just enough of a WebSocket
+// client (RFC 6455) for that: text messages, pings and closing.
+
+// maxMessage bounds a message from a relay, so a broken one can't make the
+// run buffer without end
+const maxMessage = 16 << 20
+
+// errClosed is returned once the relay closed the connection
+var errClosed = errors.New("relay closed the connection")
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+
+	// mu keeps pongs from interleaving with messages being written
+	mu sync.Mutex
+}
+
+// dial opens a WebSocket to a ws:// or wss:// URL
+func dial(ctx context.Context, rawURL string) (*conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		switch u.Scheme {
+		case "wss":
+			host = net.JoinHostPort(u.Hostname(), "443")
+		case "ws":
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+	if u.Scheme != "ws" && u.Scheme != "wss" {
+		return nil, fmt.Errorf("relay %s must be a ws:// or wss:// URL", rawURL)
+	}
+
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "wss" {
+		tc := tls.Client(nc, &tls.Config{ServerName: u.Hostname()})
+		if err := tc.HandshakeContext(ctx); err != nil {
+			nc.Close()
+			return nil, err
+		}
+		nc = tc
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		nc.SetDeadline(deadline)
+		defer nc.SetDeadline(noDeadline)
+	}
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+	path := u.RequestURI()
+	fmt.Fprintf(nc, "GET %s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\nUser-Agent: go-del-socials\r\n\r\n", path, u.Host, key)
+
+	c := &conn{Conn: nc, r: bufio.NewReader(nc)}
+	resp, err := http.ReadResponse(c.r, nil)
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("websocket handshake failed: %v", err)
+	}
+	resp.Body.Close()
+	sum := sha1.Sum([]byte(key + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		nc.Close()
+		return nil, fmt.Errorf("websocket handshake failed: %s", resp.Status)
+	}
+	return c, nil
+}
+
+// The opcodes of frames
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+// write sends one frame. Frames from clients are masked.
+func (c *conn) write(op byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	header := []byte{0x80 | op, 0}
+	switch n := len(payload); {
+	case n < 126:
+		header[1] = byte(n)
+	case n <= 0xffff:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	header[1] |= 0x80
+	mask := make([]byte, 4)
+	rand.Read(mask)
+	header = append(header, mask...)
+
+	masked := make([]byte, len(payload))
+	for i, b := range payload {
+		masked[i] = b ^ mask[i%4]
+	}
+	_, err := c.Write(append(header, masked...))
+	return err
+}
+
+// writeText sends a text message
+func (c *conn) writeText(msg []byte) error {
+	return c.write(opText, msg)
+}
+
+// read returns the next message, answering pings on the way
+func (c *conn) read() ([]byte, error) {
+	var msg []byte
+	for {
+		var head [2]byte
+		if _, err := io.ReadFull(c.r, head[:]); err != nil {
+			return nil, err
+		}
+		fin, op := head[0]&0x80 != 0, head[0]&0x0f
+		n := uint64(head[1] & 0x7f)
+		switch n {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+				return nil, err
+			}
+			n = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+				return nil, err
+			}
+			n = binary.BigEndian.Uint64(ext[:])
+		}
+		if n+uint64(len(msg)) > maxMessage {
+			return nil, fmt.Errorf("relay sent a message over %d bytes", maxMessage)
+		}
+		var mask [4]byte
+		if head[1]&0x80 != 0 {
+			if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+				return nil, err
+			}
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(c.r, payload); err != nil {
+			return nil, err
+		}
+		if head[1]&0x80 != 0 {
+			for i := range payload {
+				payload[i] ^= mask[i%4]
+			}
+		}
+
+		switch op {
+		case opPing:
+			if err := c.write(opPong, payload); err != nil {
+				return nil, err
+			}
+		case opPong:
+		case opClose:
+			c.write(opClose, nil)
+			return nil, errClosed
+		case opContinuation, opText:
+			msg = append(msg, payload...)
+			if fin {
+				return msg, nil
+			}
+		default:
+			// Binary messages aren't part of the protocol
+			msg = msg[:0]
+		}
+	}
+}
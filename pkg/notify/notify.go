@@ -0,0 +1,25 @@
+// Package notify shows desktop notifications through the tools each system
+// ships with
+package notify
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// Send shows a notification with title and body
+func Send(title, body string) error {
+	name, args := command()
+	if name == "" {
+		return fmt.Errorf("desktop notifications aren't supported on this system")
+	}
+	cmd := exec.Command(name, args...)
+	// The texts go through the environment, so they are never parsed as
+	// part of a script
+	cmd.Env = append(os.Environ(), "NOTIFY_TITLE="+title, "NOTIFY_BODY="+body)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to send notification: %v: %s", err, out)
+	}
+	return nil
+}
@@ -0,0 +1,5 @@
+package notify
+
+func command() (string, []string) {
+	return "osascript", []string{"-e", `display notification (system attribute "NOTIFY_BODY") with title (system attribute "NOTIFY_TITLE")`}
+}
@@ -0,0 +1,7 @@
+//go:build !unix && !windows
+
+package notify
+
+func command() (string, []string) {
+	return "", nil
+}
@@ -0,0 +1,8 @@
+//go:build unix && !darwin
+
+package notify
+
+// command uses notify-send from libnotify
+func command() (string, []string) {
+	return "sh", []string{"-c", `notify-send --app-name=go-del-socials "$NOTIFY_TITLE" "$NOTIFY_BODY"`}
+}
@@ -0,0 +1,16 @@
+package notify
+
+// script shows a toast through the Windows Runtime, which needs no modules
+const script = `
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $xml.GetElementsByTagName('text')
+$text.Item(0).AppendChild($xml.CreateTextNode($env:NOTIFY_TITLE)) > $null
+$text.Item(1).AppendChild($xml.CreateTextNode($env:NOTIFY_BODY)) > $null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($xml)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('go-del-socials').Show($toast)
+`
+
+func command() (string, []string) {
+	return "powershell", []string{"-NoProfile", "-NonInteractive", "-Command", script}
+}
@@ -0,0 +1,168 @@
+// Package outbox writes deleted content as an ActivityPub outbox, laid out
+// like a Mastodon account export, so it can be kept or moved to the
+// fediverse with tools that read those exports
+package outbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/tombstone"
+)
+
+const (
+	activityStreams = "https://www.w3.org/ns/activitystreams"
+	public          = activityStreams + "#Public"
+)
+
+// note is a status as Mastodon exports it
+type note struct {
+	ID           string   `json:"id"`
+	Type         string   `json:"type"`
+	AttributedTo string   `json:"attributedTo"`
+	Published    string   `json:"published"`
+	To           []string `json:"to"`
+	CC           []string `json:"cc"`
+	Content      string   `json:"content"`
+	URL          string   `json:"url"`
+	Sensitive    bool     `json:"sensitive"`
+	Attachment   []any    `json:"attachment"`
+	Tag          []any    `json:"tag"`
+}
+
+type activity struct {
+	ID        string   `json:"id"`
+	Type      string   `json:"type"`
+	Actor     string   `json:"actor"`
+	Published string   `json:"published"`
+	To        []string `json:"to"`
+	CC        []string `json:"cc"`
+	Object    note     `json:"object"`
+}
+
+type collection struct {
+	Context      string     `json:"@context"`
+	ID           string     `json:"id"`
+	Type         string     `json:"type"`
+	TotalItems   int        `json:"totalItems"`
+	OrderedItems []activity `json:"orderedItems"`
+}
+
+type person struct {
+	Context           string `json:"@context"`
+	ID                string `json:"id"`
+	Type              string `json:"type"`
+	PreferredUsername string `json:"preferredUsername"`
+	Name              string `json:"name"`
+	Outbox            string `json:"outbox"`
+}
+
+// Write writes the tombstones that kept their text to dir as outbox.json,
+// with an actor.json for the account at actor, e.g.
+// https://mastodon.social/users/alice. Posts are public Notes in the order
+// they were posted, whose url is where they were. It returns how many were
+// written.
+func Write(dir, actor string, stones []tombstone.Tombstone) (int, error) {
+	actor = strings.TrimSuffix(actor, "/")
+	if !strings.HasPrefix(actor, "https://") && !strings.HasPrefix(actor, "http://") {
+		return 0, fmt.Errorf("actor must be the URL of a fediverse account, e.g. https://mastodon.social/users/alice")
+	}
+
+	stones = keepText(stones)
+	sort.SliceStable(stones, func(i, j int) bool { return stones[i].Created.Before(stones[j].Created) })
+
+	to, cc := []string{public}, []string{actor + "/followers"}
+	outbox := collection{
+		Context:      activityStreams,
+		ID:           actor + "/outbox",
+		Type:         "OrderedCollection",
+		TotalItems:   len(stones),
+		OrderedItems: make([]activity, len(stones)),
+	}
+	for i, t := range stones {
+		// IDs are made up under the actor, keeping the platform's for
+		// reference
+		id := fmt.Sprintf("%s/statuses/%s-%s", actor, t.Platform, strings.ReplaceAll(t.ID, "/", "-"))
+		published := t.Created.UTC().Format(time.RFC3339)
+		url := t.URL
+		if url == "" {
+			url = id
+		}
+		outbox.OrderedItems[i] = activity{
+			ID:        id + "/activity",
+			Type:      "Create",
+			Actor:     actor,
+			Published: published,
+			To:        to,
+			CC:        cc,
+			Object: note{
+				ID:           id,
+				Type:         "Note",
+				AttributedTo: actor,
+				Published:    published,
+				To:           to,
+				CC:           cc,
+				Content:      content(t.Text),
+				URL:          url,
+				Attachment:   []any{},
+				Tag:          []any{},
+			},
+		}
+	}
+
+	name := actor[strings.LastIndex(actor, "/")+1:]
+	account := person{
+		Context:           activityStreams,
+		ID:                actor,
+		Type:              "Person",
+		PreferredUsername: name,
+		Name:              name,
+		Outbox:            "outbox.json",
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return 0, fmt.Errorf("failed to create outbox directory: %v", err)
+	}
+	for file, v := range map[string]any{"outbox.json": outbox, "actor.json": account} {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return 0, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), data, 0o600); err != nil {
+			return 0, fmt.Errorf("failed to write %s: %v", file, err)
+		}
+	}
+	return len(stones), nil
+}
+
+// keepText drops tombstones without text: likes, undone retweets and items
+// that were already gone, which have nothing to archive
+func keepText(stones []tombstone.Tombstone) []tombstone.Tombstone {
+	var kept []tombstone.Tombstone
+	for _, t := range stones {
+		if strings.TrimSpace(t.Text) != "" && t.Kind != "like" && t.Kind != "retweet" {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// content turns plain text into the HTML of a status, a paragraph per
+// blank-line-separated block
+func content(text string) string {
+	var b strings.Builder
+	for _, para := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		lines := strings.Split(strings.TrimSpace(para), "\n")
+		for i := range lines {
+			lines[i] = html.EscapeString(lines[i])
+		}
+		b.WriteString("<p>" + strings.Join(lines, "<br>") + "</p>")
+	}
+	return b.String()
+}
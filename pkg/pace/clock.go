@@ -0,0 +1,40 @@
+package pace
+
+import (
+	"context"
+	"time"
+)
+
+// tick is the longest a wait sleeps before looking at the clocks again
+const tick = 15 * time.Second
+
+// Wait waits d, or until ctx is done. Timers count the monotonic clock,
+// which stops while the machine is suspended, so a laptop closed during a
+// long wait would end it late by however long it slept, well past the reset
+// it was waiting for. Wait therefore sleeps in short steps and ends as soon
+// as either the monotonic clock or the wall clock says d has passed: the
+// wall clock covers suspends, and the monotonic clock keeps the wall
+// clock being set back from stretching the wait.
+func Wait(ctx context.Context, d time.Duration) error {
+	start := time.Now()
+	wall := start.Round(0)
+	for {
+		left := min(d-time.Since(start), d-time.Now().Round(0).Sub(wall))
+		if left <= 0 {
+			return nil
+		}
+		t := time.NewTimer(min(left, tick))
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// Until waits until the wall clock reaches t, e.g. a rate limit's reset
+// time, or until ctx is done
+func Until(ctx context.Context, t time.Time) error {
+	return Wait(ctx, time.Until(t))
+}
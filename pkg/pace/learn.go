@@ -0,0 +1,119 @@
+package pace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"go-del-socials/pkg/store"
+)
+
+const learnedBucket = "rate-limits"
+
+// Stats is what a pacer saw of the platform's rate limits during a run
+type Stats struct {
+	// PerMinute is the cap on writes the run was paced at, 0 without one
+	PerMinute float64
+
+	// Writes counts the writes sent; Held those the cap made wait
+	Writes int
+	Held   int
+
+	// Limited counts the requests the platform refused for its rate limit
+	Limited int
+
+	// Limit and Window are the last allowance the platform announced
+	Limit  int
+	Window time.Duration
+}
+
+// Learned is what earlier runs found out about an account's rate limits on
+// a platform and API tier, so the next run starts at a rate that worked
+// instead of the cautious default
+type Learned struct {
+	// PerMinute is the write rate the next run starts at
+	PerMinute float64 `json:"per_minute"`
+
+	// Limit requests per Window is the platform's own allowance, when it
+	// announces one
+	Limit         int     `json:"limit,omitempty"`
+	WindowSeconds float64 `json:"window_seconds,omitempty"`
+
+	Runs    int       `json:"runs"`
+	Writes  int       `json:"writes"`
+	Limited int       `json:"limited"`
+	Updated time.Time `json:"updated"`
+}
+
+// How the rate moves between runs: down sharply after being rate limited,
+// up slowly while the cap was what held writes back
+const (
+	backOff = 0.75
+	speedUp = 1.1
+)
+
+// Learn folds a run's stats into what was learned. A run that was rate
+// limited slows the next one down; a run the cap held back without being
+// limited speeds it up, but never past the platform's announced allowance.
+// It reports whether anything was learned.
+func (l *Learned) Learn(s Stats, now time.Time) bool {
+	if s.PerMinute == 0 || (s.Writes == 0 && s.Limited == 0) {
+		return false
+	}
+
+	rate := s.PerMinute
+	switch {
+	case s.Limited > 0:
+		rate *= backOff
+	case s.Held > s.Writes/2:
+		rate *= speedUp
+	}
+	if s.Limit > 0 && s.Window > 0 {
+		l.Limit, l.WindowSeconds = s.Limit, s.Window.Seconds()
+	}
+	if l.Limit > 0 && l.WindowSeconds > 0 {
+		rate = min(rate, float64(l.Limit)/l.WindowSeconds*60)
+	}
+
+	l.PerMinute = max(math.Round(rate*100)/100, 0.1)
+	l.Runs++
+	l.Writes += s.Writes
+	l.Limited += s.Limited
+	l.Updated = now
+	return true
+}
+
+func learnedKey(platform, tier string) string {
+	return platform + "/" + tier
+}
+
+// LoadLearned returns what was learned about the platform and tier, or nil
+// if nothing has been yet
+func LoadLearned(s store.Store, platform, tier string) (*Learned, error) {
+	data, err := s.Get(learnedBucket, learnedKey(platform, tier))
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read learned rate limits: %v", err)
+	}
+	var l Learned
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("corrupt learned rate limits for %s: %v", platform, err)
+	}
+	return &l, nil
+}
+
+// SaveLearned stores what was learned about the platform and tier
+func SaveLearned(s store.Store, platform, tier string, l *Learned) error {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	if err := s.Put(learnedBucket, learnedKey(platform, tier), data); err != nil {
+		return fmt.Errorf("failed to save learned rate limits: %v", err)
+	}
+	return nil
+}
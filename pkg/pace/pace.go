@@ -0,0 +1,150 @@
+// Package pace spaces out an account's requests to stay within a
+// platform's rate limits without looking like a machine
+package pace
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Pacer spaces out one account's requests: Delay between listing pages and
+// a cap on writes. Every wait is lengthened or shortened at random by up to
+// Jitter of itself, so the timing doesn't look robotic and profiles running
+// in parallel don't fall into step. A nil Pacer doesn't pace.
+type Pacer struct {
+	Delay  time.Duration
+	Jitter float64
+
+	writes *Limiter
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// New returns a pacer with the delay, the jitter (0 to 1) and a cap on
+// writes, which may be nil
+func New(delay time.Duration, jitter float64, writes *Limiter) *Pacer {
+	return &Pacer{Delay: delay, Jitter: jitter, writes: writes}
+}
+
+// Pause waits the delay, e.g. between listing pages
+func (p *Pacer) Pause(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	return p.Sleep(ctx, p.Delay)
+}
+
+// Sleep waits d, jittered. A nil Pacer waits d exactly.
+func (p *Pacer) Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	return Wait(ctx, p.jittered(d))
+}
+
+// Write waits until the cap on writes allows another one
+func (p *Pacer) Write(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	wait := p.writes.reserve()
+	p.mu.Lock()
+	p.stats.Writes++
+	if wait > 0 {
+		p.stats.Held++
+	}
+	p.mu.Unlock()
+	return p.Sleep(ctx, wait)
+}
+
+// Limited records that the platform refused a request for its rate limit
+func (p *Pacer) Limited() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.stats.Limited++
+	p.mu.Unlock()
+}
+
+// Allowance records the rate limit the platform announced: limit requests
+// in a window that resets after reset. The longest reset seen is taken as
+// the window.
+func (p *Pacer) Allowance(limit int, reset time.Duration) {
+	if p == nil || limit <= 0 {
+		return
+	}
+	p.mu.Lock()
+	p.stats.Limit = limit
+	p.stats.Window = max(p.stats.Window, reset)
+	p.mu.Unlock()
+}
+
+// Stats returns what the pacer saw so far
+func (p *Pacer) Stats() Stats {
+	if p == nil {
+		return Stats{}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.stats
+	if p.writes != nil {
+		s.PerMinute = float64(time.Minute) / float64(p.writes.every)
+	}
+	return s
+}
+
+func (p *Pacer) jittered(d time.Duration) time.Duration {
+	if p == nil || p.Jitter <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + p.Jitter*(2*rand.Float64()-1)))
+}
+
+// Limiter lets n writes through per period, up to burst of them back to
+// back after a quiet spell. It counts the monotonic clock, so waking from a
+// suspend or a change of the system clock earns no more than burst writes at
+// once. A nil Limiter never waits.
+type Limiter struct {
+	mu     sync.Mutex
+	every  time.Duration // time to earn one more write
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a limiter of n writes per period, or nil when n isn't
+// positive
+func NewLimiter(n int, per time.Duration, burst int) *Limiter {
+	if n <= 0 {
+		return nil
+	}
+	burst = max(burst, 1)
+	return &Limiter{
+		every:  per / time.Duration(n),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve takes the next write and returns how long to wait before it
+func (l *Limiter) reserve() time.Duration {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+float64(now.Sub(l.last))/float64(l.every))
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens * float64(l.every))
+}
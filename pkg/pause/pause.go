@@ -0,0 +1,76 @@
+// Package pause holds a run between two items until it is resumed, without
+// giving up its session
+package pause
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Gate is passed by runs before every item. A nil Gate never pauses.
+type Gate struct {
+	mu     sync.Mutex
+	since  time.Time
+	resume chan struct{}
+}
+
+// New returns an open gate
+func New() *Gate {
+	return &Gate{}
+}
+
+// Pause closes the gate, so runs stop before their next item. It reports
+// false if the gate was already closed.
+func (g *Gate) Pause() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.resume != nil {
+		return false
+	}
+	g.since, g.resume = time.Now(), make(chan struct{})
+	return true
+}
+
+// Resume opens the gate again and returns how long it was closed. It
+// reports false if the gate wasn't closed.
+func (g *Gate) Resume() (time.Duration, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.resume == nil {
+		return 0, false
+	}
+	close(g.resume)
+	g.resume = nil
+	return time.Since(g.since), true
+}
+
+// Paused reports whether the gate is closed
+func (g *Gate) Paused() bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.resume != nil
+}
+
+// Wait blocks while the gate is closed, until it opens or ctx ends
+func (g *Gate) Wait(ctx context.Context) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	resume := g.resume
+	g.mu.Unlock()
+	if resume == nil {
+		return
+	}
+
+	select {
+	case <-resume:
+	case <-ctx.Done():
+	}
+}
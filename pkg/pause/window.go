@@ -0,0 +1,61 @@
+package pause
+
+import (
+	"fmt"
+	"time"
+)
+
+// Window is a daily stretch of local time, e.g. 02:00-06:00. It may wrap
+// past midnight, as in 22:00-02:00.
+type Window struct {
+	Start, End time.Duration // since midnight
+	text       string
+}
+
+// ParseWindow reads a window given as HH:MM-HH:MM
+func ParseWindow(s string) (Window, error) {
+	var h1, m1, h2, m2 int
+	if _, err := fmt.Sscanf(s, "%d:%d-%d:%d", &h1, &m1, &h2, &m2); err != nil {
+		return Window{}, fmt.Errorf("invalid window %q, use HH:MM-HH:MM", s)
+	}
+	for _, v := range [][2]int{{h1, m1}, {h2, m2}} {
+		if v[0] < 0 || v[0] > 24 || v[1] < 0 || v[1] > 59 || (v[0] == 24 && v[1] != 0) {
+			return Window{}, fmt.Errorf("invalid time in window %q", s)
+		}
+	}
+	w := Window{
+		Start: time.Duration(h1)*time.Hour + time.Duration(m1)*time.Minute,
+		End:   time.Duration(h2)*time.Hour + time.Duration(m2)*time.Minute,
+		text:  s,
+	}
+	if w.Start == w.End {
+		return Window{}, fmt.Errorf("window %q is empty", s)
+	}
+	return w, nil
+}
+
+func (w Window) String() string { return w.text }
+
+// Contains reports whether t falls in the window
+func (w Window) Contains(t time.Time) bool {
+	d := sinceMidnight(t)
+	if w.Start < w.End {
+		return d >= w.Start && d < w.End
+	}
+	return d >= w.Start || d < w.End
+}
+
+// NextStart returns when the window next opens after t
+func (w Window) NextStart(t time.Time) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	start := midnight.Add(w.Start)
+	if !start.After(t) {
+		start = midnight.AddDate(0, 0, 1).Add(w.Start)
+	}
+	return start
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	h, m, s := t.Clock()
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+}
@@ -0,0 +1,180 @@
+// Package pipeline runs a listing as separate stages, fetch → stages →
+// process, each in its own goroutine, so fetching the next page and
+// archiving overlap with deleting instead of alternating with it
+package pipeline
+
+import (
+	"context"
+	"sync"
+
+	"go-del-socials/pkg/pace"
+)
+
+// Fetch returns one page of items, which may be empty, and the cursor of
+// the next page, or "" after the last
+type Fetch[T any] func(ctx context.Context, cursor string) ([]T, string, error)
+
+// Stage looks at every item on its way to deletion, e.g. to archive or
+// verify it. Returning false drops the item.
+type Stage[T any] func(ctx context.Context, it T) bool
+
+// Pipeline feeds the pages of a listing through its stages. At most Ahead
+// pages wait between two stages, which bounds memory however long the
+// listing is.
+type Pipeline[T any] struct {
+	Fetch  Fetch[T]
+	Stages []Stage[T]
+
+	// Ahead is how many pages may wait for the next stage; 1 if unset
+	Ahead int
+
+	// Pace spaces out the fetches by its delay, so listing doesn't use up
+	// the rate limit
+	Pace *pace.Pacer
+}
+
+// Run hands every page that made it through the stages to process, in
+// order. process returning false stops the run early. Run returns once all
+// stages have stopped, with the fetch error, if any.
+func (p *Pipeline[T]) Run(ctx context.Context, process func(ctx context.Context, page []T) bool) error {
+	return RunAll(ctx, []*Pipeline[T]{p}, func(ctx context.Context, _ int, page []T) bool {
+		return process(ctx, page)
+	})
+}
+
+// RunAll runs pipelines over independent listings at once and hands their
+// pages to process one at a time, as they arrive, with the index of the
+// pipeline they came from. Listing overlaps while processing stays
+// sequential. A failing fetch stops every pipeline.
+func RunAll[T any](parent context.Context, pipelines []*Pipeline[T], process func(ctx context.Context, i int, page []T) bool) error {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	type page struct {
+		i     int
+		items []T
+	}
+	merged := make(chan page)
+	errs := make([]error, len(pipelines))
+	var wg sync.WaitGroup
+
+	for i, p := range pipelines {
+		staged := p.start(ctx, cancel, &errs[i], &wg)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for items := range staged {
+				select {
+				case merged <- page{i, items}:
+				case <-ctx.Done():
+					for range staged {
+					}
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+
+	// A page that arrives once a fetch failed is dropped with the rest
+	for pg := range merged {
+		if ctx.Err() != nil || !process(ctx, pg.i, pg.items) {
+			break
+		}
+	}
+	cancel()
+	// Let the stages see the cancellation and finish
+	for range merged {
+	}
+
+	for _, err := range errs {
+		// Stopping early cancels the fetches, which isn't an error
+		if err != nil && !(err == context.Canceled && parent.Err() == nil) {
+			return err
+		}
+	}
+	return nil
+}
+
+// start runs the fetch and the stages, each in its own goroutine, and
+// returns the staged pages. The fetch error goes to errp; it cancels the
+// other pipelines too.
+func (p *Pipeline[T]) start(ctx context.Context, cancel context.CancelFunc, errp *error, wg *sync.WaitGroup) <-chan []T {
+	ahead := max(p.Ahead, 1)
+	fetched := make(chan []T, ahead)
+	staged := make(chan []T, ahead)
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer close(fetched)
+		if *errp = p.fetch(ctx, fetched); *errp != nil && *errp != context.Canceled {
+			cancel()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		defer close(staged)
+		for page := range fetched {
+			page = p.stage(ctx, page)
+			select {
+			case staged <- page:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return staged
+}
+
+// fetch lists every page into out
+func (p *Pipeline[T]) fetch(ctx context.Context, out chan<- []T) error {
+	cursor := ""
+	for {
+		page, next, err := p.Fetch(ctx, cursor)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if len(page) > 0 {
+			select {
+			case out <- page:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		cursor = next
+
+		if err := p.Pace.Pause(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// stage runs a page's items through the stages, keeping those that pass
+func (p *Pipeline[T]) stage(ctx context.Context, page []T) []T {
+	if len(p.Stages) == 0 {
+		return page
+	}
+	kept := page[:0]
+	for _, it := range page {
+		passed := true
+		for _, s := range p.Stages {
+			if passed = s(ctx, it); !passed {
+				break
+			}
+		}
+		if passed {
+			kept = append(kept, it)
+		}
+	}
+	return kept
+}
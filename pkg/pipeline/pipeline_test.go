@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// endless lists pages of one item forever, counting the pages fetched
+func endless(fetched *atomic.Int64) Fetch[int] {
+	return func(ctx context.Context, cursor string) ([]int, string, error) {
+		n := fetched.Add(1)
+		return []int{int(n)}, strconv.FormatInt(n, 10), nil
+	}
+}
+
+// With a consumer slower than the listing, fetching must wait for it
+// instead of piling up pages: each pipeline holds at most Ahead pages in
+// each of its two channels, plus one in each of its three goroutines
+func TestRunAllSlowConsumer(t *testing.T) {
+	const ahead, pages = 2, 40
+	var fetched [2]atomic.Int64
+	pipelines := []*Pipeline[int]{
+		{Fetch: endless(&fetched[0]), Ahead: ahead},
+		{Fetch: endless(&fetched[1]), Ahead: ahead, Stages: []Stage[int]{
+			func(ctx context.Context, it int) bool { return it%2 == 0 },
+		}},
+	}
+
+	// Pages the stage empties are still handed on, so every fetched page
+	// is processed
+	var processed [2]int64
+	var last [2]int
+	bound := int64(2*ahead + 3)
+	err := RunAll(context.Background(), pipelines, func(ctx context.Context, i int, page []int) bool {
+		time.Sleep(time.Millisecond)
+		processed[i]++
+		for _, it := range page {
+			if it <= last[i] {
+				t.Errorf("pipeline %d handed item %d after %d", i, it, last[i])
+			}
+			last[i] = it
+		}
+		if waiting := fetched[i].Load() - processed[i]; waiting > bound {
+			t.Errorf("pipeline %d fetched %d pages ahead of processing, want at most %d", i, waiting, bound)
+		}
+		return processed[0] < pages
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed[0] != pages {
+		t.Errorf("processed %d pages, want %d", processed[0], pages)
+	}
+}
+
+// A failing fetch stops the run: the pages other pipelines already staged
+// are dropped, not processed
+func TestRunAllFetchError(t *testing.T) {
+	failed := errors.New("listing failed")
+	fail := make(chan struct{})
+	var fetched atomic.Int64
+	pipelines := []*Pipeline[int]{
+		{Fetch: endless(&fetched), Ahead: 2},
+		{Fetch: func(ctx context.Context, cursor string) ([]int, string, error) {
+			<-fail
+			return nil, "", failed
+		}},
+	}
+
+	processed := 0
+	err := RunAll(context.Background(), pipelines, func(ctx context.Context, i int, page []int) bool {
+		if processed++; processed > 1 {
+			t.Errorf("page %v processed after the fetch failed", page)
+			return true
+		}
+		// Hold the first page until the failure has cancelled the run,
+		// while the endless listing stages more behind it
+		close(fail)
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		return true
+	})
+	if err != failed {
+		t.Errorf("got error %v, want %v", err, failed)
+	}
+}
@@ -0,0 +1,151 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Item is one piece of content a plan deletes
+type Item struct {
+	ID   string    `json:"id"`
+	Kind string    `json:"kind"`
+	Date time.Time `json:"date"`
+	Text string    `json:"text,omitempty"`
+
+	// Where is the place it was posted in, e.g. its subreddit
+	Where string `json:"where,omitempty"`
+}
+
+// Set is the items planned for one profile
+type Set struct {
+	Items []Item `json:"items"`
+
+	mu      sync.Mutex
+	ids     map[string]bool
+	reached map[string]bool
+	watch   func(reached, total int)
+}
+
+// Add records an item once
+func (s *Set) Add(item Item) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.index()
+	if !s.ids[item.ID] {
+		s.ids[item.ID] = true
+		s.Items = append(s.Items, item)
+	}
+}
+
+// Has reports whether the plan lists the item. Runs ask once per item they
+// reach, which is what Watch follows.
+func (s *Set) Has(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.index()
+	if s.ids[id] && !s.reached[id] {
+		if s.reached == nil {
+			s.reached = map[string]bool{}
+		}
+		s.reached[id] = true
+		if s.watch != nil {
+			s.watch(len(s.reached), len(s.Items))
+		}
+	}
+	return s.ids[id]
+}
+
+// Watch calls fn whenever a run reaches another item of the plan
+func (s *Set) Watch(fn func(reached, total int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watch = fn
+}
+
+func (s *Set) index() {
+	if s.ids != nil {
+		return
+	}
+	s.ids = make(map[string]bool, len(s.Items))
+	for _, item := range s.Items {
+		s.ids[item.ID] = true
+	}
+}
+
+// Plan lists exactly what a run will delete, per profile, along with the
+// choices it was made with so it can be applied unchanged
+type Plan struct {
+	Created     time.Time       `json:"created"`
+	Platform    string          `json:"platform"`
+	ContentType string          `json:"content_type"`
+	Cutoff      time.Time       `json:"cutoff"`
+	Options     json.RawMessage `json:"options,omitempty"`
+	Profiles    map[string]*Set `json:"profiles"`
+
+	mu sync.Mutex
+}
+
+// New starts an empty plan
+func New(platform, contentType string, cutoff time.Time) *Plan {
+	return &Plan{
+		Created:     time.Now(),
+		Platform:    platform,
+		ContentType: contentType,
+		Cutoff:      cutoff,
+		Profiles:    map[string]*Set{},
+	}
+}
+
+// Profile returns the items of a profile, creating an empty set as needed
+func (p *Plan) Profile(name string) *Set {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.Profiles[name] == nil {
+		p.Profiles[name] = &Set{}
+	}
+	return p.Profiles[name]
+}
+
+// Len returns the number of planned items across profiles
+func (p *Plan) Len() int {
+	n := 0
+	for _, s := range p.Profiles {
+		n += len(s.Items)
+	}
+	return n
+}
+
+// Write saves the plan to path
+func (p *Plan) Write(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write plan: %v", err)
+	}
+	return nil
+}
+
+// Read loads a plan written by Write
+func Read(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %v", err)
+	}
+
+	var p Plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %v", err)
+	}
+	if p.Platform == "" || p.Profiles == nil {
+		return nil, fmt.Errorf("%s is not a deletion plan", path)
+	}
+	return &p, nil
+}
@@ -0,0 +1,215 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Plugins are executables named go-del-socials-<platform> in the plugin
+// directory. For every request the core starts the plugin, writes one JSON
+// Request to its stdin and reads Messages from its stdout, one JSON object
+// per line, until it exits. Anything written to stderr is shown to the user.
+const (
+	Prefix          = "go-del-socials-"
+	ProtocolVersion = 1
+)
+
+// Request is sent to a plugin on stdin
+type Request struct {
+	// Method is "describe", "check" or "delete"
+	Method          string `json:"method"`
+	ProtocolVersion int    `json:"protocol_version"`
+
+	// Config is the profile's section for the plugin, passed on as is
+	Config      json.RawMessage `json:"config,omitempty"`
+	ContentType string          `json:"content_type,omitempty"`
+	Cutoff      *time.Time      `json:"cutoff,omitempty"`
+
+	// DryRun asks for the matching items to be reported as "matched"
+	// without deleting anything, for plans and estimates
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// Simulate asks for a normal run whose deletes are pretended, reported
+	// as "deleted" without being sent. Only sent to plugins whose Info has
+	// Simulate set.
+	Simulate bool `json:"simulate,omitempty"`
+
+	// Approved, when not null, lists the only items that may be deleted.
+	// An empty list allows nothing.
+	Approved []string `json:"approved"`
+}
+
+// Info describes a plugin in answer to "describe"
+type Info struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	ContentTypes    []string `json:"content_types"`
+
+	// Overwrite is set when content is edited before it is deleted, and
+	// Undo when deleted content can be restored
+	Overwrite bool `json:"overwrite,omitempty"`
+	Undo      bool `json:"undo,omitempty"`
+
+	// MaxRate is the most deletions per minute the platform allows, if known
+	MaxRate int `json:"max_rate,omitempty"`
+
+	// Check is set when the plugin answers "check" requests
+	Check bool `json:"check,omitempty"`
+
+	// Simulate is set when the plugin honours simulated runs
+	Simulate bool `json:"simulate,omitempty"`
+}
+
+// Item is a piece of content the plugin acted on
+type Item struct {
+	ID   string    `json:"id"`
+	Kind string    `json:"kind"`
+	Date time.Time `json:"date"`
+	URL  string    `json:"url,omitempty"`
+	Text string    `json:"text,omitempty"`
+
+	// Action is "matched" (dry runs only), "deleted", "kept" or "failed"
+	Action string `json:"action"`
+
+	// Reason explains why an item was kept or failed
+	Reason string `json:"reason,omitempty"`
+}
+
+// Count is a line of the run summary
+type Count struct {
+	Label string `json:"label"`
+	N     int    `json:"n"`
+}
+
+// Message is read from a plugin's stdout
+type Message struct {
+	// Type is "log", "item", "info", "result" or "error"
+	Type string `json:"type"`
+
+	Message string  `json:"message,omitempty"`
+	Item    *Item   `json:"item,omitempty"`
+	Info    *Info   `json:"info,omitempty"`
+	Counts  []Count `json:"counts,omitempty"`
+}
+
+// Plugin is a discovered plugin executable
+type Plugin struct {
+	Name string
+	Path string
+	Info Info
+}
+
+// Discover finds the plugins in dir without starting them; Describe asks
+// one what it supports. A missing directory has none.
+func Discover(dir string) ([]*Plugin, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin directory: %v", err)
+	}
+
+	var plugins []*Plugin
+	for _, e := range entries {
+		name, ok := strings.CutPrefix(e.Name(), Prefix)
+		if !ok || e.IsDir() {
+			continue
+		}
+		name = strings.TrimSuffix(name, ".exe")
+
+		plugins = append(plugins, &Plugin{Name: name, Path: filepath.Join(dir, e.Name())})
+	}
+
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins, nil
+}
+
+// Describe asks the plugin for its Info
+func (p *Plugin) Describe(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var info Info
+	err := p.Run(ctx, Request{Method: "describe"}, io.Discard, func(m Message) error {
+		if m.Type == "info" && m.Info != nil {
+			info = *m.Info
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if info.ProtocolVersion != ProtocolVersion {
+		return fmt.Errorf("speaks protocol version %d, not %d", info.ProtocolVersion, ProtocolVersion)
+	}
+	if len(info.ContentTypes) == 0 {
+		return fmt.Errorf("declares no content types")
+	}
+	p.Info = info
+	return nil
+}
+
+// Run sends req to the plugin and passes every message it answers with to
+// handle. "error" messages and unsuccessful exits are returned as errors.
+func (p *Plugin) Run(ctx context.Context, req Request, stderr io.Writer, handle func(Message) error) error {
+	req.ProtocolVersion = ProtocolVersion
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, p.Path)
+	cmd.Stdin = bytes.NewReader(append(data, '\n'))
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start: %v", err)
+	}
+
+	var failed error
+	sc := bufio.NewScanner(stdout)
+	sc.Buffer(nil, 16<<20)
+	for sc.Scan() {
+		var m Message
+		if err := json.Unmarshal(sc.Bytes(), &m); err != nil {
+			failed = fmt.Errorf("invalid message %q: %v", sc.Text(), err)
+			break
+		}
+		if m.Type == "error" {
+			failed = errors.New(m.Message)
+			continue
+		}
+		if err := handle(m); err != nil {
+			failed = err
+			break
+		}
+	}
+	if failed == nil {
+		failed = sc.Err()
+	}
+	if failed != nil {
+		// Stop the plugin rather than wait for output nobody reads
+		cmd.Process.Kill()
+		cmd.Wait()
+		return failed
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("exited: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// historySize bounds the events replayed to clients that connect late
+const historySize = 1000
+
+// Event is one progress update of a run
+type Event struct {
+	Time    time.Time `json:"time"`
+	Profile string    `json:"profile"`
+
+	// Type is "start", "line" or "done"
+	Type string `json:"type"`
+
+	Line   string         `json:"line,omitempty"`
+	Counts map[string]int `json:"counts,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// Broker fans progress events out to Server-Sent Events clients
+type Broker struct {
+	mu      sync.Mutex
+	subs    map[chan Event]bool
+	history []Event
+}
+
+func NewBroker() *Broker {
+	return &Broker{subs: map[chan Event]bool{}}
+}
+
+// Publish sends an event to every connected client. Slow clients miss
+// events rather than holding up the run.
+func (b *Broker) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.history = append(b.history, e)
+	if len(b.history) > historySize {
+		b.history = b.history[len(b.history)-historySize:]
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Writer returns a writer publishing every line written to it as a "line"
+// event of profile
+func (b *Broker) Writer(profile string) io.Writer {
+	return &lineWriter{b: b, profile: profile}
+}
+
+type lineWriter struct {
+	b       *Broker
+	profile string
+	buf     []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if line := bytes.TrimSpace(w.buf[:i]); len(line) > 0 {
+			w.b.Publish(Event{Profile: w.profile, Type: "line", Line: string(line)})
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// ServeHTTP streams events as Server-Sent Events, starting with the recent
+// history so a dashboard opened mid-run catches up
+func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := make(chan Event, 256)
+	b.mu.Lock()
+	backlog := append([]Event(nil), b.history...)
+	b.subs[ch] = true
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.subs, ch)
+		b.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	send := func(e Event) error {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+		flusher.Flush()
+		return err
+	}
+
+	for _, e := range backlog {
+		if err := send(e); err != nil {
+			return
+		}
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-ch:
+			if err := send(e); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// errForbidden is the error of requests Reddit refuses with 403 Forbidden
+var errForbidden = errors.New("forbidden")
+
+// statusRecheck is how many refused deletes in a row pass between checks
+// of the account's standing after the first one
+const statusRecheck = 20
+
+// account is what /api/v1/me says about the signed-in user
+type account struct {
+	Name                    string  `json:"name"`
+	IsSuspended             bool    `json:"is_suspended"`
+	SuspensionExpirationUTC float64 `json:"suspension_expiration_utc"`
+}
+
+// standing explains why Reddit refuses the account as a whole, or returns
+// nil when it doesn't
+func (a *account) standing() error {
+	if !a.IsSuspended {
+		return nil
+	}
+	if a.SuspensionExpirationUTC == 0 {
+		return fmt.Errorf("u/%s is permanently suspended, so Reddit refuses every delete. "+
+			"Appeal at https://www.reddit.com/appeals; until it is lifted, a copy of your data can still be requested at https://www.reddit.com/settings/data-request", a.Name)
+	}
+	until := time.Unix(int64(a.SuspensionExpirationUTC), 0)
+	return fmt.Errorf("u/%s is suspended until %s, so Reddit refuses every delete. "+
+		"Run again after that, or appeal at https://www.reddit.com/appeals; items already deleted are skipped", a.Name, until.Format("2006-01-02 15:04 MST"))
+}
+
+// me fetches the signed-in user
+func (c *Client) me(ctx context.Context) (*account, int, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.config.apiURL()+"/api/v1/me", nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	req.Header.Set("User-Agent", c.config.UserAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, fmt.Errorf("request failed: %s", resp.Status)
+	}
+
+	var a account
+	if err := json.NewDecoder(resp.Body).Decode(&a); err != nil {
+		return nil, resp.StatusCode, fmt.Errorf("failed to decode account identity: %v", err)
+	}
+	return &a, resp.StatusCode, nil
+}
+
+// checkStanding follows a delete: when Reddit refused it, the account's
+// standing is looked up, since a suspended account is refused every item.
+// Once it is, the run stops with the explanation instead of failing item by
+// item. Items in private or banned subreddits are refused too, so only the
+// first refusal in a row and every statusRecheck-th after it are checked.
+func (c *Client) checkStanding(ctx context.Context, err error) {
+	if !errors.Is(err, errForbidden) {
+		if err == nil {
+			c.refused = 0
+		}
+		return
+	}
+	c.refused++
+	if c.refused != 1 && c.refused%statusRecheck != 0 {
+		return
+	}
+
+	a, status, err := c.me(ctx)
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		c.blocked = fmt.Errorf("reddit no longer accepts the sign-in of u/%s (%d %s), so every delete is refused. "+
+			"The account may be locked pending a password reset: sign in at https://www.reddit.com, follow what it asks, update password in config.json and run again",
+			c.config.Username, status, http.StatusText(status))
+	case err != nil:
+		c.printf("Warning: couldn't check the account's standing: %v\n", err)
+	default:
+		c.blocked = a.standing()
+	}
+}
+
+// stopped reports whether the account can't delete anything more, so the
+// run should end
+func (c *Client) stopped() bool {
+	return c.blocked != nil
+}
@@ -0,0 +1,132 @@
+package reddit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go-del-socials/pkg/httpclient"
+)
+
+// DefaultRedirectURI is where Authorize listens unless told otherwise. It
+// must match the redirect uri of the app at https://www.reddit.com/prefs/apps.
+const DefaultRedirectURI = "http://localhost:8080"
+
+// Authorize signs an installed app in to an account the way such apps
+// must, through the browser, and returns a refresh token that keeps it
+// signed in. show is given the address to open; Authorize then waits on
+// redirectURI for Reddit to send the user back. authURL replaces
+// DefaultAuthURL when set.
+func Authorize(ctx context.Context, authURL, clientID, redirectURI, userAgent string, show func(link string)) (string, error) {
+	if authURL == "" {
+		authURL = DefaultAuthURL
+	}
+	authURL = strings.TrimSuffix(authURL, "/")
+
+	redirect, err := url.Parse(redirectURI)
+	if err != nil || redirect.Scheme != "http" || redirect.Host == "" {
+		return "", fmt.Errorf("redirect uri %q must be a local http address such as %s", redirectURI, DefaultRedirectURI)
+	}
+	listener, err := net.Listen("tcp", redirect.Host)
+	if err != nil {
+		return "", fmt.Errorf("failed to listen on %s: %v", redirect.Host, err)
+	}
+	defer listener.Close()
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	state := hex.EncodeToString(nonce)
+
+	// The refresh token is only issued for permanent grants. Every scope is
+	// asked for, as script apps have, since runs edit, delete, hide and
+	// read all kinds of content.
+	query := url.Values{
+		"client_id":     {clientID},
+		"response_type": {"code"},
+		"state":         {state},
+		"redirect_uri":  {redirectURI},
+		"duration":      {"permanent"},
+		"scope":         {"*"},
+	}
+	show(authURL + "/api/v1/authorize?" + query.Encode())
+
+	codes := make(chan string, 1)
+	errs := make(chan error, 1)
+	path := redirect.Path
+	if path == "" {
+		path = "/"
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch {
+		case q.Get("state") != state:
+			http.Error(w, "This sign-in wasn't started by go-del-socials.", http.StatusBadRequest)
+			return
+		case q.Get("error") != "":
+			fmt.Fprintln(w, "Reddit didn't authorize go-del-socials. You can close this tab.")
+			errs <- fmt.Errorf("reddit refused authorization: %s", q.Get("error"))
+		default:
+			fmt.Fprintln(w, "go-del-socials is authorized. You can close this tab.")
+			codes <- q.Get("code")
+		}
+	})
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+
+	var code string
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case err := <-errs:
+		return "", err
+	case code = <-codes:
+	}
+
+	data := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {redirectURI},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", authURL+"/api/v1/access_token", strings.NewReader(data.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %v", err)
+	}
+	// Installed apps have no secret, so it is left empty
+	req.SetBasicAuth(clientID, "")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := httpclient.New().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get token: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %v", err)
+	}
+	if err := tokenError(resp.StatusCode, body, true); err != nil {
+		return "", err
+	}
+	var tokenResp struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %v", err)
+	}
+	if tokenResp.RefreshToken == "" {
+		return "", fmt.Errorf("reddit sent no refresh token: %s", body)
+	}
+	return tokenResp.RefreshToken, nil
+}
@@ -0,0 +1,75 @@
+package reddit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-del-socials/pkg/matrix"
+	"go-del-socials/pkg/pipeline"
+)
+
+// Reddit chat is served by a Matrix homeserver that accepts Reddit OAuth
+// tokens in place of a password
+const chatHomeserver = "https://matrix.redditspace.com"
+
+// DeleteChatMessages redacts the user's own chat messages sent before
+// cutoffDate in every joined chat room and returns how many were removed.
+func (c *Client) DeleteChatMessages(ctx context.Context, cutoffDate time.Time) (int, error) {
+	chat, err := matrix.Login(ctx, chatHomeserver, map[string]any{
+		"type":                        "com.reddit.token",
+		"token":                       c.accessToken,
+		"initial_device_display_name": c.config.UserAgent,
+	}, c.httpClient)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sign in to Reddit chat: %v", err)
+	}
+
+	rooms, err := chat.JoinedRooms(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list chat rooms: %v", err)
+	}
+
+	c.printf("Found %d chat rooms\n", len(rooms))
+	deleted := 0
+
+	for _, room := range rooms {
+		// The next page of the room is fetched while this one is redacted
+		p := &pipeline.Pipeline[matrix.Event]{
+			Fetch: func(ctx context.Context, from string) ([]matrix.Event, string, error) {
+				return chat.Messages(ctx, room, from, 100)
+			},
+			Pace: c.config.Pace,
+		}
+		err := p.Run(ctx, func(ctx context.Context, events []matrix.Event) bool {
+			for _, ev := range events {
+				if ev.Sender != chat.UserID || ev.Type != "m.room.message" || ev.Redacted() {
+					continue
+				}
+				if !ev.Time().Before(cutoffDate) {
+					continue
+				}
+
+				if err := chat.Redact(ctx, room, ev.EventID, ""); err != nil {
+					c.printf("Error deleting chat message %s: %v\n", ev.EventID, err)
+					continue
+				}
+
+				c.printf("Successfully deleted chat message from %s\n", ev.Time().Format("2006-01-02"))
+				deleted++
+				if c.config.Pace.Pause(ctx) != nil {
+					return false
+				}
+			}
+			return true
+		})
+		if ctx.Err() != nil {
+			return deleted, ctx.Err()
+		}
+		if err != nil {
+			c.printf("Error reading chat room %s: %v\n", room, err)
+		}
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,87 @@
+package reddit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/plan"
+)
+
+// ownCrossposts returns the crossposts of a post that were made by the
+// configured user
+func (c *Client) ownCrossposts(ctx context.Context, postID string) ([]item, error) {
+	req, err := c.NewRequest("GET", fmt.Sprintf("duplicates/%s?crossposts_only=true&limit=100&raw_json=1", postID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The response holds the original post followed by its crossposts
+	var listings []listing
+	if _, err := c.Do(ctx, req, &listings); err != nil {
+		return nil, err
+	}
+	if len(listings) < 2 {
+		return nil, nil
+	}
+
+	var own []item
+	for _, child := range listings[1].Data.Children {
+		cp := child.Data
+		if strings.EqualFold(cp.Author, c.config.Username) && cp.CrosspostParent == "t3_"+postID {
+			own = append(own, cp)
+		}
+	}
+
+	return own, nil
+}
+
+// deleteCrossposts deletes the user's crossposts of a deleted post regardless
+// of their age, so no orphaned copies survive. Deleted IDs are recorded in
+// done so the listing doesn't process them again.
+func (c *Client) deleteCrossposts(ctx context.Context, postID string, done map[string]bool, opts *DeleteOptions, result *Result) {
+	crossposts, err := c.ownCrossposts(ctx, postID)
+	if err != nil {
+		c.printf("Error fetching crossposts of t3_%s: %v\n", postID, err)
+		return
+	}
+
+	for _, cp := range crossposts {
+		if c.stopped() {
+			return
+		}
+		if done[cp.ID] {
+			continue
+		}
+
+		fullname := "t3_" + cp.ID
+		if c.alreadyDeleted(opts, "crosspost", fullname, result) {
+			continue
+		}
+
+		result.Matched++
+		if c.skipForPlan(opts, plan.Item{ID: fullname, Kind: "crosspost", Date: cp.created(), Text: cp.Title, Where: cp.Subreddit}, result) {
+			continue
+		}
+		if c.vetoed(ctx, opts, "crosspost", fullname, &cp, nil, result) {
+			continue
+		}
+
+		c.printf("Attempting to delete crosspost in r/%s (Fullname: %s)\n", cp.Subreddit, fullname)
+		dctx, receipt := audit.Expect(ctx)
+		if err := c.deleteContent(dctx, fullname); err != nil {
+			if c.gone(opts, "crosspost", fullname, err, result) {
+				continue
+			}
+			c.printf("Error deleting %s: %v\n", describe("crosspost", &cp, fullname), err)
+			result.Failed++
+			continue
+		}
+
+		c.printf("Successfully deleted crosspost: %s\n", c.excerpt(cp.Title))
+		done[cp.ID] = true
+		result.CrosspostsDeleted++
+		c.bury(ctx, opts, receipt, "crosspost", fullname, &cp)
+	}
+}
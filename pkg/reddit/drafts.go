@@ -0,0 +1,58 @@
+package reddit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+type draft struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Created  int64  `json:"created"`
+	Modified int64  `json:"modified"`
+}
+
+// lastChanged returns when the draft was last saved; Reddit reports
+// milliseconds since the epoch
+func (d *draft) lastChanged() time.Time {
+	if d.Modified > 0 {
+		return time.UnixMilli(d.Modified)
+	}
+	return time.UnixMilli(d.Created)
+}
+
+// DeleteDrafts deletes saved post drafts last changed before cutoffDate and
+// returns how many were removed.
+func (c *Client) DeleteDrafts(ctx context.Context, cutoffDate time.Time) (int, error) {
+	var resp struct {
+		Drafts []draft `json:"drafts"`
+	}
+	if err := c.apiRequest(ctx, "GET", "/api/v1/drafts", nil, &resp); err != nil {
+		return 0, fmt.Errorf("failed to fetch drafts: %v", err)
+	}
+
+	c.printf("Found %d drafts\n", len(resp.Drafts))
+	deleted := 0
+
+	for _, d := range resp.Drafts {
+		changed := d.lastChanged()
+		if !changed.Before(cutoffDate) {
+			continue
+		}
+
+		data := url.Values{}
+		data.Set("draft_id", d.ID)
+		if err := c.apiRequest(ctx, "DELETE", "/api/v1/draft", data, nil); err != nil {
+			c.printf("Error deleting draft %s: %v\n", d.ID, err)
+			continue
+		}
+
+		c.printf("Successfully deleted draft: %s (saved on %s)\n", c.excerpt(d.Title), changed.Format("2006-01-02"))
+		deleted++
+		c.config.Pace.Pause(ctx)
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,48 @@
+package reddit
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"go-del-socials/pkg/export"
+	"go-del-socials/pkg/index"
+)
+
+// exportPrefix is the fullname prefix of each kind of exported item
+var exportPrefix = map[string]string{"post": "t3_", "comment": "t1_"}
+
+// ImportExport adds the posts and comments of an extracted data export to
+// the item index, so they can be processed without listing them
+func ImportExport(dir string, ix *index.Index) (int, error) {
+	imported := 0
+	err := export.Reddit.Read(dir, func(it export.Item) (bool, error) {
+		i := item{
+			ID:         it.ID,
+			Name:       exportPrefix[it.Kind] + it.ID,
+			Subreddit:  it.Where,
+			CreatedUTC: float64(it.Date.Unix()),
+			Title:      it.Title,
+		}
+		listing := "submitted"
+		if it.Kind == "comment" {
+			i.Body, listing = it.Text, "comments"
+		} else {
+			i.Selftext = it.Text
+		}
+		// Exports have full URLs, listings only the path
+		if u, err := url.Parse(it.URL); err == nil {
+			i.Permalink = u.Path
+		}
+
+		raw, err := json.Marshal(&i)
+		if err != nil {
+			return false, err
+		}
+		if err := ix.Put("reddit", listing, i.Name, it.Date, raw); err != nil {
+			return false, err
+		}
+		imported++
+		return true, nil
+	})
+	return imported, err
+}
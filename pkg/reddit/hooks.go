@@ -0,0 +1,44 @@
+package reddit
+
+import (
+	"context"
+
+	"go-del-socials/pkg/hook"
+)
+
+// hookItem describes an item to the hooks
+func hookItem(kind, fullname string, i *item) hook.Item {
+	return hook.Item{
+		Platform: "reddit",
+		Kind:     kind,
+		ID:       fullname,
+		Date:     i.created(),
+		URL:      i.url(),
+		Text:     i.text(kind),
+	}
+}
+
+// vetoed waits out a pause, then runs the before-delete hook and reports
+// whether it keeps the item online. Otherwise the hook's overwrite text, if
+// any, replaces template.
+func (c *Client) vetoed(ctx context.Context, opts *DeleteOptions, kind, fullname string, i *item, template *string, result *Result) bool {
+	opts.Pause.Wait(ctx)
+	d := opts.Hooks.Before(ctx, hookItem(kind, fullname, i))
+	if d.Veto {
+		c.printf("Keeping %s %s: %s\n", kind, fullname, d.Reason)
+		result.Vetoed++
+		opts.keep(kind, fullname, i, d.Reason)
+		return true
+	}
+	if d.Overwrite != "" && template != nil {
+		*template = d.Overwrite
+	}
+	return false
+}
+
+// afterDelete runs the after-delete hook
+func (c *Client) afterDelete(ctx context.Context, opts *DeleteOptions, kind, fullname string, i *item) {
+	if err := opts.Hooks.After(ctx, hookItem(kind, fullname, i)); err != nil {
+		c.printf("Warning: %v\n", err)
+	}
+}
@@ -0,0 +1,50 @@
+package reddit
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// KeepRules spare items by where they were posted, how they were received
+// and their IDs, like the whitelists of shreddit, the Python tool
+type KeepRules struct {
+	// Subreddits whose content is kept
+	Subreddits []string `json:"subreddits,omitempty"`
+
+	// IDs of kept items, with or without their t1_ or t3_ prefix
+	IDs []string `json:"ids,omitempty"`
+
+	// MaxScore, when set, keeps items scoring higher
+	MaxScore *int `json:"max_score,omitempty"`
+
+	// Distinguished keeps items posted as a moderator or admin, Gilded
+	// items that received gold
+	Distinguished bool `json:"distinguished,omitempty"`
+	Gilded        bool `json:"gilded,omitempty"`
+
+	// NukeHours, when set, deletes items older than this many hours
+	// whatever the other rules say
+	NukeHours int `json:"nuke_hours,omitempty"`
+}
+
+// reason says why the rules keep an item, or returns "" if they don't
+func (k *KeepRules) reason(i *item) string {
+	if k.NukeHours > 0 && time.Since(i.created()) > time.Duration(k.NukeHours)*time.Hour {
+		return ""
+	}
+	switch {
+	case slices.ContainsFunc(k.Subreddits, func(s string) bool { return strings.EqualFold(s, i.Subreddit) }):
+		return "kept subreddit r/" + i.Subreddit
+	case slices.ContainsFunc(k.IDs, func(id string) bool { return id == i.ID || id == i.Name }):
+		return "kept ID"
+	case k.MaxScore != nil && i.Score > *k.MaxScore:
+		return fmt.Sprintf("score %d above %d", i.Score, *k.MaxScore)
+	case k.Distinguished && i.Distinguished != "":
+		return "distinguished"
+	case k.Gilded && i.Gilded > 0:
+		return "gilded"
+	}
+	return ""
+}
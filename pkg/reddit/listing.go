@@ -0,0 +1,166 @@
+package reddit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// item is a post or comment from a user listing. go-reddit's Post and
+// Comment types leave out several fields needed for filtering, so listings
+// are decoded here instead.
+type item struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Title      string  `json:"title"`
+	Body       string  `json:"body"`
+	Selftext   string  `json:"selftext"`
+	IsSelf     bool    `json:"is_self"`
+	Subreddit  string  `json:"subreddit"`
+	Author     string  `json:"author"`
+	Permalink  string  `json:"permalink"`
+	CreatedUTC float64 `json:"created_utc"`
+
+	// Set on posts in quarantined subreddits
+	Quarantine bool `json:"quarantine"`
+
+	// Fullname of the original post when this post is a crosspost
+	CrosspostParent string `json:"crosspost_parent"`
+
+	// Set when moderators, Reddit's filters or admins removed the item
+	RemovedByCategory string `json:"removed_by_category"`
+
+	// Archived (over six months old in most subreddits) and locked items
+	// can still be deleted, but no longer edited
+	Archived bool `json:"archived"`
+	Locked   bool `json:"locked"`
+
+	// Score and awards, and the role the author posted it in, e.g.
+	// "moderator", for the keep rules
+	Score         int    `json:"score"`
+	Gilded        int    `json:"gilded"`
+	Distinguished string `json:"distinguished"`
+}
+
+// frozen describes why the item can't be edited, or returns "" if it can
+func (i *item) frozen() string {
+	switch {
+	case i.Archived:
+		return "archived"
+	case i.Locked:
+		return "locked"
+	}
+	return ""
+}
+
+func (i *item) created() time.Time {
+	return time.Unix(int64(i.CreatedUTC), 0)
+}
+
+// url returns the item's permalink on reddit.com
+func (i *item) url() string {
+	if i.Permalink == "" {
+		return ""
+	}
+	return "https://www.reddit.com" + i.Permalink
+}
+
+// text is the full text of a comment, or the title and body of a post
+func (i *item) text(kind string) string {
+	if kind == "comment" {
+		return i.Body
+	}
+	return strings.TrimSpace(i.Title + "\n\n" + i.Selftext)
+}
+
+// removedByOthers reports whether the item was removed by moderators, spam
+// filters or admins, as opposed to being deleted by its author
+func (i *item) removedByOthers() bool {
+	switch i.RemovedByCategory {
+	case "", "author", "deleted":
+		return false
+	}
+	return true
+}
+
+type listing struct {
+	Data struct {
+		After    string `json:"after"`
+		Children []struct {
+			Data item `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+func (l *listing) items() []item {
+	items := make([]item, 0, len(l.Data.Children))
+	for _, child := range l.Data.Children {
+		items = append(items, child.Data)
+	}
+	return items
+}
+
+// listUser fetches a page of the user's submitted posts or comments. where is
+// "submitted" or "comments". The returned cursor is empty on the last page.
+func (c *Client) listUser(ctx context.Context, where, after string) (items []item, next string, err error) {
+	ctx, span := telemetry.Start(ctx, "reddit.fetch", attribute.String("listing", where), attribute.String("after", after))
+	defer func() {
+		span.SetAttributes(attribute.Int("items", len(items)))
+		telemetry.End(span, err)
+	}()
+
+	q := url.Values{}
+	q.Set("limit", strconv.Itoa(100))
+	q.Set("raw_json", "1")
+	if after != "" {
+		q.Set("after", after)
+	}
+
+	l, err := c.fetchListing(ctx, fmt.Sprintf("user/%s/%s?%s", c.config.Username, where, q.Encode()))
+	if err != nil {
+		return nil, "", err
+	}
+	return l.items(), l.Data.After, nil
+}
+
+// info fetches up to 100 posts and comments by fullname. Items that no
+// longer exist are left out.
+func (c *Client) info(ctx context.Context, fullnames []string) ([]item, error) {
+	q := url.Values{}
+	q.Set("id", strings.Join(fullnames, ","))
+	q.Set("raw_json", "1")
+	l, err := c.fetchListing(ctx, "api/info?"+q.Encode())
+	if err != nil {
+		return nil, err
+	}
+	return l.items(), nil
+}
+
+// fetchListing fetches a page of a listing, waiting out rate limits
+func (c *Client) fetchListing(ctx context.Context, path string) (*listing, error) {
+	var l listing
+	for attempt := 1; ; attempt++ {
+		req, err := c.NewRequest("GET", path, nil)
+		if err != nil {
+			return nil, err
+		}
+		_, err = c.Do(ctx, req, &l)
+		wait, limited := libraryRateLimitWait(err)
+		if !limited || attempt == maxAttempts {
+			if err != nil {
+				return nil, err
+			}
+			return &l, nil
+		}
+		if err := c.waitRateLimit(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
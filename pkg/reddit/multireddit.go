@@ -0,0 +1,47 @@
+package reddit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MultiredditSubreddits returns the subreddits belonging to a multireddit
+// (custom feed). path may be "user/<name>/m/<multi>", the "/u/" short form or
+// a full reddit.com URL.
+func (c *Client) MultiredditSubreddits(ctx context.Context, path string) ([]string, error) {
+	multiPath, err := normalizeMultiPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	multi, _, err := c.Multi.Get(ctx, multiPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch multireddit %s: %v", multiPath, err)
+	}
+
+	if len(multi.Subreddits) == 0 {
+		return nil, fmt.Errorf("multireddit %s contains no subreddits", multiPath)
+	}
+
+	return multi.Subreddits, nil
+}
+
+func normalizeMultiPath(path string) (string, error) {
+	if u, err := url.Parse(path); err == nil && u.Host != "" {
+		path = u.Path
+	}
+
+	path = strings.Trim(path, "/")
+	if strings.HasPrefix(path, "u/") {
+		path = "user/" + strings.TrimPrefix(path, "u/")
+	}
+
+	parts := strings.Split(path, "/")
+	if len(parts) != 4 || parts[0] != "user" || parts[2] != "m" || parts[1] == "" || parts[3] == "" {
+		return "", fmt.Errorf("invalid multireddit %q: expected user/<name>/m/<multireddit>", path)
+	}
+
+	return path, nil
+}
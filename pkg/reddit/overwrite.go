@@ -0,0 +1,86 @@
+package reddit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// OverwriteTemplates holds the text written over an item's body before it is
+// deleted, so scrapers that only capture bodies keep garbage. An empty
+// template disables overwriting for that type. Templates may contain
+// {random} (a random string) and {date} (today's date).
+type OverwriteTemplates struct {
+	Posts    string `json:"posts"`
+	Comments string `json:"comments"`
+}
+
+func renderOverwrite(template string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return strings.NewReplacer(
+		"{random}", hex.EncodeToString(b),
+		"{date}", time.Now().Format("2006-01-02"),
+	).Replace(template)
+}
+
+// overwrite replaces the body of a self post or comment with the template.
+// Failures are logged rather than returned since the item is deleted anyway.
+func (c *Client) overwrite(ctx context.Context, i *item, fullname, template string) {
+	if template == "" {
+		return
+	}
+
+	// Link posts have no body to overwrite
+	if strings.HasPrefix(fullname, "t3_") && (!i.IsSelf || i.Selftext == "") {
+		return
+	}
+
+	// Archived and locked items reject edits but can still be deleted
+	if f := i.frozen(); f != "" {
+		c.printf("Skipping overwrite of %s: %s items can't be edited\n", fullname, f)
+		return
+	}
+
+	if err := c.editContent(ctx, fullname, renderOverwrite(template)); err != nil {
+		c.printf("Error overwriting %s: %v\n", fullname, err)
+		return
+	}
+
+	c.printf("Overwrote %s\n", fullname)
+}
+
+func (c *Client) editContent(ctx context.Context, fullname, text string) (err error) {
+	_, span := telemetry.Start(ctx, "reddit.overwrite", attribute.String("fullname", fullname))
+	defer func() { telemetry.End(span, err) }()
+
+	data := url.Values{}
+	data.Set("api_type", "json")
+	data.Set("thing_id", fullname)
+	data.Set("text", text)
+
+	// editusertext reports failures in the body of a 200 response
+	var resp struct {
+		JSON struct {
+			Errors [][]any `json:"errors"`
+		} `json:"json"`
+	}
+	if err := c.apiPost(ctx, "/api/editusertext", data, &resp); err != nil {
+		return fmt.Errorf("edit %v", err)
+	}
+
+	if len(resp.JSON.Errors) > 0 {
+		return fmt.Errorf("edit rejected: %v", resp.JSON.Errors[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Preflight verifies the token is valid, belongs to the configured user and
+// is allowed to delete content before a run starts.
+func (c *Client) Preflight(ctx context.Context) error {
+	if c.accessToken == "" {
+		return fmt.Errorf("Reddit did not issue an access token: check client_id, client_secret, username and password")
+	}
+
+	// /api/del requires the "edit" scope; script apps are granted "*"
+	scopes := strings.Fields(c.scope)
+	if len(scopes) > 0 && !slices.Contains(scopes, "*") && !slices.Contains(scopes, "edit") {
+		return fmt.Errorf("access token lacks the \"edit\" scope needed for deletion (granted: %s)", c.scope)
+	}
+	if c.config.Verified {
+		return nil
+	}
+
+	me, status, err := c.me(ctx)
+	switch {
+	case status == http.StatusUnauthorized:
+		return fmt.Errorf("authentication failed: the access token was rejected")
+	case status == http.StatusForbidden:
+		return fmt.Errorf("access denied: the token is not allowed to read account identity")
+	case err != nil:
+		return fmt.Errorf("preflight request failed: %v", err)
+	}
+
+	if !strings.EqualFold(me.Name, c.config.Username) {
+		return fmt.Errorf("token belongs to u/%s, not the configured user u/%s", me.Name, c.config.Username)
+	}
+
+	// A suspended account can still sign in, but can't delete anything
+	return me.standing()
+}
+
+// Ping fetches the first page of the user's comments, a read-only request
+// for timing the API
+func (c *Client) Ping(ctx context.Context) error {
+	_, _, err := c.listUser(ctx, "comments", "")
+	return err
+}
+
+// tokenError explains a failed token request by the setting to fix, or
+// returns nil if it succeeded. installed says the request used a refresh
+// token rather than a password.
+func tokenError(status int, body []byte, installed bool) error {
+	var resp struct {
+		Error any `json:"error"`
+	}
+	// Bodies that aren't JSON leave it unset
+	_ = json.Unmarshal(body, &resp)
+
+	switch {
+	case status == http.StatusOK && resp.Error == nil:
+		return nil
+	case installed && resp.Error == "invalid_grant":
+		return fmt.Errorf("reddit rejected refresh_token (invalid_grant); it was revoked or belongs to another app, so run go-del-socials reddit-authorize again")
+	case status == http.StatusUnauthorized:
+		return fmt.Errorf("reddit rejected client_id or client_secret (401 Unauthorized); compare them with your app at https://www.reddit.com/prefs/apps")
+	case resp.Error == "invalid_grant":
+		return fmt.Errorf("reddit rejected username or password (invalid_grant); check them, and that the app is a script app listing this account as a developer. " +
+			"If they are right, Reddit may have locked the account: sign in at https://www.reddit.com and follow what it asks, such as resetting the password")
+	case resp.Error == "unsupported_grant_type":
+		return fmt.Errorf("reddit refused password sign-in (unsupported_grant_type); the app at https://www.reddit.com/prefs/apps must be of type script")
+	case status == http.StatusTooManyRequests || status == http.StatusForbidden:
+		return fmt.Errorf("reddit refused the token request (%d %s); this usually means user_agent is too generic, so follow the format \"script:go-del-socials:v1.0 (by /u/yourname)\"",
+			status, http.StatusText(status))
+	case resp.Error != nil:
+		return fmt.Errorf("token request failed: %v", resp.Error)
+	default:
+		return fmt.Errorf("token request failed: %d %s: %.200s", status, http.StatusText(status), body)
+	}
+}
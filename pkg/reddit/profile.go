@@ -0,0 +1,66 @@
+package reddit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// ScrubProfile clears the profile of the configured user: the about text,
+// display name, banner and avatar, and every post made to the profile itself
+// (the u_<username> subreddit). It returns the number of profile elements
+// cleared and profile posts deleted.
+func (c *Client) ScrubProfile(ctx context.Context) (int, int, error) {
+	sr := "u_" + c.config.Username
+	cleared := 0
+
+	settings, _, err := c.Subreddit.GetSettings(ctx, sr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to fetch profile settings: %v", err)
+	}
+
+	// site_admin expects every setting, so edit the current ones in place
+	empty := ""
+	settings.Title = &empty
+	settings.Description = &empty
+	if _, err := c.Subreddit.Edit(ctx, settings.ID, settings); err != nil {
+		c.printf("Error clearing display name and about text: %v\n", err)
+	} else {
+		c.printf("Cleared display name and about text\n")
+		cleared += 2
+	}
+
+	images := []struct {
+		name   string
+		remove func(context.Context, string) (*reddit.Response, error)
+	}{
+		{"banner", c.Subreddit.RemoveMobileHeader},
+		{"avatar", c.Subreddit.RemoveMobileIcon},
+		{"header image", c.Subreddit.RemoveHeader},
+	}
+	for _, img := range images {
+		if _, err := img.remove(ctx, sr); err != nil {
+			c.printf("Error removing %s: %v\n", img.name, err)
+			continue
+		}
+		c.printf("Removed %s\n", img.name)
+		cleared++
+	}
+
+	// Reddit exposes no public API for social links
+	c.printf("Social links can't be removed through the API; remove them at https://www.reddit.com/settings/profile\n")
+
+	// Posts made to the profile are removed regardless of the cutoff date
+	result, err := c.DeleteContent(ctx, DeleteOptions{
+		ContentType: "posts",
+		CutoffDate:  time.Now(),
+		Subreddits:  []string{sr},
+	})
+	if err != nil {
+		return cleared, result.PostsDeleted, err
+	}
+
+	return cleared, result.PostsDeleted, nil
+}
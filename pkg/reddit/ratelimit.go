@@ -0,0 +1,89 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"go-del-socials/pkg/httpclient"
+	"go-del-socials/pkg/pace"
+
+	"github.com/vartanbeno/go-reddit/v2/reddit"
+)
+
+// maxAttempts is how often a rate-limited request is sent before giving up
+const maxAttempts = 4
+
+// tryAgainIn finds the wait in Reddit's "you are doing that too much. try
+// again in 5 minutes." errors
+var tryAgainIn = regexp.MustCompile(`try again in (\d+) (second|minute|hour)s?`)
+
+// rateLimitWait reports whether a response asks to slow down, and for how
+// long: a 429 with its Retry-After or ratelimit reset header, or a
+// RATELIMIT error in the JSON body of an otherwise successful response. A
+// second is added, as Reddit rounds the waits down.
+func rateLimitWait(resp *http.Response, body []byte) (time.Duration, bool) {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if wait, ok := httpclient.RetryAfter(resp.Header); ok {
+			return wait + time.Second, true
+		}
+		if secs, err := strconv.ParseFloat(resp.Header.Get("X-Ratelimit-Reset"), 64); err == nil {
+			return time.Duration(secs)*time.Second + time.Second, true
+		}
+		return time.Minute, true
+	}
+
+	var answer struct {
+		JSON struct {
+			Errors    [][]any `json:"errors"`
+			Ratelimit float64 `json:"ratelimit"`
+		} `json:"json"`
+	}
+	if json.Unmarshal(body, &answer) != nil {
+		return 0, false
+	}
+	for _, e := range answer.JSON.Errors {
+		if len(e) == 0 || e[0] != "RATELIMIT" {
+			continue
+		}
+		if answer.JSON.Ratelimit > 0 {
+			return time.Duration(answer.JSON.Ratelimit*float64(time.Second)) + time.Second, true
+		}
+		if len(e) > 1 {
+			msg, _ := e[1].(string)
+			if m := tryAgainIn.FindStringSubmatch(msg); m != nil {
+				n, _ := strconv.Atoi(m[1])
+				unit := map[string]time.Duration{"second": time.Second, "minute": time.Minute, "hour": time.Hour}[m[2]]
+				return time.Duration(n)*unit + time.Second, true
+			}
+		}
+		return time.Minute, true
+	}
+	return 0, false
+}
+
+// libraryRateLimitWait is rateLimitWait for errors of the reddit library,
+// which stops at the end of the allowance rather than letting Reddit answer
+// 429
+func libraryRateLimitWait(err error) (time.Duration, bool) {
+	var limited *reddit.RateLimitError
+	if errors.As(err, &limited) {
+		return max(time.Until(limited.Rate.Reset), 0) + time.Second, true
+	}
+	var failed *reddit.ErrorResponse
+	if errors.As(err, &failed) && failed.Response != nil && failed.Response.StatusCode == http.StatusTooManyRequests {
+		return rateLimitWait(failed.Response, nil)
+	}
+	return 0, false
+}
+
+// waitRateLimit waits out a rate limit, counting the time
+func (c *Client) waitRateLimit(ctx context.Context, wait time.Duration) error {
+	c.printf("\nRate limit reached. Waiting %v before trying again...\n", wait.Round(time.Second))
+	c.rateLimited.Add(int64(wait))
+	return pace.Wait(ctx, wait)
+}
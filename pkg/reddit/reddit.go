@@ -3,13 +3,34 @@ package reddit
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/export"
+	"go-del-socials/pkg/filter"
+	"go-del-socials/pkg/hook"
+	"go-del-socials/pkg/httpclient"
+	"go-del-socials/pkg/index"
+	"go-del-socials/pkg/inventory"
+	"go-del-socials/pkg/logtext"
+	"go-del-socials/pkg/pace"
+	"go-del-socials/pkg/pause"
+	"go-del-socials/pkg/pipeline"
+	"go-del-socials/pkg/plan"
+	"go-del-socials/pkg/telemetry"
+	"go-del-socials/pkg/tombstone"
+
 	"github.com/vartanbeno/go-reddit/v2/reddit"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Config struct {
@@ -18,35 +39,389 @@ type Config struct {
 	Username     string
 	Password     string
 	UserAgent    string
+
+	// RefreshToken signs in an installed app, which has no secret, in
+	// place of the password; see Authorize
+	RefreshToken string
+
+	// APIURL and AuthURL replace DefaultAPIURL and DefaultAuthURL, to go
+	// through a gateway or to a Reddit-compatible server
+	APIURL  string
+	AuthURL string
+
+	// Overwrite holds the text written over posts and comments before they
+	// are deleted
+	Overwrite OverwriteTemplates
+
+	// Simulate acknowledges every write without sending it, so runs can be
+	// rehearsed against the real listings
+	Simulate bool
+
+	// HideContent leaves the text of items out of progress messages, which
+	// otherwise show it cleaned and shortened
+	HideContent bool
+
+	// Verified skips asking Reddit whose token it is when an earlier
+	// preflight already found it belongs to Username
+	Verified bool
+
+	// Pace sets the delay between listing pages and between drafts, chat
+	// messages and export-only items, and caps deletes and edits; nil
+	// leaves them unpaced
+	Pace *pace.Pacer
+
+	// Output receives progress messages; defaults to os.Stdout
+	Output io.Writer
+}
+
+type DeleteOptions struct {
+	ContentType string
+	CutoffDate  time.Time
+
+	// Subreddits restricts deletion to content posted in these subreddits
+	// when non-empty
+	Subreddits []string
+
+	// Hide hides matching posts instead of deleting them. Comments can't be
+	// hidden, so it is only for the posts content type.
+	Hide bool
+
+	// RemovedOnly limits deletion to content already removed by moderators,
+	// spam filters or admins
+	RemovedOnly bool
+
+	// Crossposts also deletes the user's crossposts of every deleted post
+	Crossposts bool
+
+	// QuarantineOptIn opts in to quarantined subreddits before acting on
+	// content posted there
+	QuarantineOptIn bool
+
+	// Keep spares items the other options would delete
+	Keep KeepRules
+
+	// KeepLast spares the newest posts and comments by count, whatever
+	// their date
+	KeepLast filter.KeepLast
+
+	// Retention overrides the cutoff in some subreddits, named as
+	// r/<subreddit>
+	Retention filter.Retention
+
+	// Targets, when set, are the fullnames of the items to process instead
+	// of listing the user's content
+	Targets []string
+
+	// ExportDir points to an extracted Reddit data request. Items listed
+	// there but missing from the user listings, such as content in banned
+	// subreddits, are deleted as well.
+	ExportDir string
+
+	// Plan, when set, records matching items instead of acting on them
+	Plan *plan.Set
+
+	// Approved, when set, limits deletion to the items of a reviewed plan
+	Approved *plan.Set
+
+	// Kept, when set, receives the listed items that stay online
+	Kept *inventory.List
+
+	// Tombstones, when set, records every deleted item
+	Tombstones *tombstone.Index
+
+	// Receipts, when set, records the API's raw answer to every delete
+	Receipts *audit.Log
+
+	// Index, when set, keeps a local copy of every listed item. With
+	// Incremental, listings stop at already indexed items and retention is
+	// applied to the older ones from the index.
+	Index       *index.Index
+	Incremental bool
+
+	// Hooks, when set, may veto or customize each deletion and are told
+	// about every deleted item
+	Hooks *hook.Hooks
+
+	// Pause, when set, holds the run before each item while it is paused
+	Pause *pause.Gate
+}
+
+// matches reports whether an item older than the cutoff may be deleted
+func (o *DeleteOptions) matches(i *item) bool {
+	if o.RemovedOnly && !i.removedByOthers() {
+		return false
+	}
+	return o.inScope(i.Subreddit) && o.Keep.reason(i) == ""
+}
+
+// skipForPlan records the item when planning and refuses items missing from
+// an approved plan. It reports whether the item must be left alone.
+func (c *Client) skipForPlan(opts *DeleteOptions, it plan.Item, result *Result) bool {
+	if opts.Plan != nil {
+		opts.Plan.Add(it)
+		return true
+	}
+	if opts.Approved != nil && !opts.Approved.Has(it.ID) {
+		c.printf("Refusing %s %s: it is not in the plan\n", it.Kind, it.ID)
+		result.NotPlanned++
+		return true
+	}
+	return false
+}
+
+// keep records an item that stays online, and why, in the kept inventory
+func (o *DeleteOptions) keep(kind, fullname string, i *item, reason string) {
+	if o.Kept == nil {
+		return
+	}
+	text := i.Title
+	if kind == "comment" {
+		text = i.Body
+	}
+	o.Kept.Add(inventory.Item{
+		ID:     fullname,
+		Kind:   kind,
+		Date:   i.created(),
+		URL:    i.url(),
+		Text:   text,
+		Reason: reason,
+	})
+}
+
+// receipt records the API's answer to the delete that just succeeded
+func (c *Client) receipt(opts *DeleteOptions, r *audit.Receipt, kind, fullname string) {
+	if opts.Receipts == nil || !r.Captured() {
+		return
+	}
+	r.Platform, r.Kind, r.ID = "reddit", kind, fullname
+	if err := opts.Receipts.Record(*r); err != nil {
+		c.printf("Warning: %v\n", err)
+	}
+}
+
+// bury records a deleted item in the tombstone index, along with its
+// receipt, and marks it deleted in the item index
+func (c *Client) bury(ctx context.Context, opts *DeleteOptions, receipt *audit.Receipt, kind, fullname string, i *item) {
+	c.receipt(opts, receipt, kind, fullname)
+	c.afterDelete(ctx, opts, kind, fullname, i)
+	if opts.Index != nil {
+		if err := opts.Index.MarkDeleted("reddit", fullname); err != nil {
+			c.printf("Warning: %v\n", err)
+		}
+	}
+	if opts.Tombstones == nil {
+		return
+	}
+	err := opts.Tombstones.Record(tombstone.Tombstone{
+		Platform: "reddit",
+		ID:       fullname,
+		Kind:     kind,
+		URL:      i.url(),
+		Text:     i.text(kind),
+		Created:  i.created(),
+	})
+	if err != nil {
+		c.printf("Warning: %v\n", err)
+	}
+}
+
+// errGone is the error of deletes of items that no longer exist
+var errGone = errors.New("no longer exists")
+
+// errNotFound is the error of requests answered with 404. Only a delete
+// takes it to mean the item is gone; a failed hide or edit is a failure.
+var errNotFound = errors.New("not found")
+
+// gone handles a delete that failed because the item is already gone, e.g.
+// one returned by a stale listing: it is counted apart from failures and
+// marked deleted in the index. It reports whether err was such a failure.
+func (c *Client) gone(opts *DeleteOptions, kind, fullname string, err error, result *Result) bool {
+	if !errors.Is(err, errGone) {
+		return false
+	}
+	c.printf("Skipping %s %s: already gone\n", kind, fullname)
+	result.AlreadyGone++
+	if opts.Index != nil {
+		if err := opts.Index.MarkDeleted("reddit", fullname); err != nil {
+			c.printf("Warning: %v\n", err)
+		}
+	}
+	if opts.Tombstones != nil {
+		// There is no copy to point at, but later runs needn't ask again
+		if err := opts.Tombstones.Record(tombstone.Tombstone{Platform: "reddit", ID: fullname, Kind: kind}); err != nil {
+			c.printf("Warning: %v\n", err)
+		}
+	}
+	return true
+}
+
+// alreadyDeleted reports whether an earlier run deleted the item, so it
+// isn't attempted again
+func (c *Client) alreadyDeleted(opts *DeleteOptions, kind, fullname string, result *Result) bool {
+	if opts.Tombstones == nil {
+		return false
+	}
+	gone, err := opts.Tombstones.Has("reddit", fullname)
+	if err != nil {
+		c.printf("Warning: %v\n", err)
+		return false
+	}
+	if !gone {
+		return false
+	}
+
+	c.printf("Skipping %s %s: already deleted by an earlier run\n", kind, fullname)
+	result.AlreadyDeleted++
+	if opts.Index != nil {
+		if err := opts.Index.MarkDeleted("reddit", fullname); err != nil {
+			c.printf("Warning: %v\n", err)
+		}
+	}
+	return true
+}
+
+// cutoff returns the cutoff of the subreddit an item was posted in, and
+// why an item newer than it is kept
+func (o *DeleteOptions) cutoff(subreddit string) (time.Time, string) {
+	return o.Retention.Cutoff(o.CutoffDate, "r/"+subreddit)
+}
+
+// old reports whether an item was posted before its cutoff
+func (o *DeleteOptions) old(i *item) bool {
+	cutoff, _ := o.cutoff(i.Subreddit)
+	return i.created().Before(cutoff)
+}
+
+// unmatchedReason explains why an item wasn't selected for deletion
+func (o *DeleteOptions) unmatchedReason(i *item) string {
+	if cutoff, reason := o.cutoff(i.Subreddit); !i.created().Before(cutoff) {
+		return reason
+	}
+	if reason := o.Keep.reason(i); reason != "" {
+		return reason
+	}
+	return "not selected by the filters"
+}
+
+// inScope reports whether content posted in subreddit may be deleted
+func (o *DeleteOptions) inScope(subreddit string) bool {
+	if len(o.Subreddits) == 0 {
+		return true
+	}
+	for _, s := range o.Subreddits {
+		if strings.EqualFold(s, subreddit) {
+			return true
+		}
+	}
+	return false
+}
+
+// Result counts what a DeleteContent run did
+type Result struct {
+	PostsDeleted      int
+	CommentsDeleted   int
+	CrosspostsDeleted int
+
+	// Matched items in archived or locked threads, counted separately
+	// because they can be deleted but not edited
+	Archived int
+	Locked   int
+
+	// Matched items in quarantined subreddits
+	Quarantined int
+
+	// Items deleted from the data export because listings didn't return
+	// them, usually because their subreddit is banned
+	ExportOnlyDeleted int
+	ExportOnlyFailed  int
+
+	// Matched items and those that could not be deleted or hidden
+	Matched int
+	Failed  int
+
+	// Matched items refused because the approved plan doesn't list them
+	NotPlanned int
+
+	// Items an earlier run already deleted, returned again by a stale
+	// listing or the data export
+	AlreadyDeleted int
+
+	// Matched items Reddit no longer has, counted apart from failures
+	AlreadyGone int
+
+	// Matched items the before-delete hook kept online
+	Vetoed int
+}
+
+func (r *Result) countFrozen(i *item) {
+	switch i.frozen() {
+	case "archived":
+		r.Archived++
+	case "locked":
+		r.Locked++
+	}
+}
+
+// The hosts of the API and of signing in
+const (
+	DefaultAPIURL  = "https://oauth.reddit.com"
+	DefaultAuthURL = "https://www.reddit.com"
+)
+
+func (c *Config) apiURL() string {
+	if c.APIURL != "" {
+		return strings.TrimSuffix(c.APIURL, "/")
+	}
+	return DefaultAPIURL
+}
+
+func (c *Config) authURL() string {
+	if c.AuthURL != "" {
+		return strings.TrimSuffix(c.AuthURL, "/")
+	}
+	return DefaultAuthURL
 }
 
 type Client struct {
 	*reddit.Client
 	accessToken string
+	scope       string
 	httpClient  *http.Client
 	config      *Config
+
+	// rateLimited is the total time spent waiting for rate limits, in
+	// nanoseconds
+	rateLimited atomic.Int64
+
+	// refused counts deletes refused in a row; blocked, once set, says why
+	// the account can't delete anything
+	refused int
+	blocked error
 }
 
-func NewClient(config *Config) (*Client, error) {
-	credentials := reddit.Credentials{
-		ID:       config.ClientID,
-		Secret:   config.ClientSecret,
-		Username: config.Username,
-		Password: config.Password,
-	}
+// RateLimitWait returns how long the client has waited for rate limits
+func (c *Client) RateLimitWait() time.Duration {
+	return time.Duration(c.rateLimited.Load())
+}
 
-	client, err := reddit.NewClient(credentials, reddit.WithUserAgent(config.UserAgent))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Reddit client: %v", err)
+func NewClient(config *Config) (*Client, error) {
+	if config.Output == nil {
+		config.Output = os.Stdout
 	}
 
-	// Get OAuth2 token
+	// Get OAuth2 token. Installed apps have no secret and can't sign in
+	// with a password, so they use the refresh token from Authorize.
 	data := url.Values{}
-	data.Set("grant_type", "password")
-	data.Set("username", config.Username)
-	data.Set("password", config.Password)
+	if config.RefreshToken != "" {
+		data.Set("grant_type", "refresh_token")
+		data.Set("refresh_token", config.RefreshToken)
+	} else {
+		data.Set("grant_type", "password")
+		data.Set("username", config.Username)
+		data.Set("password", config.Password)
+	}
 
-	req, err := http.NewRequest("POST", "https://www.reddit.com/api/v1/access_token", strings.NewReader(data.Encode()))
+	req, err := http.NewRequest("POST", config.authURL()+"/api/v1/access_token", strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create token request: %v", err)
 	}
@@ -55,7 +430,7 @@ func NewClient(config *Config) (*Client, error) {
 	req.Header.Set("User-Agent", config.UserAgent)
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	httpClient := &http.Client{}
+	httpClient := httpclient.New()
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get token: %v", err)
@@ -64,27 +439,146 @@ func NewClient(config *Config) (*Client, error) {
 
 	var tokenResp struct {
 		AccessToken string `json:"access_token"`
+		Scope       string `json:"scope"`
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %v", err)
+	}
+	if err := tokenError(resp.StatusCode, body, config.RefreshToken != ""); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
 		return nil, fmt.Errorf("failed to decode token response: %v", err)
 	}
+	if tokenResp.AccessToken == "" {
+		return nil, fmt.Errorf("reddit sent no access token: %s", body)
+	}
+
+	// The library wraps its client's transport in its own OAuth, so it gets
+	// a client apart from the one for raw requests. Its OAuth only knows
+	// passwords, so installed apps give it the token fetched here instead.
+	hc := httpclient.New()
+	var client *reddit.Client
+	if config.RefreshToken != "" {
+		hc.Transport = &bearerTransport{token: tokenResp.AccessToken, base: hc.Transport}
+		if config.Simulate {
+			audit.Simulate(hc, signIn)
+		}
+		client, err = reddit.NewReadonlyClient(reddit.WithBaseURL(config.apiURL()), reddit.WithUserAgent(config.UserAgent), reddit.WithHTTPClient(hc))
+	} else {
+		credentials := reddit.Credentials{
+			ID:       config.ClientID,
+			Secret:   config.ClientSecret,
+			Username: config.Username,
+			Password: config.Password,
+		}
+		if config.Simulate {
+			audit.Simulate(hc, signIn)
+		}
+		client, err = reddit.NewClient(credentials, reddit.WithUserAgent(config.UserAgent), reddit.WithHTTPClient(hc),
+			reddit.WithBaseURL(config.apiURL()), reddit.WithTokenURL(config.authURL()+"/api/v1/access_token"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Reddit client: %v", err)
+	}
+
+	if config.Simulate {
+		audit.Simulate(httpClient, signIn)
+	}
 
 	return &Client{
 		Client:      client,
 		accessToken: tokenResp.AccessToken,
+		scope:       tokenResp.Scope,
 		httpClient:  httpClient,
 		config:      config,
 	}, nil
 }
 
-func (c *Client) deleteContent(fullname string) error {
-	data := url.Values{}
-	data.Set("id", fullname)
+// bearerTransport signs the library's requests with an access token
+type bearerTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(req)
+}
+
+// signIn reports whether a request only fetches a token, which a simulated
+// run still needs
+func signIn(req *http.Request) bool {
+	return req.URL.Path == "/api/v1/access_token" || strings.HasSuffix(req.URL.Path, "/login")
+}
+
+func (c *Client) printf(format string, args ...any) {
+	fmt.Fprintf(c.config.Output, format, args...)
+}
+
+// excerpt is how an item's text appears in progress messages
+func (c *Client) excerpt(text string) string {
+	return logtext.Short(text, c.config.HideContent)
+}
+
+// apiPost sends a form request to an authenticated endpoint such as /api/del
+// and decodes the JSON response into out unless it is nil
+func (c *Client) apiPost(ctx context.Context, endpoint string, data url.Values, out any) error {
+	return c.apiRequest(ctx, "POST", endpoint, data, out)
+}
+
+// apiRequest calls an authenticated endpoint. data is sent as the form body,
+// or as the query string for GET and DELETE requests. When Reddit asks to
+// slow down, the request is sent again once it allows.
+func (c *Client) apiRequest(ctx context.Context, method, endpoint string, data url.Values, out any) error {
+	for attempt := 1; ; attempt++ {
+		body, wait, err := c.send(ctx, method, endpoint, data)
+		if err != nil {
+			return err
+		}
+		if wait > 0 {
+			if attempt == maxAttempts {
+				return fmt.Errorf("request failed: still rate limited after %d attempts", attempt)
+			}
+			if err := c.waitRateLimit(ctx, wait); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if out != nil {
+			if err := json.Unmarshal(body, out); err != nil {
+				return fmt.Errorf("failed to decode response: %v", err)
+			}
+		}
+		return nil
+	}
+}
+
+// send makes one attempt at an API request and returns the response body,
+// or how long Reddit asked to wait before trying again
+func (c *Client) send(ctx context.Context, method, endpoint string, data url.Values) ([]byte, time.Duration, error) {
+	target := c.config.apiURL() + endpoint
+	var body io.Reader
+	if method == "GET" || method == "DELETE" {
+		if len(data) > 0 {
+			target += "?" + data.Encode()
+		}
+	} else {
+		body = strings.NewReader(data.Encode())
+	}
 
-	req, err := http.NewRequest("POST", "https://oauth.reddit.com/api/del", strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
 	if err != nil {
-		return fmt.Errorf("failed to create delete request: %v", err)
+		return nil, 0, fmt.Errorf("failed to create request: %v", err)
+	}
+	if method != "GET" {
+		if err := c.config.Pace.Write(ctx); err != nil {
+			return nil, 0, err
+		}
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.accessToken)
@@ -93,109 +587,502 @@ func (c *Client) deleteContent(fullname string) error {
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send delete request: %v", err)
+		return nil, 0, fmt.Errorf("failed to send request: %v", err)
 	}
 	defer resp.Body.Close()
+	c.observeRateLimit(resp)
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("delete request failed: %s", resp.Status)
+	answer, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response: %v", err)
+	}
+	if wait, limited := rateLimitWait(resp, answer); limited {
+		c.config.Pace.Limited()
+		return nil, wait, nil
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, 0, fmt.Errorf("request failed: %s: %w", resp.Status, errNotFound)
 	}
+	if resp.StatusCode == http.StatusForbidden {
+		return nil, 0, fmt.Errorf("request failed: %s: %w", resp.Status, errForbidden)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, 0, fmt.Errorf("request failed: %s", resp.Status)
+	}
+	return answer, 0, nil
+}
 
+// observeRateLimit tells the pacer about the rate limit headers of a
+// response, and whether it was refused for the limit
+func (c *Client) observeRateLimit(resp *http.Response) {
+	used, err1 := strconv.ParseFloat(resp.Header.Get("X-Ratelimit-Used"), 64)
+	remaining, err2 := strconv.ParseFloat(resp.Header.Get("X-Ratelimit-Remaining"), 64)
+	reset, err3 := strconv.ParseFloat(resp.Header.Get("X-Ratelimit-Reset"), 64)
+	if err1 == nil && err2 == nil && err3 == nil {
+		c.config.Pace.Allowance(int(used+remaining), time.Duration(reset)*time.Second)
+	}
+}
+
+func (c *Client) deleteContent(ctx context.Context, fullname string) (err error) {
+	_, span := telemetry.Start(ctx, "reddit.delete", attribute.String("fullname", fullname))
+	defer func() { telemetry.End(span, err) }()
+
+	data := url.Values{}
+	data.Set("id", fullname)
+
+	err = c.apiPost(ctx, "/api/del", data, nil)
+	c.checkStanding(ctx, err)
+	if errors.Is(err, errNotFound) {
+		return fmt.Errorf("delete %w: %w", err, errGone)
+	}
+	if err != nil {
+		return fmt.Errorf("delete %w", err)
+	}
+	return nil
+}
+
+func (c *Client) hideContent(ctx context.Context, fullname string) (err error) {
+	_, span := telemetry.Start(ctx, "reddit.hide", attribute.String("fullname", fullname))
+	defer func() { telemetry.End(span, err) }()
+
+	data := url.Values{}
+	data.Set("id", fullname)
+
+	err = c.apiPost(ctx, "/api/hide", data, nil)
+	c.checkStanding(ctx, err)
+	if err != nil {
+		return fmt.Errorf("hide %w", err)
+	}
 	return nil
 }
 
-func (c *Client) DeleteContent(contentType string, cutoffDate time.Time) (int, int, error) {
-	postsDeleted := 0
-	commentsDeleted := 0
+// describe names an item in messages, mentioning archived or locked threads
+// so those failures aren't mistaken for generic errors
+func describe(kind string, i *item, fullname string) string {
+	if f := i.frozen(); f != "" {
+		return fmt.Sprintf("%s %s (%s)", kind, fullname, f)
+	}
+	return kind + " " + fullname
+}
+
+// contentRun is the state shared by the items of one DeleteContent run
+type contentRun struct {
+	opts   *DeleteOptions
+	result *Result
+
+	// IDs returned by the listings, to find items only present in an export
+	seen           map[string]bool
+	optedIn        map[string]bool
+	crosspostsDone map[string]bool
+
+	// newest counts the listed posts and comments for KeepLast; listings
+	// come newest first
+	newest *filter.Newest
+}
+
+func (c *Client) DeleteContent(ctx context.Context, opts DeleteOptions) (*Result, error) {
+	r := &contentRun{
+		opts:           &opts,
+		result:         &Result{},
+		seen:           map[string]bool{},
+		optedIn:        map[string]bool{},
+		crosspostsDone: map[string]bool{},
+	}
+	if opts.Receipts != nil {
+		audit.NewCapture(c.httpClient)
+	}
 
-	// Delete posts if requested
-	if contentType == "all" || contentType == "posts" {
-		postsOpts := reddit.ListUserOverviewOptions{
-			ListOptions: reddit.ListOptions{
-				Limit: 100,
-			},
+	if len(opts.Targets) > 0 {
+		if err := c.deleteTargets(ctx, r); err != nil {
+			return r.result, err
 		}
+		return r.result, c.blocked
+	}
+	r.newest = opts.KeepLast.Newest()
 
-		for {
-			posts, resp, err := c.User.Posts(context.Background(), &postsOpts)
-			if err != nil {
-				return postsDeleted, commentsDeleted, fmt.Errorf("failed to fetch posts: %v", err)
-			}
+	// Posts and comments are listed at once, as they are independent
+	// listings, while their items are deleted one at a time
+	var listings []*listingRun
+	if opts.ContentType == "all" || opts.ContentType == "posts" {
+		listings = append(listings, &listingRun{where: "submitted", noun: "posts", process: c.processPost})
+	}
+	if opts.ContentType == "all" || opts.ContentType == "comments" {
+		listings = append(listings, &listingRun{where: "comments", noun: "comments", process: c.processComment})
+	}
+	if err := c.walk(ctx, r, listings); err != nil {
+		return r.result, err
+	}
+	if c.stopped() {
+		return r.result, c.blocked
+	}
 
-			if len(posts) == 0 {
-				break
-			}
+	if opts.ExportDir != "" {
+		if err := c.deleteExportOnly(ctx, opts, r.seen, r.result); err != nil {
+			return r.result, err
+		}
+	}
 
-			for _, post := range posts {
-				postTime := time.Unix(post.Created.Unix(), 0)
-				fmt.Printf("Found post: %s (posted on %s)\n", post.Title, postTime.Format("2006-01-02"))
+	return r.result, c.blocked
+}
 
-				if postTime.Before(cutoffDate) {
-					fullname := fmt.Sprintf("t3_%s", post.ID)
-					fmt.Printf("Attempting to delete post: %s (Fullname: %s)\n", post.Title, fullname)
+// listingRun is one of the user's listings walked by a DeleteContent run
+type listingRun struct {
+	where   string
+	noun    string
+	process func(context.Context, *contentRun, *item)
 
-					if err := c.deleteContent(fullname); err != nil {
-						fmt.Printf("Error deleting post %s: %v\n", fullname, err)
-						continue
-					}
+	newest      time.Time
+	incremental bool
+	caughtUp    bool
+	failed      bool
+}
 
-					fmt.Printf("Successfully deleted post: %s\n", post.Title)
-					postsDeleted++
-				}
+// walk lists the user's posts and comments and processes every item. In
+// incremental mode a listing stops at the first item already indexed, and
+// older items are processed from the index instead.
+func (c *Client) walk(ctx context.Context, r *contentRun, listings []*listingRun) error {
+	opts := r.opts
+
+	// The next pages are fetched and indexed while this one is deleted
+	pipelines := make([]*pipeline.Pipeline[*item], len(listings))
+	for i, l := range listings {
+		if opts.Index != nil && opts.Incremental {
+			var err error
+			if l.newest, l.incremental, err = opts.Index.Newest("reddit", l.where); err != nil {
+				return err
 			}
+		}
+		pipelines[i] = c.listingPipeline(opts, l)
+	}
 
-			if resp.After == "" {
+	err := pipeline.RunAll(ctx, pipelines, func(ctx context.Context, i int, items []*item) bool {
+		l := listings[i]
+		// Filtering and acting on the page; fetches and deletes are child spans
+		pageCtx, page := telemetry.Start(ctx, "reddit.page", attribute.String("listing", l.where), attribute.Int("items", len(items)))
+		for _, it := range items {
+			if c.stopped() {
 				break
 			}
-
-			postsOpts.After = resp.After
-			time.Sleep(2 * time.Second)
+			l.process(pageCtx, r, it)
 		}
+		page.End()
+		return !c.stopped()
+	})
+	if c.stopped() {
+		return nil
+	}
+	if err != nil {
+		noun := listings[0].noun
+		for _, l := range listings {
+			if l.failed {
+				noun = l.noun
+			}
+		}
+		return fmt.Errorf("failed to fetch %s: %v", noun, err)
 	}
 
-	// Delete comments if requested
-	if contentType == "all" || contentType == "comments" {
-		commentsOpts := reddit.ListUserOverviewOptions{
-			ListOptions: reddit.ListOptions{
-				Limit: 100,
-			},
+	for _, l := range listings {
+		if l.caughtUp {
+			c.printf("Caught up with the index; processing older %s from it\n", l.where)
+		}
+		if !l.incremental {
+			continue
 		}
 
-		for {
-			comments, resp, err := c.User.Comments(context.Background(), &commentsOpts)
-			if err != nil {
-				return postsDeleted, commentsDeleted, fmt.Errorf("failed to fetch comments: %v", err)
+		err := opts.Index.Live("reddit", l.where, func(raw []byte) (bool, error) {
+			if c.stopped() {
+				return false, nil
+			}
+			var it item
+			if err := json.Unmarshal(raw, &it); err != nil {
+				return false, fmt.Errorf("corrupt item in index: %v", err)
+			}
+			if !r.seen[it.ID] {
+				l.process(ctx, r, &it)
 			}
+			return true, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-			if len(comments) == 0 {
-				break
+// listingPipeline fetches a listing's pages, indexing every item, up to the
+// first item already indexed in incremental mode
+func (c *Client) listingPipeline(opts *DeleteOptions, l *listingRun) *pipeline.Pipeline[*item] {
+	return &pipeline.Pipeline[*item]{
+		Fetch: func(ctx context.Context, after string) ([]*item, string, error) {
+			items, next, err := c.listUser(ctx, l.where, after)
+			if err != nil && ctx.Err() == nil {
+				l.failed = true
 			}
+			if len(items) == 0 {
+				return nil, "", err
+			}
+			page := make([]*item, len(items))
+			for i := range items {
+				if l.incremental && !items[i].created().After(l.newest) {
+					l.caughtUp = true
+					return page[:i], "", err
+				}
+				page[i] = &items[i]
+			}
+			return page, next, err
+		},
+		Stages: []pipeline.Stage[*item]{func(ctx context.Context, it *item) bool {
+			c.index(opts, l.where, it)
+			return true
+		}},
+		Pace: c.config.Pace,
+	}
+}
 
-			for _, comment := range comments {
-				commentTime := time.Unix(comment.Created.Unix(), 0)
+// index stores a listed item for later incremental runs
+func (c *Client) index(opts *DeleteOptions, where string, i *item) {
+	if opts.Index == nil {
+		return
+	}
+	raw, err := json.Marshal(i)
+	if err == nil {
+		err = opts.Index.Put("reddit", where, i.Name, i.created(), raw)
+	}
+	if err != nil {
+		c.printf("Warning: %v\n", err)
+	}
+}
 
-				if commentTime.Before(cutoffDate) {
-					fullname := fmt.Sprintf("t1_%s", comment.ID)
-					fmt.Printf("Attempting to delete comment from %s (Fullname: %s)\n", commentTime.Format("2006-01-02"), fullname)
+func (c *Client) processPost(ctx context.Context, r *contentRun, post *item) {
+	opts, result := r.opts, r.result
 
-					if err := c.deleteContent(fullname); err != nil {
-						fmt.Printf("Error deleting comment %s: %v\n", fullname, err)
-						continue
-					}
+	r.seen[post.ID] = true
+	if r.crosspostsDone[post.ID] {
+		return
+	}
 
-					fmt.Printf("Successfully deleted comment from %s\n", commentTime.Format("2006-01-02"))
-					commentsDeleted++
-				}
-			}
+	postTime := post.created()
+	c.printf("Found post: %s (posted on %s)\n", c.excerpt(post.Title), postTime.Format("2006-01-02"))
 
-			if resp.After == "" {
-				break
-			}
+	fullname := fmt.Sprintf("t3_%s", post.ID)
+	if r.newest.Keep("posts") {
+		opts.keep("post", fullname, post, opts.KeepLast.Reason("posts"))
+		return
+	}
+	if !opts.old(post) || !opts.matches(post) {
+		opts.keep("post", fullname, post, opts.unmatchedReason(post))
+		return
+	}
+	if c.alreadyDeleted(opts, "post", fullname, result) {
+		return
+	}
 
-			commentsOpts.After = resp.After
-			time.Sleep(2 * time.Second)
+	result.Matched++
+	if c.skipForPlan(opts, plan.Item{ID: fullname, Kind: "post", Date: postTime, Text: post.Title, Where: post.Subreddit}, result) {
+		if opts.Plan != nil && opts.Crossposts {
+			c.deleteCrossposts(ctx, post.ID, r.crosspostsDone, opts, result)
+		}
+		if opts.Plan == nil {
+			opts.keep("post", fullname, post, "not in the plan")
 		}
+		return
 	}
+	result.countFrozen(post)
+	c.handleQuarantine(ctx, post, *opts, r.optedIn, result)
+
+	template := c.config.Overwrite.Posts
+	if c.vetoed(ctx, opts, "post", fullname, post, &template, result) {
+		return
+	}
+
+	if opts.Hide {
+		c.printf("Attempting to hide post: %s (Fullname: %s)\n", c.excerpt(post.Title), fullname)
+		if err := c.hideContent(ctx, fullname); err != nil {
+			c.printf("Error hiding post %s: %v\n", fullname, err)
+			result.Failed++
+			opts.keep("post", fullname, post, "hide failed")
+			return
+		}
+		c.printf("Successfully hid post: %s\n", c.excerpt(post.Title))
+		result.PostsDeleted++
+		return
+	}
+
+	c.overwrite(ctx, post, fullname, template)
+
+	c.printf("Attempting to delete post: %s (Fullname: %s)\n", c.excerpt(post.Title), fullname)
+
+	dctx, receipt := audit.Expect(ctx)
+	if err := c.deleteContent(dctx, fullname); err != nil {
+		if c.gone(opts, "post", fullname, err, result) {
+			return
+		}
+		c.printf("Error deleting %s: %v\n", describe("post", post, fullname), err)
+		result.Failed++
+		opts.keep("post", fullname, post, "delete failed")
+		return
+	}
+
+	c.printf("Successfully deleted post: %s\n", c.excerpt(post.Title))
+	result.PostsDeleted++
+	c.bury(ctx, opts, receipt, "post", fullname, post)
+
+	if opts.Crossposts {
+		c.deleteCrossposts(ctx, post.ID, r.crosspostsDone, opts, result)
+	}
+}
+
+func (c *Client) processComment(ctx context.Context, r *contentRun, comment *item) {
+	opts, result := r.opts, r.result
+
+	r.seen[comment.ID] = true
+	commentTime := comment.created()
+
+	fullname := fmt.Sprintf("t1_%s", comment.ID)
+	if r.newest.Keep("comments") {
+		opts.keep("comment", fullname, comment, opts.KeepLast.Reason("comments"))
+		return
+	}
+	if !opts.old(comment) || !opts.matches(comment) {
+		opts.keep("comment", fullname, comment, opts.unmatchedReason(comment))
+		return
+	}
+	if c.alreadyDeleted(opts, "comment", fullname, result) {
+		return
+	}
+
+	result.Matched++
+	if c.skipForPlan(opts, plan.Item{ID: fullname, Kind: "comment", Date: commentTime, Text: comment.Body, Where: comment.Subreddit}, result) {
+		if opts.Plan == nil {
+			opts.keep("comment", fullname, comment, "not in the plan")
+		}
+		return
+	}
+	result.countFrozen(comment)
+	c.handleQuarantine(ctx, comment, *opts, r.optedIn, result)
+
+	template := c.config.Overwrite.Comments
+	if c.vetoed(ctx, opts, "comment", fullname, comment, &template, result) {
+		return
+	}
+	c.overwrite(ctx, comment, fullname, template)
+
+	c.printf("Attempting to delete comment from %s (Fullname: %s)\n", commentTime.Format("2006-01-02"), fullname)
+
+	dctx, receipt := audit.Expect(ctx)
+	if err := c.deleteContent(dctx, fullname); err != nil {
+		if c.gone(opts, "comment", fullname, err, result) {
+			return
+		}
+		c.printf("Error deleting %s: %v\n", describe("comment", comment, fullname), err)
+		result.Failed++
+		opts.keep("comment", fullname, comment, "delete failed")
+		return
+	}
+
+	c.printf("Successfully deleted comment from %s\n", commentTime.Format("2006-01-02"))
+	result.CommentsDeleted++
+	c.bury(ctx, opts, receipt, "comment", fullname, comment)
+}
+
+// handleQuarantine counts items in quarantined subreddits and, if enabled,
+// opts in to each such subreddit once so the item can be acted on
+func (c *Client) handleQuarantine(ctx context.Context, i *item, opts DeleteOptions, optedIn map[string]bool, result *Result) {
+	if !i.Quarantine {
+		return
+	}
+	result.Quarantined++
+
+	if !opts.QuarantineOptIn || optedIn[i.Subreddit] {
+		return
+	}
+
+	data := url.Values{}
+	data.Set("sr_name", i.Subreddit)
+	if err := c.apiPost(ctx, "/api/quarantine_optin", data, nil); err != nil {
+		c.printf("Error opting in to quarantined r/%s: %v\n", i.Subreddit, err)
+		return
+	}
+
+	c.printf("Opted in to quarantined r/%s\n", i.Subreddit)
+	optedIn[i.Subreddit] = true
+}
+
+// deleteExportOnly deletes items from the data export that the listings
+// didn't return, such as content in banned subreddits
+func (c *Client) deleteExportOnly(ctx context.Context, opts DeleteOptions, seen map[string]bool, result *Result) error {
+	return export.Reddit.Read(opts.ExportDir, func(it export.Item) (bool, error) {
+		if c.stopped() {
+			return false, nil
+		}
+		if opts.ContentType != "all" && opts.ContentType != it.Kind+"s" {
+			return true, nil
+		}
+		if cutoff, _ := opts.cutoff(it.Where); seen[it.ID] || !it.Date.Before(cutoff) {
+			return true, nil
+		}
+
+		// Export rows carry no removal details or scores, so they never match
+		// --removed-only and only the subreddit and ID keep rules apply
+		if !opts.matches(&item{ID: it.ID, Name: exportPrefix[it.Kind] + it.ID, Subreddit: it.Where, CreatedUTC: float64(it.Date.Unix())}) {
+			return true, nil
+		}
+
+		fullname := exportPrefix[it.Kind] + it.ID
+		if c.alreadyDeleted(&opts, it.Kind, fullname, result) {
+			return true, nil
+		}
+
+		result.Matched++
+		if c.skipForPlan(&opts, plan.Item{ID: fullname, Kind: it.Kind, Date: it.Date, Where: it.Where}, result) {
+			return true, nil
+		}
+		// Export rows have the text, so overwriting works as for listed items;
+		// posts with a body are self posts
+		exported := item{ID: it.ID, Subreddit: it.Where, CreatedUTC: float64(it.Date.Unix())}
+		template := c.config.Overwrite.Comments
+		if it.Kind == "post" {
+			exported.Permalink = "/comments/" + it.ID
+			exported.Title, exported.Selftext, exported.IsSelf = it.Title, it.Text, it.Text != ""
+			template = c.config.Overwrite.Posts
+		} else {
+			exported.Body = it.Text
+		}
+		if c.vetoed(ctx, &opts, it.Kind, fullname, &exported, &template, result) {
+			return true, nil
+		}
+
+		if opts.Hide {
+			c.printf("Attempting to hide export-only %s in r/%s (Fullname: %s)\n", it.Kind, it.Where, fullname)
+			if err := c.hideContent(ctx, fullname); err != nil {
+				c.printf("Error hiding export-only %s %s: %v\n", it.Kind, fullname, err)
+				result.ExportOnlyFailed++
+				result.Failed++
+				return true, nil
+			}
+			c.printf("Successfully hid export-only %s from %s\n", it.Kind, it.Date.Format("2006-01-02"))
+			result.ExportOnlyDeleted++
+			c.config.Pace.Pause(ctx)
+			return true, nil
+		}
+
+		c.overwrite(ctx, &exported, fullname, template)
+		c.printf("Attempting to delete export-only %s in r/%s (Fullname: %s)\n", it.Kind, it.Where, fullname)
+		dctx, receipt := audit.Expect(ctx)
+		if err := c.deleteContent(dctx, fullname); err != nil {
+			if c.gone(&opts, it.Kind, fullname, err, result) {
+				return true, nil
+			}
+			c.printf("Error deleting export-only %s %s: %v\n", it.Kind, fullname, err)
+			result.ExportOnlyFailed++
+			result.Failed++
+			return true, nil
+		}
 
-	return postsDeleted, commentsDeleted, nil
+		c.printf("Successfully deleted export-only %s from %s\n", it.Kind, it.Date.Format("2006-01-02"))
+		result.ExportOnlyDeleted++
+		c.bury(ctx, &opts, receipt, it.Kind, fullname, &exported)
+		c.config.Pace.Pause(ctx)
+		return true, nil
+	})
 }
@@ -0,0 +1,85 @@
+package reddit
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	fullnamePattern  = regexp.MustCompile(`^t[13]_[a-z0-9]+$`)
+	permalinkPattern = regexp.MustCompile(`/comments/([a-z0-9]+)(?:/[^/?#]*/([a-z0-9]+))?`)
+	shortLinkPattern = regexp.MustCompile(`^https?://redd\.it/([a-z0-9]+)`)
+)
+
+// Fullname turns a reference from a target list into a fullname: a
+// permalink, a redd.it link, a fullname, or a bare ID when kind says
+// whether it is a post or a comment
+func Fullname(ref, kind string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if fullnamePattern.MatchString(ref) {
+		return ref, nil
+	}
+	if m := permalinkPattern.FindStringSubmatch(ref); m != nil {
+		if m[2] != "" {
+			return "t1_" + m[2], nil
+		}
+		return "t3_" + m[1], nil
+	}
+	if m := shortLinkPattern.FindStringSubmatch(ref); m != nil {
+		return "t3_" + m[1], nil
+	}
+
+	switch strings.ToLower(kind) {
+	case "post", "posts", "submission", "submissions", "link", "t3":
+		return "t3_" + ref, nil
+	case "comment", "comments", "t1":
+		return "t1_" + ref, nil
+	}
+	return "", fmt.Errorf("%q is not a Reddit link or fullname, and no kind says whether it is a post or a comment", ref)
+}
+
+// deleteTargets processes the items of a target list instead of the
+// listings. They are looked up 100 at a time, so the cutoff, filters and
+// keep rules apply as usual.
+func (c *Client) deleteTargets(ctx context.Context, r *contentRun) error {
+	opts, targets := r.opts, r.opts.Targets
+	for start := 0; start < len(targets); start += 100 {
+		batch := targets[start:min(start+100, len(targets))]
+		items, err := c.info(ctx, batch)
+		if err != nil {
+			return fmt.Errorf("failed to look up targets: %v", err)
+		}
+		found := map[string]*item{}
+		for i := range items {
+			found[items[i].Name] = &items[i]
+		}
+
+		for _, fullname := range batch {
+			if c.stopped() || ctx.Err() != nil {
+				return ctx.Err()
+			}
+			kind, process := "comment", c.processComment
+			if strings.HasPrefix(fullname, "t3_") {
+				kind, process = "post", c.processPost
+			}
+			if opts.ContentType != "all" && opts.ContentType != kind+"s" {
+				continue
+			}
+
+			i := found[fullname]
+			switch {
+			case i == nil || i.Author == "[deleted]":
+				c.gone(opts, kind, fullname, errGone, r.result)
+			case !strings.EqualFold(i.Author, c.config.Username):
+				c.printf("Refusing %s %s: it was posted by u/%s\n", kind, fullname, i.Author)
+				r.result.Failed++
+				opts.keep(kind, fullname, i, "posted by someone else")
+			default:
+				process(ctx, r, i)
+			}
+		}
+	}
+	return nil
+}
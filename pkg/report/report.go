@@ -0,0 +1,107 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Report is the structured summary of one profile's run on one platform,
+// kept after the terminal output is gone
+type Report struct {
+	Profile     string    `json:"profile"`
+	Platform    string    `json:"platform"`
+	ContentType string    `json:"content_type"`
+	Cutoff      time.Time `json:"cutoff"`
+	Started     time.Time `json:"started"`
+	Finished    time.Time `json:"finished"`
+
+	DurationSeconds      float64 `json:"duration_seconds"`
+	RateLimitWaitSeconds float64 `json:"rate_limit_wait_seconds"`
+
+	Matched int `json:"matched"`
+	Deleted int `json:"deleted"`
+	Failed  int `json:"failed"`
+
+	// Skipped counts matched items left alone, by reason
+	Skipped map[string]int `json:"skipped,omitempty"`
+
+	// Counts holds the per-kind numbers shown in the summary
+	Counts map[string]int `json:"counts"`
+
+	// StillPublic lists the URLs of deleted items strangers could still
+	// see when checked with --verify-public
+	StillPublic []string `json:"still_public,omitempty"`
+
+	// Archived lists the Wayback Machine snapshots saved with --wayback
+	Archived []string `json:"archived,omitempty"`
+
+	// ArchivedElsewhere lists the deleted items the Wayback Machine still
+	// has a copy of, found with --check-archived
+	ArchivedElsewhere []ArchivedCopy `json:"archived_elsewhere,omitempty"`
+
+	// ResumeAt is when a run stopped by a daily write limit can go on
+	ResumeAt *time.Time `json:"resume_at,omitempty"`
+
+	// Simulated reports are of --simulate runs, whose deletes weren't sent
+	Simulated bool `json:"simulated,omitempty"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// ArchivedCopy is a public copy of a deleted item
+type ArchivedCopy struct {
+	URL      string `json:"url"`
+	Snapshot string `json:"snapshot"`
+}
+
+// New starts a report for a run beginning now
+func New(profile, platform, contentType string, cutoff time.Time) *Report {
+	return &Report{
+		Profile:     profile,
+		Platform:    platform,
+		ContentType: contentType,
+		Cutoff:      cutoff,
+		Started:     time.Now(),
+		Skipped:     map[string]int{},
+		Counts:      map[string]int{},
+	}
+}
+
+// Skip records n matched items that were not deleted for reason
+func (r *Report) Skip(reason string, n int) {
+	if n > 0 {
+		r.Skipped[reason] += n
+	}
+}
+
+// RateLimited records time spent waiting for rate limits
+func (r *Report) RateLimited(d time.Duration) {
+	r.RateLimitWaitSeconds += d.Seconds()
+}
+
+// Finish stamps the end of the run and records its error, if any
+func (r *Report) Finish(err error) {
+	r.Finished = time.Now()
+	r.DurationSeconds = r.Finished.Sub(r.Started).Round(time.Millisecond).Seconds()
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
+
+// Write saves the report to dir, named after the platform and start time,
+// and returns its path
+func (r *Report) Write(dir string) (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.json", r.Platform, r.Started.UTC().Format("20060102T150405Z")))
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write report: %v", err)
+	}
+	return path, nil
+}
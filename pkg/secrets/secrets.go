@@ -0,0 +1,74 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Resolve returns the secret referenced by value, or value itself when it is
+// not a reference. Supported references:
+//
+//	op://vault/item/field      1Password CLI (op read)
+//	vault://secret/path#field  HashiCorp Vault CLI (vault kv get)
+//	pass://path/to/entry       pass (first line of the entry)
+func Resolve(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, "op://"):
+		return run("op", "read", "--no-newline", value)
+
+	case strings.HasPrefix(value, "vault://"):
+		ref := strings.TrimPrefix(value, "vault://")
+		path, field, ok := strings.Cut(ref, "#")
+		if !ok || path == "" || field == "" {
+			return "", fmt.Errorf("invalid vault reference %q: expected vault://path#field", value)
+		}
+		return run("vault", "kv", "get", "-field="+field, path)
+
+	case strings.HasPrefix(value, "pass://"):
+		entry := strings.TrimPrefix(value, "pass://")
+		if entry == "" {
+			return "", fmt.Errorf("invalid pass reference %q: expected pass://path/to/entry", value)
+		}
+		out, err := run("pass", "show", entry)
+		if err != nil {
+			return "", err
+		}
+		// pass stores the password on the first line, metadata below it
+		first, _, _ := strings.Cut(out, "\n")
+		return first, nil
+	}
+
+	return value, nil
+}
+
+// ResolveAll resolves every referenced string in place, stopping at the
+// first failure.
+func ResolveAll(values ...*string) error {
+	for _, v := range values {
+		resolved, err := Resolve(*v)
+		if err != nil {
+			return err
+		}
+		*v = resolved
+	}
+	return nil
+}
+
+func run(name string, args ...string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return "", fmt.Errorf("secret reference requires the %q command: %v", name, err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed: %v: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
@@ -0,0 +1,118 @@
+// Package spool queues items on their way to deletion, keeping only a
+// bounded number in memory and spilling the rest to a temporary file, so
+// listing an account of any size doesn't grow the process
+package spool
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Queue is a first-in, first-out queue. Once Limit items are held in
+// memory, further items go to a temporary file until the queue drains.
+type Queue[T any] struct {
+	limit int
+	mem   []T
+
+	file    *os.File
+	w       *bufio.Writer
+	r       *bufio.Reader
+	spilled int // items written to the file
+	read    int // of those, items read back
+}
+
+// New returns an empty queue holding up to limit items in memory
+func New[T any](limit int) *Queue[T] {
+	return &Queue[T]{limit: max(limit, 1)}
+}
+
+// Len returns the number of queued items
+func (q *Queue[T]) Len() int {
+	return len(q.mem) + q.spilled - q.read
+}
+
+// Push adds v at the end of the queue
+func (q *Queue[T]) Push(v T) error {
+	// Items already on disk are older, so later ones follow them there
+	if len(q.mem) < q.limit && q.spilled == q.read {
+		q.mem = append(q.mem, v)
+		return nil
+	}
+
+	if q.file == nil {
+		f, err := os.CreateTemp("", "go-del-socials-spool-*")
+		if err != nil {
+			return fmt.Errorf("failed to create spool file: %v", err)
+		}
+		// Only the open handle keeps the file, so it can't be left behind
+		os.Remove(f.Name())
+		q.file, q.w = f, bufio.NewWriter(f)
+		q.r = bufio.NewReader(&readerAt{f: f})
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	q.w.Write(data)
+	if err := q.w.WriteByte('\n'); err != nil {
+		return fmt.Errorf("failed to spool item: %v", err)
+	}
+	q.spilled++
+	return nil
+}
+
+// Pop removes and returns the first item; ok is false when the queue is
+// empty
+func (q *Queue[T]) Pop() (v T, ok bool, err error) {
+	if len(q.mem) > 0 {
+		v, q.mem[0] = q.mem[0], *new(T)
+		q.mem = q.mem[1:]
+		return v, true, nil
+	}
+	if q.spilled == q.read {
+		return v, false, nil
+	}
+
+	if err := q.w.Flush(); err != nil {
+		return v, false, fmt.Errorf("failed to spool item: %v", err)
+	}
+	line, err := q.r.ReadBytes('\n')
+	if err != nil {
+		return v, false, fmt.Errorf("failed to read spooled item: %v", err)
+	}
+	q.read++
+	if err := json.Unmarshal(line, &v); err != nil {
+		return v, false, fmt.Errorf("corrupt spooled item: %v", err)
+	}
+	return v, true, nil
+}
+
+// Close removes the queue's file, if it spilled
+func (q *Queue[T]) Close() error {
+	if q.file == nil {
+		return nil
+	}
+	return q.file.Close()
+}
+
+// readerAt reads a file from its own offset, so reading and appending
+// don't move each other's position
+type readerAt struct {
+	f   *os.File
+	off int64
+}
+
+// Read only reports the end of the file when it has nothing to return, as
+// the reader would otherwise keep the EOF for the next line, which may well
+// be appended by then
+func (r *readerAt) Read(p []byte) (int, error) {
+	n, err := r.f.ReadAt(p, r.off)
+	r.off += int64(n)
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
@@ -0,0 +1,96 @@
+package spool
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// A consumer popping one item for every three pushed leaves most of the
+// queue waiting; it must wait on disk, in order
+func TestQueueSlowConsumer(t *testing.T) {
+	const limit, n = 100, 30000
+	q := New[string](limit)
+	defer q.Close()
+
+	payload := strings.Repeat("x", 1024)
+	heap := func() uint64 {
+		runtime.GC()
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		return m.HeapAlloc
+	}
+	before := heap()
+
+	next := 0
+	pop := func() {
+		v, ok, err := q.Pop()
+		if err != nil || !ok {
+			t.Fatalf("Pop() = %v, %v, want item %d", ok, err, next)
+		}
+		if want := payload + strconv.Itoa(next); v != want {
+			t.Fatalf("popped item %q, want item %d", v[len(payload):], next)
+		}
+		next++
+	}
+
+	for i := 0; i < n; i++ {
+		if err := q.Push(payload + strconv.Itoa(i)); err != nil {
+			t.Fatal(err)
+		}
+		if len(q.mem) > limit {
+			t.Fatalf("%d items in memory, want at most %d", len(q.mem), limit)
+		}
+		if i%3 == 0 {
+			pop()
+		}
+	}
+
+	// n items of 1 KiB are queued; only the limit may be held
+	if grown := int64(heap()) - int64(before); grown > 4<<20 {
+		t.Errorf("heap grew by %d bytes with %d items queued", grown, q.Len())
+	}
+
+	for q.Len() > 0 {
+		pop()
+	}
+	if next != n {
+		t.Errorf("popped %d items, want %d", next, n)
+	}
+	if _, ok, err := q.Pop(); ok || err != nil {
+		t.Errorf("Pop() on an empty queue = %v, %v", ok, err)
+	}
+}
+
+// Items pushed after the queue spilled go to the file even once memory has
+// room again, so they can't overtake the spilled ones
+func TestQueueOrderAfterSpill(t *testing.T) {
+	q := New[int](2)
+	defer q.Close()
+
+	for i := 0; i < 5; i++ {
+		q.Push(i)
+	}
+	var got []int
+	for i := 5; i < 10; i++ {
+		v, _, err := q.Pop()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+		q.Push(i)
+	}
+	for q.Len() > 0 {
+		v, _, err := q.Pop()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("got %v, want 0 through 9 in order", got)
+		}
+	}
+}
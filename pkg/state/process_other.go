@@ -0,0 +1,9 @@
+//go:build !unix
+
+package state
+
+// processAlive cannot be determined portably, so locks are never considered
+// stale and must be removed by hand.
+func processAlive(pid int) bool {
+	return true
+}
@@ -0,0 +1,137 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Subdirectories created for every profile
+const (
+	Tokens      = "tokens"
+	Checkpoints = "checkpoints"
+	Audit       = "audit"
+	Archives    = "archives"
+	Reports     = "reports"
+)
+
+const appName = "go-del-socials"
+
+// Dir is the locked state directory of a single profile:
+//
+//	<base>/<profile>/{tokens,checkpoints,audit,archives,reports}
+type Dir struct {
+	Root    string
+	Profile string
+
+	lockPath string
+}
+
+// BaseDir returns the directory holding all profile state, honouring
+// XDG_STATE_HOME and falling back to ~/.local/state.
+func BaseDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, appName), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine home directory: %v", err)
+	}
+	return filepath.Join(home, ".local", "state", appName), nil
+}
+
+// Open creates the state layout for profile below base (BaseDir() when
+// empty) and locks it against concurrent runs. Close releases the lock.
+func Open(base, profile string) (*Dir, error) {
+	if profile == "" || strings.ContainsAny(profile, `/\`) || profile == "." || profile == ".." {
+		return nil, fmt.Errorf("invalid profile name %q", profile)
+	}
+
+	if base == "" {
+		var err error
+		if base, err = BaseDir(); err != nil {
+			return nil, err
+		}
+	}
+
+	d := &Dir{
+		Root:    filepath.Join(base, profile),
+		Profile: profile,
+	}
+
+	for _, sub := range []string{Tokens, Checkpoints, Audit, Archives, Reports} {
+		if err := os.MkdirAll(filepath.Join(d.Root, sub), 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create state directory: %v", err)
+		}
+	}
+
+	if err := d.lock(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Path joins elem onto the profile's state directory
+func (d *Dir) Path(elem ...string) string {
+	return filepath.Join(append([]string{d.Root}, elem...)...)
+}
+
+func (d *Dir) lock() error {
+	path := d.Path("lock")
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n%s\n", os.Getpid(), time.Now().Format(time.RFC3339))
+			f.Close()
+			d.lockPath = path
+			return nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("failed to lock profile %s: %v", d.Profile, err)
+		}
+
+		// Take over locks left behind by runs that no longer exist
+		pid := lockOwner(path)
+		if pid > 0 && processAlive(pid) {
+			return fmt.Errorf("profile %s is in use by another run (pid %d)", d.Profile, pid)
+		}
+		if pid == 0 {
+			return fmt.Errorf("profile %s is locked; remove %s if no other run is active", d.Profile, path)
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove stale lock: %v", err)
+		}
+	}
+
+	return fmt.Errorf("failed to lock profile %s", d.Profile)
+}
+
+func lockOwner(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	pid, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// Close releases the profile lock
+func (d *Dir) Close() error {
+	if d.lockPath == "" {
+		return nil
+	}
+	err := os.Remove(d.lockPath)
+	d.lockPath = ""
+	return err
+}
@@ -0,0 +1,140 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func init() {
+	register("bolt", ".bolt", openBolt)
+}
+
+// boltStore maps buckets to bbolt buckets
+type boltStore struct {
+	db *bolt.DB
+}
+
+func openBolt(path string) (Store, error) {
+	// bbolt locks the file, so a run and a lookup can't open it at once
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("state store %s is in use by another run", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state store: %v", err)
+	}
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) Get(bucket, key string) ([]byte, error) {
+	var value []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return ErrNotFound
+		}
+		v := b.Get([]byte(key))
+		if v == nil {
+			return ErrNotFound
+		}
+		// Values are only valid during the transaction
+		value = append([]byte(nil), v...)
+		return nil
+	})
+	return value, err
+}
+
+func (s *boltStore) Put(bucket, key string, value []byte) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), value)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write %s/%s: %v", bucket, key, err)
+	}
+	return nil
+}
+
+func (s *boltStore) Delete(bucket, key string) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(bucket)); b != nil {
+			return b.Delete([]byte(key))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete %s/%s: %v", bucket, key, err)
+	}
+	return nil
+}
+
+func (s *boltStore) Scan(bucket string, fn func(key string, value []byte) error) error {
+	return paged(func(after string, first bool) ([]entry, error) {
+		return s.page(bucket, after, first, true)
+	}, fn)
+}
+
+func (s *boltStore) Keys(bucket string, fn func(key string) error) error {
+	return paged(func(after string, first bool) ([]entry, error) {
+		return s.page(bucket, after, first, false)
+	}, func(key string, _ []byte) error { return fn(key) })
+}
+
+// page reads a page of entries, with their values if values is set, in a
+// transaction of its own, so fn can write between pages
+func (s *boltStore) page(bucket, after string, first, values bool) ([]entry, error) {
+	var entries []entry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		k, v := c.First()
+		if !first {
+			if k, v = c.Seek([]byte(after)); string(k) == after {
+				k, v = c.Next()
+			}
+		}
+		for ; k != nil && len(entries) < pageSize; k, v = c.Next() {
+			e := entry{key: string(k)}
+			if values {
+				e.value = append([]byte(nil), v...)
+			}
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", bucket, err)
+	}
+	return entries, nil
+}
+
+func (s *boltStore) Last(bucket string) (string, []byte, error) {
+	var key string
+	var value []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return ErrNotFound
+		}
+		k, v := b.Cursor().Last()
+		if k == nil {
+			return ErrNotFound
+		}
+		key, value = string(k), append([]byte(nil), v...)
+		return nil
+	})
+	return key, value, err
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,222 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+func init() {
+	register("json", ".json", openJSON)
+}
+
+// saveEvery bounds how often the JSON file is rewritten; changes made in
+// between are written together when it has passed
+const saveEvery = time.Second
+
+// jsonStore keeps everything in memory and rewrites a single, readable JSON
+// file at most every saveEvery and on Close. It needs no database, but a
+// crash loses the changes not yet written.
+type jsonStore struct {
+	mu   sync.Mutex
+	path string
+	data map[string]map[string]json.RawMessage
+
+	// dirty is set by changes not yet written, saved is when the file was
+	// last written and pending writes them later. err is the failure of a
+	// later write, returned by the next change or Close.
+	dirty   bool
+	saved   time.Time
+	pending *time.Timer
+	err     error
+}
+
+func openJSON(path string) (Store, error) {
+	s := &jsonStore{path: path, data: map[string]map[string]json.RawMessage{}}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to open state store: %v", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &s.data); err != nil {
+			return nil, fmt.Errorf("failed to parse state store %s: %v", path, err)
+		}
+	}
+	return s, nil
+}
+
+func (s *jsonStore) Get(bucket, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.data[bucket][key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (s *jsonStore) Put(bucket, key string, value []byte) error {
+	if !json.Valid(value) {
+		return fmt.Errorf("failed to write %s/%s: value is not JSON", bucket, key)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data[bucket] == nil {
+		s.data[bucket] = map[string]json.RawMessage{}
+	}
+	s.data[bucket][key] = append(json.RawMessage(nil), value...)
+	return s.changed()
+}
+
+func (s *jsonStore) Delete(bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.data[bucket][key]; !ok {
+		return nil
+	}
+	delete(s.data[bucket], key)
+	return s.changed()
+}
+
+// Scan copies one value at a time; the keys are sorted up front, as the
+// store holds everything in memory anyway
+func (s *jsonStore) Scan(bucket string, fn func(key string, value []byte) error) error {
+	for _, k := range s.sortedKeys(bucket) {
+		s.mu.Lock()
+		v, ok := s.data[bucket][k]
+		v = append([]byte(nil), v...)
+		s.mu.Unlock()
+
+		// Entries fn deleted are skipped
+		if !ok {
+			continue
+		}
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *jsonStore) Keys(bucket string, fn func(key string) error) error {
+	for _, k := range s.sortedKeys(bucket) {
+		if err := fn(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *jsonStore) Last(bucket string) (string, []byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var last string
+	found := false
+	for k := range s.data[bucket] {
+		if !found || k > last {
+			last, found = k, true
+		}
+	}
+	if !found {
+		return "", nil, ErrNotFound
+	}
+	return last, append([]byte(nil), s.data[bucket][last]...), nil
+}
+
+// sortedKeys returns the keys of a bucket in order
+func (s *jsonStore) sortedKeys(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.data[bucket]))
+	for k := range s.data[bucket] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// changed writes the file once saveEvery has passed since it last was, so
+// a run of changes costs one rewrite a second rather than one each
+func (s *jsonStore) changed() error {
+	s.dirty = true
+	if err := s.err; err != nil {
+		s.err = nil
+		return err
+	}
+	if wait := saveEvery - time.Since(s.saved); wait > 0 {
+		if s.pending == nil {
+			s.pending = time.AfterFunc(wait, s.flush)
+		}
+		return nil
+	}
+	return s.save()
+}
+
+// flush writes the changes held since the last write
+func (s *jsonStore) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending = nil
+	if s.dirty {
+		s.err = s.save()
+	}
+}
+
+// save replaces the file atomically, so a crash never leaves it half written
+func (s *jsonStore) save() error {
+	data, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".state-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write state store: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state store: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state store: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state store: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write state store: %v", err)
+	}
+	s.dirty, s.saved = false, time.Now()
+	return nil
+}
+
+// Close writes the changes held in memory
+func (s *jsonStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending != nil {
+		s.pending.Stop()
+		s.pending = nil
+	}
+	if !s.dirty {
+		return s.err
+	}
+	return s.save()
+}
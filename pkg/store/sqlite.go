@@ -0,0 +1,121 @@
+//go:build !nosqlite
+
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+func init() {
+	register("sqlite", ".db", openSQLite)
+}
+
+// sqliteStore keeps all buckets in one table, which can also be queried
+// directly with the sqlite3 shell
+type sqliteStore struct {
+	db *sql.DB
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS kv (
+	bucket TEXT NOT NULL,
+	key    TEXT NOT NULL,
+	value  BLOB NOT NULL,
+	PRIMARY KEY (bucket, key)
+) WITHOUT ROWID;
+`
+
+func openSQLite(path string) (Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state store: %v", err)
+	}
+	// Profiles run in parallel each have their own store, but SQLite still
+	// only allows one writer per file
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create state store: %v", err)
+	}
+	return &sqliteStore{db: db}, nil
+}
+
+func (s *sqliteStore) Get(bucket, key string) ([]byte, error) {
+	var value []byte
+	err := s.db.QueryRow(`SELECT value FROM kv WHERE bucket = ? AND key = ?`, bucket, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s/%s: %v", bucket, key, err)
+	}
+	return value, nil
+}
+
+func (s *sqliteStore) Put(bucket, key string, value []byte) error {
+	if _, err := s.db.Exec(`INSERT OR REPLACE INTO kv (bucket, key, value) VALUES (?, ?, ?)`, bucket, key, value); err != nil {
+		return fmt.Errorf("failed to write %s/%s: %v", bucket, key, err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) Delete(bucket, key string) error {
+	if _, err := s.db.Exec(`DELETE FROM kv WHERE bucket = ? AND key = ?`, bucket, key); err != nil {
+		return fmt.Errorf("failed to delete %s/%s: %v", bucket, key, err)
+	}
+	return nil
+}
+
+func (s *sqliteStore) Scan(bucket string, fn func(key string, value []byte) error) error {
+	return paged(func(after string, first bool) ([]entry, error) {
+		return s.page(`SELECT key, value FROM kv WHERE bucket = ? AND (? OR key > ?) ORDER BY key LIMIT ?`, bucket, after, first)
+	}, fn)
+}
+
+func (s *sqliteStore) Keys(bucket string, fn func(key string) error) error {
+	return paged(func(after string, first bool) ([]entry, error) {
+		return s.page(`SELECT key, NULL FROM kv WHERE bucket = ? AND (? OR key > ?) ORDER BY key LIMIT ?`, bucket, after, first)
+	}, func(key string, _ []byte) error { return fn(key) })
+}
+
+// page reads a page of entries with query, which selects a key and a value
+func (s *sqliteStore) page(query, bucket, after string, first bool) ([]entry, error) {
+	// The connection is released before fn runs, so fn can write
+	rows, err := s.db.Query(query, bucket, first, after, pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", bucket, err)
+	}
+	defer rows.Close()
+
+	var entries []entry
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.key, &e.value); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (s *sqliteStore) Last(bucket string) (string, []byte, error) {
+	var key string
+	var value []byte
+	err := s.db.QueryRow(`SELECT key, value FROM kv WHERE bucket = ? ORDER BY key DESC LIMIT 1`, bucket).Scan(&key, &value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil, ErrNotFound
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read %s: %v", bucket, err)
+	}
+	return key, value, nil
+}
+
+func (s *sqliteStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrNotFound is returned by Get for keys that aren't stored
+var ErrNotFound = errors.New("not found")
+
+// Store holds a profile's checkpoints, audit log and indexes as JSON values
+// grouped into buckets
+type Store interface {
+	Get(bucket, key string) ([]byte, error)
+	Put(bucket, key string, value []byte) error
+	Delete(bucket, key string) error
+
+	// Scan calls fn for every entry of the bucket in key order. Entries are
+	// read a page at a time, so only a page is held in memory. fn may write
+	// to the store.
+	Scan(bucket string, fn func(key string, value []byte) error) error
+
+	// Keys is Scan without reading the values
+	Keys(bucket string, fn func(key string) error) error
+
+	// Last returns the entry of the bucket with the greatest key, or
+	// ErrNotFound if it is empty
+	Last(bucket string) (key string, value []byte, err error)
+
+	Close() error
+}
+
+type backend struct {
+	ext  string
+	open func(path string) (Store, error)
+}
+
+// Backends register themselves, so builds can leave some out
+var backends = map[string]backend{}
+
+func register(name, ext string, open func(path string) (Store, error)) {
+	backends[name] = backend{ext: ext, open: open}
+}
+
+// Backends returns the names of the backends compiled in
+func Backends() []string {
+	var names []string
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolve looks up a backend by name. An empty name selects SQLite, or the
+// JSON file when the build left SQLite out.
+func resolve(name string) (backend, error) {
+	if name == "" {
+		name = "sqlite"
+		if _, ok := backends[name]; !ok {
+			name = "json"
+		}
+	}
+	b, ok := backends[name]
+	if !ok {
+		return backend{}, fmt.Errorf("unknown state backend %q (available: %s)", name, strings.Join(Backends(), ", "))
+	}
+	return b, nil
+}
+
+// Path returns the file in dir the named backend keeps its data in
+func Path(name, dir string) (string, error) {
+	b, err := resolve(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "state"+b.ext), nil
+}
+
+// Open opens or creates the named backend's store in dir
+func Open(name, dir string) (Store, error) {
+	b, err := resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	return b.open(filepath.Join(dir, "state"+b.ext))
+}
+
+// pageSize is how many entries a scan reads at a time
+const pageSize = 1000
+
+// entry is a bucket entry gathered by Scan, so fn is called without holding
+// a transaction or query open
+type entry struct {
+	key   string
+	value []byte
+}
+
+// paged calls fn for the entries read page by page. read returns up to
+// pageSize entries in key order, from the first one or those after after.
+func paged(read func(after string, first bool) ([]entry, error), fn func(key string, value []byte) error) error {
+	after, first := "", true
+	for {
+		entries, err := read(after, first)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := fn(e.key, e.value); err != nil {
+				return err
+			}
+		}
+		if len(entries) < pageSize {
+			return nil
+		}
+		after, first = entries[len(entries)-1].key, false
+	}
+}
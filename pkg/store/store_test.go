@@ -0,0 +1,60 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// Scans span several pages, in key order, and fn may write to the store
+// between the entries it gets
+func TestScanPages(t *testing.T) {
+	for _, name := range Backends() {
+		t.Run(name, func(t *testing.T) {
+			s, err := Open(name, t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			if _, _, err := s.Last("items"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Last() of an empty bucket = %v, want ErrNotFound", err)
+			}
+
+			const n = 2*pageSize + 10
+			for i := 0; i < n; i++ {
+				if err := s.Put("items", fmt.Sprintf("%05d", i), []byte(fmt.Sprint(i))); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			i := 0
+			err = s.Scan("items", func(key string, value []byte) error {
+				if want := fmt.Sprintf("%05d", i); key != want || string(value) != fmt.Sprint(i) {
+					t.Fatalf("entry %d is %s=%s", i, key, value)
+				}
+				i++
+				return s.Put("seen", key, []byte("true"))
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if i != n {
+				t.Errorf("Scan returned %d entries, want %d", i, n)
+			}
+
+			keys := 0
+			if err := s.Keys("seen", func(string) error { keys++; return nil }); err != nil {
+				t.Fatal(err)
+			}
+			if keys != n {
+				t.Errorf("Keys returned %d keys, want %d", keys, n)
+			}
+
+			key, value, err := s.Last("items")
+			if want := fmt.Sprintf("%05d", n-1); err != nil || key != want || string(value) != fmt.Sprint(n-1) {
+				t.Errorf("Last() = %s, %s, %v, want %s", key, value, err, want)
+			}
+		})
+	}
+}
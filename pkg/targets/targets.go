@@ -0,0 +1,168 @@
+// Package targets reads lists of items to delete that were put together
+// elsewhere, by another tool or by hand
+package targets
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Target is an item named by a list: a URL, a fullname or a bare ID, and
+// its kind when the list gives one
+type Target struct {
+	Ref  string
+	Kind string
+}
+
+// Columns and JSON fields that hold an item's reference and its kind
+var (
+	refFields  = []string{"url", "link", "permalink", "id", "tweet_id", "fullname"}
+	kindFields = []string{"kind", "type"}
+)
+
+// Read reads a list. Plain text has one URL or ID per line, skipping blank
+// lines and lines starting with #. A .csv file has a column named url,
+// link, permalink, id, tweet_id or fullname, and optionally kind or type.
+// A .json file is an array of references or of objects with those fields,
+// or an object holding such an array in "items".
+func Read(path string) ([]Target, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read target list: %v", err)
+	}
+
+	var targets []Target
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		targets, err = readCSV(data)
+	case ".json":
+		targets, err = readJSON(data)
+	default:
+		targets, err = readText(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("%s lists no items", path)
+	}
+	return targets, nil
+}
+
+func readText(data []byte) ([]Target, error) {
+	var targets []Target
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		targets = append(targets, Target{Ref: line})
+	}
+	return targets, scanner.Err()
+}
+
+func readCSV(data []byte) ([]Target, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.Comment = '#'
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %v", err)
+	}
+	col := map[string]int{}
+	for i, name := range header {
+		col[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	ref, kind := -1, -1
+	for _, name := range refFields {
+		if i, ok := col[name]; ok && ref < 0 {
+			ref = i
+		}
+	}
+	for _, name := range kindFields {
+		if i, ok := col[name]; ok && kind < 0 {
+			kind = i
+		}
+	}
+	if ref < 0 {
+		return nil, fmt.Errorf("no column named %s", strings.Join(refFields, ", "))
+	}
+
+	var targets []Target
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return targets, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ref >= len(rec) || strings.TrimSpace(rec[ref]) == "" {
+			continue
+		}
+		t := Target{Ref: strings.TrimSpace(rec[ref])}
+		if kind >= 0 && kind < len(rec) {
+			t.Kind = strings.TrimSpace(rec[kind])
+		}
+		targets = append(targets, t)
+	}
+}
+
+func readJSON(data []byte) ([]Target, error) {
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		var wrapped struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		if json.Unmarshal(data, &wrapped) != nil || wrapped.Items == nil {
+			return nil, fmt.Errorf("expected an array of items or an object with items: %v", err)
+		}
+		entries = wrapped.Items
+	}
+
+	targets := make([]Target, 0, len(entries))
+	for i, e := range entries {
+		var ref string
+		if json.Unmarshal(e, &ref) == nil {
+			targets = append(targets, Target{Ref: ref})
+			continue
+		}
+		var fields map[string]any
+		dec := json.NewDecoder(bytes.NewReader(e))
+		dec.UseNumber()
+		if err := dec.Decode(&fields); err != nil {
+			return nil, fmt.Errorf("item %d is neither a reference nor an object", i+1)
+		}
+		t := Target{Ref: field(fields, refFields), Kind: field(fields, kindFields)}
+		if t.Ref == "" {
+			return nil, fmt.Errorf("item %d has none of %s", i+1, strings.Join(refFields, ", "))
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// field returns the first of names that is set. Some tools write IDs as
+// numbers, which are kept exact.
+func field(fields map[string]any, names []string) string {
+	for _, name := range names {
+		switch v := fields[name].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case json.Number:
+			return v.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+//go:build !nosqlite
+
+package tombstone
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Migrate moves the tombstones of the SQLite index at path, where they were
+// kept before the state store, into ix and renames the file so it isn't read
+// again. Tombstones ix already has win. It returns how many were moved.
+func Migrate(ix *Index, path string) (int, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open old tombstone index %s: %v", path, err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT platform, id, kind, url, text, created_at, deleted_at, archive FROM tombstones`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read old tombstone index %s: %v", path, err)
+	}
+	var stones []Tombstone
+	for rows.Next() {
+		var t Tombstone
+		var created, deleted string
+		if err := rows.Scan(&t.Platform, &t.ID, &t.Kind, &t.URL, &t.Text, &created, &deleted, &t.Archive); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to read old tombstone index %s: %v", path, err)
+		}
+		t.Created, _ = time.Parse(time.RFC3339, created)
+		t.Deleted, _ = time.Parse(time.RFC3339, deleted)
+		stones = append(stones, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read old tombstone index %s: %v", path, err)
+	}
+
+	n := 0
+	for _, t := range stones {
+		has, err := ix.Has(t.Platform, t.ID)
+		if err != nil {
+			return n, err
+		}
+		if has {
+			continue
+		}
+		if err := ix.Record(t); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	db.Close()
+	if err := os.Rename(path, path+".migrated"); err != nil {
+		return n, fmt.Errorf("failed to retire old tombstone index: %v", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,18 @@
+//go:build nosqlite
+
+package tombstone
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Migrate refuses to go on when an old SQLite tombstone index is at path, as
+// builds without SQLite can't read it
+func Migrate(ix *Index, path string) (int, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	return 0, fmt.Errorf("%s holds tombstones from an older version, which this build can't read as it leaves SQLite out; run a build with SQLite once to move them into the state store", path)
+}
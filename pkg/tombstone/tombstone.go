@@ -0,0 +1,176 @@
+package tombstone
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/store"
+)
+
+// Tombstone records a deleted item and where a copy of it is kept
+type Tombstone struct {
+	Platform string    `json:"platform"`
+	ID       string    `json:"id"`
+	Kind     string    `json:"kind"`
+	URL      string    `json:"url"`
+	Text     string    `json:"text"`
+	Created  time.Time `json:"created"`
+	Deleted  time.Time `json:"deleted"`
+
+	// Archive is the path of the archived copy, if one was saved
+	Archive string `json:"archive,omitempty"`
+}
+
+// Index keeps tombstones in the profile's store, so a link to deleted content
+// can be traced back to the local copy
+type Index struct {
+	s store.Store
+}
+
+const (
+	bucket = "tombstones"
+
+	// urlBucket maps URLs to tombstone keys, for lookups by link
+	urlBucket = "tombstone-urls"
+)
+
+func New(s store.Store) *Index {
+	return &Index{s: s}
+}
+
+func key(platform, id string) string {
+	return platform + "/" + id
+}
+
+// Record adds a tombstone, replacing an earlier one for the same item
+func (ix *Index) Record(t Tombstone) error {
+	if t.Deleted.IsZero() {
+		t.Deleted = time.Now()
+	}
+	t.Created, t.Deleted = t.Created.UTC(), t.Deleted.UTC()
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	k := key(t.Platform, t.ID)
+	if err := ix.s.Put(bucket, k, data); err != nil {
+		return fmt.Errorf("failed to record tombstone for %s: %v", t.ID, err)
+	}
+	if t.URL != "" {
+		if data, err = json.Marshal(k); err != nil {
+			return err
+		}
+		if err := ix.s.Put(urlBucket, t.URL, data); err != nil {
+			return fmt.Errorf("failed to record tombstone for %s: %v", t.ID, err)
+		}
+	}
+	return nil
+}
+
+// Has reports whether the item has a tombstone, i.e. an earlier run deleted it
+func (ix *Index) Has(platform, id string) (bool, error) {
+	_, err := ix.s.Get(bucket, key(platform, id))
+	if errors.Is(err, store.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to search tombstone index: %v", err)
+	}
+	return true, nil
+}
+
+// Since returns the tombstones of the platform's items deleted at or after
+// t, or those of every platform when platform is empty
+func (ix *Index) Since(platform string, t time.Time) ([]Tombstone, error) {
+	var found []Tombstone
+	err := ix.s.Scan(bucket, func(k string, value []byte) error {
+		if platform != "" && !strings.HasPrefix(k, platform+"/") {
+			return nil
+		}
+		var ts Tombstone
+		if err := json.Unmarshal(value, &ts); err != nil {
+			return fmt.Errorf("corrupt tombstone %s: %v", k, err)
+		}
+		if !ts.Deleted.Before(t) {
+			found = append(found, ts)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tombstone index: %v", err)
+	}
+	return found, nil
+}
+
+var (
+	tweetURL  = regexp.MustCompile(`/status(?:es)?/(\d+)`)
+	redditURL = regexp.MustCompile(`/comments/([a-z0-9]+)(?:/[^/]*/([a-z0-9]+))?`)
+)
+
+// keys returns the item IDs a link or ID given to Lookup may refer to
+func keys(query string) []string {
+	query = strings.TrimSpace(query)
+	if m := tweetURL.FindStringSubmatch(query); m != nil {
+		return []string{m[1]}
+	}
+	if m := redditURL.FindStringSubmatch(query); m != nil {
+		// Comment permalinks carry the comment ID after the post's slug
+		if m[2] != "" {
+			return []string{"t1_" + m[2]}
+		}
+		return []string{"t3_" + m[1]}
+	}
+	if strings.HasPrefix(query, "t1_") || strings.HasPrefix(query, "t3_") {
+		return []string{query}
+	}
+	return []string{query, "t1_" + query, "t3_" + query}
+}
+
+// Lookup finds tombstones by URL, permalink or item ID
+func (ix *Index) Lookup(query string) ([]Tombstone, error) {
+	var candidates []string
+	if data, err := ix.s.Get(urlBucket, strings.TrimSpace(query)); err == nil {
+		var k string
+		if err := json.Unmarshal(data, &k); err == nil {
+			candidates = append(candidates, k)
+		}
+	} else if !errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("failed to search tombstone index: %v", err)
+	}
+	for _, id := range keys(query) {
+		for _, platform := range []string{"twitter", "reddit"} {
+			candidates = append(candidates, key(platform, id))
+		}
+	}
+
+	var found []Tombstone
+	seen := map[string]bool{}
+	for _, k := range candidates {
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		data, err := ix.s.Get(bucket, k)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to search tombstone index: %v", err)
+		}
+		var t Tombstone
+		if err := json.Unmarshal(data, &t); err != nil {
+			return nil, fmt.Errorf("corrupt tombstone %s: %v", k, err)
+		}
+		found = append(found, t)
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].Deleted.Before(found[j].Deleted) })
+	return found, nil
+}
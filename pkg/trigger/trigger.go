@@ -0,0 +1,221 @@
+// Package trigger fires automation services when runs end: ntfy, IFTTT
+// Maker webhooks and Zapier catch hooks, with a title and message rendered
+// from templates for each kind of event
+package trigger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"go-del-socials/pkg/httpclient"
+	"go-del-socials/pkg/secrets"
+)
+
+// The kinds of events a trigger can fire on
+const (
+	RunFinished     = "run_finished"
+	RunFailed       = "run_failed"
+	BudgetExhausted = "budget_exhausted"
+)
+
+// Kinds lists every kind of event
+var Kinds = []string{RunFinished, RunFailed, BudgetExhausted}
+
+// defaults are the templates of events a config doesn't give its own
+var defaults = map[string]Template{
+	RunFinished: {
+		Title:   "{{.Platform}} run finished",
+		Message: "Deleted {{.Deleted}} items for {{.Profile}}{{if .Simulated}} (simulated){{end}}",
+	},
+	RunFailed: {
+		Title:   "{{.Platform}} run failed",
+		Message: "The run for {{.Profile}} failed: {{.Error}}",
+	},
+	BudgetExhausted: {
+		Title:   "{{.Platform}} daily limit reached",
+		Message: "The run for {{.Profile}} stopped after {{.Deleted}} items and can go on at {{.ResumeAt.Local.Format \"2006-01-02 15:04\"}}",
+	},
+}
+
+// Template renders a title and message from an Event
+type Template struct {
+	Title   string `json:"title"`
+	Message string `json:"message"`
+}
+
+// Config is one automation service to fire
+type Config struct {
+	// Type is ntfy, ifttt or zapier
+	Type string `json:"type"`
+
+	// URL is the ntfy topic, e.g. https://ntfy.sh/my-topic, or the Zapier
+	// catch hook
+	URL string `json:"url"`
+
+	// Token is an ntfy access token, for protected topics
+	Token string `json:"token"`
+
+	// Key and Event are the IFTTT webhook key and event name
+	Key   string `json:"key"`
+	Event string `json:"event"`
+
+	// Events are the kinds of events to fire on, each with its own
+	// templates; a template left empty uses the default. Without events
+	// the trigger fires on all of them.
+	Events map[string]Template `json:"events"`
+}
+
+// Event is what happened, as seen by the templates
+type Event struct {
+	Kind      string         `json:"event"`
+	Profile   string         `json:"profile"`
+	Platform  string         `json:"platform"`
+	Deleted   int            `json:"deleted"`
+	Failed    int            `json:"failed"`
+	Counts    map[string]int `json:"counts"`
+	Error     string         `json:"error,omitempty"`
+	ResumeAt  *time.Time     `json:"resume_at,omitempty"`
+	Simulated bool           `json:"simulated,omitempty"`
+}
+
+type templates struct {
+	title, message *template.Template
+}
+
+// Trigger fires one configured service
+type Trigger struct {
+	cfg    Config
+	events map[string]templates
+	client *http.Client
+}
+
+// New checks cfg and parses its templates
+func New(cfg Config) (*Trigger, error) {
+	if err := secrets.ResolveAll(&cfg.Token, &cfg.Key); err != nil {
+		return nil, err
+	}
+	switch cfg.Type {
+	case "ntfy", "zapier":
+		if !strings.HasPrefix(cfg.URL, "https://") && !strings.HasPrefix(cfg.URL, "http://") {
+			return nil, fmt.Errorf("%s trigger needs a url", cfg.Type)
+		}
+	case "ifttt":
+		if cfg.Key == "" || cfg.Event == "" {
+			return nil, fmt.Errorf("ifttt trigger needs a key and an event")
+		}
+	default:
+		return nil, fmt.Errorf("unknown trigger type %q: must be ntfy, ifttt or zapier", cfg.Type)
+	}
+
+	events := cfg.Events
+	if len(events) == 0 {
+		events = map[string]Template{}
+		for _, k := range Kinds {
+			events[k] = Template{}
+		}
+	}
+	t := &Trigger{cfg: cfg, events: map[string]templates{}, client: httpclient.New()}
+	for kind, tmpl := range events {
+		def, ok := defaults[kind]
+		if !ok {
+			return nil, fmt.Errorf("unknown trigger event %q: must be one of %s", kind, strings.Join(Kinds, ", "))
+		}
+		if tmpl.Title == "" {
+			tmpl.Title = def.Title
+		}
+		if tmpl.Message == "" {
+			tmpl.Message = def.Message
+		}
+		var parsed templates
+		var err error
+		if parsed.title, err = template.New(kind).Parse(tmpl.Title); err != nil {
+			return nil, fmt.Errorf("invalid %s title template: %v", kind, err)
+		}
+		if parsed.message, err = template.New(kind).Parse(tmpl.Message); err != nil {
+			return nil, fmt.Errorf("invalid %s message template: %v", kind, err)
+		}
+		t.events[kind] = parsed
+	}
+	return t, nil
+}
+
+// Fire sends e to the service, unless the trigger doesn't fire on its kind
+func (t *Trigger) Fire(ctx context.Context, e Event) error {
+	tmpl, ok := t.events[e.Kind]
+	if !ok {
+		return nil
+	}
+	var title, message strings.Builder
+	if err := tmpl.title.Execute(&title, e); err != nil {
+		return fmt.Errorf("failed to render %s trigger: %v", t.cfg.Type, err)
+	}
+	if err := tmpl.message.Execute(&message, e); err != nil {
+		return fmt.Errorf("failed to render %s trigger: %v", t.cfg.Type, err)
+	}
+
+	var req *http.Request
+	var err error
+	switch t.cfg.Type {
+	case "ntfy":
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, t.cfg.URL, strings.NewReader(message.String()))
+		if err == nil {
+			req.Header.Set("Title", title.String())
+			req.Header.Set("Tags", "wastebasket")
+			if e.Kind != RunFinished {
+				req.Header.Set("Priority", "high")
+			}
+			if t.cfg.Token != "" {
+				req.Header.Set("Authorization", "Bearer "+t.cfg.Token)
+			}
+		}
+	case "ifttt":
+		// IFTTT takes up to three values, shown in the applet as
+		// {{Value1}} to {{Value3}}
+		u := "https://maker.ifttt.com/trigger/" + t.cfg.Event + "/with/key/" + t.cfg.Key
+		req, err = jsonRequest(ctx, u, map[string]string{
+			"value1": title.String(),
+			"value2": message.String(),
+			"value3": e.Kind,
+		})
+	case "zapier":
+		req, err = jsonRequest(ctx, t.cfg.URL, struct {
+			Event
+			Title   string `json:"title"`
+			Message string `json:"message"`
+		}{e, title.String(), message.String()})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fire %s trigger: %v", t.cfg.Type, err)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fire %s trigger: %v", t.cfg.Type, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s trigger failed with status %d: %s", t.cfg.Type, resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+func jsonRequest(ctx context.Context, url string, v any) (*http.Request, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
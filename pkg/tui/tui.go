@@ -0,0 +1,198 @@
+// Package tui draws full-screen terminal interfaces with plain ANSI escape
+// codes and reads keys from a terminal in raw mode
+package tui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// Names of the special keys sent on Keys. Other keys arrive as the
+// character typed.
+const (
+	Up        = "up"
+	Down      = "down"
+	Left      = "left"
+	Right     = "right"
+	Enter     = "enter"
+	Escape    = "esc"
+	Tab       = "tab"
+	Backspace = "backspace"
+	PageUp    = "pgup"
+	PageDown  = "pgdown"
+	CtrlC     = "ctrl-c"
+)
+
+// Style is how a line is drawn
+type Style int
+
+const (
+	Plain Style = iota
+	Bold
+	Dim
+	Selected
+)
+
+var styleCodes = map[Style]string{
+	Bold:     "\x1b[1m",
+	Dim:      "\x1b[2m",
+	Selected: "\x1b[7m",
+}
+
+// Line is one line of the screen
+type Line struct {
+	Text  string
+	Style Style
+}
+
+// Screen is a terminal taken over by the interface until Close
+type Screen struct {
+	in   *os.File
+	out  *bufio.Writer
+	old  *term.State
+	keys chan string
+}
+
+// Open switches the terminal to raw mode and the alternate screen
+func Open() (*Screen, error) {
+	in := os.Stdin
+	if !term.IsTerminal(int(in.Fd())) {
+		return nil, fmt.Errorf("stdin is not a terminal")
+	}
+	old, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the terminal: %v", err)
+	}
+
+	s := &Screen{in: in, out: bufio.NewWriter(os.Stdout), old: old, keys: make(chan string)}
+	s.out.WriteString("\x1b[?1049h\x1b[?25l")
+	s.out.Flush()
+	go s.read()
+	return s, nil
+}
+
+// Close gives the terminal back as it was
+func (s *Screen) Close() error {
+	s.out.WriteString("\x1b[?25h\x1b[?1049l")
+	s.out.Flush()
+	return term.Restore(int(s.in.Fd()), s.old)
+}
+
+// Size returns the width and height of the terminal
+func (s *Screen) Size() (int, int) {
+	w, h, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || w == 0 || h == 0 {
+		return 80, 24
+	}
+	return w, h
+}
+
+// Keys delivers the keys pressed
+func (s *Screen) Keys() <-chan string {
+	return s.keys
+}
+
+// read turns terminal input into key names
+func (s *Screen) read() {
+	buf := make([]byte, 64)
+	for {
+		n, err := s.in.Read(buf)
+		if err != nil {
+			close(s.keys)
+			return
+		}
+		for _, k := range parseKeys(string(buf[:n])) {
+			s.keys <- k
+		}
+	}
+}
+
+var escapes = map[string]string{
+	"\x1b[A": Up, "\x1b[B": Down, "\x1b[C": Right, "\x1b[D": Left,
+	"\x1bOA": Up, "\x1bOB": Down, "\x1bOC": Right, "\x1bOD": Left,
+	"\x1b[5~": PageUp, "\x1b[6~": PageDown,
+}
+
+// parseKeys splits a chunk of input into keys
+func parseKeys(in string) []string {
+	var keys []string
+	for in != "" {
+		if in[0] == '\x1b' {
+			matched := false
+			for seq, name := range escapes {
+				if strings.HasPrefix(in, seq) {
+					keys, in, matched = append(keys, name), in[len(seq):], true
+					break
+				}
+			}
+			if !matched {
+				// A lone escape, or a sequence we don't know
+				keys, in = append(keys, Escape), ""
+			}
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(in)
+		in = in[size:]
+		switch r {
+		case '\r', '\n':
+			keys = append(keys, Enter)
+		case '\t':
+			keys = append(keys, Tab)
+		case 0x7f, '\b':
+			keys = append(keys, Backspace)
+		case 0x03:
+			keys = append(keys, CtrlC)
+		default:
+			keys = append(keys, string(r))
+		}
+	}
+	return keys
+}
+
+// Draw replaces the screen with lines, cut to its size
+func (s *Screen) Draw(lines []Line) error {
+	w, h := s.Size()
+	s.out.WriteString("\x1b[H\x1b[2J")
+	for i, l := range lines {
+		if i == h {
+			break
+		}
+		if i > 0 {
+			s.out.WriteString("\r\n")
+		}
+		text := Cut(l.Text, w)
+		if l.Style == Selected {
+			// Highlight the whole row, not just the text
+			text += strings.Repeat(" ", w-utf8.RuneCountInString(text))
+		}
+		if code, ok := styleCodes[l.Style]; ok {
+			text = code + text + "\x1b[0m"
+		}
+		s.out.WriteString(text)
+	}
+	return s.out.Flush()
+}
+
+// Cut shortens text to width characters, marking the cut with an ellipsis
+func Cut(text string, width int) string {
+	text = strings.Map(func(r rune) rune {
+		if r < ' ' {
+			return ' '
+		}
+		return r
+	}, text)
+	if width <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:width-1]) + "…"
+}
@@ -0,0 +1,83 @@
+package twitter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/michimani/gotwi"
+	"github.com/michimani/gotwi/resources"
+)
+
+// Error codes Twitter gives requests of accounts that can't act
+const (
+	codeSuspended = 64
+	codeLocked    = 326
+)
+
+// refusedLimit is how many writes in a row may be refused with 403
+// Forbidden before the run stops
+const refusedLimit = 5
+
+// standing explains why an error shows the account can't act at all, or
+// returns nil when it doesn't
+func (c *Client) standing(err error) error {
+	var gtwErr *gotwi.GotwiError
+	if !errors.As(err, &gtwErr) {
+		return nil
+	}
+
+	text := strings.ToLower(gtwErr.Title + " " + gtwErr.Detail)
+	codes := map[resources.ErrorCode]bool{}
+	for _, e := range gtwErr.APIErrors {
+		codes[e.Code] = true
+		text += " " + strings.ToLower(e.Message)
+	}
+	// Tweets of other suspended users are mentioned as such, so only the
+	// wording about the account itself counts
+	switch {
+	case codes[codeSuspended] || strings.Contains(text, "your account is suspended"):
+		return suspended(c.config.Username)
+	case codes[codeLocked] || strings.Contains(text, "account is temporarily locked"):
+		return fmt.Errorf("@%s is temporarily locked, so Twitter refuses every delete. "+
+			"Sign in at https://x.com and complete the steps it shows to unlock it, then run again", c.config.Username)
+	}
+	return nil
+}
+
+// suspended explains a suspended account and what to do about it
+func suspended(username string) error {
+	return fmt.Errorf("@%s is suspended, so Twitter refuses every delete. "+
+		"Sign in at https://x.com to see why and appeal at https://help.x.com/forms/account-access/appeals; "+
+		"run again once it is lifted, and items already deleted are skipped", username)
+}
+
+// checkStanding follows a write. Once it shows the account can't delete
+// anything, or refusedLimit writes in a row were refused with 403
+// Forbidden, the run stops with an explanation instead of failing item by
+// item.
+func (c *Client) checkStanding(err error) {
+	if err == nil {
+		c.refused = 0
+		return
+	}
+	if c.blocked = c.standing(err); c.blocked != nil {
+		return
+	}
+
+	var gtwErr *gotwi.GotwiError
+	if !errors.As(err, &gtwErr) || gtwErr.StatusCode != 403 {
+		return
+	}
+	if c.refused++; c.refused >= refusedLimit {
+		c.blocked = fmt.Errorf("twitter refused %d deletes in a row (403 Forbidden), so the run stopped. "+
+			"The account may be suspended or locked: sign in at https://x.com to check. "+
+			"Otherwise the app may have lost write access: check its permissions in the developer portal and regenerate the access token", c.refused)
+	}
+}
+
+// stopped reports whether the account can't delete anything more, so the
+// run should end
+func (c *Client) stopped() bool {
+	return c.blocked != nil
+}
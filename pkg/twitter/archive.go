@@ -0,0 +1,56 @@
+package twitter
+
+import (
+	"encoding/json"
+
+	"go-del-socials/pkg/export"
+	"go-del-socials/pkg/index"
+
+	"github.com/michimani/gotwi"
+	"github.com/michimani/gotwi/resources"
+)
+
+// archivedTweet converts a tweet of the archive to the v2 form the timeline
+// returns
+func archivedTweet(it *export.Item) *resources.Tweet {
+	t := &resources.Tweet{
+		ID:        gotwi.String(it.ID),
+		Text:      gotwi.String(it.Text),
+		CreatedAt: &it.Date,
+	}
+	if it.ReplyTo != "" {
+		t.ReferencedTweets = []resources.ReferencedTweet{{Type: gotwi.String("replied_to"), ID: gotwi.String(it.ReplyTo)}}
+		t.InReplyToUserID = gotwi.String(it.ReplyToUser)
+	}
+	if len(it.Tags) > 0 {
+		t.Entities = &resources.TweetEntities{}
+		for _, tag := range it.Tags {
+			t.Entities.HashTags = append(t.Entities.HashTags, resources.TweetEntityTag{Tag: gotwi.String(tag)})
+		}
+	}
+	return t
+}
+
+// ImportArchive adds the tweets and replies in tweets.js of a Twitter
+// archive to the item index, so they can be processed without listing the
+// timeline. Retweets are skipped: the archive doesn't say which tweet they
+// retweeted, which undoing them needs.
+func ImportArchive(path string, ix *index.Index) (imported, skipped int, err error) {
+	err = export.Twitter.Read(path, func(it export.Item) (bool, error) {
+		if it.Kind == kindRetweet {
+			skipped++
+			return true, nil
+		}
+
+		raw, err := json.Marshal(archivedTweet(&it))
+		if err != nil {
+			return false, err
+		}
+		if err := ix.Put("twitter", "timeline", it.ID, it.Date, raw); err != nil {
+			return false, err
+		}
+		imported++
+		return true, nil
+	})
+	return imported, skipped, err
+}
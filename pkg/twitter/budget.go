@@ -0,0 +1,192 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-del-socials/pkg/pace"
+	"go-del-socials/pkg/store"
+)
+
+// Budget tracks writes per UTC day across runs, so a limited API tier
+// (around 50 deletes a day on the free tier) is used up but never exceeded.
+type Budget struct {
+	Daily int `json:"-"`
+
+	Date string `json:"date"`
+	Used int    `json:"used"`
+
+	// Until is when Twitter's own daily cap, once used up, lets the
+	// account write again; it may come before or after midnight UTC
+	Until time.Time `json:"until"`
+
+	s store.Store
+}
+
+const budgetKey = "twitter-budget"
+
+// LoadBudget reads the budget state from the profile's store, starting fresh
+// when there is none yet.
+func LoadBudget(s store.Store, daily int) (*Budget, error) {
+	b := &Budget{Daily: daily, s: s}
+
+	data, err := s.Get("checkpoints", budgetKey)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("failed to read write budget: %v", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, b); err != nil {
+			return nil, fmt.Errorf("failed to parse write budget: %v", err)
+		}
+	}
+
+	b.rollover()
+	return b, nil
+}
+
+func today() string {
+	return time.Now().UTC().Format("2006-01-02")
+}
+
+func (b *Budget) rollover() {
+	if b.Date != today() {
+		b.Date = today()
+		b.Used = 0
+	}
+}
+
+// Remaining returns the writes left today
+func (b *Budget) Remaining() int {
+	b.rollover()
+	if b.Used >= b.Daily || time.Now().Before(b.Until) {
+		return 0
+	}
+	return b.Daily - b.Used
+}
+
+// Spend records one write and persists the budget
+func (b *Budget) Spend() error {
+	b.rollover()
+	b.Used++
+	return b.save()
+}
+
+// Exhaust records that Twitter's daily cap is used up until the given time,
+// so no run writes before then
+func (b *Budget) Exhaust(until time.Time) error {
+	b.Until = until
+	return b.save()
+}
+
+func (b *Budget) save() error {
+	data, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	return b.s.Put("checkpoints", budgetKey, data)
+}
+
+// ResetIn returns the time until writes may go on: midnight UTC, or when
+// Twitter's own cap resets if that is later
+func (b *Budget) ResetIn() time.Duration {
+	now := time.Now().UTC()
+	reset := now
+	if b.Used >= b.Daily {
+		reset = time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	}
+	if b.Until.After(reset) {
+		reset = b.Until
+	}
+	return reset.Sub(now)
+}
+
+// Days returns how many days writing n items takes, given what is left today
+func (b *Budget) Days(n int) int {
+	if n <= 0 || b.Daily <= 0 {
+		return 0
+	}
+	left := n - b.Remaining()
+	if left <= 0 {
+		return 1
+	}
+	return 1 + (left+b.Daily-1)/b.Daily
+}
+
+// dailyCap watches the 24-hour write cap Twitter reports in the headers of
+// write responses, which the rate limit information of gotwi leaves out
+type dailyCap struct {
+	next http.RoundTripper
+
+	mu    sync.Mutex
+	until time.Time
+}
+
+func (d *dailyCap) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := d.next.RoundTrip(req)
+	if err != nil || resp.Header.Get("x-user-limit-24hour-remaining") != "0" {
+		return resp, err
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("x-user-limit-24hour-reset"), 10, 64); err == nil {
+		d.mu.Lock()
+		d.until = time.Unix(reset, 0)
+		d.mu.Unlock()
+	}
+	return resp, err
+}
+
+// usedUp returns when the cap resets, if it is used up
+func (d *dailyCap) usedUp() (time.Time, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.until, time.Now().Before(d.until)
+}
+
+// capError is a write refused because the daily cap is used up
+type capError struct {
+	until time.Time
+}
+
+func (e *capError) Error() string {
+	return fmt.Sprintf("daily write cap used up until %s", e.until.Local().Format("2006-01-02 15:04"))
+}
+
+// capped handles a write refused by the daily cap: it is recorded in the
+// budget so later runs don't try before the cap resets. It reports whether
+// err was such a refusal, and retry is true once the run waited for the
+// reset with WaitForBudget, so the write may be tried again.
+func (c *Client) capped(ctx context.Context, opts *DeleteOptions, err error) (capped, retry bool) {
+	var ce *capError
+	if !errors.As(err, &ce) {
+		return false, false
+	}
+	if opts.Budget != nil {
+		if err := opts.Budget.Exhaust(ce.until); err != nil {
+			c.printf("Warning: failed to record write budget: %v\n", err)
+		}
+	}
+	if !opts.WaitForBudget {
+		c.printf("\nTwitter's daily write cap for @%s is used up until %s\n", c.config.Username, ce.until.Local().Format("2006-01-02 15:04"))
+		return true, false
+	}
+	wait := time.Until(ce.until) + time.Minute
+	c.printf("\nTwitter's daily write cap for @%s is used up. Waiting %v for it to reset...\n", c.config.Username, wait.Round(time.Minute))
+	return true, pace.Wait(ctx, wait) == nil
+}
+
+// ResumeAt returns when writes may go on after a run stopped at the daily
+// budget or at Twitter's own cap
+func (c *Client) ResumeAt(b *Budget) time.Time {
+	at, _ := c.daily.usedUp()
+	if b != nil {
+		if reset := time.Now().Add(b.ResetIn()); reset.After(at) {
+			at = reset
+		}
+	}
+	return at
+}
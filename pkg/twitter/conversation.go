@@ -0,0 +1,205 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"go-del-socials/pkg/archive"
+
+	"github.com/michimani/gotwi"
+	"github.com/michimani/gotwi/fields"
+	"github.com/michimani/gotwi/resources"
+	"github.com/michimani/gotwi/tweet/tweetlookup"
+	tltypes "github.com/michimani/gotwi/tweet/tweetlookup/types"
+)
+
+// maxParents bounds how far up a reply chain conversations are archived
+const maxParents = 25
+
+// What the archive remembers is bounded, so a huge timeline doesn't grow the
+// process: the user's newest replies in the most recently seen
+// conversations, and the most recently fetched parents
+const (
+	maxConversations = 10000
+	maxReplies       = 100
+	maxLookups       = 10000
+)
+
+// ArchivedTweet is a tweet as stored in a conversation archive
+type ArchivedTweet struct {
+	ID      string    `json:"id"`
+	Author  string    `json:"author,omitempty"`
+	Text    string    `json:"text"`
+	Created time.Time `json:"created_at"`
+}
+
+// Conversation is a deleted tweet together with the tweets it replied to,
+// oldest first, and the user's own replies in the same conversation
+type Conversation struct {
+	Tweet   ArchivedTweet   `json:"tweet"`
+	Parents []ArchivedTweet `json:"parents,omitempty"`
+	Replies []ArchivedTweet `json:"replies,omitempty"`
+}
+
+// conversationArchive writes the context of deleted tweets to an archive,
+// one JSON file per tweet
+type conversationArchive struct {
+	w archive.Writer
+
+	// locations holds where the conversations of tweets not yet deleted
+	// were written
+	locations map[string]string
+
+	// own holds the user's tweets seen so far by conversation ID. The
+	// timeline is newest first, so replies are seen before what they reply to.
+	own *recent[[]ArchivedTweet]
+
+	// lookups caches fetched parent tweets, which are often shared
+	lookups *recent[*parentTweet]
+}
+
+// parentTweet is a fetched parent, nil if it can no longer be read, and the
+// username of its author
+type parentTweet struct {
+	tweet  *resources.Tweet
+	author string
+}
+
+func newConversationArchive(dir, format string) (*conversationArchive, error) {
+	w, err := archive.Open(format, filepath.Dir(dir), filepath.Base(dir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create conversation archive: %v", err)
+	}
+	return &conversationArchive{
+		w:         w,
+		locations: map[string]string{},
+		own:       newRecent[[]ArchivedTweet](maxConversations),
+		lookups:   newRecent[*parentTweet](maxLookups),
+	}, nil
+}
+
+func archived(t *resources.Tweet, author string) ArchivedTweet {
+	a := ArchivedTweet{ID: gotwi.StringValue(t.ID), Author: author, Text: gotwi.StringValue(t.Text)}
+	if t.CreatedAt != nil {
+		a.Created = *t.CreatedAt
+	}
+	return a
+}
+
+// see records one of the user's own tweets
+func (a *conversationArchive) see(t *resources.Tweet, username string) {
+	conv := gotwi.StringValue(t.ConversationID)
+	if conv == "" {
+		return
+	}
+	replies, _ := a.own.get(conv)
+	if len(replies) < maxReplies {
+		a.own.put(conv, append(replies, archived(t, username)))
+	}
+}
+
+// lookupTweet fetches a single tweet with its author
+func (c *Client) lookupTweet(ctx context.Context, a *conversationArchive, id string) (*parentTweet, error) {
+	if p, ok := a.lookups.get(id); ok {
+		return p, nil
+	}
+
+	var out *tltypes.ListOutput
+	err := c.withRetry(ctx, func() error {
+		var err error
+		out, err = tweetlookup.List(ctx, c.client, &tltypes.ListInput{
+			IDs: []string{id},
+			TweetFields: fields.TweetFieldList{
+				fields.TweetFieldCreatedAt,
+				fields.TweetFieldReferencedTweets,
+				fields.TweetFieldAuthorID,
+			},
+			Expansions: fields.ExpansionList{fields.ExpansionAuthorID},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Deleted or protected parents come back as partial errors
+	p := &parentTweet{}
+	if len(out.Data) > 0 {
+		p.tweet = &out.Data[0]
+		for _, u := range out.Includes.Users {
+			if gotwi.StringValue(u.ID) == gotwi.StringValue(p.tweet.AuthorID) {
+				p.author = gotwi.StringValue(u.Username)
+			}
+		}
+	}
+	a.lookups.put(id, p)
+	return p, nil
+}
+
+// archiveConversation saves t with its parents and the user's replies before
+// it is deleted. Parents that can no longer be read end the chain.
+func (c *Client) archiveConversation(ctx context.Context, a *conversationArchive, t *resources.Tweet) error {
+	conv := Conversation{Tweet: archived(t, c.config.Username)}
+
+	id := gotwi.StringValue(t.ID)
+	replies, _ := a.own.get(gotwi.StringValue(t.ConversationID))
+	for _, r := range replies {
+		if r.ID != id {
+			conv.Replies = append(conv.Replies, r)
+		}
+	}
+
+	parent := repliedTo(t)
+	for n := 0; parent != "" && n < maxParents; n++ {
+		p, err := c.lookupTweet(ctx, a, parent)
+		if err != nil {
+			return fmt.Errorf("failed to fetch parent tweet %s: %v", parent, err)
+		}
+		if p.tweet == nil {
+			conv.Parents = append(conv.Parents, ArchivedTweet{ID: parent, Text: "[unavailable]"})
+			break
+		}
+		conv.Parents = append(conv.Parents, archived(p.tweet, p.author))
+		parent = repliedTo(p.tweet)
+	}
+
+	// Oldest first, like reading the thread
+	for i, j := 0, len(conv.Parents)-1; i < j; i, j = i+1, j-1 {
+		conv.Parents[i], conv.Parents[j] = conv.Parents[j], conv.Parents[i]
+	}
+	for i, j := 0, len(conv.Replies)-1; i < j; i, j = i+1, j-1 {
+		conv.Replies[i], conv.Replies[j] = conv.Replies[j], conv.Replies[i]
+	}
+
+	data, err := json.MarshalIndent(conv, "", "  ")
+	if err != nil {
+		return err
+	}
+	location, err := a.w.Write(id+".json", data)
+	if err != nil {
+		return fmt.Errorf("failed to write conversation archive: %v", err)
+	}
+	a.locations[id] = location
+	return nil
+}
+
+// path returns where the conversation of a tweet is archived. It is only
+// asked for once the tweet is deleted, so it's forgotten then.
+func (a *conversationArchive) path(id string) string {
+	location := a.locations[id]
+	delete(a.locations, id)
+	return location
+}
+
+// repliedTo returns the ID of the tweet t replies to, if any
+func repliedTo(t *resources.Tweet) string {
+	for _, ref := range t.ReferencedTweets {
+		if gotwi.StringValue(ref.Type) == "replied_to" {
+			return gotwi.StringValue(ref.ID)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package twitter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/michimani/gotwi"
+	"github.com/michimani/gotwi/list/listtweetlookup"
+	lttypes "github.com/michimani/gotwi/list/listtweetlookup/types"
+)
+
+var tweetIDPattern = regexp.MustCompile(`(?:^|/status(?:es)?/)(\d+)`)
+
+// parseTweetID extracts the ID from a tweet URL or returns a bare ID as is
+func parseTweetID(s string) (string, bool) {
+	m := tweetIDPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// ReadKeepFile reads tweet URLs or IDs, one per line, from path. Blank lines
+// and lines starting with # are ignored.
+func ReadKeepFile(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open keep file: %v", err)
+	}
+	defer f.Close()
+
+	keep := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		id, ok := parseTweetID(line)
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: not a tweet URL or ID: %q", path, n, line)
+		}
+		keep[id] = true
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read keep file: %v", err)
+	}
+
+	return keep, nil
+}
+
+// ListTweetIDs returns the IDs of the tweets shown in a Twitter List, to be
+// protected from deletion.
+func (c *Client) ListTweetIDs(ctx context.Context, listID string) (map[string]bool, error) {
+	keep := map[string]bool{}
+	params := &lttypes.ListInput{
+		ID:         listID,
+		MaxResults: lttypes.ListMaxResults(100),
+	}
+
+	for {
+		var out *lttypes.ListOutput
+		err := c.withRetry(ctx, func() error {
+			var err error
+			out, err = listtweetlookup.List(ctx, c.client, params)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch tweets of list %s: %v", listID, err)
+		}
+
+		for _, t := range out.Data {
+			keep[gotwi.StringValue(t.ID)] = true
+		}
+
+		next := gotwi.StringValue(out.Meta.NextToken)
+		if next == "" {
+			break
+		}
+		params.PaginationToken = next
+	}
+
+	return keep, nil
+}
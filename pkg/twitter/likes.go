@@ -0,0 +1,243 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/export"
+	"go-del-socials/pkg/inventory"
+	"go-del-socials/pkg/plan"
+	"go-del-socials/pkg/telemetry"
+
+	"github.com/michimani/gotwi"
+	"github.com/michimani/gotwi/fields"
+	"github.com/michimani/gotwi/tweet/like"
+	liketypes "github.com/michimani/gotwi/tweet/like/types"
+	"github.com/michimani/gotwi/tweet/tweetlookup"
+	tltypes "github.com/michimani/gotwi/tweet/tweetlookup/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Tweets since November 2010 have Snowflake IDs, which encode their
+// creation time in milliseconds since snowflakeEpoch. Lower IDs are from the
+// old sequential scheme and have to be looked up.
+const (
+	snowflakeEpoch   = 1288834974657
+	firstSnowflakeID = 30_000_000_000
+)
+
+// Like is a liked tweet from the archive
+type Like struct {
+	TweetID string
+	Text    string
+
+	// Created is when the liked tweet was posted, zero when that can't be
+	// found. The archive doesn't record when the like itself happened.
+	Created time.Time
+}
+
+// ReadLikeArchive reads like.js from a Twitter archive. path may be the
+// extracted archive directory or the like.js file itself.
+func ReadLikeArchive(path string) ([]Like, error) {
+	data, err := export.ReadArchiveFile(path, "like.js")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read likes archive: %v", err)
+	}
+
+	var entries []struct {
+		Like struct {
+			TweetID  string `json:"tweetId"`
+			FullText string `json:"fullText"`
+		} `json:"like"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse likes archive: %v", err)
+	}
+
+	likes := make([]Like, 0, len(entries))
+	for _, e := range entries {
+		likes = append(likes, Like{TweetID: e.Like.TweetID, Text: e.Like.FullText})
+	}
+	return likes, nil
+}
+
+// snowflakeTime returns the creation time encoded in a tweet ID
+func snowflakeTime(id string) (time.Time, bool) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || n < firstSnowflakeID {
+		return time.Time{}, false
+	}
+	return time.UnixMilli((n >> 22) + snowflakeEpoch), true
+}
+
+// dateLikes fills in when each liked tweet was posted: from the ID where
+// possible, otherwise with batched lookups of 100 tweets.
+func (c *Client) dateLikes(ctx context.Context, likes []Like) error {
+	var lookup []int
+	for i := range likes {
+		if t, ok := snowflakeTime(likes[i].TweetID); ok {
+			likes[i].Created = t
+		} else {
+			lookup = append(lookup, i)
+		}
+	}
+
+	for start := 0; start < len(lookup); start += 100 {
+		batch := lookup[start:min(start+100, len(lookup))]
+		ids := make([]string, len(batch))
+		for n, i := range batch {
+			ids[n] = likes[i].TweetID
+		}
+
+		var out *tltypes.ListOutput
+		err := c.withRetry(ctx, func() error {
+			var err error
+			out, err = tweetlookup.List(ctx, c.client, &tltypes.ListInput{
+				IDs:         ids,
+				TweetFields: fields.TweetFieldList{fields.TweetFieldCreatedAt},
+			})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to look up liked tweets: %v", err)
+		}
+
+		created := map[string]time.Time{}
+		for _, t := range out.Data {
+			if t.CreatedAt != nil {
+				created[gotwi.StringValue(t.ID)] = *t.CreatedAt
+			}
+		}
+
+		// Gone tweets with pre-Snowflake IDs stay undated
+		for _, i := range batch {
+			likes[i].Created = created[likes[i].TweetID]
+		}
+	}
+
+	return nil
+}
+
+// DeleteLikes removes likes of tweets posted before the cutoff date, taken
+// from the archive since the API only lists recent likes. The budget, keep
+// list and CountOnly options apply as for DeleteContent.
+func (c *Client) DeleteLikes(ctx context.Context, likes []Like, opts DeleteOptions) (*Result, error) {
+	result := &Result{}
+	c.startReceipts(&opts)
+
+	if err := c.dateLikes(ctx, likes); err != nil {
+		return result, err
+	}
+
+	for _, l := range likes {
+		// Without a date the cutoff can't be checked
+		if l.Created.IsZero() {
+			c.printf("Keeping like of tweet %s: the tweet is gone and its date unknown\n", l.TweetID)
+			result.Undated++
+			keepLike(&opts, l, "liked tweet is gone and its date unknown")
+			continue
+		}
+		if !l.Created.Before(opts.CutoffDate) {
+			keepLike(&opts, l, "newer than the cutoff")
+			continue
+		}
+		if opts.Keep[l.TweetID] {
+			keepLike(&opts, l, "on the keep list")
+			continue
+		}
+
+		if c.alreadyDeleted(&opts, "like", l.TweetID, result) {
+			continue
+		}
+
+		result.Matched++
+		if opts.CountOnly {
+			continue
+		}
+		if c.skipForPlan(&opts, plan.Item{ID: l.TweetID, Kind: "like", Date: l.Created, Text: l.Text}, result) {
+			if opts.Plan == nil {
+				keepLike(&opts, l, "not in the plan")
+			}
+			continue
+		}
+
+		opts.Pause.Wait(ctx)
+		if ok, err := c.spendBudget(ctx, opts); err != nil || !ok {
+			result.BudgetExhausted = err == nil
+			return result, err
+		}
+
+		dctx, receipt := audit.Expect(ctx)
+		err := c.unlike(dctx, l.TweetID)
+		if capped, retry := c.capped(ctx, &opts, err); capped {
+			if !retry {
+				result.BudgetExhausted = ctx.Err() == nil
+				return result, ctx.Err()
+			}
+			err = c.unlike(dctx, l.TweetID)
+		}
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		if c.checkStanding(err); c.blocked != nil {
+			c.printf("Error removing like of tweet %s: %v\n", l.TweetID, err)
+			result.Failed++
+			return result, c.blocked
+		}
+		if c.gone(&opts, "like", l.TweetID, err, result) {
+			continue
+		}
+		if err != nil {
+			c.printf("Error removing like of tweet %s: %v\n", l.TweetID, err)
+			result.Failed++
+			keepLike(&opts, l, "delete failed")
+			continue
+		}
+
+		c.printf("Removed like of tweet %s from %s\n", l.TweetID, l.Created.Format("2006-01-02"))
+		result.LikesRemoved++
+		c.receipt(&opts, receipt, "like", l.TweetID)
+		c.markGone(&opts, "like", l.TweetID)
+		c.config.Pace.Sleep(ctx, time.Second)
+	}
+
+	return result, nil
+}
+
+// unlike removes the like of a tweet
+func (c *Client) unlike(ctx context.Context, tweetID string) (err error) {
+	_, span := telemetry.Start(ctx, "twitter.unlike", attribute.String("tweet_id", tweetID))
+	defer func() { telemetry.End(span, err) }()
+
+	if err := c.config.Pace.Write(ctx); err != nil {
+		return err
+	}
+	return c.withRetry(ctx, func() error {
+		_, err := like.Delete(ctx, c.client, &liketypes.DeleteInput{ID: c.userID, TweetID: tweetID})
+		return err
+	})
+}
+
+// likeKey is the tombstone ID of a like, kept apart from the liked tweet,
+// which may be the user's own
+func likeKey(tweetID string) string {
+	return "like:" + tweetID
+}
+
+// keepLike records a like that stays in place in the kept inventory
+func keepLike(opts *DeleteOptions, l Like, reason string) {
+	if opts.Kept != nil {
+		opts.Kept.Add(inventory.Item{
+			ID:     l.TweetID,
+			Kind:   "like",
+			Date:   l.Created,
+			URL:    "https://x.com/i/status/" + l.TweetID,
+			Text:   l.Text,
+			Reason: reason,
+		})
+	}
+}
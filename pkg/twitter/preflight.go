@@ -0,0 +1,60 @@
+package twitter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Preflight verifies the credentials can delete content before a run starts.
+// Twitter reports the app's permissions in the x-access-level header, so a
+// read-only app is caught here instead of failing on every delete.
+func (c *Client) Preflight(ctx context.Context) error {
+	req, err := c.newSignedRequest(ctx, http.MethodGet, apiBaseURL+"/2/users/me", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create preflight request: %v", err)
+	}
+
+	resp, err := c.client.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send preflight request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return fmt.Errorf("authentication failed: please check your API key and access token")
+	case http.StatusForbidden:
+		return fmt.Errorf("access denied: make sure your app is attached to a project in the developer portal")
+	default:
+		return fmt.Errorf("preflight request failed: %s", resp.Status)
+	}
+
+	level := resp.Header.Get("x-access-level")
+	if level != "" && !strings.Contains(level, "write") {
+		return fmt.Errorf("your access token only has %q permission: enable \"Read and write\" in the app's "+
+			"user authentication settings, then regenerate the access token and secret", level)
+	}
+
+	return nil
+}
+
+// Ping looks up the authenticated user, a read-only request for timing the
+// API
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := c.newSignedRequest(ctx, http.MethodGet, apiBaseURL+"/2/users/me", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request failed: %s", resp.Status)
+	}
+	return nil
+}
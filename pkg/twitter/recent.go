@@ -0,0 +1,46 @@
+package twitter
+
+import "container/list"
+
+// recent is a map that forgets its least recently used entries once it
+// holds max, so caches of a long timeline stay the same size
+type recent[V any] struct {
+	max   int
+	order *list.List // of *entry[V], most recently used first
+	items map[string]*list.Element
+}
+
+type entry[V any] struct {
+	key   string
+	value V
+}
+
+func newRecent[V any](max int) *recent[V] {
+	return &recent[V]{max: max, order: list.New(), items: map[string]*list.Element{}}
+}
+
+// get returns the value of key and marks it used
+func (r *recent[V]) get(key string) (V, bool) {
+	e, ok := r.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	r.order.MoveToFront(e)
+	return e.Value.(*entry[V]).value, true
+}
+
+// put sets the value of key, forgetting the least recently used entry if
+// there are too many
+func (r *recent[V]) put(key string, value V) {
+	if e, ok := r.items[key]; ok {
+		e.Value.(*entry[V]).value = value
+		r.order.MoveToFront(e)
+		return
+	}
+	r.items[key] = r.order.PushFront(&entry[V]{key, value})
+	if r.order.Len() > r.max {
+		last := r.order.Remove(r.order.Back()).(*entry[V])
+		delete(r.items, last.key)
+	}
+}
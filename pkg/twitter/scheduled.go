@@ -0,0 +1,123 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Scheduled and draft tweets are only exposed through the Ads API, under the
+// ads account of the user
+const adsAPIBaseURL = "https://ads-api.twitter.com/12"
+
+type queuedTweet struct {
+	ID          string     `json:"id_str"`
+	Text        string     `json:"text"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+// adsRequest sends a signed Ads API request and decodes the response into out
+// when it is non-nil
+func (c *Client) adsRequest(ctx context.Context, method, path string, params map[string]string, out any) error {
+	req, err := c.newSignedRequest(ctx, method, adsAPIBaseURL+path, params)
+	if err != nil {
+		return err
+	}
+	if method != http.MethodGet {
+		if err := c.config.Pace.Write(ctx); err != nil {
+			return err
+		}
+	}
+
+	q := url.Values{}
+	for k, v := range params {
+		q.Set(k, v)
+	}
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.client.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if resp.StatusCode == http.StatusForbidden {
+			return fmt.Errorf("%s: your app needs Ads API access and the account must have an ads account", resp.Status)
+		}
+		return fmt.Errorf("request failed: %s", resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// listQueued returns all scheduled_tweets or draft_tweets of the ads account
+func (c *Client) listQueued(ctx context.Context, account, kind string) ([]queuedTweet, error) {
+	var all []queuedTweet
+	params := map[string]string{"count": "200"}
+
+	for {
+		var page struct {
+			Data       []queuedTweet `json:"data"`
+			NextCursor *string       `json:"next_cursor"`
+		}
+		if err := c.adsRequest(ctx, http.MethodGet, "/accounts/"+account+"/"+kind, params, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.Data...)
+
+		if page.NextCursor == nil || *page.NextCursor == "" {
+			return all, nil
+		}
+		params["cursor"] = *page.NextCursor
+	}
+}
+
+// DeleteQueued deletes scheduled tweets that haven't been posted yet and
+// draft tweets created before cutoffDate. It returns how many of each were
+// removed.
+func (c *Client) DeleteQueued(ctx context.Context, adsAccountID string, cutoffDate time.Time) (scheduled, drafts int, err error) {
+	if adsAccountID == "" {
+		return 0, 0, errors.New("deleting scheduled and draft tweets needs ads_account_id in the Twitter config, " +
+			"since they are only available through the Ads API")
+	}
+
+	for _, kind := range []string{"scheduled_tweets", "draft_tweets"} {
+		queued, err := c.listQueued(ctx, adsAccountID, kind)
+		if err != nil {
+			return scheduled, drafts, fmt.Errorf("failed to list %s: %v", kind, err)
+		}
+
+		c.printf("Found %d %s\n", len(queued), kind)
+
+		for _, q := range queued {
+			if q.CompletedAt != nil || !q.CreatedAt.Before(cutoffDate) {
+				continue
+			}
+
+			path := "/accounts/" + adsAccountID + "/" + kind + "/" + q.ID
+			if err := c.adsRequest(ctx, http.MethodDelete, path, nil, nil); err != nil {
+				c.printf("Error deleting %s %s: %v\n", kind, q.ID, err)
+				continue
+			}
+
+			c.printf("Successfully deleted %s from %s\nContent: %s\n---\n", kind, q.CreatedAt.Format("2006-01-02"), c.excerpt(q.Text))
+			if kind == "scheduled_tweets" {
+				scheduled++
+			} else {
+				drafts++
+			}
+			c.config.Pace.Sleep(ctx, time.Second)
+		}
+	}
+
+	return scheduled, drafts, nil
+}
@@ -0,0 +1,75 @@
+package twitter
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/michimani/gotwi"
+	"github.com/michimani/gotwi/fields"
+	"github.com/michimani/gotwi/resources"
+	"github.com/michimani/gotwi/tweet/tweetlookup"
+	tltypes "github.com/michimani/gotwi/tweet/tweetlookup/types"
+)
+
+// TweetID returns the ID of a tweet URL or bare ID from a target list
+func TweetID(ref string) (string, error) {
+	id, ok := parseTweetID(ref)
+	if !ok {
+		return "", fmt.Errorf("%q is not a tweet URL or ID", ref)
+	}
+	return id, nil
+}
+
+// deleteTargets processes the tweets of a target list instead of the
+// timeline. They are looked up 100 at a time, so the cutoff and filters
+// apply as usual.
+func (c *Client) deleteTargets(ctx context.Context, r *timelineRun) error {
+	targets := r.opts.Targets
+	for start := 0; start < len(targets); start += 100 {
+		batch := targets[start:min(start+100, len(targets))]
+
+		var out *tltypes.ListOutput
+		err := c.withRetry(ctx, func() error {
+			var err error
+			out, err = tweetlookup.List(ctx, c.client, &tltypes.ListInput{
+				IDs:         batch,
+				TweetFields: append(slices.Clone(timelineFields), fields.TweetFieldAuthorID),
+				Expansions:  timelineExpansions,
+			})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to look up targets: %v", err)
+		}
+
+		// Deleted tweets come back as partial errors
+		found := map[string]*resources.Tweet{}
+		for i := range out.Data {
+			found[gotwi.StringValue(out.Data[i].ID)] = &out.Data[i]
+		}
+
+		for _, id := range batch {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			t := found[id]
+			switch {
+			case t == nil:
+				c.printf("Skipping tweet %s: already gone\n", id)
+				r.result.AlreadyGone++
+				c.markGone(r.opts, kindTweet, id)
+			case gotwi.StringValue(t.AuthorID) != c.userID:
+				c.printf("Refusing tweet %s: it isn't yours\n", id)
+				r.result.Failed++
+			case c.stopped():
+				return c.blocked
+			default:
+				if ok, err := c.process(ctx, r, t); err != nil || !ok {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
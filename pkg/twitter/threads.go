@@ -0,0 +1,35 @@
+package twitter
+
+import (
+	"github.com/michimani/gotwi"
+	"github.com/michimani/gotwi/resources"
+)
+
+// threadTracker remembers which of the user's self-threads still have
+// surviving tweets, so deleting an earlier tweet would cut the thread in half.
+type threadTracker struct {
+	userID string
+	live   map[string]bool // conversation IDs with surviving self-replies
+}
+
+func newThreadTracker(userID string) *threadTracker {
+	return &threadTracker{userID: userID, live: map[string]bool{}}
+}
+
+// survive records a tweet that is not being deleted. Only replies to the
+// user's own tweets continue a self-thread.
+func (t *threadTracker) survive(tweet *resources.Tweet) {
+	if gotwi.StringValue(tweet.InReplyToUserID) != t.userID {
+		return
+	}
+	if conv := gotwi.StringValue(tweet.ConversationID); conv != "" {
+		t.live[conv] = true
+	}
+}
+
+// orphans reports whether deleting tweet would orphan later tweets of the
+// same self-thread that were seen surviving
+func (t *threadTracker) orphans(tweet *resources.Tweet) bool {
+	conv := gotwi.StringValue(tweet.ConversationID)
+	return conv != "" && t.live[conv]
+}
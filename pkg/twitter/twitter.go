@@ -5,83 +5,245 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"go-del-socials/pkg/audit"
+	"go-del-socials/pkg/filter"
+	"go-del-socials/pkg/hook"
+	"go-del-socials/pkg/httpclient"
+	"go-del-socials/pkg/index"
+	"go-del-socials/pkg/inventory"
+	"go-del-socials/pkg/logtext"
+	"go-del-socials/pkg/pace"
+	"go-del-socials/pkg/pause"
+	"go-del-socials/pkg/pipeline"
+	"go-del-socials/pkg/plan"
+	"go-del-socials/pkg/telemetry"
+	"go-del-socials/pkg/tombstone"
+
 	"github.com/michimani/gotwi"
 	"github.com/michimani/gotwi/fields"
+	"github.com/michimani/gotwi/resources"
 	"github.com/michimani/gotwi/tweet/managetweet"
 	mttypes "github.com/michimani/gotwi/tweet/managetweet/types"
+	"github.com/michimani/gotwi/tweet/retweet"
+	rttypes "github.com/michimani/gotwi/tweet/retweet/types"
 	"github.com/michimani/gotwi/tweet/timeline"
 	ttypes "github.com/michimani/gotwi/tweet/timeline/types"
 	"github.com/michimani/gotwi/user/userlookup"
 	ultypes "github.com/michimani/gotwi/user/userlookup/types"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-type configFile struct {
-	Twitter Credentials `json:"twitter"`
-}
-
-type Credentials struct {
-	APIKey            string `json:"api_key"`
-	APIKeySecret      string `json:"api_key_secret"`
-	AccessToken       string `json:"access_token"`
-	AccessTokenSecret string `json:"access_token_secret"`
-	Username          string `json:"username"`
-}
+const apiBaseURL = "https://api.twitter.com"
 
 type Config struct {
-	Username string
-}
+	APIKey            string
+	APIKeySecret      string
+	AccessToken       string
+	AccessTokenSecret string
+	Username          string
 
-func loadCredentials(path string) (*Credentials, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %v", err)
-	}
+	// UserID is the account's ID when it is already known, which saves
+	// looking up Username
+	UserID string
 
-	var config configFile
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %v", err)
-	}
+	// Simulate acknowledges every write without sending it, so runs can be
+	// rehearsed against the real timeline
+	Simulate bool
 
-	creds := &config.Twitter
-	if creds.APIKey == "" || creds.APIKeySecret == "" || creds.AccessToken == "" || creds.AccessTokenSecret == "" {
-		return nil, fmt.Errorf("missing required credentials in config file")
-	}
+	// HideContent leaves the text of items out of progress messages, which
+	// otherwise show it cleaned and shortened
+	HideContent bool
 
-	return creds, nil
+	// Pace sets the delay between timeline pages and caps deletes, unlikes
+	// and unretweets; nil leaves them unpaced
+	Pace *pace.Pacer
+
+	// Output receives progress messages; defaults to os.Stdout
+	Output io.Writer
 }
 
 func (c *Config) Validate() error {
 	if c.Username == "" {
 		return errors.New("username is required")
 	}
+	if c.APIKey == "" || c.APIKeySecret == "" || c.AccessToken == "" || c.AccessTokenSecret == "" {
+		return errors.New("missing required credentials in config file")
+	}
 	return nil
 }
 
+type DeleteOptions struct {
+	ContentType string
+	CutoffDate  time.Time
+
+	// Hashtags limits deletion to tweets with (or without) these hashtags
+	Hashtags filter.Set
+
+	// KeepLast spares the newest tweets, replies and retweets by count,
+	// whatever their date
+	KeepLast filter.KeepLast
+
+	// Retention overrides the cutoff for tweets with some hashtags
+	Retention filter.Retention
+
+	// KeepThreads skips tweets whose deletion would orphan later tweets in
+	// the user's own thread
+	KeepThreads bool
+
+	// OnlyQuotes limits deletion to tweets and replies quoting another tweet
+	OnlyQuotes bool
+
+	// Keep holds IDs of tweets that are never deleted, nor are retweets of
+	// them undone
+	Keep map[string]bool
+
+	// Budget caps writes per day when set. Once it is used up the run stops,
+	// or waits for the next day when WaitForBudget is set.
+	Budget        *Budget
+	WaitForBudget bool
+
+	// CountOnly counts matching entries without deleting anything
+	CountOnly bool
+
+	// ConversationDir, when set, receives the surrounding conversation of
+	// every tweet and reply before it is deleted. ArchiveFormat chooses
+	// between loose files and a compressed archive per run.
+	ConversationDir string
+	ArchiveFormat   string
+
+	// Plan, when set, records matching entries instead of deleting them
+	Plan *plan.Set
+
+	// Approved, when set, limits deletion to the entries of a reviewed plan
+	Approved *plan.Set
+
+	// Kept, when set, receives the listed entries that stay online
+	Kept *inventory.List
+
+	// Tombstones, when set, records every deleted tweet
+	Tombstones *tombstone.Index
+
+	// Receipts, when set, records the API's raw answer to every delete
+	Receipts *audit.Log
+
+	// Index, when set, keeps a local copy of every listed tweet. With
+	// Incremental, the timeline is only listed up to the newest indexed
+	// tweet and retention is applied to the index.
+	Index       *index.Index
+	Incremental bool
+
+	// Hooks, when set, may veto each deletion and are told about every
+	// deleted tweet
+	Hooks *hook.Hooks
+
+	// Pause, when set, holds the run before each item while it is paused
+	Pause *pause.Gate
+
+	// Targets, when set, are the IDs of the tweets to process instead of
+	// listing the timeline
+	Targets []string
+}
+
+// Result counts what a DeleteContent run did
+type Result struct {
+	TweetsDeleted  int
+	RepliesDeleted int
+	RetweetsUndone int
+	QuotesDeleted  int
+	LikesRemoved   int
+
+	// Matched tweets kept because later tweets in their thread survive
+	ThreadTweetsKept int
+
+	// Matched tweets kept because they are on the keep list
+	Protected int
+
+	// Matched tweets kept because their conversation couldn't be archived
+	NotArchived int
+
+	// Failed counts deletes that returned an error
+	Failed int
+
+	// Matched entries refused because the approved plan doesn't list them
+	NotPlanned int
+
+	// Entries that matched and were, or in CountOnly mode would be, deleted
+	Matched int
+
+	// Matched entries an earlier run already deleted, listed again because
+	// the timeline was stale
+	AlreadyDeleted int
+
+	// Matched entries Twitter no longer has, counted apart from failures
+	AlreadyGone int
+
+	// Matched entries the before-delete hook kept online
+	Vetoed int
+
+	// Likes kept because the liked tweet's date can't be found
+	Undated int
+
+	// BudgetExhausted is set when the run stopped on the daily write budget
+	BudgetExhausted bool
+}
+
 type Client struct {
 	client *gotwi.Client
 	userID string
 	config *Config
+
+	// rateLimited is the total time spent waiting for rate limits, in
+	// nanoseconds; listing and deleting both wait
+	rateLimited atomic.Int64
+
+	// daily watches Twitter's own cap on writes per 24 hours
+	daily *dailyCap
+
+	// refused counts writes refused in a row; blocked, once set, says why
+	// the account can't delete anything
+	refused int
+	blocked error
 }
 
-func NewClient(config *Config) (*Client, error) {
+func NewClient(ctx context.Context, config *Config) (*Client, error) {
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %v", err)
 	}
 
-	creds, err := loadCredentials("config.json")
-	if err != nil {
-		return nil, err
+	if config.Output == nil {
+		config.Output = os.Stdout
+	}
+
+	hc := httpclient.New()
+	if config.Simulate {
+		audit.Simulate(hc, nil)
 	}
+	daily := &dailyCap{next: hc.Transport}
+	if daily.next == nil {
+		daily.next = httpclient.Transport
+	}
+	hc.Transport = daily
 
 	in := &gotwi.NewClientInput{
 		AuthenticationMethod: gotwi.AuthenMethodOAuth1UserContext,
-		OAuthToken:           creds.AccessToken,
-		OAuthTokenSecret:     creds.AccessTokenSecret,
-		APIKey:               creds.APIKey,
-		APIKeySecret:         creds.APIKeySecret,
+		OAuthToken:           config.AccessToken,
+		OAuthTokenSecret:     config.AccessTokenSecret,
+		APIKey:               config.APIKey,
+		APIKeySecret:         config.APIKeySecret,
+
+		// gotwi shares one HTTP client between its clients by default; each
+		// profile gets its own, as the daily cap, simulation and receipts
+		// wrap it for one account
+		HTTPClient: hc,
 	}
 
 	client, err := gotwi.NewClient(in)
@@ -94,7 +256,17 @@ func NewClient(config *Config) (*Client, error) {
 		Username: config.Username,
 	}
 
-	res, err := userlookup.GetByUsername(context.Background(), client, p)
+	c := &Client{
+		client: client,
+		config: config,
+		userID: config.UserID,
+		daily:  daily,
+	}
+	if c.userID != "" {
+		return c, nil
+	}
+
+	res, err := userlookup.GetByUsername(ctx, client, p)
 	if err != nil {
 		var gtwErr *gotwi.GotwiError
 		if errors.As(err, &gtwErr) {
@@ -105,7 +277,9 @@ func NewClient(config *Config) (*Client, error) {
 				return nil, fmt.Errorf("user '%s' not found: please verify the username", config.Username)
 			}
 			if gtwErr.StatusCode == 429 {
-				waitForRateLimit(err)
+				if err := c.waitForRateLimit(ctx, err); err != nil {
+					return nil, err
+				}
 			}
 		}
 		return nil, fmt.Errorf("failed to get user ID: %v", err)
@@ -114,153 +288,750 @@ func NewClient(config *Config) (*Client, error) {
 	if res == nil {
 		return nil, fmt.Errorf("user data not found for username: %s", config.Username)
 	}
+	if res.Data.ID == nil {
+		// Suspended accounts are reported as errors of a successful lookup
+		for _, e := range res.Errors {
+			if detail := gotwi.StringValue(e.Detail); strings.Contains(strings.ToLower(detail), "suspended") {
+				return nil, suspended(config.Username)
+			}
+		}
+		return nil, fmt.Errorf("user data not found for username: %s", config.Username)
+	}
 
-	return &Client{
-		client: client,
-		userID: gotwi.StringValue(res.Data.ID),
-		config: config,
-	}, nil
+	c.userID = gotwi.StringValue(res.Data.ID)
+	return c, nil
+}
+
+// UserID returns the ID of the account
+func (c *Client) UserID() string {
+	return c.userID
 }
 
-func waitForRateLimit(err error) {
+// newSignedRequest builds a request signed with the client's OAuth 1.0a user
+// context, for endpoints gotwi doesn't cover. params holds the query or form
+// parameters included in the signature.
+func (c *Client) newSignedRequest(ctx context.Context, method, rawURL string, params map[string]string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := gotwi.CreateOAuthSignature(&gotwi.CreateOAuthSignatureInput{
+		HTTPMethod:       method,
+		RawEndpoint:      rawURL,
+		OAuthConsumerKey: c.client.OAuthConsumerKey(),
+		OAuthToken:       c.client.OAuthToken(),
+		SigningKey:       c.client.SigningKey(),
+		ParameterMap:     params,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		`OAuth oauth_consumer_key="%s",oauth_nonce="%s",oauth_signature="%s",oauth_signature_method="%s",oauth_timestamp="%s",oauth_token="%s",oauth_version="%s"`,
+		url.QueryEscape(c.client.OAuthConsumerKey()),
+		url.QueryEscape(sig.OAuthNonce),
+		url.QueryEscape(sig.OAuthSignature),
+		url.QueryEscape(sig.OAuthSignatureMethod),
+		url.QueryEscape(sig.OAuthTimestamp),
+		url.QueryEscape(c.client.OAuthToken()),
+		url.QueryEscape(sig.OAuthVersion),
+	))
+
+	return req, nil
+}
+
+func (c *Client) printf(format string, args ...any) {
+	fmt.Fprintf(c.config.Output, format, args...)
+}
+
+// excerpt is how an item's text appears in progress messages
+func (c *Client) excerpt(text string) string {
+	return logtext.Short(text, c.config.HideContent)
+}
+
+// waitForRateLimit waits out a 429 answer. It returns early with the
+// context's error when the run is cancelled.
+func (c *Client) waitForRateLimit(ctx context.Context, err error) error {
 	var gtwErr *gotwi.GotwiError
-	if errors.As(err, &gtwErr) && gtwErr.StatusCode == 429 {
-		// Use a fixed wait time since the Twitter API doesn't provide reset time in the error
-		waitTime := 15 * time.Minute
-		fmt.Printf("\nRate limit reached. Waiting for %v before continuing...\n", waitTime)
-		time.Sleep(waitTime)
+	if !errors.As(err, &gtwErr) || gtwErr.StatusCode != 429 {
+		return nil
+	}
+	c.config.Pace.Limited()
+	if info := gtwErr.RateLimitInfo; info != nil && info.ResetAt != nil {
+		c.config.Pace.Allowance(info.Limit, time.Until(*info.ResetAt))
 	}
+	wait := c.rateLimitWait(gtwErr)
+	c.rateLimited.Add(int64(wait))
+	return pace.Wait(ctx, wait)
 }
 
-func (c *Client) DeleteContent(contentType string, cutoffDate time.Time) (int, int, error) {
+// RateLimitWait returns how long the client has waited for rate limits
+func (c *Client) RateLimitWait() time.Duration {
+	return time.Duration(c.rateLimited.Load())
+}
 
-	tweetsDeleted := 0
-	repliesDeleted := 0
-	ctx := context.Background()
+// rateLimitWait returns how long to wait after a 429: until the reset time
+// from the x-rate-limit-reset header plus a little jitter, or a full 15
+// minute window when the header is missing.
+func (c *Client) rateLimitWait(gtwErr *gotwi.GotwiError) time.Duration {
+	waitTime := 15 * time.Minute
 
-	params := &ttypes.ListTweetsInput{
-		ID:         c.userID,
-		MaxResults: ttypes.ListMaxResults(20), // Maximum allowed per page
-		TweetFields: fields.TweetFieldList{
-			fields.TweetFieldCreatedAt,
-			fields.TweetFieldReferencedTweets,
-			fields.TweetFieldText, // Add text field to get tweet content
-		},
-		Expansions: fields.ExpansionList{
-			fields.ExpansionReferencedTweetsID,
-		},
+	if info := gtwErr.RateLimitInfo; info != nil && info.ResetAt != nil {
+		jitter := time.Second + rand.N(4*time.Second)
+		waitTime = time.Until(*info.ResetAt) + jitter
+		if waitTime < jitter {
+			waitTime = jitter
+		}
+		c.printf("\nRate limit reached. Waiting %v until it resets at %s...\n", waitTime.Round(time.Second), info.ResetAt.Local().Format("15:04:05"))
+		return waitTime
 	}
 
-	baseDelay := 5 * time.Second
-	maxRetries := 3
+	c.printf("\nRate limit reached. Waiting for %v before continuing...\n", waitTime)
+	return waitTime
+}
 
-	for {
-		var tweets *ttypes.ListTweetsOutput
-		var err error
+func hashtags(t *resources.Tweet) []string {
+	if t.Entities == nil {
+		return nil
+	}
+	tags := make([]string, 0, len(t.Entities.HashTags))
+	for _, h := range t.Entities.HashTags {
+		tags = append(tags, gotwi.StringValue(h.Tag))
+	}
+	return tags
+}
+
+// withRetry runs call, waiting out rate limits between attempts
+func (c *Client) withRetry(ctx context.Context, call func() error) error {
+	const maxRetries = 3
 
-		tweets, err = timeline.ListTweets(ctx, c.client, params)
-		if err != nil {
-			var gtwErr *gotwi.GotwiError
-			if errors.As(err, &gtwErr) && gtwErr.StatusCode == 429 {
-				waitForRateLimit(err)
-				continue // Retry the same request after waiting
+	var err error
+	for retry := 0; retry < maxRetries; retry++ {
+		if err = call(); err == nil {
+			return nil
+		}
+
+		var gtwErr *gotwi.GotwiError
+		if errors.As(err, &gtwErr) && gtwErr.StatusCode == 429 {
+			// The daily cap outlasts any wait worth making here
+			if until, ok := c.daily.usedUp(); ok {
+				return &capError{until}
+			}
+			if err := c.waitForRateLimit(ctx, err); err != nil {
+				return err
 			}
-			return tweetsDeleted, repliesDeleted, fmt.Errorf("failed to fetch tweets: %v", err)
+			continue
+		}
+		return err
+	}
+
+	return err
+}
+
+func (c *Client) deleteTweet(ctx context.Context, tweetID string) (err error) {
+	_, span := telemetry.Start(ctx, "twitter.delete", attribute.String("tweet_id", tweetID))
+	defer func() { telemetry.End(span, err) }()
+
+	if err := c.config.Pace.Write(ctx); err != nil {
+		return err
+	}
+	return c.withRetry(ctx, func() error {
+		_, err := managetweet.Delete(ctx, c.client, &mttypes.DeleteInput{ID: tweetID})
+		return err
+	})
+}
+
+func (c *Client) undoRetweet(ctx context.Context, sourceTweetID string) (err error) {
+	_, span := telemetry.Start(ctx, "twitter.unretweet", attribute.String("tweet_id", sourceTweetID))
+	defer func() { telemetry.End(span, err) }()
+
+	if err := c.config.Pace.Write(ctx); err != nil {
+		return err
+	}
+	return c.withRetry(ctx, func() error {
+		_, err := retweet.Delete(ctx, c.client, &rttypes.DeleteInput{ID: c.userID, SourceTweetID: sourceTweetID})
+		return err
+	})
+}
+
+// Kinds of timeline entries
+const (
+	kindTweet   = "tweet"
+	kindQuote   = "quote tweet"
+	kindReply   = "reply"
+	kindRetweet = "retweet"
+)
+
+// classify returns the kind of a timeline entry and, for retweets, the ID of
+// the retweeted tweet. Replies that quote another tweet stay replies.
+func classify(t *resources.Tweet) (string, string) {
+	kind := kindTweet
+	for _, ref := range t.ReferencedTweets {
+		switch gotwi.StringValue(ref.Type) {
+		case "retweeted":
+			return kindRetweet, gotwi.StringValue(ref.ID)
+		case "replied_to":
+			kind = kindReply
+		case "quoted":
+			if kind == kindTweet {
+				kind = kindQuote
+			}
+		}
+	}
+	return kind, ""
+}
+
+func quotes(t *resources.Tweet) bool {
+	for _, ref := range t.ReferencedTweets {
+		if gotwi.StringValue(ref.Type) == "quoted" {
+			return true
+		}
+	}
+	return false
+}
+
+// wants reports whether a content type choice covers a kind of entry
+// contentType returns the content type a kind of tweet is deleted with
+func contentType(kind string) string {
+	switch kind {
+	case kindReply:
+		return "replies"
+	case kindRetweet:
+		return "retweets"
+	}
+	return "tweets"
+}
+
+func wants(contentType, kind string) bool {
+	switch contentType {
+	case "all":
+		return true
+	case "tweets":
+		return kind == kindTweet || kind == kindQuote
+	case "replies":
+		return kind == kindReply
+	case "retweets":
+		return kind == kindRetweet
+	}
+	return false
+}
+
+// startReceipts begins capturing API responses when receipts are wanted
+func (c *Client) startReceipts(opts *DeleteOptions) {
+	if opts.Receipts != nil {
+		audit.NewCapture(c.client.Client)
+	}
+}
+
+// receipt records the API's answer to the delete that just succeeded
+func (c *Client) receipt(opts *DeleteOptions, r *audit.Receipt, kind, id string) {
+	if opts.Receipts == nil || !r.Captured() {
+		return
+	}
+	r.Platform, r.Kind, r.ID = "twitter", kind, id
+	if err := opts.Receipts.Record(*r); err != nil {
+		c.printf("Warning: %v\n", err)
+	}
+}
+
+// bury records a deleted timeline entry in the tombstone index, pointing at
+// its archived conversation if there is one, and marks it deleted in the
+// item index
+func (c *Client) bury(opts *DeleteOptions, t *resources.Tweet, kind string, conversations *conversationArchive) {
+	id := gotwi.StringValue(t.ID)
+	if opts.Index != nil {
+		if err := opts.Index.MarkDeleted("twitter", id); err != nil {
+			c.printf("Warning: %v\n", err)
 		}
+	}
+	if opts.Tombstones == nil {
+		return
+	}
+
+	archive := ""
+	if conversations != nil && kind != kindRetweet {
+		archive = conversations.path(id)
+	}
+
+	err := opts.Tombstones.Record(tombstone.Tombstone{
+		Platform: "twitter",
+		ID:       id,
+		Kind:     kind,
+		URL:      c.tweetURL(id),
+		Text:     gotwi.StringValue(t.Text),
+		Created:  *t.CreatedAt,
+		Archive:  archive,
+	})
+	if err != nil {
+		c.printf("Warning: %v\n", err)
+	}
+}
+
+// hookItem describes a tweet to the hooks
+func (c *Client) hookItem(t *resources.Tweet, kind string) hook.Item {
+	id := gotwi.StringValue(t.ID)
+	return hook.Item{
+		Platform: "twitter",
+		Kind:     kind,
+		ID:       id,
+		Date:     *t.CreatedAt,
+		URL:      c.tweetURL(id),
+		Text:     gotwi.StringValue(t.Text),
+	}
+}
+
+// gone handles a delete that failed because the entry is already gone, e.g.
+// one listed by a stale timeline or archive: it is counted apart from
+// failures, marked deleted in the index and given a tombstone, so later
+// runs skip it without asking the API again. It reports whether err was
+// such a failure.
+func (c *Client) gone(opts *DeleteOptions, kind, id string, err error, result *Result) bool {
+	var gtwErr *gotwi.GotwiError
+	if !errors.As(err, &gtwErr) || gtwErr.StatusCode != 404 {
+		return false
+	}
+	c.printf("Skipping %s %s: already gone\n", kind, id)
+	result.AlreadyGone++
+	if opts.Index != nil && kind != "like" {
+		if err := opts.Index.MarkDeleted("twitter", id); err != nil {
+			c.printf("Warning: %v\n", err)
+		}
+	}
+	c.markGone(opts, kind, id)
+	return true
+}
+
+// markGone adds a tombstone for an entry with no copy to point at: a removed
+// like, or an entry that was already gone
+func (c *Client) markGone(opts *DeleteOptions, kind, id string) {
+	if opts.Tombstones == nil {
+		return
+	}
+	if kind == "like" {
+		id = likeKey(id)
+	}
+	if err := opts.Tombstones.Record(tombstone.Tombstone{Platform: "twitter", ID: id, Kind: kind}); err != nil {
+		c.printf("Warning: %v\n", err)
+	}
+}
+
+// alreadyDeleted reports whether an earlier run deleted the entry, so it
+// isn't attempted again
+func (c *Client) alreadyDeleted(opts *DeleteOptions, kind, id string, result *Result) bool {
+	if opts.Tombstones == nil {
+		return false
+	}
+	key := id
+	if kind == "like" {
+		key = likeKey(id)
+	}
+	gone, err := opts.Tombstones.Has("twitter", key)
+	if err != nil {
+		c.printf("Warning: %v\n", err)
+		return false
+	}
+	if !gone {
+		return false
+	}
+
+	c.printf("Skipping %s %s: already deleted by an earlier run\n", kind, id)
+	result.AlreadyDeleted++
+	if opts.Index != nil && kind != "like" {
+		if err := opts.Index.MarkDeleted("twitter", id); err != nil {
+			c.printf("Warning: %v\n", err)
+		}
+	}
+	return true
+}
+
+func (c *Client) tweetURL(id string) string {
+	return fmt.Sprintf("https://x.com/%s/status/%s", c.config.Username, id)
+}
 
-		fmt.Printf("tweets: %+v\n", tweets)
+// skipForPlan records the entry when planning and refuses entries missing
+// from an approved plan. It reports whether the entry must be left alone.
+func (c *Client) skipForPlan(opts *DeleteOptions, it plan.Item, result *Result) bool {
+	if opts.Plan != nil {
+		opts.Plan.Add(it)
+		return true
+	}
+	if opts.Approved != nil && !opts.Approved.Has(it.ID) {
+		c.printf("Refusing %s %s: it is not in the plan\n", it.Kind, it.ID)
+		result.NotPlanned++
+		return true
+	}
+	return false
+}
+
+// spendBudget takes one write from the daily budget, if any. It returns false
+// when the budget is used up and the run should stop, or waits for the next
+// day when WaitForBudget is set.
+func (c *Client) spendBudget(ctx context.Context, opts DeleteOptions) (bool, error) {
+	if opts.Budget == nil {
+		return true, nil
+	}
+
+	if opts.Budget.Remaining() == 0 {
+		if !opts.WaitForBudget {
+			return false, nil
+		}
+		wait := opts.Budget.ResetIn()
+		c.printf("\nDaily write budget of %d used up. Waiting %v for it to reset...\n", opts.Budget.Daily, wait.Round(time.Minute))
+		if err := pace.Wait(ctx, wait); err != nil {
+			return false, err
+		}
+	}
+
+	if err := opts.Budget.Spend(); err != nil {
+		return false, fmt.Errorf("failed to record write budget: %v", err)
+	}
+	return true, nil
+}
+
+// Fields of the tweets processed by a DeleteContent run
+var (
+	timelineFields = fields.TweetFieldList{
+		fields.TweetFieldCreatedAt,
+		fields.TweetFieldReferencedTweets,
+		fields.TweetFieldText, // Add text field to get tweet content
+		fields.TweetFieldEntities,
+		fields.TweetFieldConversationID,
+		fields.TweetFieldInReplyToUserID,
+	}
+	timelineExpansions = fields.ExpansionList{
+		fields.ExpansionReferencedTweetsID,
+	}
+)
+
+// timelineRun is the state shared by the tweets of one DeleteContent run
+type timelineRun struct {
+	opts   *DeleteOptions
+	result *Result
 
-		// Safely check for nil tweets response
-		if tweets == nil {
-			return tweetsDeleted, repliesDeleted, fmt.Errorf("received nil response from Twitter API")
+	// The timeline is listed newest first, so later tweets of a thread are
+	// always seen before the earlier ones they depend on
+	threads *threadTracker
+
+	conversations *conversationArchive
+
+	// newest counts the timeline's entries for KeepLast
+	newest *filter.Newest
+}
+
+func (c *Client) DeleteContent(ctx context.Context, opts DeleteOptions) (*Result, error) {
+	r := &timelineRun{
+		opts:    &opts,
+		result:  &Result{},
+		threads: newThreadTracker(c.userID),
+	}
+	c.startReceipts(&opts)
+
+	params := &ttypes.ListTweetsInput{
+		ID:          c.userID,
+		MaxResults:  ttypes.ListMaxResults(20), // Maximum allowed per page
+		TweetFields: timelineFields,
+		Expansions:  timelineExpansions,
+	}
+
+	if opts.ConversationDir != "" && !opts.CountOnly {
+		var err error
+		if r.conversations, err = newConversationArchive(opts.ConversationDir, opts.ArchiveFormat); err != nil {
+			return r.result, err
 		}
+		defer func() {
+			if err := r.conversations.w.Close(); err != nil {
+				c.printf("Warning: %v\n", err)
+			}
+		}()
+	}
 
-		fmt.Printf("Found %d tweets to delete\n", len(tweets.Data))
+	if len(opts.Targets) > 0 {
+		return r.result, c.deleteTargets(ctx, r)
+	}
+	r.newest = opts.KeepLast.Newest()
 
-		// Check for empty data
-		if len(tweets.Data) == 0 {
-			break
+	var newest time.Time
+	incremental := false
+	if opts.Index != nil && opts.Incremental {
+		var err error
+		if newest, incremental, err = opts.Index.Newest("twitter", "timeline"); err != nil {
+			return r.result, err
 		}
+	}
 
-		for _, t := range tweets.Data {
-			createdAt := t.CreatedAt
-			if createdAt.Before(cutoffDate) {
-				isReply := false
-				if t.ReferencedTweets != nil {
-					for _, ref := range t.ReferencedTweets {
-						if gotwi.StringValue(ref.Type) == "replied_to" {
-							isReply = true
-							break
+	// The next page is fetched and indexed while this one is deleted
+	caughtUp := false
+	p := &pipeline.Pipeline[*resources.Tweet]{
+		Fetch: func(ctx context.Context, token string) ([]*resources.Tweet, string, error) {
+			params.PaginationToken = token
+			for {
+				_, fetch := telemetry.Start(ctx, "twitter.fetch", attribute.String("pagination_token", token))
+				tweets, err := timeline.ListTweets(ctx, c.client, params)
+				telemetry.End(fetch, err)
+				if err != nil {
+					var gtwErr *gotwi.GotwiError
+					if errors.As(err, &gtwErr) && gtwErr.StatusCode == 429 {
+						if err := c.waitForRateLimit(ctx, err); err != nil {
+							return nil, "", err
 						}
+						continue // Retry the same request after waiting
 					}
+					return nil, "", fmt.Errorf("failed to fetch tweets: %v", err)
 				}
 
-				tweetID := gotwi.StringValue(t.ID)
-				if tweetID == "" {
-					continue // Skip if tweet ID is empty
+				// Safely check for nil tweets response
+				if tweets == nil {
+					return nil, "", fmt.Errorf("received nil response from Twitter API")
 				}
 
-				tweetText := gotwi.StringValue(t.Text)
-				fmt.Printf("Found %s from %s (ID: %s)\nContent: %s\n",
-					map[bool]string{true: "reply", false: "tweet"}[isReply],
-					createdAt.Format("2006-01-02"),
-					tweetID,
-					tweetText,
-				)
-
-				if contentType == "all" ||
-					(contentType == "tweets" && !isReply) ||
-					(contentType == "replies" && isReply) {
+				c.printf("Found %d tweets to delete\n", len(tweets.Data))
+				if len(tweets.Data) == 0 {
+					return nil, "", nil
+				}
 
-					deleteParams := &mttypes.DeleteInput{
-						ID: tweetID,
+				page := make([]*resources.Tweet, len(tweets.Data))
+				for i := range tweets.Data {
+					t := &tweets.Data[i]
+					if incremental && t.CreatedAt != nil && !t.CreatedAt.After(newest) {
+						caughtUp = true
+						return page[:i], "", nil
 					}
+					page[i] = t
+				}
+				// Handle pagination using next_token
+				return page, gotwi.StringValue(tweets.Meta.NextToken), nil
+			}
+		},
+		// Newly listed tweets go to the index first; in incremental mode the
+		// whole timeline is then processed from the index
+		Stages: []pipeline.Stage[*resources.Tweet]{func(ctx context.Context, t *resources.Tweet) bool {
+			c.index(&opts, t)
+			return !incremental
+		}},
+		// Add a base delay between requests to prevent rate limiting
+		Pace: c.config.Pace,
+	}
+	var processErr error
+	err := p.Run(ctx, func(ctx context.Context, tweets []*resources.Tweet) bool {
+		if len(tweets) == 0 {
+			return true
+		}
+		// Filtering and acting on the page; deletes are child spans
+		pageCtx, page := telemetry.Start(ctx, "twitter.page", attribute.Int("items", len(tweets)))
+		defer page.End()
+		for _, t := range tweets {
+			ok, err := c.process(pageCtx, r, t)
+			if err != nil || !ok {
+				processErr = err
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return r.result, err
+	}
+	if processErr != nil {
+		return r.result, processErr
+	}
+	if caughtUp {
+		c.printf("Caught up with the index; processing older tweets from it\n")
+	}
 
-					// Retry loop for deleting tweets
-					var deleteErr error
-					for retry := 0; retry < maxRetries; retry++ {
-						_, deleteErr = managetweet.Delete(ctx, c.client, deleteParams)
-						if deleteErr == nil {
-							break
-						}
+	if incremental {
+		return r.result, c.processIndexed(ctx, r)
+	}
+	return r.result, nil
+}
 
-						var gtwErr *gotwi.GotwiError
-						if errors.As(deleteErr, &gtwErr) && gtwErr.StatusCode == 429 {
-							waitForRateLimit(deleteErr)
-							continue
-						}
+// index stores a listed tweet for later incremental runs
+func (c *Client) index(opts *DeleteOptions, t *resources.Tweet) {
+	if opts.Index == nil || t.ID == nil || t.CreatedAt == nil {
+		return
+	}
+	raw, err := json.Marshal(t)
+	if err == nil {
+		err = opts.Index.Put("twitter", "timeline", *t.ID, *t.CreatedAt, raw)
+	}
+	if err != nil {
+		c.printf("Warning: %v\n", err)
+	}
+}
 
-						fmt.Printf("Error deleting tweet %s: %v\n", tweetID, deleteErr)
-						break
-					}
+// processIndexed applies retention to every indexed tweet that hasn't been
+// deleted, newest first like the timeline
+func (c *Client) processIndexed(ctx context.Context, r *timelineRun) error {
+	ctx, page := telemetry.Start(ctx, "twitter.page", attribute.Bool("indexed", true))
+	defer page.End()
+	n := 0
+	err := r.opts.Index.Live("twitter", "timeline", func(raw []byte) (bool, error) {
+		var t resources.Tweet
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return false, fmt.Errorf("corrupt tweet in index: %v", err)
+		}
+		n++
+		return c.process(ctx, r, &t)
+	})
+	page.SetAttributes(attribute.Int("items", n))
+	return err
+}
 
-					if deleteErr == nil {
-						fmt.Printf("Successfully deleted %s from %s\nContent: %s\n---\n",
-							map[bool]string{true: "reply", false: "tweet"}[isReply],
-							createdAt.Format("2006-01-02"),
-							tweetText,
-						)
-
-						if isReply {
-							repliesDeleted++
-						} else {
-							tweetsDeleted++
-						}
-					}
-				}
+// process applies the run's filters to one timeline entry and deletes it if
+// it matches. It returns false when the run has to stop.
+func (c *Client) process(ctx context.Context, r *timelineRun, t *resources.Tweet) (bool, error) {
+	opts, result := r.opts, r.result
+
+	tweetID := gotwi.StringValue(t.ID)
+	if tweetID == "" || t.CreatedAt == nil {
+		return true, nil // Skip if tweet ID or date is missing
+	}
+
+	deleted := false
+	cutoff, kept := opts.Retention.Cutoff(opts.CutoffDate, hashtags(t)...)
+	kind, sourceID := classify(t)
+	createdAt := t.CreatedAt
+	if r.newest.Keep(contentType(kind)) {
+		kept = opts.KeepLast.Reason(contentType(kind))
+	} else if createdAt.Before(cutoff) {
+		tweetText := gotwi.StringValue(t.Text)
+		c.printf("Found %s from %s (ID: %s)\nContent: %s\n",
+			kind,
+			createdAt.Format("2006-01-02"),
+			tweetID,
+			c.excerpt(tweetText),
+		)
+
+		matched := wants(opts.ContentType, kind) && opts.Hashtags.Allows(hashtags(t)) &&
+			(!opts.OnlyQuotes || quotes(t))
+		kept = "not selected by the filters"
+
+		if matched && c.alreadyDeleted(opts, kind, tweetID, result) {
+			return true, nil
+		}
+
+		if matched && (opts.Keep[tweetID] || (sourceID != "" && opts.Keep[sourceID])) {
+			c.printf("Keeping %s: it is on the keep list\n", tweetID)
+			result.Protected++
+			matched, kept = false, "on the keep list"
+		}
+
+		if matched && opts.KeepThreads && r.threads.orphans(t) {
+			c.printf("Keeping %s: later tweets in your thread would be orphaned\n", tweetID)
+			result.ThreadTweetsKept++
+			matched, kept = false, "keeps a thread intact"
+		}
+
+		if matched {
+			result.Matched++
+		}
+
+		if matched && c.skipForPlan(opts, plan.Item{ID: tweetID, Kind: kind, Date: *createdAt, Text: tweetText}, result) {
+			// Planned tweets count as gone when tracking threads
+			deleted = opts.Plan != nil
+			matched, kept = false, "not in the plan"
+		}
+
+		if matched && opts.CountOnly {
+			matched, kept = false, ""
+		}
+
+		if matched && r.conversations != nil && kind != kindRetweet {
+			if err := c.archiveConversation(ctx, r.conversations, t); err != nil {
+				c.printf("Keeping %s: could not archive its conversation: %v\n", tweetID, err)
+				result.NotArchived++
+				matched, kept = false, "conversation could not be archived"
 			}
 		}
 
-		// Handle pagination using next_token
-		nextToken := gotwi.StringValue(tweets.Meta.NextToken)
-		if nextToken == "" {
-			break
+		if matched {
+			opts.Pause.Wait(ctx)
+			if d := opts.Hooks.Before(ctx, c.hookItem(t, kind)); d.Veto {
+				c.printf("Keeping %s: %s\n", tweetID, d.Reason)
+				result.Vetoed++
+				matched, kept = false, d.Reason
+			}
 		}
-		params.PaginationToken = nextToken
 
-		// Add a base delay between requests to prevent rate limiting
-		time.Sleep(baseDelay)
+		if matched {
+			if ok, err := c.spendBudget(ctx, *opts); err != nil || !ok {
+				result.BudgetExhausted = err == nil
+				return false, err
+			}
+
+			var err error
+			dctx, receipt := audit.Expect(ctx)
+			for {
+				if kind == kindRetweet {
+					err = c.undoRetweet(dctx, sourceID)
+				} else {
+					err = c.deleteTweet(dctx, tweetID)
+				}
+				capped, retry := c.capped(ctx, opts, err)
+				if !capped {
+					break
+				}
+				if !retry {
+					result.BudgetExhausted = ctx.Err() == nil
+					return false, ctx.Err()
+				}
+			}
+			if c.checkStanding(err); c.blocked != nil {
+				c.printf("Error deleting %s %s: %v\n", kind, tweetID, err)
+				result.Failed++
+				return false, c.blocked
+			}
+
+			if c.gone(opts, kind, tweetID, err, result) {
+				deleted = true // nothing is left online
+			} else if err != nil {
+				c.printf("Error deleting %s %s: %v\n", kind, tweetID, err)
+				result.Failed++
+				kept = "delete failed"
+			} else {
+				deleted = true
+				c.printf("Successfully deleted %s from %s\nContent: %s\n---\n",
+					kind,
+					createdAt.Format("2006-01-02"),
+					c.excerpt(tweetText),
+				)
+
+				c.receipt(opts, receipt, kind, tweetID)
+				c.bury(opts, t, kind, r.conversations)
+				if err := opts.Hooks.After(ctx, c.hookItem(t, kind)); err != nil {
+					c.printf("Warning: %v\n", err)
+				}
+
+				switch kind {
+				case kindReply:
+					result.RepliesDeleted++
+				case kindRetweet:
+					result.RetweetsUndone++
+				case kindQuote:
+					result.QuotesDeleted++
+				default:
+					result.TweetsDeleted++
+				}
+			}
+		}
 	}
 
-	return tweetsDeleted, repliesDeleted, nil
+	if !deleted {
+		r.threads.survive(t)
+		if opts.Kept != nil && kept != "" {
+			opts.Kept.Add(inventory.Item{
+				ID:     tweetID,
+				Kind:   kind,
+				Date:   *createdAt,
+				URL:    c.tweetURL(tweetID),
+				Text:   gotwi.StringValue(t.Text),
+				Reason: kept,
+			})
+		}
+	}
+	if r.conversations != nil && kind != kindRetweet {
+		r.conversations.see(t, c.config.Username)
+	}
+	return true, nil
 }
@@ -0,0 +1,180 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3 talks to S3-compatible storage with path-style URLs and Signature
+// Version 4, which AWS, MinIO, Cloud Storage and most others accept
+type s3 struct {
+	hc       *http.Client
+	endpoint *url.URL
+	region   string
+	bucket   string
+	access   string
+	secret   string
+}
+
+func newS3(hc *http.Client, endpoint, region string, config *Config) (*s3, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid upload endpoint %q", endpoint)
+	}
+	if config.Bucket == "" || config.AccessKey == "" || config.SecretKey == "" {
+		return nil, fmt.Errorf("%s uploads need bucket, access_key and secret_key", config.Type)
+	}
+	return &s3{
+		hc:       hc,
+		endpoint: u,
+		region:   region,
+		bucket:   config.Bucket,
+		access:   config.AccessKey,
+		secret:   config.SecretKey,
+	}, nil
+}
+
+// escape percent-encodes everything but unreserved characters, and slashes
+// too unless keepSlash is set, as Signature Version 4 requires
+func escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sum(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+func mac(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// do sends a signed request for key (empty for the bucket itself)
+func (s *s3) do(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	path := "/" + s.bucket
+	if key != "" {
+		path += "/" + key
+	}
+
+	var params []string
+	for k, vs := range query {
+		for _, v := range vs {
+			params = append(params, escape(k, false)+"="+escape(v, false))
+		}
+	}
+	sort.Strings(params)
+	rawQuery := strings.Join(params, "&")
+
+	u := *s.endpoint
+	u.Path, u.RawPath, u.RawQuery = path, escape(path, true), rawQuery
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	stamp, day := now.Format("20060102T150405Z"), now.Format("20060102")
+	payload := sum(body)
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", payload)
+
+	canonical := strings.Join([]string{
+		method,
+		escape(path, true),
+		rawQuery,
+		"host:" + u.Host + "\nx-amz-content-sha256:" + payload + "\nx-amz-date:" + stamp + "\n",
+		"host;x-amz-content-sha256;x-amz-date",
+		payload,
+	}, "\n")
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + stamp + "\n" + scope + "\n" + sum([]byte(canonical))
+
+	key4 := mac(mac(mac(mac([]byte("AWS4"+s.secret), day), s.region), "s3"), "aws4_request")
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=%s",
+		s.access, scope, hex.EncodeToString(mac(key4, toSign))))
+
+	return s.hc.Do(req)
+}
+
+func (s *s3) put(ctx context.Context, key string, body []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, nil, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkStatus(resp, "PUT "+key)
+}
+
+func (s *s3) remove(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkStatus(resp, "DELETE "+key)
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *s3) list(ctx context.Context, prefix string) ([]object, error) {
+	var objects []object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkStatus(resp, "list "+prefix); err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse bucket listing: %v", err)
+		}
+
+		for _, c := range result.Contents {
+			objects = append(objects, object{Key: c.Key, Modified: c.LastModified})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
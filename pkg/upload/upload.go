@@ -0,0 +1,207 @@
+package upload
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/httpclient"
+	"go-del-socials/pkg/secrets"
+	"go-del-socials/pkg/store"
+)
+
+// Config selects where archives and reports are uploaded after each run
+type Config struct {
+	// Type is "s3" (any S3-compatible storage), "gcs" or "webdav"
+	Type string `json:"type"`
+
+	// Endpoint is the storage's base URL. S3 defaults to AWS in Region and
+	// GCS to storage.googleapis.com; WebDAV needs the URL of a folder.
+	Endpoint string `json:"endpoint"`
+	Region   string `json:"region"`
+	Bucket   string `json:"bucket"`
+
+	// Prefix is put in front of every uploaded path, followed by the profile
+	Prefix string `json:"prefix"`
+
+	// S3 access keys, or GCS HMAC keys
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+
+	// WebDAV credentials; Nextcloud wants an app password here
+	Username string `json:"username"`
+	Password string `json:"password"`
+
+	// RetentionDays removes uploads older than this many days; 0 keeps them
+	RetentionDays int `json:"retention_days"`
+}
+
+// object is a file in remote storage
+type object struct {
+	Key      string
+	Modified time.Time
+}
+
+// target is a remote storage service
+type target interface {
+	put(ctx context.Context, key string, body []byte) error
+	list(ctx context.Context, prefix string) ([]object, error)
+	remove(ctx context.Context, key string) error
+}
+
+// Uploader copies state directories to remote storage
+type Uploader struct {
+	config *Config
+	target target
+}
+
+// New checks the configuration and resolves its secrets. It returns nil when
+// uploading isn't configured.
+func New(config *Config) (*Uploader, error) {
+	if config == nil {
+		return nil, nil
+	}
+	if err := secrets.ResolveAll(&config.SecretKey, &config.Password); err != nil {
+		return nil, err
+	}
+
+	if config.Prefix != "" && !strings.HasSuffix(config.Prefix, "/") {
+		config.Prefix += "/"
+	}
+	// Archives take longer to send than an API call
+	hc := httpclient.New()
+	hc.Timeout = 5 * time.Minute
+
+	var t target
+	var err error
+	switch config.Type {
+	case "s3":
+		region := config.Region
+		if region == "" {
+			region = "us-east-1"
+		}
+		endpoint := config.Endpoint
+		if endpoint == "" {
+			endpoint = "https://s3." + region + ".amazonaws.com"
+		}
+		t, err = newS3(hc, endpoint, region, config)
+	case "gcs":
+		// Cloud Storage speaks the S3 protocol to HMAC keys
+		endpoint := config.Endpoint
+		if endpoint == "" {
+			endpoint = "https://storage.googleapis.com"
+		}
+		t, err = newS3(hc, endpoint, "auto", config)
+	case "webdav":
+		t, err = newWebDAV(hc, config)
+	default:
+		return nil, fmt.Errorf("unknown upload type %q (use s3, gcs or webdav)", config.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Uploader{config: config, target: t}, nil
+}
+
+// uploaded is what the store remembers about an uploaded file, so unchanged
+// files aren't sent again
+type uploaded struct {
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// Sync uploads the new and changed files below the given subdirectories of
+// root to <prefix><profile>/<subdirectory>/... and returns how many it sent
+func (u *Uploader) Sync(ctx context.Context, s store.Store, root, profile string, dirs ...string) (int, error) {
+	sent := 0
+	for _, dir := range dirs {
+		err := filepath.WalkDir(filepath.Join(root, dir), func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+
+			key := u.config.Prefix + profile + "/" + filepath.ToSlash(rel)
+			record := u.config.Type + ":" + key
+			now := uploaded{Size: info.Size(), Modified: info.ModTime().UTC()}
+			if data, err := s.Get("uploads", record); err == nil {
+				var before uploaded
+				if json.Unmarshal(data, &before) == nil && before.Size == now.Size && before.Modified.Equal(now.Modified) {
+					return nil
+				}
+			} else if !errors.Is(err, store.ErrNotFound) {
+				return err
+			}
+
+			body, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			if err := u.target.put(ctx, key, body); err != nil {
+				return fmt.Errorf("failed to upload %s: %v", rel, err)
+			}
+			sent++
+
+			data, err := json.Marshal(now)
+			if err != nil {
+				return err
+			}
+			return s.Put("uploads", record, data)
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// Prune removes the profile's uploads older than the retention period and
+// returns how many it removed
+func (u *Uploader) Prune(ctx context.Context, profile string) (int, error) {
+	if u.config.RetentionDays <= 0 {
+		return 0, nil
+	}
+
+	objects, err := u.target.list(ctx, u.config.Prefix+profile+"/")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list uploads: %v", err)
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -u.config.RetentionDays)
+	removed := 0
+	for _, o := range objects {
+		if !o.Modified.Before(cutoff) {
+			continue
+		}
+		if err := u.target.remove(ctx, o.Key); err != nil {
+			return removed, fmt.Errorf("failed to remove old upload %s: %v", o.Key, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// checkStatus turns an unsuccessful response into an error carrying the
+// start of its body, where storage services explain what went wrong
+func checkStatus(resp *http.Response, what string) error {
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	var buf [512]byte
+	n, _ := resp.Body.Read(buf[:])
+	return fmt.Errorf("%s: %s: %s", what, resp.Status, strings.TrimSpace(string(buf[:n])))
+}
@@ -0,0 +1,169 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// webdav uploads into a folder on a WebDAV server such as Nextcloud
+type webdav struct {
+	hc       *http.Client
+	base     *url.URL
+	username string
+	password string
+
+	// Folders known to exist
+	made map[string]bool
+}
+
+func newWebDAV(hc *http.Client, config *Config) (*webdav, error) {
+	u, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("webdav uploads need the folder's URL as endpoint")
+	}
+	return &webdav{
+		hc:       hc,
+		base:     u,
+		username: config.Username,
+		password: config.Password,
+		made:     map[string]bool{},
+	}, nil
+}
+
+func (w *webdav) url(key string) string {
+	u := *w.base
+	u.Path += "/" + key
+	u.RawPath = ""
+	return u.String()
+}
+
+func (w *webdav) do(ctx context.Context, method, key string, header http.Header, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, w.url(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	if w.username != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+	return w.hc.Do(req)
+}
+
+// mkdirs creates the folders leading to key, one level at a time
+func (w *webdav) mkdirs(ctx context.Context, key string) error {
+	parts := strings.Split(key, "/")
+	dir := ""
+	for _, part := range parts[:len(parts)-1] {
+		dir += part + "/"
+		if w.made[dir] {
+			continue
+		}
+		resp, err := w.do(ctx, "MKCOL", dir, nil, nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		// 405 means the folder already exists
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			if err := checkStatus(resp, "MKCOL "+dir); err != nil {
+				return err
+			}
+		}
+		w.made[dir] = true
+	}
+	return nil
+}
+
+func (w *webdav) put(ctx context.Context, key string, body []byte) error {
+	if err := w.mkdirs(ctx, key); err != nil {
+		return err
+	}
+	resp, err := w.do(ctx, http.MethodPut, key, nil, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkStatus(resp, "PUT "+key)
+}
+
+func (w *webdav) remove(ctx context.Context, key string) error {
+	resp, err := w.do(ctx, http.MethodDelete, key, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkStatus(resp, "DELETE "+key)
+}
+
+type multistatus struct {
+	Responses []struct {
+		Href string `xml:"DAV: href"`
+		Prop struct {
+			LastModified string    `xml:"DAV: getlastmodified"`
+			Collection   *struct{} `xml:"DAV: resourcetype>collection"`
+		} `xml:"DAV: propstat>prop"`
+	} `xml:"DAV: response"`
+}
+
+const propfind = `<?xml version="1.0"?><d:propfind xmlns:d="DAV:"><d:prop><d:getlastmodified/><d:resourcetype/></d:prop></d:propfind>`
+
+// list walks the folder tree below prefix one level at a time, since many
+// servers refuse infinite depth
+func (w *webdav) list(ctx context.Context, prefix string) ([]object, error) {
+	header := http.Header{"Depth": {"1"}, "Content-Type": {"application/xml"}}
+	resp, err := w.do(ctx, "PROPFIND", prefix, header, []byte(propfind))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err := checkStatus(resp, "PROPFIND "+prefix); err != nil {
+		return nil, err
+	}
+
+	var ms multistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("failed to parse folder listing: %v", err)
+	}
+
+	var objects []object
+	for _, r := range ms.Responses {
+		href, err := url.Parse(r.Href)
+		if err != nil {
+			continue
+		}
+		key := strings.TrimPrefix(strings.TrimPrefix(href.Path, w.base.Path), "/")
+		if key == prefix || key+"/" == prefix {
+			continue // The folder itself
+		}
+
+		if r.Prop.Collection != nil {
+			if !strings.HasSuffix(key, "/") {
+				key += "/"
+			}
+			below, err := w.list(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, below...)
+			continue
+		}
+
+		modified, err := time.Parse(time.RFC1123, r.Prop.LastModified)
+		if err != nil {
+			continue
+		}
+		objects = append(objects, object{Key: key, Modified: modified})
+	}
+	return objects, nil
+}
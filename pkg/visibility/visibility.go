@@ -0,0 +1,117 @@
+// Package visibility checks what strangers see of deleted content, by
+// fetching its public page without credentials. Content can be gone for
+// its owner yet still be served to everyone else, e.g. from a cache.
+package visibility
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// userAgent identifies the checks; Reddit refuses generic agents
+const userAgent = "go-del-socials visibility check"
+
+// Public reports whether the item with the ID and URL on the platform can
+// still be seen without signing in
+func Public(ctx context.Context, hc *http.Client, platform, id, link string) (bool, error) {
+	switch platform {
+	case "reddit":
+		return redditPublic(ctx, hc, id, link)
+	case "twitter":
+		// The oEmbed endpoint only embeds tweets anyone may see
+		u := "https://publish.twitter.com/oembed?url=" + url.QueryEscape(link)
+		status, _, err := get(ctx, hc, u)
+		if err != nil {
+			return false, err
+		}
+		return servedOK(status)
+	default:
+		status, _, err := get(ctx, hc, link)
+		if err != nil {
+			return false, err
+		}
+		return servedOK(status)
+	}
+}
+
+// redditPublic looks for the item in the JSON of its public thread. Deleted
+// posts and comments lose their author; comments without replies drop out
+// of the thread altogether.
+func redditPublic(ctx context.Context, hc *http.Client, id, link string) (bool, error) {
+	status, body, err := get(ctx, hc, strings.TrimSuffix(link, "/")+".json?raw_json=1")
+	if err != nil {
+		return false, err
+	}
+	if ok, err := servedOK(status); !ok || err != nil {
+		return ok, err
+	}
+
+	var thread any
+	if err := json.Unmarshal(body, &thread); err != nil {
+		return false, fmt.Errorf("failed to decode public thread: %v", err)
+	}
+	thing := find(thread, id)
+	if thing == nil {
+		return false, nil
+	}
+	author, _ := thing["author"].(string)
+	return author != "[deleted]", nil
+}
+
+// find returns the thing named id anywhere in a Reddit listing
+func find(v any, id string) map[string]any {
+	switch v := v.(type) {
+	case []any:
+		for _, e := range v {
+			if t := find(e, id); t != nil {
+				return t
+			}
+		}
+	case map[string]any:
+		if v["name"] == id {
+			return v
+		}
+		for _, e := range v {
+			if t := find(e, id); t != nil {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// servedOK reads a status: served means visible, missing or refused means
+// gone, anything else can't tell
+func servedOK(status int) (bool, error) {
+	switch {
+	case status >= 200 && status < 300:
+		return true, nil
+	case status == http.StatusNotFound, status == http.StatusGone, status == http.StatusForbidden:
+		return false, nil
+	default:
+		return false, fmt.Errorf("public page answered %d", status)
+	}
+}
+
+func get(ctx context.Context, hc *http.Client, u string) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := hc.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to fetch public page: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read public page: %v", err)
+	}
+	return resp.StatusCode, body, nil
+}
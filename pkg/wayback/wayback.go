@@ -0,0 +1,128 @@
+// Package wayback saves public pages to the Internet Archive's Wayback
+// Machine through its Save Page Now service, so chosen content stays
+// preserved publicly after it is deleted
+package wayback
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-del-socials/pkg/httpclient"
+	"go-del-socials/pkg/pace"
+)
+
+const (
+	saveURL      = "https://web.archive.org/save/"
+	webURL       = "https://web.archive.org"
+	availableURL = "https://archive.org/wayback/available?url="
+
+	// userAgent identifies the captures to the Internet Archive
+	userAgent = "go-del-socials wayback snapshot"
+
+	// perMinute keeps within what Save Page Now allows a visitor who isn't
+	// signed in
+	perMinute = 12
+
+	// timeout is longer than usual, as a capture loads the whole page
+	timeout = 2 * time.Minute
+
+	// attempts bounds the retries of a capture that was rate limited
+	attempts = 3
+)
+
+// Saver submits pages to Save Page Now, spaced out to its rate limit
+type Saver struct {
+	hc    *http.Client
+	pacer *pace.Pacer
+}
+
+func New() *Saver {
+	return &Saver{
+		hc:    &http.Client{Transport: httpclient.Transport, Timeout: timeout},
+		pacer: pace.New(0, 0, pace.NewLimiter(perMinute, time.Minute, 1)),
+	}
+}
+
+// Save captures the page at link and returns the URL of the snapshot
+func (s *Saver) Save(ctx context.Context, link string) (string, error) {
+	for attempt := 1; ; attempt++ {
+		if err := s.pacer.Write(ctx); err != nil {
+			return "", err
+		}
+		req, err := http.NewRequestWithContext(ctx, "GET", saveURL+link, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to create request: %v", err)
+		}
+		req.Header.Set("User-Agent", userAgent)
+		resp, err := s.hc.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to reach Save Page Now: %v", err)
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 8<<20))
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests && attempt < attempts:
+			wait, ok := httpclient.RetryAfter(resp.Header)
+			if !ok {
+				wait = time.Minute
+			}
+			if err := pace.Wait(ctx, wait); err != nil {
+				return "", err
+			}
+			continue
+		case resp.StatusCode < 200 || resp.StatusCode >= 300:
+			return "", fmt.Errorf("Save Page Now answered %d", resp.StatusCode)
+		}
+
+		// The snapshot is named by the response, or is where the request
+		// was redirected to
+		if loc := resp.Header.Get("Content-Location"); strings.HasPrefix(loc, "/web/") {
+			return webURL + loc, nil
+		}
+		if u := resp.Request.URL.String(); strings.HasPrefix(u, webURL+"/web/") {
+			return u, nil
+		}
+		return "", fmt.Errorf("Save Page Now didn't say where the snapshot is")
+	}
+}
+
+// Snapshot returns the URL of the Wayback Machine's snapshot of link, or ""
+// when it has none
+func Snapshot(ctx context.Context, hc *http.Client, link string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", availableURL+url.QueryEscape(link), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := hc.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach the Wayback Machine: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("the Wayback Machine answered %d", resp.StatusCode)
+	}
+
+	var available struct {
+		ArchivedSnapshots struct {
+			Closest *struct {
+				Available bool   `json:"available"`
+				URL       string `json:"url"`
+			} `json:"closest"`
+		} `json:"archived_snapshots"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&available); err != nil {
+		return "", fmt.Errorf("failed to decode the Wayback Machine's answer: %v", err)
+	}
+	if c := available.ArchivedSnapshots.Closest; c != nil && c.Available {
+		return c.URL, nil
+	}
+	return "", nil
+}